@@ -0,0 +1,113 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"app/internal/fakellm"
+)
+
+func newTestClient(builder RequestBuilder, timeout time.Duration) *HTTPChatClient {
+	return NewHTTPChatClient(builder, NewResponseParser(), &http.Client{}, timeout)
+}
+
+func TestHTTPChatClient_SendMessage(t *testing.T) {
+	server := fakellm.New(t, fakellm.WithReplies(fakellm.Text("Краткий ответ")), fakellm.WithAPIKey("test-key"))
+	builder := NewRequestBuilderWithOptions("test-key", server.ChatURL(), "test-model", 500,
+		[]string{"[END]", "[STOP]"}, "Ответь кратко")
+
+	resp, dur, err := newTestClient(builder, 5*time.Second).SendMessage(context.Background(), "Привет")
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if GetAnswerContent(resp) != "Краткий ответ" || dur <= 0 {
+		t.Errorf("answer = %q, duration = %v", GetAnswerContent(resp), dur)
+	}
+	// fakellm считает слова запроса и ответа
+	if resp.Usage == nil || resp.Usage.PromptTokens != 3 || resp.Usage.CompletionTokens != 2 || resp.Usage.TotalTokens != 5 {
+		t.Errorf("Usage = %+v", resp.Usage)
+	}
+
+	sent := server.LastRequest()
+	if sent.Method != http.MethodPost || sent.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("request = %s, Content-Type = %q", sent.Method, sent.Header.Get("Content-Type"))
+	}
+	if sent.Model != "test-model" || sent.Param("max_tokens") != 500.0 || sent.Contents() != "Ответь кратко,Привет" {
+		t.Errorf("request = %+v", sent)
+	}
+	if stop := sent.Param("stop"); !reflect.DeepEqual(stop, []any{"[END]", "[STOP]"}) {
+		t.Errorf("stop = %v", stop)
+	}
+}
+
+func TestHTTPChatClient_SendMessage_WithoutOptions(t *testing.T) {
+	server := fakellm.New(t)
+	builder := NewRequestBuilder("test-key", server.ChatURL(), "test-model", 4096)
+
+	if _, _, err := newTestClient(builder, 5*time.Second).SendMessage(context.Background(), "Привет"); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	sent := server.LastRequest()
+	if sent.HasParam("stop") || sent.HasParam("response_format") || sent.Contents() != "Привет" {
+		t.Errorf("request body = %s", sent.Body)
+	}
+}
+
+func TestHTTPChatClient_SendMessage_NoUsage(t *testing.T) {
+	server := fakellm.New(t, fakellm.WithReplies(fakellm.Raw(http.StatusOK, "application/json",
+		`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`)))
+	builder := NewRequestBuilder("test-key", server.ChatURL(), "test-model", 4096)
+
+	resp, _, err := newTestClient(builder, 5*time.Second).SendMessage(context.Background(), "Привет")
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if resp.Usage != nil || GetAnswerContent(resp) != "ok" {
+		t.Errorf("response = %+v, want answer without usage", resp)
+	}
+}
+
+func TestHTTPChatClient_SendMessage_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply fakellm.Reply
+		want  string
+	}{
+		{"api error", fakellm.Error(http.StatusUnauthorized, "invalid api key"), "HTTP 401"},
+		{"server error", fakellm.ServerError(), "internal server error"},
+		{"invalid json", fakellm.Raw(http.StatusOK, "application/json", "not json"), "ошибка парсинга JSON"},
+		{"connection dropped", fakellm.Drop(), "ошибка отправки запроса"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := fakellm.New(t, fakellm.WithReplies(tt.reply))
+			builder := NewRequestBuilder("test-key", server.ChatURL(), "test-model", 4096)
+
+			resp, _, err := newTestClient(builder, 5*time.Second).SendMessage(context.Background(), "Привет")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("SendMessage() = %+v, %v, want error with %q", resp, err, tt.want)
+			}
+			server.AssertRequestCount(1)
+		})
+	}
+}
+
+func TestHTTPChatClient_SendMessage_Timeout(t *testing.T) {
+	server := fakellm.New(t, fakellm.WithReplies(fakellm.Reply{Content: "поздно", Delay: time.Minute}))
+	builder := NewRequestBuilder("test-key", server.ChatURL(), "test-model", 4096)
+
+	start := time.Now()
+	_, _, err := newTestClient(builder, 50*time.Millisecond).SendMessage(context.Background(), "Привет")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("SendMessage() error = %v, want deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("SendMessage() took %v, timeout should stop the request", elapsed)
+	}
+}
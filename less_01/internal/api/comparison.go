@@ -6,6 +6,8 @@ import (
 	"log"
 	"strings"
 	"time"
+
+	"app/internal/tokenizer"
 )
 
 // GetAnswerContent извлекает текст ответа из Response
@@ -34,12 +36,43 @@ func truncateText(text string, maxLen int) string {
 	return text[:half] + "\n<вырезаный текст>\n" + text[len(text)-half:]
 }
 
+// tokenLabel возвращает подпись количества токенов в зависимости от точности подсчета
+func tokenLabel(counter *tokenizer.Counter) string {
+	if counter.Exact() {
+		return "Токенов"
+	}
+	return "Токенов (примерно)"
+}
+
+// answerTokens возвращает количество токенов обоих ответов и подпись к ним
+// resp1, resp2 - ответы от API
+// counter - счетчик токенов модели
+// Возвращает: completion_tokens из usage, если оба ответа его содержат, иначе подсчет по тексту
+func answerTokens(resp1, resp2 *Response, counter *tokenizer.Counter) (int, int, string) {
+	if resp1.Usage != nil && resp2.Usage != nil {
+		return resp1.Usage.CompletionTokens, resp2.Usage.CompletionTokens, "Токенов (по данным API)"
+	}
+	return counter.Count(GetAnswerContent(resp1)), counter.Count(GetAnswerContent(resp2)), tokenLabel(counter)
+}
+
+// printUsage выводит расход токенов из ответа API, если он есть
+// resp - ответ от API
+func printUsage(resp *Response) {
+	if resp.Usage == nil {
+		return
+	}
+	log.Printf("   Расход: запрос %d + ответ %d = %d токенов",
+		resp.Usage.PromptTokens, resp.Usage.CompletionTokens, resp.Usage.TotalTokens)
+}
+
 // PrintComparison выводит сравнение двух ответов в консоль
 // resp1, resp2 - ответы для сравнения
 // dur1, dur2 - длительности выполнения
-func PrintComparison(resp1, resp2 *Response, dur1, dur2 time.Duration) {
+// counter - счетчик токенов модели (используется, если API не вернул usage)
+func PrintComparison(resp1, resp2 *Response, dur1, dur2 time.Duration, counter *tokenizer.Counter) {
 	content1 := GetAnswerContent(resp1)
 	content2 := GetAnswerContent(resp2)
+	tokens1, tokens2, label := answerTokens(resp1, resp2, counter)
 
 	separator := strings.Repeat("=", 60)
 
@@ -50,7 +83,8 @@ func PrintComparison(resp1, resp2 *Response, dur1, dur2 time.Duration) {
 	log.Println("\n📋 ЗАПРОС 1 (с ограничениями):")
 	log.Printf("   Время: %v", dur1)
 	log.Printf("   Длина: %d символов", len(content1))
-	log.Printf("   Токенов (примерно): %d", len(content1)/4)
+	log.Printf("   %s: %d", label, tokens1)
+	printUsage(resp1)
 	log.Println("   Ответ:")
 	log.Println("   " + strings.Repeat("-", 50))
 	truncated1 := truncateText(content1, 500)
@@ -61,7 +95,8 @@ func PrintComparison(resp1, resp2 *Response, dur1, dur2 time.Duration) {
 	log.Println("\n📋 ЗАПРОС 2 (без ограничений):")
 	log.Printf("   Время: %v", dur2)
 	log.Printf("   Длина: %d символов", len(content2))
-	log.Printf("   Токенов (примерно): %d", len(content2)/4)
+	log.Printf("   %s: %d", label, tokens2)
+	printUsage(resp2)
 	log.Println("   Ответ:")
 	log.Println("   " + strings.Repeat("-", 50))
 	truncated2 := truncateText(content2, 500)
@@ -72,6 +107,7 @@ func PrintComparison(resp1, resp2 *Response, dur1, dur2 time.Duration) {
 	log.Println("\n" + separator)
 	log.Println("РАЗНИЦА:")
 	log.Printf("   Длина: %d символов", len(content2)-len(content1))
+	log.Printf("   %s: %d", label, tokens2-tokens1)
 	log.Printf("   Время: %v", dur2-dur1)
 	log.Println(separator)
 }
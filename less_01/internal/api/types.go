@@ -33,6 +33,14 @@ type Message struct {
 // Response представляет структуру ответа от API
 type Response struct {
 	Choices []Choice `json:"choices"`
+	Usage   *Usage   `json:"usage,omitempty"` // Расход токенов (nil, если провайдер его не вернул)
+}
+
+// Usage представляет расход токенов на запрос
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`     // Токены запроса
+	CompletionTokens int `json:"completion_tokens"` // Токены ответа
+	TotalTokens      int `json:"total_tokens"`      // Всего токенов
 }
 
 // Choice представляет один вариант ответа от API
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"time"
+
+	"app/internal/tokenizer"
 )
 
 // Config содержит все настройки приложения
@@ -14,6 +16,8 @@ type Config struct {
 	Model     string        // Название модели
 	MaxTokens int           // Максимальное количество токенов для запроса без ограничений
 	Timeout   time.Duration // Глобальный таймаут для HTTP запросов
+
+	TokenizerDir string // Каталог словарей токенизатора (*.tiktoken)
 }
 
 const (
@@ -24,6 +28,7 @@ const (
 )
 
 // Load загружает конфигурацию из переменных окружения
+// Каталог словарей токенизатора можно переопределить через LLM_CLIENT_TOKENIZER_DIR
 // Возвращает: заполненную Config или ошибку если ROUTERAI_API_KEY не задан
 func Load() (*Config, error) {
 	apiKey := os.Getenv("ROUTERAI_API_KEY")
@@ -31,11 +36,18 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("переменная окружения ROUTERAI_API_KEY не установлена")
 	}
 
+	tokenizerDir := os.Getenv("LLM_CLIENT_TOKENIZER_DIR")
+	if tokenizerDir == "" {
+		tokenizerDir = tokenizer.DefaultDir()
+	}
+
 	return &Config{
 		APIKey:    apiKey,
 		APIURL:    defaultAPIURL,
 		Model:     defaultModel,
 		MaxTokens: defaultMaxTokens,
 		Timeout:   defaultTimeout,
+
+		TokenizerDir: tokenizerDir,
 	}, nil
 }
@@ -0,0 +1,254 @@
+// Package fakellm запускает в процессе теста OpenAI-совместимый сервер chat completions:
+// ответы по сценарию, ошибки API, задержки и обрыв соединения, проверки полученных запросов.
+package fakellm
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	// ChatPath - эндпоинт chat completions
+	ChatPath = "/v1/chat/completions"
+	// DefaultModel - модель в ответах, если запрос её не указал
+	DefaultModel = "fake-model"
+)
+
+// Option - функция опция для настройки Server
+type Option func(*Server)
+
+// WithReplies задаёт ответы на запросы по порядку
+func WithReplies(replies ...Reply) Option {
+	return func(s *Server) {
+		s.replies = append(s.replies, replies...)
+	}
+}
+
+// WithDefaultReply задаёт ответ после исчерпания сценария (по умолчанию Text("ok"))
+func WithDefaultReply(reply Reply) Option {
+	return func(s *Server) {
+		s.fallback = reply
+	}
+}
+
+// WithAPIKey требует заголовок Authorization: Bearer <key>, иначе ответ 401
+func WithAPIKey(key string) Option {
+	return func(s *Server) {
+		s.apiKey = key
+	}
+}
+
+// Server - поддельный LLM сервер. Безопасен для использования из нескольких горутин.
+type Server struct {
+	// URL - адрес сервера; эндпоинт для api.NewRequestBuilder - ChatURL()
+	URL string
+
+	t      testing.TB
+	server *httptest.Server
+	apiKey string
+
+	mu       sync.Mutex
+	replies  []Reply
+	fallback Reply
+	requests []Request
+}
+
+// New запускает сервер; он останавливается по завершении теста
+func New(t testing.TB, opts ...Option) *Server {
+	t.Helper()
+	s := &Server{
+		t:        t,
+		fallback: Text("ok"),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+ChatPath, s.handleChat)
+	s.server = httptest.NewServer(mux)
+	s.URL = s.server.URL
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Close останавливает сервер
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// ChatURL возвращает полный адрес эндпоинта chat completions
+func (s *Server) ChatURL() string {
+	return s.URL + ChatPath
+}
+
+// Requests возвращает полученные запросы по порядку
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// LastRequest возвращает последний запрос; без запросов тест завершается с ошибкой
+func (s *Server) LastRequest() Request {
+	s.t.Helper()
+	requests := s.Requests()
+	if len(requests) == 0 {
+		s.t.Fatalf("fakellm: no requests received")
+	}
+	return requests[len(requests)-1]
+}
+
+// AssertRequestCount проверяет количество полученных запросов
+func (s *Server) AssertRequestCount(n int) {
+	s.t.Helper()
+	if got := len(s.Requests()); got != n {
+		s.t.Errorf("fakellm: received %d requests, want %d", got, n)
+	}
+}
+
+// handleChat записывает запрос и отвечает следующим ответом сценария
+func (s *Server) handleChat(w http.ResponseWriter, r *http.Request) {
+	if s.apiKey != "" && r.Header.Get("Authorization") != "Bearer "+s.apiKey {
+		writeError(w, http.StatusUnauthorized, "invalid api key")
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	req := newRequest(r, body)
+	reply := s.record(req)
+
+	if req.err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+req.err.Error())
+		return
+	}
+	reply.write(w, r, req)
+}
+
+// record сохраняет запрос и выбирает ответ
+func (s *Server) record(req Request) Reply {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, req)
+	if len(s.replies) == 0 {
+		return s.fallback
+	}
+	reply := s.replies[0]
+	s.replies = s.replies[1:]
+	return reply
+}
+
+// Message - сообщение чата в запросе.
+// Свой тип вместо api.Message: пакет api сам использует fakellm в тестах.
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Request - запрос, полученный сервером
+type Request struct {
+	Method string
+	Path   string
+	Header http.Header
+	// Body - тело запроса как есть
+	Body []byte
+
+	Model    string
+	Messages []Message
+
+	// params - все поля тела запроса
+	params map[string]json.RawMessage
+	err    error
+}
+
+// newRequest разбирает тело запроса chat completions
+func newRequest(r *http.Request, body []byte) Request {
+	req := Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Header: r.Header.Clone(),
+		Body:   body,
+	}
+	var decoded struct {
+		Model    string    `json:"model"`
+		Messages []Message `json:"messages"`
+	}
+	if req.err = json.Unmarshal(body, &decoded); req.err != nil {
+		return req
+	}
+	json.Unmarshal(body, &req.params)
+
+	req.Model = decoded.Model
+	req.Messages = decoded.Messages
+	return req
+}
+
+// Param возвращает поле тела запроса, декодированное в значение Go (nil - поля нет)
+func (r Request) Param(name string) any {
+	raw, ok := r.params[name]
+	if !ok {
+		return nil
+	}
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil
+	}
+	if n, ok := v.(json.Number); ok {
+		if f, err := n.Float64(); err == nil {
+			return f
+		}
+	}
+	return v
+}
+
+// HasParam сообщает, есть ли поле в теле запроса
+func (r Request) HasParam(name string) bool {
+	_, ok := r.params[name]
+	return ok
+}
+
+// LastUserMessage возвращает текст последнего сообщения пользователя
+func (r Request) LastUserMessage() string {
+	for i := len(r.Messages) - 1; i >= 0; i-- {
+		if r.Messages[i].Role == "user" {
+			return r.Messages[i].Content
+		}
+	}
+	return ""
+}
+
+// Contents возвращает тексты сообщений через запятую: "system,q1"
+func (r Request) Contents() string {
+	contents := make([]string, len(r.Messages))
+	for i, msg := range r.Messages {
+		contents[i] = msg.Content
+	}
+	return strings.Join(contents, ",")
+}
+
+// writeJSON отправляет JSON ответ
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError отправляет ошибку в формате OpenAI
+func writeError(w http.ResponseWriter, status int, message string) {
+	type apiError struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	}
+	writeJSON(w, status, struct {
+		Error apiError `json:"error"`
+	}{apiError{Message: message, Type: "fakellm_error"}})
+}
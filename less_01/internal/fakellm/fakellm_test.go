@@ -0,0 +1,103 @@
+package fakellm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// post отправляет запрос chat completions с ключом key
+func post(t *testing.T, ctx context.Context, s *Server, key, body string) (*http.Response, error) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.ChatURL(), strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+key)
+	return http.DefaultClient.Do(req)
+}
+
+// answer возвращает текст ответа chat completions
+func answer(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	defer resp.Body.Close()
+	var decoded struct {
+		Choices []struct {
+			Message Message `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil || len(decoded.Choices) != 1 {
+		t.Fatalf("decode response: %v, %+v", err, decoded)
+	}
+	return decoded.Choices[0].Message.Content
+}
+
+const chatBody = `{"model":"test-model","messages":[{"role":"system","content":"s"},{"role":"user","content":"q"}],"max_tokens":10}`
+
+func TestServer_ScriptedReplies(t *testing.T) {
+	s := New(t, WithReplies(Text("первый"), Text("второй")), WithDefaultReply(Text("дальше")))
+
+	for _, want := range []string{"первый", "второй", "дальше"} {
+		resp, err := post(t, context.Background(), s, "k", chatBody)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := answer(t, resp); got != want {
+			t.Errorf("answer = %q, want %q", got, want)
+		}
+	}
+	s.AssertRequestCount(3)
+
+	req := s.LastRequest()
+	if req.Model != "test-model" || req.LastUserMessage() != "q" || req.Contents() != "s,q" || req.Param("max_tokens") != 10.0 {
+		t.Errorf("request = %+v", req)
+	}
+	if req.HasParam("stop") || req.Header.Get("Authorization") != "Bearer k" {
+		t.Errorf("request = %+v", req)
+	}
+}
+
+func TestServer_Faults(t *testing.T) {
+	s := New(t, WithAPIKey("secret"), WithReplies(
+		Error(http.StatusTooManyRequests, "slow down"),
+		Raw(http.StatusOK, "text/plain", "not json"),
+		Drop(),
+		Reply{Content: "late", Delay: time.Minute},
+	))
+
+	if resp, err := post(t, context.Background(), s, "wrong", chatBody); err != nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("wrong key: %v, %v", resp, err)
+	}
+
+	resp, err := post(t, context.Background(), s, "secret", chatBody)
+	if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("error reply: %v, %v", resp, err)
+	}
+	if body, _ := io.ReadAll(resp.Body); !strings.Contains(string(body), "slow down") {
+		t.Errorf("error body = %s", body)
+	}
+
+	resp, err = post(t, context.Background(), s, "secret", chatBody)
+	if err != nil || resp.Header.Get("Content-Type") != "text/plain" {
+		t.Fatalf("raw reply: %v, %v", resp, err)
+	}
+	if body, _ := io.ReadAll(resp.Body); string(body) != "not json" {
+		t.Errorf("raw body = %s", body)
+	}
+
+	if _, err := post(t, context.Background(), s, "secret", chatBody); err == nil {
+		t.Errorf("dropped connection: want error")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := post(t, ctx, s, "secret", chatBody); err == nil {
+		t.Errorf("delayed reply: want timeout")
+	}
+	// Запрос с неверным ключом не записывается
+	s.AssertRequestCount(4)
+}
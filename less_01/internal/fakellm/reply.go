@@ -0,0 +1,144 @@
+package fakellm
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Reply - ответ сервера на один запрос
+type Reply struct {
+	// Content - текст ответа
+	Content string
+	// FinishReason - причина завершения (по умолчанию stop)
+	FinishReason string
+	// Delay - задержка перед ответом; отмена запроса клиентом её прерывает
+	Delay time.Duration
+
+	// Status - код ошибки вместо ответа; Message - текст ошибки
+	Status  int
+	Message string
+
+	// Drop - соединение закрывается без ответа
+	Drop bool
+
+	// Body - тело ответа как есть вместо сгенерированного (код Status, по умолчанию 200)
+	Body        string
+	ContentType string
+}
+
+// Text возвращает ответ с текстом
+func Text(content string) Reply {
+	return Reply{Content: content}
+}
+
+// Error возвращает ошибку провайдера с кодом status
+func Error(status int, message string) Reply {
+	return Reply{Status: status, Message: message}
+}
+
+// ServerError возвращает 500
+func ServerError() Reply {
+	return Error(http.StatusInternalServerError, "internal server error")
+}
+
+// Drop возвращает ответ, при котором соединение закрывается без ответа
+func Drop() Reply {
+	return Reply{Drop: true}
+}
+
+// Raw возвращает ответ с телом body как есть.
+// Нужен для форматов, которые сервер не генерирует: ответ без usage, некорректный JSON.
+func Raw(status int, contentType, body string) Reply {
+	return Reply{Status: status, ContentType: contentType, Body: body}
+}
+
+// write отправляет ответ на запрос
+func (r Reply) write(w http.ResponseWriter, hr *http.Request, req Request) {
+	if !sleep(hr, r.Delay) {
+		return
+	}
+
+	switch {
+	case r.Drop:
+		if conn, _, err := w.(http.Hijacker).Hijack(); err == nil {
+			conn.Close()
+		}
+	case r.Body != "":
+		r.writeRaw(w)
+	case r.Status != 0:
+		writeError(w, r.Status, r.Message)
+	default:
+		r.writeCompletion(w, req)
+	}
+}
+
+// writeRaw отправляет Body как есть
+func (r Reply) writeRaw(w http.ResponseWriter) {
+	status := r.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if r.ContentType != "" {
+		w.Header().Set("Content-Type", r.ContentType)
+	}
+	w.WriteHeader(status)
+	w.Write([]byte(r.Body))
+}
+
+// writeCompletion отправляет ответ chat completions
+func (r Reply) writeCompletion(w http.ResponseWriter, req Request) {
+	finishReason := r.FinishReason
+	if finishReason == "" {
+		finishReason = "stop"
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"id":      "chatcmpl-fake",
+		"object":  "chat.completion",
+		"created": time.Now().Unix(),
+		"model":   model(req),
+		"choices": []map[string]any{{
+			"index":         0,
+			"message":       map[string]any{"role": "assistant", "content": r.Content},
+			"finish_reason": finishReason,
+		}},
+		"usage": r.usage(req),
+	})
+}
+
+// usage возвращает расход токенов: слова сообщений запроса и слова ответа
+func (r Reply) usage(req Request) map[string]int {
+	prompt := 0
+	for _, msg := range req.Messages {
+		prompt += len(strings.Fields(msg.Content))
+	}
+	completion := len(strings.Fields(r.Content))
+	return map[string]int{
+		"prompt_tokens":     prompt,
+		"completion_tokens": completion,
+		"total_tokens":      prompt + completion,
+	}
+}
+
+// model возвращает модель для ответа
+func model(req Request) string {
+	if req.Model != "" {
+		return req.Model
+	}
+	return DefaultModel
+}
+
+// sleep ждёт d; false - запрос отменён клиентом
+func sleep(r *http.Request, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-r.Context().Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,97 @@
+package tokenizer
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// vocabularyExt - расширение файлов словарей
+const vocabularyExt = ".tiktoken"
+
+// Counter подсчитывает токены для конкретной модели
+// Без словаря используется оценка 1 токен ≈ 4 байта
+type Counter struct {
+	enc *Encoding
+}
+
+// NewCounter создает счетчик для кодировки
+// enc - словарь (nil - приблизительная оценка)
+func NewCounter(enc *Encoding) *Counter {
+	return &Counter{enc: enc}
+}
+
+// Exact сообщает, используется ли настоящий словарь
+func (c *Counter) Exact() bool {
+	return c != nil && c.enc != nil
+}
+
+// Count возвращает количество токенов текста
+// text - текст для подсчета
+// Возвращает: точное количество по словарю или оценку len/4
+func (c *Counter) Count(text string) int {
+	if !c.Exact() {
+		return len(text) / 4
+	}
+	return c.enc.Count(text)
+}
+
+// Loader загружает словари из каталога по требованию и кэширует их
+type Loader struct {
+	dir string
+
+	mu        sync.Mutex
+	encodings map[string]*Encoding
+	failed    map[string]error
+}
+
+// NewLoader создает загрузчик словарей
+// dir - каталог с файлами <encoding>.tiktoken
+func NewLoader(dir string) *Loader {
+	return &Loader{
+		dir:       dir,
+		encodings: make(map[string]*Encoding),
+		failed:    make(map[string]error),
+	}
+}
+
+// DefaultDir возвращает каталог словарей по умолчанию (~/.llm-client/tokenizers)
+func DefaultDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".llm-client", "tokenizers")
+	}
+	return filepath.Join(home, ".llm-client", "tokenizers")
+}
+
+// Encoding загружает кодировку по имени
+// Ошибка загрузки запоминается, чтобы не читать отсутствующий файл повторно
+func (l *Loader) Encoding(name string) (*Encoding, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if enc, ok := l.encodings[name]; ok {
+		return enc, nil
+	}
+	if err, ok := l.failed[name]; ok {
+		return nil, err
+	}
+
+	enc, err := LoadEncodingFile(name, filepath.Join(l.dir, name+vocabularyExt))
+	if err != nil {
+		l.failed[name] = err
+		log.Printf("⚠️  Словарь токенизатора недоступен, токены считаются приблизительно: %v", err)
+		return nil, err
+	}
+	l.encodings[name] = enc
+	return enc, nil
+}
+
+// ForModel возвращает счетчик токенов для модели
+// model - название модели (например, "openai/gpt-4o")
+// Возвращает: счетчик, который при отсутствии словаря работает по оценке
+func (l *Loader) ForModel(model string) *Counter {
+	enc, _ := l.Encoding(EncodingForModel(model))
+	return NewCounter(enc)
+}
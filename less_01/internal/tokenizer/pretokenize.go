@@ -0,0 +1,207 @@
+package tokenizer
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Предварительное разбиение текста на фрагменты перед BPE.
+// Функции повторяют регулярные выражения tiktoken, которые используют
+// опережающую проверку (?!\S), недоступную в пакете regexp:
+//
+//	cl100k_base: (?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}|
+//	             ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+(?!\S)|\s+
+//	o200k_base:  [^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|...)?|
+//	             [^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|...)?|
+//	             \p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n/]*|\s*[\r\n]+|\s+(?!\S)|\s+
+//
+// Каждая функция возвращает длину (в байтах) фрагмента в начале строки.
+
+// matchFunc возвращает длину первого фрагмента непустой строки
+type matchFunc func(s string) int
+
+// splitText разбивает текст на фрагменты и вызывает fn для каждого
+func splitText(text string, match matchFunc, fn func(piece string)) {
+	for len(text) > 0 {
+		n := match(text)
+		if n <= 0 {
+			_, n = utf8.DecodeRuneInString(text)
+		}
+		fn(text[:n])
+		text = text[n:]
+	}
+}
+
+// matchCL100K реализует разбиение cl100k_base
+func matchCL100K(s string) int {
+	if n := matchContraction(s); n > 0 {
+		return n
+	}
+
+	r, size := utf8.DecodeRuneInString(s)
+	// [^\r\n\p{L}\p{N}]?\p{L}+
+	if unicode.IsLetter(r) {
+		return size + scanWhile(s[size:], unicode.IsLetter)
+	}
+	if !isNewline(r) && !unicode.IsNumber(r) {
+		if n := scanWhile(s[size:], unicode.IsLetter); n > 0 {
+			return size + n
+		}
+	}
+	// \p{N}{1,3}
+	if unicode.IsNumber(r) {
+		return scanUpTo(s, unicode.IsNumber, 3)
+	}
+	// ?[^\s\p{L}\p{N}]+[\r\n]*
+	if n := matchPunct(s, isNewline); n > 0 {
+		return n
+	}
+	return matchSpace(s)
+}
+
+// matchO200K реализует разбиение o200k_base
+func matchO200K(s string) int {
+	r, size := utf8.DecodeRuneInString(s)
+	// [^\r\n\p{L}\p{N}]? + слово с учётом регистра
+	if !isNewline(r) && !unicode.IsLetter(r) && !unicode.IsNumber(r) {
+		if n := matchCasedWord(s[size:]); n > 0 {
+			return size + n
+		}
+	}
+	if n := matchCasedWord(s); n > 0 {
+		return n
+	}
+	// \p{N}{1,3}
+	if unicode.IsNumber(r) {
+		return scanUpTo(s, unicode.IsNumber, 3)
+	}
+	// ?[^\s\p{L}\p{N}]+[\r\n/]*
+	if n := matchPunct(s, func(r rune) bool { return isNewline(r) || r == '/' }); n > 0 {
+		return n
+	}
+	return matchSpace(s)
+}
+
+// matchCasedWord сопоставляет две альтернативы слова o200k_base:
+// A = upper* lower+ и B = upper+ lower*, обе с необязательным окончанием-сокращением
+func matchCasedWord(s string) int {
+	upper := scanWhile(s, isUpperClass)
+	lower := scanWhile(s[upper:], isLowerClass)
+
+	end := upper + lower
+	if lower == 0 {
+		// Альтернатива A после отката: lower+ забирает последний символ серии,
+		// входящий в оба класса (Lm, Lo, M). Иначе подходит только B.
+		if shared := lastIndexEnd(s[:upper], isLowerClass); shared > 0 {
+			end = shared
+		}
+	}
+	if end == 0 {
+		return 0
+	}
+	return end + matchContraction(s[end:])
+}
+
+// matchContraction сопоставляет английские сокращения 's, 't, 're, 've, 'm, 'll, 'd
+func matchContraction(s string) int {
+	if len(s) < 2 || s[0] != '\'' {
+		return 0
+	}
+	if len(s) >= 3 {
+		switch strings.ToLower(s[1:3]) {
+		case "re", "ve", "ll":
+			return 3
+		}
+	}
+	switch s[1] | 0x20 {
+	case 's', 't', 'm', 'd':
+		return 2
+	}
+	return 0
+}
+
+// matchPunct сопоставляет " ?[^\s\p{L}\p{N}]+" и следующий за ним хвост
+func matchPunct(s string, tail func(rune) bool) int {
+	start := 0
+	if s[0] == ' ' {
+		start = 1
+	}
+	n := scanWhile(s[start:], isPunct)
+	if n == 0 {
+		return 0
+	}
+	end := start + n
+	return end + scanWhile(s[end:], tail)
+}
+
+// matchSpace сопоставляет "\s*[\r\n]+|\s+(?!\S)|\s+"
+func matchSpace(s string) int {
+	run := scanWhile(s, unicode.IsSpace)
+	if run == 0 {
+		return 0
+	}
+	// \s*[\r\n]+ - до последнего перевода строки в серии пробелов
+	if last := strings.LastIndexAny(s[:run], "\r\n"); last >= 0 {
+		return last + 1
+	}
+	// \s+(?!\S) - последний пробел остаётся для следующего слова
+	if run == len(s) {
+		return run
+	}
+	_, lastSize := utf8.DecodeLastRuneInString(s[:run])
+	if run > lastSize {
+		return run - lastSize
+	}
+	// \s+
+	return run
+}
+
+// scanWhile возвращает длину префикса из символов, удовлетворяющих условию
+func scanWhile(s string, ok func(rune) bool) int {
+	for i, r := range s {
+		if !ok(r) {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// scanUpTo возвращает длину префикса из не более чем max символов, удовлетворяющих условию
+func scanUpTo(s string, ok func(rune) bool, max int) int {
+	count := 0
+	for i, r := range s {
+		if count == max || !ok(r) {
+			return i
+		}
+		count++
+	}
+	return len(s)
+}
+
+// lastIndexEnd возвращает позицию после последнего символа, удовлетворяющего условию (0 если нет)
+func lastIndexEnd(s string, ok func(rune) bool) int {
+	end := 0
+	for i, r := range s {
+		if ok(r) {
+			end = i + utf8.RuneLen(r)
+		}
+	}
+	return end
+}
+
+func isNewline(r rune) bool {
+	return r == '\r' || r == '\n'
+}
+
+func isPunct(r rune) bool {
+	return !unicode.IsSpace(r) && !unicode.IsLetter(r) && !unicode.IsNumber(r)
+}
+
+func isUpperClass(r rune) bool {
+	return unicode.In(r, unicode.Lu, unicode.Lt, unicode.Lm, unicode.Lo, unicode.M)
+}
+
+func isLowerClass(r rune) bool {
+	return unicode.In(r, unicode.Ll, unicode.Lm, unicode.Lo, unicode.M)
+}
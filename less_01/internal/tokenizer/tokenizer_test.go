@@ -0,0 +1,123 @@
+package tokenizer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testVocabulary возвращает словарь tiktoken из всех байтов и нескольких слияний
+func testVocabulary(merges ...string) string {
+	var b strings.Builder
+	for i := 0; i < 256; i++ {
+		fmt.Fprintf(&b, "%s %d\n", base64.StdEncoding.EncodeToString([]byte{byte(i)}), i)
+	}
+	for i, token := range merges {
+		fmt.Fprintf(&b, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(token)), 256+i)
+	}
+	return b.String()
+}
+
+func testEncoding(t *testing.T) *Encoding {
+	t.Helper()
+	enc, err := LoadEncoding(Cl100kBase, strings.NewReader(testVocabulary("he", "ll", "hell", " w", "П", "р", "Пр")))
+	if err != nil {
+		t.Fatalf("LoadEncoding() error = %v", err)
+	}
+	return enc
+}
+
+func TestEncoding_Encode(t *testing.T) {
+	enc := testEncoding(t)
+
+	// he + ll -> hell, "o" остаётся отдельным байтом
+	if got, want := enc.Encode("hello"), []int{258, 'o'}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Encode(hello) = %v, want %v", got, want)
+	}
+	// Фрагмент целиком есть в словаре
+	if got, want := enc.Encode("hell"), []int{258}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Encode(hell) = %v, want %v", got, want)
+	}
+	if got, want := enc.Encode(" wo"), []int{259, 'o'}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Encode( wo) = %v, want %v", got, want)
+	}
+
+	// Кириллица: 2 байта на букву, "Пр" собирается слияниями "П" + "р"
+	if got := enc.Count("Привет"); got != 9 {
+		t.Errorf("Count(Привет) = %d, want 9", got)
+	}
+	if got := enc.Count("hello world"); got != len(enc.Encode("hello world")) {
+		t.Errorf("Count() = %d, want len(Encode()) = %d", got, len(enc.Encode("hello world")))
+	}
+}
+
+func TestLoadEncoding_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		data     string
+	}{
+		{"unknown encoding", "p50k_base", testVocabulary()},
+		{"malformed line", Cl100kBase, testVocabulary() + "abc\n"},
+		{"bad rank", Cl100kBase, testVocabulary() + "YQ== x\n"},
+		{"missing bytes", Cl100kBase, "YQ== 0\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadEncoding(tt.encoding, strings.NewReader(tt.data)); err == nil {
+				t.Errorf("LoadEncoding() should return error")
+			}
+		})
+	}
+}
+
+func TestEncodingForModel(t *testing.T) {
+	tests := []struct {
+		model string
+		want  string
+	}{
+		{"gpt-4o-mini", O200kBase},
+		{"openai/gpt-4.1", O200kBase},
+		{"o3-mini", O200kBase},
+		{"gpt-4-turbo", Cl100kBase},
+		{"GPT-3.5-turbo", Cl100kBase},
+		{"deepseek/deepseek-v3.2", DefaultEncoding},
+		{"llama3", DefaultEncoding},
+	}
+	for _, tt := range tests {
+		if got := EncodingForModel(tt.model); got != tt.want {
+			t.Errorf("EncodingForModel(%q) = %q, want %q", tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestLoader_ForModel(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, Cl100kBase+vocabularyExt), []byte(testVocabulary("he", "ll", "hell")), 0600); err != nil {
+		t.Fatal(err)
+	}
+	loader := NewLoader(dir)
+
+	counter := loader.ForModel("llama3")
+	if !counter.Exact() {
+		t.Fatalf("ForModel(llama3) should use %s vocabulary", Cl100kBase)
+	}
+	if got := counter.Count("hello"); got != 2 {
+		t.Errorf("Count(hello) = %d, want 2", got)
+	}
+
+	t.Run("missing vocabulary falls back to estimate", func(t *testing.T) {
+		counter := loader.ForModel("gpt-4o")
+		if counter.Exact() {
+			t.Fatalf("counter without vocabulary should not be exact")
+		}
+		if got := counter.Count(strings.Repeat("a", 40)); got != 10 {
+			t.Errorf("Count() = %d, want 10", got)
+		}
+	})
+}
@@ -8,6 +8,7 @@ import (
 
 	"app/internal/api"
 	"app/internal/config"
+	"app/internal/tokenizer"
 	"app/internal/ui"
 )
 
@@ -71,5 +72,6 @@ func main() {
 	}
 
 	// Выводим сравнение
-	api.PrintComparison(resp1, resp2, dur1, dur2)
+	counter := tokenizer.NewLoader(cfg.TokenizerDir).ForModel(cfg.Model)
+	api.PrintComparison(resp1, resp2, dur1, dur2, counter)
 }
@@ -0,0 +1,206 @@
+// Package batch прогоняет файл запросов JSONL через модель: запросы выполняются
+// пулом воркеров с ограничением частоты, результаты пишутся в JSONL в порядке входного файла.
+// Обработку можно продолжить после сбоя: строки, для которых результат уже записан, пропускаются.
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"llm-client/internal/chat"
+	"llm-client/internal/client"
+	"llm-client/internal/config"
+	apperrors "llm-client/internal/errors"
+	"llm-client/internal/logger"
+)
+
+// Request - строка входного файла: prompt или messages и необязательные
+// переопределения параметров модели
+type Request struct {
+	// ID - идентификатор строки, копируется в результат
+	ID string `json:"id,omitempty"`
+	// Prompt - текст запроса; с messages добавляется последним сообщением пользователя
+	Prompt string `json:"prompt,omitempty"`
+	// Messages - диалог целиком
+	Messages []chat.Message `json:"messages,omitempty"`
+	// System - системный промпт вместо промпта из конфигурации
+	System string `json:"system,omitempty"`
+
+	Model       string   `json:"model,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	MaxTokens   *int     `json:"max_tokens,omitempty"`
+}
+
+// Result - строка выходного файла
+type Result struct {
+	// Line - номер строки входного файла (с 1)
+	Line         int                `json:"line"`
+	ID           string             `json:"id,omitempty"`
+	Model        string             `json:"model,omitempty"`
+	Content      string             `json:"content,omitempty"`
+	FinishReason string             `json:"finish_reason,omitempty"`
+	Usage        *client.Usage      `json:"usage,omitempty"`
+	LatencyMs    int64              `json:"latency_ms"`
+	Error        *apperrors.Details `json:"error,omitempty"`
+}
+
+// Summary - итоги обработки
+type Summary struct {
+	// Total - строк с запросами во входном файле
+	Total int
+	// Skipped - строк, обработанных в предыдущих запусках
+	Skipped   int
+	Succeeded int
+	Failed    int
+}
+
+// Runner выполняет пакетную обработку
+type Runner struct {
+	client      *client.Client
+	config      *config.Config
+	concurrency int
+	rate        float64
+	logger      *logger.Logger
+	progress    func(Result)
+}
+
+// Option - функция опция для настройки Runner
+type Option func(*Runner)
+
+// WithConcurrency устанавливает число одновременных запросов (по умолчанию 4)
+func WithConcurrency(n int) Option {
+	return func(r *Runner) {
+		if n > 0 {
+			r.concurrency = n
+		}
+	}
+}
+
+// WithRate ограничивает частоту запросов (в секунду, 0 - без ограничения)
+func WithRate(perSecond float64) Option {
+	return func(r *Runner) {
+		r.rate = perSecond
+	}
+}
+
+// WithLogger устанавливает логгер
+func WithLogger(log *logger.Logger) Option {
+	return func(r *Runner) {
+		r.logger = log
+	}
+}
+
+// WithProgress устанавливает функцию, вызываемую после записи каждого результата
+func WithProgress(fn func(Result)) Option {
+	return func(r *Runner) {
+		r.progress = fn
+	}
+}
+
+// New создаёт Runner
+func New(cfg *config.Config, c *client.Client, opts ...Option) *Runner {
+	r := &Runner{
+		client:      c,
+		config:      cfg,
+		concurrency: 4,
+		logger:      logger.DefaultLogger,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// execute выполняет запрос одной строки
+func (r *Runner) execute(ctx context.Context, line int, raw []byte) Result {
+	result := Result{Line: line}
+
+	var row Request
+	if err := json.Unmarshal(raw, &row); err != nil {
+		return r.failed(result, apperrors.NewValidationError("PARSE_ERROR", "invalid JSON in request line", err))
+	}
+	result.ID = row.ID
+
+	req, err := r.buildRequest(row)
+	if err != nil {
+		return r.failed(result, err)
+	}
+	result.Model = req.Model
+
+	start := time.Now()
+	completion, err := r.client.ChatCompletion(ctx, req)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		return r.failed(result, err)
+	}
+
+	result.Content = completion.Message.Content
+	result.FinishReason = completion.FinishReason
+	result.Usage = completion.Usage
+	return result
+}
+
+// failed заполняет описание ошибки результата
+func (r *Runner) failed(result Result, err error) Result {
+	details := apperrors.Describe(err)
+	result.Error = &details
+	return result
+}
+
+// buildRequest собирает запрос из строки и настроек конфигурации
+func (r *Runner) buildRequest(row Request) (*client.ChatRequest, error) {
+	if row.Prompt == "" && len(row.Messages) == 0 {
+		return nil, apperrors.NewValidationError("EMPTY_REQUEST", "request has neither prompt nor messages", nil)
+	}
+
+	system := r.config.Model.SystemPrompt
+	if row.System != "" {
+		system = row.System
+	}
+
+	history := chat.NewChatHistory(system)
+	if len(row.Messages) > 0 {
+		for _, msg := range row.Messages {
+			if !msg.Role.IsValid() {
+				return nil, apperrors.NewValidationError("INVALID_ROLE", "invalid message role", apperrors.ErrInvalidMessage).
+					WithContext("role", msg.Role)
+			}
+		}
+		history = chat.NewChatHistoryFromMessages(row.Messages)
+		if row.System != "" || row.Messages[0].Role != chat.RoleSystem {
+			history.SetSystemPrompt(system)
+		}
+	}
+	if row.Prompt != "" {
+		history.AddUser(row.Prompt)
+	}
+
+	req := &client.ChatRequest{
+		Model:       r.config.Model.Name,
+		Messages:    history.GetMessages(),
+		Temperature: r.config.Model.Temperature,
+		TopP:        r.config.Model.TopP,
+		MaxTokens:   r.config.Model.MaxTokens,
+	}
+	if row.Model != "" {
+		req.Model = row.Model
+	}
+	if row.Temperature != nil {
+		req.Temperature = *row.Temperature
+	}
+	if row.TopP != nil {
+		req.TopP = *row.TopP
+	}
+	if row.MaxTokens != nil {
+		req.MaxTokens = *row.MaxTokens
+	}
+	return req, nil
+}
+
+// interrupted сообщает, что запрос не выполнен из-за остановки обработки;
+// такой результат не записывается, строка будет выполнена при следующем запуске
+func interrupted(ctx context.Context, result Result) bool {
+	return ctx.Err() != nil && result.Error != nil
+}
@@ -0,0 +1,197 @@
+package batch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"llm-client/internal/client"
+	"llm-client/internal/config"
+	"llm-client/internal/logger"
+)
+
+// newTestRunner создаёт Runner с сервером, который отвечает "echo: <последнее сообщение>".
+// Запросы с текстом "slow" отвечают с задержкой, "fail" - ошибкой 400.
+func newTestRunner(t *testing.T, calls *int32, opts ...Option) *Runner {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		var req client.ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		last := req.Messages[len(req.Messages)-1].Content
+
+		switch last {
+		case "slow":
+			time.Sleep(50 * time.Millisecond)
+		case "fail":
+			http.Error(w, `{"error":"bad request"}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{
+				"message":       map[string]string{"role": "assistant", "content": req.Model + " echo: " + last},
+				"finish_reason": "stop",
+			}},
+			"usage": map[string]int{"prompt_tokens": len(req.Messages), "completion_tokens": 2},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := config.DefaultConfig()
+	cfg.Server.Address = server.URL
+	cfg.Server.Retry.MaxAttempts = 1
+	log := logger.NewLogger(logger.Config{Enabled: false})
+	opts = append([]Option{WithLogger(log)}, opts...)
+	return New(cfg, client.NewClientFromConfig(cfg, client.WithLogger(log)), opts...)
+}
+
+func writeLines(t *testing.T, path string, lines ...string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readResults(t *testing.T, path string) []Result {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var results []Result
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r Result
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("invalid result line %q: %v", scanner.Text(), err)
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+func TestRunner_Run(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.jsonl")
+	output := filepath.Join(dir, "out.jsonl")
+	writeLines(t, input,
+		`{"id":"a","prompt":"slow"}`,
+		``,
+		`{"id":"b","messages":[{"role":"user","content":"hi"},{"role":"assistant","content":"hello"},{"role":"user","content":"bye"}],"model":"other"}`,
+		`{"id":"c","prompt":"fail"}`,
+		`not json`,
+		`{"id":"e"}`,
+	)
+
+	var calls int32
+	r := newTestRunner(t, &calls, WithConcurrency(3))
+	summary, err := r.Run(context.Background(), input, output)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if summary.Total != 5 || summary.Succeeded != 2 || summary.Failed != 3 {
+		t.Errorf("summary = %+v", summary)
+	}
+
+	results := readResults(t, output)
+	var lines []string
+	for _, res := range results {
+		lines = append(lines, fmt.Sprint(res.Line))
+	}
+	// Порядок входного файла сохраняется, несмотря на медленный первый запрос
+	if got := strings.Join(lines, ","); got != "1,3,4,5,6" {
+		t.Fatalf("result lines = %s", got)
+	}
+
+	if results[0].ID != "a" || results[0].Content != "llama3 echo: slow" || results[0].LatencyMs < 50 {
+		t.Errorf("first result = %+v", results[0])
+	}
+	// Переопределение модели и диалог с системным промптом из конфигурации
+	if results[1].Content != "other echo: bye" || results[1].Usage == nil || results[1].Usage.PromptTokens != 4 {
+		t.Errorf("messages result = %+v, usage = %+v", results[1], results[1].Usage)
+	}
+	for i, kind := range map[int]string{2: "api", 3: "validation", 4: "validation"} {
+		if results[i].Error == nil || results[i].Error.Kind != kind {
+			t.Errorf("result %d error = %+v, want kind %s", i, results[i].Error, kind)
+		}
+	}
+	if results[2].Error.StatusCode != http.StatusBadRequest {
+		t.Errorf("api error status = %d", results[2].Error.StatusCode)
+	}
+}
+
+func TestRunner_Resume(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.jsonl")
+	output := filepath.Join(dir, "out.jsonl")
+	writeLines(t, input, `{"prompt":"q1"}`, `{"prompt":"q2"}`, `{"prompt":"q3"}`)
+
+	// Первый запуск упал, успев записать первую строку и половину второй
+	os.WriteFile(output, []byte(`{"line":1,"content":"done before","latency_ms":1}`+"\n"+`{"line":2,"cont`), 0644)
+
+	var calls int32
+	summary, err := newTestRunner(t, &calls).Run(context.Background(), input, output)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if summary.Skipped != 1 || summary.Succeeded != 2 || calls != 2 {
+		t.Errorf("summary = %+v, calls = %d", summary, calls)
+	}
+
+	results := readResults(t, output)
+	if len(results) != 3 || results[0].Content != "done before" || results[1].Line != 2 || results[2].Line != 3 {
+		t.Errorf("results = %+v", results)
+	}
+
+	// Повторный запуск ничего не отправляет
+	calls = 0
+	summary, _ = newTestRunner(t, &calls).Run(context.Background(), input, output)
+	if calls != 0 || summary.Skipped != 3 {
+		t.Errorf("completed batch should be skipped, summary = %+v, calls = %d", summary, calls)
+	}
+}
+
+func TestRunner_Cancel(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.jsonl")
+	output := filepath.Join(dir, "out.jsonl")
+	writeLines(t, input, `{"prompt":"q1"}`, `{"prompt":"slow"}`, `{"prompt":"q3"}`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int32
+	r := newTestRunner(t, &calls, WithConcurrency(1), WithProgress(func(Result) { cancel() }))
+
+	if _, err := r.Run(ctx, input, output); err != context.Canceled {
+		t.Errorf("Run() error = %v, want context.Canceled", err)
+	}
+	// Прерванный запрос не записан как ошибка и будет выполнен при следующем запуске
+	if results := readResults(t, output); len(results) != 1 || results[0].Error != nil {
+		t.Errorf("results = %+v", results)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter(100)
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := l.wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Пять запросов при 100/с занимают не меньше 40 мс
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("limiter allowed 5 requests in %v", elapsed)
+	}
+}
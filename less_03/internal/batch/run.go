@@ -0,0 +1,270 @@
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	apperrors "llm-client/internal/errors"
+)
+
+// maxLineSize - максимальный размер строки входного файла
+const maxLineSize = 16 << 20
+
+// job - строка входного файла, ожидающая выполнения
+type job struct {
+	// seq - порядковый номер среди невыполненных строк, задаёт порядок записи
+	seq  int
+	line int
+	raw  []byte
+}
+
+// done - выполненная строка
+type done struct {
+	seq    int
+	result Result
+}
+
+// Run обрабатывает входной файл и дописывает результаты в выходной.
+// Строки, результаты которых уже есть в выходном файле, пропускаются.
+// При отмене контекста незавершённые запросы не записываются и будут выполнены при следующем запуске.
+func (r *Runner) Run(ctx context.Context, inputPath, outputPath string) (Summary, error) {
+	jobs, total, err := readInput(inputPath)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	completed, err := loadCompleted(outputPath)
+	if err != nil {
+		return Summary{}, err
+	}
+	pending := jobs[:0]
+	for _, j := range jobs {
+		if !completed[j.line] {
+			j.seq = len(pending)
+			pending = append(pending, j)
+		}
+	}
+	summary := Summary{Total: total, Skipped: total - len(pending)}
+
+	out, err := os.OpenFile(outputPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return summary, apperrors.NewInternalError("OPEN_ERROR", "failed to open output file", err).
+			WithContext("path", outputPath)
+	}
+	defer out.Close()
+
+	r.logger.Info("Batch started",
+		"input", inputPath,
+		"output", outputPath,
+		"total", total,
+		"skipped", summary.Skipped,
+		"concurrency", r.concurrency,
+		"rate", r.rate,
+	)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	queue := make(chan job)
+	results := make(chan done)
+	limit := newLimiter(r.rate)
+
+	go func() {
+		defer close(queue)
+		for _, j := range pending {
+			select {
+			case queue <- j:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < r.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				if err := limit.wait(ctx); err != nil {
+					return
+				}
+				results <- done{seq: j.seq, result: r.execute(ctx, j.line, j.raw)}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Результаты пишутся строго по порядку: пришедшие раньше ждут в буфере
+	buffered := make(map[int]Result)
+	next := 0
+	var writeErr error
+	for d := range results {
+		if writeErr != nil || interrupted(ctx, d.result) {
+			continue
+		}
+		buffered[d.seq] = d.result
+
+		for {
+			result, ok := buffered[next]
+			if !ok {
+				break
+			}
+			delete(buffered, next)
+			next++
+
+			if err := writeResult(out, result); err != nil {
+				writeErr = apperrors.NewInternalError("WRITE_ERROR", "failed to write result", err).
+					WithContext("path", outputPath)
+				// Без записи результатов продолжать бессмысленно
+				cancel()
+				break
+			}
+			if result.Error != nil {
+				summary.Failed++
+				r.logger.Warn("Batch request failed", "line", result.Line, "error", result.Error.Message)
+			} else {
+				summary.Succeeded++
+			}
+			if r.progress != nil {
+				r.progress(result)
+			}
+		}
+	}
+
+	r.logger.Info("Batch finished",
+		"succeeded", summary.Succeeded,
+		"failed", summary.Failed,
+		"remaining", len(pending)-next,
+	)
+	if writeErr != nil {
+		return summary, writeErr
+	}
+	return summary, ctx.Err()
+}
+
+// readInput читает входной файл; пустые строки пропускаются.
+// Возвращает строки с запросами и их количество.
+func readInput(path string) ([]job, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, apperrors.NewValidationError("INPUT_NOT_FOUND", "failed to open input file", err).
+			WithContext("path", path)
+	}
+	defer f.Close()
+
+	var jobs []job
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		jobs = append(jobs, job{line: line, raw: append([]byte(nil), raw...)})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, apperrors.NewValidationError("READ_ERROR", "failed to read input file", err).
+			WithContext("path", path)
+	}
+	return jobs, len(jobs), nil
+}
+
+// loadCompleted возвращает номера строк, результаты которых уже записаны.
+// Недописанная при сбое последняя строка обрезается, чтобы продолжить запись с чистой строки.
+func loadCompleted(path string) (map[int]bool, error) {
+	completed := make(map[int]bool)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return completed, nil
+		}
+		return nil, apperrors.NewInternalError("READ_ERROR", "failed to read output file", err).
+			WithContext("path", path)
+	}
+
+	valid := 0
+	for offset := 0; offset < len(data); {
+		end := bytes.IndexByte(data[offset:], '\n')
+		if end < 0 {
+			// Строка без перевода строки - запись прервана
+			break
+		}
+		var result Result
+		if err := json.Unmarshal(data[offset:offset+end], &result); err != nil {
+			return nil, apperrors.NewValidationError("PARSE_ERROR", "output file contains invalid result line", err).
+				WithContext("path", path).
+				WithContext("offset", offset)
+		}
+		completed[result.Line] = true
+		offset += end + 1
+		valid = offset
+	}
+
+	if valid < len(data) {
+		if err := os.Truncate(path, int64(valid)); err != nil {
+			return nil, apperrors.NewInternalError("WRITE_ERROR", "failed to truncate partial result", err).
+				WithContext("path", path)
+		}
+	}
+	return completed, nil
+}
+
+// writeResult записывает результат одной строкой
+func writeResult(f *os.File, result Result) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// limiter равномерно распределяет запросы во времени
+type limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// newLimiter создаёт ограничитель на perSecond запросов в секунду (0 - без ограничения)
+func newLimiter(perSecond float64) *limiter {
+	if perSecond <= 0 {
+		return &limiter{}
+	}
+	return &limiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// wait ждёт очередного разрешённого момента отправки запроса
+func (l *limiter) wait(ctx context.Context) error {
+	if l.interval == 0 {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	at := l.next
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(at))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
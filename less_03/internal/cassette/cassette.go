@@ -0,0 +1,538 @@
+// Package cassette записывает HTTP обмен с провайдером в файлы и воспроизводит его
+// без сети: для детерминированных тестов и демонстрации интерфейса офлайн.
+package cassette
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	apperrors "llm-client/internal/errors"
+	"llm-client/internal/logger"
+)
+
+// Mode - режим работы кассет
+type Mode string
+
+const (
+	// ModeRecord - запросы уходят провайдеру, ответы сохраняются в файлы
+	ModeRecord Mode = "record"
+	// ModeReplay - ответы берутся из файлов, сеть не используется
+	ModeReplay Mode = "replay"
+)
+
+const (
+	// fileExt - расширение файлов кассет
+	fileExt = ".json"
+	// keyLength - количество шестнадцатеричных символов SHA-256 в ключе запроса
+	keyLength = 16
+)
+
+// Cassette - запрос и записанные на него ответы в порядке получения.
+// Повторы одного запроса (retry, /regen) получают ответы по очереди, последний - повторяется.
+type Cassette struct {
+	Request   Request    `json:"request"`
+	Responses []Response `json:"responses"`
+}
+
+// Request - записанный запрос; заголовки сохраняются без учётных данных
+type Request struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Header http.Header     `json:"header,omitempty"`
+	Body   json.RawMessage `json:"body"`
+}
+
+// Response - записанный ответ
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	// DelayMs - время до получения заголовков ответа
+	DelayMs int64 `json:"delay_ms"`
+	// Body - тело обычного ответа
+	Body string `json:"body,omitempty"`
+	// Events - тело потокового ответа по частям с задержками между ними
+	Events []Event `json:"events,omitempty"`
+}
+
+// Event - часть потокового ответа из целых строк
+type Event struct {
+	// DelayMs - задержка после предыдущей части (для первой - после заголовков)
+	DelayMs int64  `json:"delay_ms"`
+	Data    string `json:"data"`
+}
+
+// Option - функция опция для настройки Transport
+type Option func(*Transport)
+
+// WithTransport устанавливает транспорт, через который запросы уходят при записи
+func WithTransport(next http.RoundTripper) Option {
+	return func(t *Transport) {
+		t.next = next
+	}
+}
+
+// WithSpeed устанавливает скорость воспроизведения: 1 - как при записи,
+// 2 - вдвое быстрее, 0 - без задержек
+func WithSpeed(speed float64) Option {
+	return func(t *Transport) {
+		t.speed = speed
+	}
+}
+
+// WithRedactor устанавливает правила скрытия значений в сохраняемых заголовках
+func WithRedactor(r *logger.Redactor) Option {
+	return func(t *Transport) {
+		t.redactor = r
+	}
+}
+
+// WithLogger устанавливает логгер
+func WithLogger(log *logger.Logger) Option {
+	return func(t *Transport) {
+		t.logger = log
+	}
+}
+
+// Transport - http.RoundTripper, записывающий или воспроизводящий кассеты.
+// Запросы сопоставляются по методу, пути и телу (модель, сообщения и параметры);
+// адрес сервера, query и заголовки в сопоставлении не участвуют.
+type Transport struct {
+	dir      string
+	mode     Mode
+	next     http.RoundTripper
+	speed    float64
+	redactor *logger.Redactor
+	logger   *logger.Logger
+
+	mu        sync.Mutex
+	cassettes map[string]*Cassette
+	// played - номер следующего ответа кассеты при воспроизведении
+	played map[string]int
+}
+
+// New создаёт транспорт с кассетами в директории dir.
+// При воспроизведении все кассеты загружаются сразу; директория без кассет - ошибка.
+func New(dir string, mode Mode, opts ...Option) (*Transport, error) {
+	t := &Transport{
+		dir:       dir,
+		mode:      mode,
+		next:      http.DefaultTransport,
+		speed:     1,
+		logger:    logger.DefaultLogger,
+		cassettes: make(map[string]*Cassette),
+		played:    make(map[string]int),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	if t.redactor == nil {
+		t.redactor, _ = logger.NewRedactor(logger.RedactConfig{Mode: logger.RedactMask})
+	}
+
+	switch mode {
+	case ModeRecord:
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, apperrors.NewConfigError("CASSETTE_DIR", "failed to create cassette directory", err).
+				WithContext("dir", dir)
+		}
+	case ModeReplay:
+		if err := t.load(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, apperrors.NewConfigError("INVALID_CASSETTE_MODE", fmt.Sprintf("unknown cassette mode %q", mode), nil)
+	}
+	return t, nil
+}
+
+// Client возвращает HTTP клиент с этим транспортом для client.WithHTTPClient
+func (t *Transport) Client() *http.Client {
+	return &http.Client{Transport: t}
+}
+
+// Len возвращает количество кассет (разных запросов)
+func (t *Transport) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.cassettes)
+}
+
+// RoundTrip выполняет запрос в режиме транспорта
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = data
+	}
+	key := requestKey(req.Method, req.URL.Path, body)
+
+	if t.mode == ModeReplay {
+		return t.replay(req, key)
+	}
+	return t.record(req, key, body)
+}
+
+// load читает кассеты из директории
+func (t *Transport) load() error {
+	paths, err := filepath.Glob(filepath.Join(t.dir, "*"+fileExt))
+	if err != nil || len(paths) == 0 {
+		return apperrors.NewConfigError("CASSETTES_NOT_FOUND", "no cassettes found", err).
+			WithContext("dir", t.dir)
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return apperrors.NewConfigError("CASSETTE_READ", "failed to read cassette", err).
+				WithContext("path", path)
+		}
+		var c Cassette
+		if err := json.Unmarshal(data, &c); err != nil {
+			return apperrors.NewConfigError("INVALID_CASSETTE", "failed to parse cassette", err).
+				WithContext("path", path)
+		}
+		if len(c.Responses) == 0 {
+			return apperrors.NewConfigError("INVALID_CASSETTE", "cassette has no responses", nil).
+				WithContext("path", path)
+		}
+		// Ключ вычисляется заново: кассету можно поправить вручную
+		t.cassettes[requestKey(c.Request.Method, c.Request.Path, c.Request.Body)] = &c
+	}
+	return nil
+}
+
+// replay возвращает следующий записанный ответ на запрос
+func (t *Transport) replay(req *http.Request, key string) (*http.Response, error) {
+	t.mu.Lock()
+	c, ok := t.cassettes[key]
+	var rec Response
+	if ok {
+		i := t.played[key]
+		if i >= len(c.Responses) {
+			i = len(c.Responses) - 1
+		}
+		rec = c.Responses[i]
+		t.played[key]++
+	}
+	t.mu.Unlock()
+
+	if !ok {
+		t.logger.WarnContext(req.Context(), "Cassette not found", "method", req.Method, "path", req.URL.Path, "key", key)
+		// Ответ, а не ошибка транспорта: отсутствие записи не должно повторяться как сбой сети
+		message, _ := json.Marshal(fmt.Sprintf("no recorded response for %s %s (cassette %s)", req.Method, req.URL.Path, key))
+		return newResponse(req, http.StatusNotFound, http.Header{"Content-Type": {"application/json"}},
+			io.NopCloser(strings.NewReader(`{"error":{"message":`+string(message)+`}}`))), nil
+	}
+
+	if err := sleep(req, t.delay(rec.DelayMs)); err != nil {
+		return nil, err
+	}
+
+	var body io.ReadCloser
+	switch {
+	case len(rec.Events) == 0:
+		body = io.NopCloser(strings.NewReader(rec.Body))
+	case t.speed <= 0:
+		var b strings.Builder
+		for _, ev := range rec.Events {
+			b.WriteString(ev.Data)
+		}
+		body = io.NopCloser(strings.NewReader(b.String()))
+	default:
+		body = t.playEvents(req, rec.Events)
+	}
+	return newResponse(req, rec.Status, rec.Header.Clone(), body), nil
+}
+
+// playEvents отдаёт части потокового ответа с записанными задержками
+func (t *Transport) playEvents(req *http.Request, events []Event) io.ReadCloser {
+	pr, pw := io.Pipe()
+	stop := make(chan struct{})
+	go func() {
+		for _, ev := range events {
+			select {
+			case <-stop:
+				return
+			case <-req.Context().Done():
+				pw.CloseWithError(req.Context().Err())
+				return
+			case <-time.After(t.delay(ev.DelayMs)):
+			}
+			if _, err := pw.Write([]byte(ev.Data)); err != nil {
+				return
+			}
+		}
+		pw.Close()
+	}()
+	return &replayBody{PipeReader: pr, stop: stop}
+}
+
+// replayBody останавливает воспроизведение при закрытии тела ответа
+type replayBody struct {
+	*io.PipeReader
+	stop chan struct{}
+	once sync.Once
+}
+
+// Close закрывает тело и останавливает горутину воспроизведения
+func (b *replayBody) Close() error {
+	b.once.Do(func() { close(b.stop) })
+	return b.PipeReader.Close()
+}
+
+// delay переводит записанную задержку с учётом скорости воспроизведения
+func (t *Transport) delay(ms int64) time.Duration {
+	if t.speed <= 0 || ms <= 0 {
+		return 0
+	}
+	return time.Duration(float64(ms) * float64(time.Millisecond) / t.speed)
+}
+
+// record отправляет запрос провайдеру и записывает ответ по мере чтения тела.
+// Кассета сохраняется при закрытии тела, если ответ прочитан без ошибок и запрос не отменён.
+func (t *Transport) record(req *http.Request, key string, body []byte) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	out.ContentLength = int64(len(body))
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+
+	header := t.redactor.Header(resp.Header)
+	header.Del("Content-Length")
+	rec := &recorder{
+		ReadCloser: resp.Body,
+		req:        req,
+		last:       time.Now(),
+		stream:     isStream(resp.Header.Get("Content-Type")),
+		response: Response{
+			Status:  resp.StatusCode,
+			Header:  header,
+			DelayMs: time.Since(start).Milliseconds(),
+		},
+		save: func(r Response) {
+			t.save(req, key, Request{
+				Method: req.Method,
+				Path:   req.URL.Path,
+				Header: t.redactor.Header(req.Header),
+				Body:   rawBody(body),
+			}, r)
+		},
+	}
+	resp.Body = rec
+	return resp, nil
+}
+
+// save добавляет ответ в кассету запроса и записывает её в файл.
+// Первая запись запроса за время работы транспорта заменяет старый файл.
+func (t *Transport) save(req *http.Request, key string, request Request, resp Response) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c, ok := t.cassettes[key]
+	if !ok {
+		c = &Cassette{Request: request}
+		t.cassettes[key] = c
+	}
+	c.Responses = append(c.Responses, resp)
+
+	if err := writeCassette(filepath.Join(t.dir, key+fileExt), c); err != nil {
+		t.logger.ErrorContext(req.Context(), "Failed to save cassette", "key", key, "error", err)
+		return
+	}
+	t.logger.DebugContext(req.Context(), "Cassette recorded", "key", key, "status", resp.Status, "responses", len(c.Responses))
+}
+
+// recorder записывает тело ответа при чтении. Части потока разбиваются по границам строк,
+// чтобы каждая часть была целым текстом (многобайтовые символы не разрываются).
+type recorder struct {
+	io.ReadCloser
+	req      *http.Request
+	stream   bool
+	response Response
+	save     func(Response)
+
+	mu      sync.Mutex
+	pending []byte
+	last    time.Time
+	failed  bool
+	closed  bool
+}
+
+// Read читает тело и запоминает полученные данные
+func (r *recorder) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if n > 0 {
+		r.pending = append(r.pending, p[:n]...)
+		if i := bytes.LastIndexByte(r.pending, '\n'); i >= 0 {
+			r.emit(r.pending[:i+1])
+			r.pending = append([]byte(nil), r.pending[i+1:]...)
+		}
+	}
+	if err != nil && err != io.EOF {
+		r.failed = true
+	}
+	return n, err
+}
+
+// emit добавляет часть потока с задержкой после предыдущей
+func (r *recorder) emit(data []byte) {
+	now := time.Now()
+	r.response.Events = append(r.response.Events, Event{
+		DelayMs: now.Sub(r.last).Milliseconds(),
+		Data:    string(data),
+	})
+	r.last = now
+}
+
+// Close закрывает тело и сохраняет ответ
+func (r *recorder) Close() error {
+	err := r.ReadCloser.Close()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return err
+	}
+	r.closed = true
+	if r.failed || r.req.Context().Err() != nil {
+		return err
+	}
+	if len(r.pending) > 0 {
+		r.emit(r.pending)
+		r.pending = nil
+	}
+
+	resp := r.response
+	if !r.stream {
+		var b strings.Builder
+		for _, ev := range resp.Events {
+			b.WriteString(ev.Data)
+		}
+		resp.Body = b.String()
+		resp.Events = nil
+	}
+	r.save(resp)
+	return err
+}
+
+// requestKey вычисляет ключ запроса по методу, пути и телу.
+// JSON тело приводится к каноническому виду: порядок полей и пробелы не влияют на ключ.
+func requestKey(method, path string, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", method, path)
+	h.Write(canonicalJSON(body))
+	return hex.EncodeToString(h.Sum(nil))[:keyLength]
+}
+
+// canonicalJSON возвращает JSON с отсортированными полями без пробелов
+// (тело не в формате JSON возвращается как есть)
+func canonicalJSON(body []byte) []byte {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return body
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return data
+}
+
+// rawBody сохраняет тело запроса как JSON; другое содержимое записывается строкой
+func rawBody(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return json.RawMessage("null")
+	}
+	if json.Valid(body) {
+		return json.RawMessage(body)
+	}
+	data, _ := json.Marshal(string(body))
+	return data
+}
+
+// isStream сообщает, что ответ потоковый (SSE или NDJSON)
+func isStream(contentType string) bool {
+	return strings.Contains(contentType, "event-stream") || strings.Contains(contentType, "ndjson")
+}
+
+// newResponse создаёт ответ на запрос
+func newResponse(req *http.Request, status int, header http.Header, body io.ReadCloser) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          body,
+		ContentLength: -1,
+		Request:       req,
+	}
+}
+
+// sleep ждёт d или отмены запроса
+func sleep(req *http.Request, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-req.Context().Done():
+		return req.Context().Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// writeCassette атомарно записывает кассету в файл
+func writeCassette(path string, c *Cassette) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
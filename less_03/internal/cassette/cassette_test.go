@@ -0,0 +1,215 @@
+package cassette
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"llm-client/internal/chat"
+	"llm-client/internal/client"
+	apperrors "llm-client/internal/errors"
+	"llm-client/internal/logger"
+)
+
+const testKey = "sk-proj-abcdefghijklmnopqrstuvwx"
+
+// provider - сервер с потоковым и обычным ответом; считает обращения
+func provider(t *testing.T, calls *atomic.Int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if !strings.Contains(readAll(r), `"stream":true`) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ответ ` + string(rune('0'+n)) + `"}}]}`))
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Set-Cookie", "session=secret")
+		for _, token := range []string{"При", "вет"} {
+			w.Write([]byte(`data: {"choices":[{"delta":{"content":"` + token + `"}}]}` + "\n\n"))
+			w.(http.Flusher).Flush()
+			time.Sleep(30 * time.Millisecond)
+		}
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func readAll(r *http.Request) string {
+	var b strings.Builder
+	buf := make([]byte, 512)
+	for {
+		n, err := r.Body.Read(buf)
+		b.Write(buf[:n])
+		if err != nil {
+			return b.String()
+		}
+	}
+}
+
+func newClient(t *testing.T, address string, transport *Transport) *client.Client {
+	t.Helper()
+	return client.NewClient(address, "/v1/chat/completions",
+		client.WithHTTPClient(transport.Client()),
+		client.WithAPIKey(testKey),
+		client.WithLogger(logger.NewLogger(logger.Config{Enabled: false})),
+	)
+}
+
+func request(content string, stream bool) *client.ChatRequest {
+	return &client.ChatRequest{
+		Model:       "test-model",
+		Messages:    []chat.Message{{Role: chat.RoleUser, Content: content}},
+		Temperature: 0.7,
+		Stream:      stream,
+	}
+}
+
+func streamText(t *testing.T, c *client.Client, req *client.ChatRequest) string {
+	t.Helper()
+	var b strings.Builder
+	for chunk := range c.ChatStream(context.Background(), req) {
+		if chunk.Error != nil {
+			t.Fatalf("stream error: %v", chunk.Error)
+		}
+		b.WriteString(chunk.Content)
+	}
+	return b.String()
+}
+
+func TestTransport_RecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	var calls atomic.Int32
+	server := provider(t, &calls)
+
+	recorder, err := New(dir, ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newClient(t, server.URL, recorder)
+	if got := streamText(t, c, request("Привет", true)); got != "Привет" {
+		t.Fatalf("recorded stream = %q", got)
+	}
+	for _, want := range []string{"ответ 2", "ответ 3"} {
+		if got, err := c.Chat(context.Background(), request("Как дела?", false)); err != nil || got != want {
+			t.Fatalf("Chat() = %q, %v, want %q", got, err, want)
+		}
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 2 || recorder.Len() != 2 {
+		t.Fatalf("files = %v, want one cassette per request", files)
+	}
+	for _, path := range files {
+		data, _ := os.ReadFile(path)
+		if strings.Contains(string(data), testKey) || strings.Contains(string(data), "session=secret") {
+			t.Errorf("%s contains credentials:\n%s", path, data)
+		}
+	}
+
+	// Воспроизведение без сети: сервер остановлен
+	server.Close()
+	player, err := New(dir, ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c = newClient(t, "http://127.0.0.1:1", player)
+
+	start := time.Now()
+	if got := streamText(t, c, request("Привет", true)); got != "Привет" {
+		t.Errorf("replayed stream = %q", got)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("replay took %v, want recorded delays", elapsed)
+	}
+
+	// Ответы на повторы отдаются по очереди, последний повторяется
+	for _, want := range []string{"ответ 2", "ответ 3", "ответ 3"} {
+		if got, err := c.Chat(context.Background(), request("Как дела?", false)); err != nil || got != want {
+			t.Errorf("Chat() = %q, %v, want %q", got, err, want)
+		}
+	}
+
+	// Другие параметры - другой запрос
+	req := request("Как дела?", false)
+	req.Temperature = 0.1
+	_, err = c.Chat(context.Background(), req)
+	if apperrors.GetStatusCode(err) != http.StatusNotFound || !strings.Contains(err.Error(), "no recorded response") {
+		t.Errorf("Chat() error = %v, want cassette miss", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("provider calls = %d, want 3", calls.Load())
+	}
+}
+
+func TestTransport_ReplaySpeed(t *testing.T) {
+	dir := t.TempDir()
+	var calls atomic.Int32
+	recorder, _ := New(dir, ModeRecord)
+	streamText(t, newClient(t, provider(t, &calls).URL, recorder), request("Привет", true))
+
+	player, err := New(dir, ModeReplay, WithSpeed(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if got := streamText(t, newClient(t, "http://127.0.0.1:1", player), request("Привет", true)); got != "Привет" {
+		t.Errorf("replayed stream = %q", got)
+	}
+	if elapsed := time.Since(start); elapsed > 30*time.Millisecond {
+		t.Errorf("replay without delays took %v", elapsed)
+	}
+}
+
+func TestTransport_CancelledStreamNotRecorded(t *testing.T) {
+	dir := t.TempDir()
+	var calls atomic.Int32
+	recorder, _ := New(dir, ModeRecord)
+	c := newClient(t, provider(t, &calls).URL, recorder)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	for chunk := range c.ChatStream(ctx, request("Привет", true)) {
+		if chunk.Content != "" {
+			cancel()
+		}
+	}
+	cancel()
+
+	if files, _ := filepath.Glob(filepath.Join(dir, "*.json")); len(files) != 0 {
+		t.Errorf("cancelled stream should not be recorded: %v", files)
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	if _, err := New(t.TempDir(), ModeReplay); !apperrors.IsConfigError(err) {
+		t.Errorf("empty directory: error = %v, want config error", err)
+	}
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0600)
+	if _, err := New(dir, ModeReplay); !apperrors.IsConfigError(err) {
+		t.Errorf("broken cassette: error = %v, want config error", err)
+	}
+
+	if _, err := New(t.TempDir(), Mode("stream")); !apperrors.IsConfigError(err) {
+		t.Errorf("unknown mode: error = %v, want config error", err)
+	}
+}
+
+func TestRequestKey_CanonicalJSON(t *testing.T) {
+	a := requestKey("POST", "/v1/chat/completions", []byte(`{"model":"m","temperature":0.7}`))
+	b := requestKey("POST", "/v1/chat/completions", []byte("{\n  \"temperature\": 0.7,\n  \"model\": \"m\"\n}"))
+	if a != b {
+		t.Errorf("field order and whitespace should not change the key: %s != %s", a, b)
+	}
+	if a == requestKey("POST", "/v1/messages", []byte(`{"model":"m","temperature":0.7}`)) {
+		t.Errorf("path should change the key")
+	}
+}
@@ -0,0 +1,292 @@
+package chat
+
+import "errors"
+
+// Ошибки операций с ветками
+var (
+	// ErrInvalidIndex возвращается когда индекс не указывает на сообщение активной ветки
+	ErrInvalidIndex = errors.New("message index out of range")
+	// ErrNotUserMessage возвращается при попытке отредактировать не пользовательское сообщение
+	ErrNotUserMessage = errors.New("only user messages can be edited")
+	// ErrNothingToRegenerate возвращается когда в активной ветке нет ответа ассистента
+	ErrNothingToRegenerate = errors.New("no assistant message to regenerate")
+)
+
+// node - узел дерева сообщений
+type node struct {
+	msg      Message
+	parent   *node
+	children []*node
+	// active - индекс активного потомка, -1 если активная ветка заканчивается на этом узле
+	active int
+}
+
+// newNode создаёт узел без потомков
+func newNode(msg Message, parent *node) *node {
+	return &node{msg: msg, parent: parent, active: -1}
+}
+
+// addChild добавляет потомка и делает его активным
+func (n *node) addChild(msg Message) *node {
+	child := newNode(msg, n)
+	n.children = append(n.children, child)
+	n.active = len(n.children) - 1
+	return child
+}
+
+// removeChild удаляет потомка; активная ветка заканчивается на этом узле
+func (n *node) removeChild(child *node) {
+	for i, c := range n.children {
+		if c == child {
+			n.children = append(n.children[:i], n.children[i+1:]...)
+			break
+		}
+	}
+	n.active = -1
+}
+
+// path возвращает узлы активной ветки, начиная с первого потомка корня
+func (n *node) path() []*node {
+	var result []*node
+	for cur := n; cur.active >= 0; {
+		cur = cur.children[cur.active]
+		result = append(result, cur)
+	}
+	return result
+}
+
+// leaf возвращает последний узел активной ветки (сам узел, если ветка пуста)
+func (n *node) leaf() *node {
+	cur := n
+	for cur.active >= 0 {
+		cur = cur.children[cur.active]
+	}
+	return cur
+}
+
+// position возвращает индекс узла среди потомков родителя
+func (n *node) position() int {
+	for i, c := range n.parent.children {
+		if c == n {
+			return i
+		}
+	}
+	return -1
+}
+
+// clone создаёт глубокую копию поддерева
+func (n *node) clone(parent *node) *node {
+	c := &node{msg: n.msg, parent: parent, active: n.active}
+	c.msg.ToolCalls = append([]ToolCall(nil), n.msg.ToolCalls...)
+	for _, child := range n.children {
+		c.children = append(c.children, child.clone(c))
+	}
+	return c
+}
+
+// nodeAt возвращает узел активной ветки по индексу.
+// Индекс считается без системного промпта и совпадает с позицией в GetDisplayMessages.
+func (h *ChatHistory) nodeAt(index int) (*node, error) {
+	path := h.root.path()
+	if index < 0 || index >= len(path) {
+		return nil, ErrInvalidIndex
+	}
+	return path[index], nil
+}
+
+// Fork обрезает активную ветку перед сообщением index.
+// Само сообщение и его продолжение сохраняются как альтернативная ветка,
+// а следующее добавленное сообщение начнёт новую ветку на его месте.
+func (h *ChatHistory) Fork(index int) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	n, err := h.nodeAt(index)
+	if err != nil {
+		return err
+	}
+	n.parent.active = -1
+	return nil
+}
+
+// EditUser создаёт новую ветку с изменённым сообщением пользователя.
+// Исходное сообщение и ответы на него остаются доступны через SwitchBranch.
+func (h *ChatHistory) EditUser(index int, content string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	n, err := h.nodeAt(index)
+	if err != nil {
+		return err
+	}
+	if n.msg.Role != RoleUser {
+		return ErrNotUserMessage
+	}
+
+	// Вложения исходного сообщения сохраняются
+	n.parent.addChild(Message{Role: RoleUser, Content: content, Parts: n.msg.Parts})
+	return nil
+}
+
+// Regenerate готовит повторную генерацию последнего ответа ассистента:
+// ответ становится альтернативной веткой, а активная ветка заканчивается перед ним.
+// Ответ с вызовами инструментов заменяется целиком, начиная с первого сообщения
+// после последнего сообщения пользователя. Возвращает индекс заменяемого сообщения.
+func (h *ChatHistory) Regenerate() (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	path := h.root.path()
+	i := len(path) - 1
+	if i < 0 || path[i].msg.Role == RoleUser {
+		return -1, ErrNothingToRegenerate
+	}
+	for i > 0 && path[i-1].msg.Role != RoleUser {
+		i--
+	}
+	if path[i].msg.Role != RoleAssistant {
+		return -1, ErrNothingToRegenerate
+	}
+
+	path[i].parent.active = -1
+	return i, nil
+}
+
+// Siblings возвращает позицию сообщения index среди альтернативных веток и их количество
+func (h *ChatHistory) Siblings(index int) (current, total int) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	n, err := h.nodeAt(index)
+	if err != nil {
+		return 0, 0
+	}
+	return n.position(), len(n.parent.children)
+}
+
+// Alternatives возвращает все варианты сообщения index (включая активный)
+func (h *ChatHistory) Alternatives(index int) []Message {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	n, err := h.nodeAt(index)
+	if err != nil {
+		return nil
+	}
+	result := make([]Message, 0, len(n.parent.children))
+	for _, c := range n.parent.children {
+		result = append(result, c.msg)
+	}
+	return result
+}
+
+// SwitchBranch переключает сообщение index на соседнюю ветку со сдвигом delta
+// (циклически). Продолжение диалога берётся из выбранной ветки.
+// Индекс, равный длине активной ветки, выбирает одно из её отброшенных продолжений.
+func (h *ChatHistory) SwitchBranch(index, delta int) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if leaf := h.root.leaf(); index == len(h.root.path()) && len(leaf.children) > 0 {
+		if delta >= 0 {
+			leaf.active = 0
+		} else {
+			leaf.active = len(leaf.children) - 1
+		}
+		return nil
+	}
+
+	n, err := h.nodeAt(index)
+	if err != nil {
+		return err
+	}
+	total := len(n.parent.children)
+	n.parent.active = ((n.position()+delta)%total + total) % total
+	return nil
+}
+
+// LastBranchPoint возвращает индекс последнего сообщения активной ветки,
+// у которого есть альтернативы, или -1
+func (h *ChatHistory) LastBranchPoint() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	path := h.root.path()
+	for i := len(path) - 1; i >= 0; i-- {
+		if len(path[i].parent.children) > 1 {
+			return i
+		}
+	}
+	// Активная ветка может заканчиваться перед альтернативами (после Fork)
+	if leaf := h.root.leaf(); len(leaf.children) > 0 {
+		return len(path)
+	}
+	return -1
+}
+
+// === Сериализация дерева ===
+
+// Branch - сериализуемый узел дерева сообщений
+type Branch struct {
+	Message Message `json:"message"`
+	// Active - индекс активного потомка, -1 если активная ветка заканчивается здесь
+	Active   int      `json:"active"`
+	Children []Branch `json:"children,omitempty"`
+}
+
+// Snapshot - сериализуемое представление истории со всеми ветками
+type Snapshot struct {
+	SystemPrompt string `json:"system_prompt"`
+	// Active - индекс активной ветки верхнего уровня, -1 если история пуста
+	Active   int      `json:"active"`
+	Branches []Branch `json:"branches,omitempty"`
+}
+
+// Snapshot возвращает полное дерево истории для сохранения
+func (h *ChatHistory) Snapshot() *Snapshot {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return &Snapshot{
+		SystemPrompt: h.systemPrompt,
+		Active:       h.root.active,
+		Branches:     toBranches(h.root.children),
+	}
+}
+
+// NewChatHistoryFromSnapshot восстанавливает историю со всеми ветками
+func NewChatHistoryFromSnapshot(s *Snapshot) *ChatHistory {
+	h := NewChatHistory(s.SystemPrompt)
+	fromBranches(h.root, s.Branches, s.Active)
+	return h
+}
+
+// toBranches конвертирует узлы в сериализуемое представление
+func toBranches(nodes []*node) []Branch {
+	if len(nodes) == 0 {
+		return nil
+	}
+	result := make([]Branch, 0, len(nodes))
+	for _, n := range nodes {
+		result = append(result, Branch{
+			Message:  n.msg,
+			Active:   n.active,
+			Children: toBranches(n.children),
+		})
+	}
+	return result
+}
+
+// fromBranches восстанавливает потомков узла из сериализуемого представления
+func fromBranches(parent *node, branches []Branch, active int) {
+	for _, b := range branches {
+		child := newNode(b.Message, parent)
+		parent.children = append(parent.children, child)
+		fromBranches(child, b.Children, b.Active)
+	}
+	// Некорректный индекс из файла не должен приводить к панике
+	if active < 0 || active >= len(parent.children) {
+		active = -1
+	}
+	parent.active = active
+}
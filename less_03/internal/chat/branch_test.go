@@ -0,0 +1,229 @@
+package chat
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// contents возвращает тексты сообщений активной ветки без системного промпта
+func contents(h *ChatHistory) []string {
+	var result []string
+	for _, msg := range h.GetDisplayMessages() {
+		result = append(result, msg.Content)
+	}
+	return result
+}
+
+func newDialog() *ChatHistory {
+	h := NewChatHistory("system")
+	h.AddUser("q1")
+	h.AddAssistant("a1")
+	h.AddUser("q2")
+	h.AddAssistant("a2")
+	return h
+}
+
+func TestChatHistory_EditUser(t *testing.T) {
+	h := newDialog()
+
+	if err := h.EditUser(2, "q2 edited"); err != nil {
+		t.Fatalf("EditUser() error = %v", err)
+	}
+	h.AddAssistant("a2 for edited")
+
+	if got, want := contents(h), []string{"q1", "a1", "q2 edited", "a2 for edited"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("active path = %v, want %v", got, want)
+	}
+	if cur, total := h.Siblings(2); cur != 1 || total != 2 {
+		t.Errorf("Siblings(2) = %d/%d, want 1/2", cur, total)
+	}
+
+	// Исходная ветка доступна целиком, включая ответ
+	if err := h.SwitchBranch(2, -1); err != nil {
+		t.Fatalf("SwitchBranch() error = %v", err)
+	}
+	if got, want := contents(h), []string{"q1", "a1", "q2", "a2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after switch path = %v, want %v", got, want)
+	}
+
+	// GetMessages по-прежнему начинается с системного промпта
+	if messages := h.GetMessages(); messages[0].Role != RoleSystem || len(messages) != 5 {
+		t.Errorf("GetMessages() = %+v", messages)
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		if err := h.EditUser(1, "x"); !errors.Is(err, ErrNotUserMessage) {
+			t.Errorf("EditUser(assistant) error = %v, want %v", err, ErrNotUserMessage)
+		}
+		if err := h.EditUser(10, "x"); !errors.Is(err, ErrInvalidIndex) {
+			t.Errorf("EditUser(10) error = %v, want %v", err, ErrInvalidIndex)
+		}
+	})
+}
+
+func TestChatHistory_Regenerate(t *testing.T) {
+	h := newDialog()
+
+	index, err := h.Regenerate()
+	if err != nil {
+		t.Fatalf("Regenerate() error = %v", err)
+	}
+	if index != 3 {
+		t.Errorf("Regenerate() index = %d, want 3", index)
+	}
+	if got, want := contents(h), []string{"q1", "a1", "q2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("path before new answer = %v, want %v", got, want)
+	}
+
+	// Потоковое обновление не должно затрагивать старый ответ
+	if h.UpdateLastAssistant("partial") {
+		t.Errorf("UpdateLastAssistant() should not modify alternative branch")
+	}
+
+	h.AddAssistant("a2 v2")
+	if alts := h.Alternatives(3); len(alts) != 2 || alts[0].Content != "a2" || alts[1].Content != "a2 v2" {
+		t.Errorf("Alternatives(3) = %+v", alts)
+	}
+
+	// Циклическое переключение
+	h.SwitchBranch(3, 1)
+	if last := h.LastAssistantMessage(); last.Content != "a2" {
+		t.Errorf("after next: last = %q, want %q", last.Content, "a2")
+	}
+	h.SwitchBranch(3, 1)
+	if last := h.LastAssistantMessage(); last.Content != "a2 v2" {
+		t.Errorf("after wrap: last = %q, want %q", last.Content, "a2 v2")
+	}
+
+	t.Run("with tool calls", func(t *testing.T) {
+		h := NewChatHistory("")
+		h.AddUser("time?")
+		h.AddAssistantToolCalls("", []ToolCall{{ID: "c1"}})
+		h.AddToolResult("c1", "get_current_time", "12:00")
+		h.AddAssistant("It is noon")
+
+		index, err := h.Regenerate()
+		if err != nil || index != 1 {
+			t.Errorf("Regenerate() = %d, %v, want 1", index, err)
+		}
+		if h.Len() != 1 {
+			t.Errorf("whole tool round should be replaced, Len() = %d", h.Len())
+		}
+	})
+
+	t.Run("nothing to regenerate", func(t *testing.T) {
+		h := NewChatHistory("system")
+		if _, err := h.Regenerate(); !errors.Is(err, ErrNothingToRegenerate) {
+			t.Errorf("Regenerate() error = %v, want %v", err, ErrNothingToRegenerate)
+		}
+	})
+
+	t.Run("unanswered question is not regenerated", func(t *testing.T) {
+		// Предыдущий ответ не должен отбрасываться, если последний вопрос остался без ответа
+		h := newDialog()
+		h.RemoveLast()
+		if _, err := h.Regenerate(); !errors.Is(err, ErrNothingToRegenerate) {
+			t.Errorf("Regenerate() error = %v, want %v", err, ErrNothingToRegenerate)
+		}
+		if h.Len() != 4 {
+			t.Errorf("Len() = %d, want 4", h.Len())
+		}
+	})
+}
+
+func TestChatHistory_LastBranchPoint(t *testing.T) {
+	h := newDialog()
+	if got := h.LastBranchPoint(); got != -1 {
+		t.Errorf("LastBranchPoint() = %d, want -1", got)
+	}
+
+	h.EditUser(0, "q1 edited")
+	h.AddAssistant("a1 edited")
+	if got := h.LastBranchPoint(); got != 0 {
+		t.Errorf("LastBranchPoint() = %d, want 0", got)
+	}
+
+	t.Run("cancelled regeneration can be restored", func(t *testing.T) {
+		h := newDialog()
+		h.Regenerate()
+
+		index := h.LastBranchPoint()
+		if index != 3 {
+			t.Fatalf("LastBranchPoint() = %d, want 3", index)
+		}
+		if err := h.SwitchBranch(index, 1); err != nil {
+			t.Fatalf("SwitchBranch() error = %v", err)
+		}
+		if last := h.LastAssistantMessage(); last.Content != "a2" {
+			t.Errorf("last = %q, want %q", last.Content, "a2")
+		}
+	})
+}
+
+func TestChatHistory_RemoveLastKeepsSiblings(t *testing.T) {
+	h := newDialog()
+	h.Regenerate()
+	h.AddAssistant("a2 v2")
+
+	if !h.RemoveLast() {
+		t.Fatalf("RemoveLast() = false")
+	}
+	if got, want := contents(h), []string{"q1", "a1", "q2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("path = %v, want %v", got, want)
+	}
+	// Старый ответ остаётся доступным
+	h.SwitchBranch(3, 1)
+	if last := h.LastAssistantMessage(); last == nil || last.Content != "a2" {
+		t.Errorf("remaining alternative should be selectable, got %+v", last)
+	}
+}
+
+func TestChatHistory_Snapshot(t *testing.T) {
+	h := newDialog()
+	h.EditUser(2, "q2 edited")
+	h.AddAssistant("a2 edited")
+
+	data, err := json.Marshal(h.Snapshot())
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	restored := NewChatHistoryFromSnapshot(&snapshot)
+
+	if !reflect.DeepEqual(restored.GetMessages(), h.GetMessages()) {
+		t.Errorf("restored messages = %+v, want %+v", restored.GetMessages(), h.GetMessages())
+	}
+	if cur, total := restored.Siblings(2); cur != 1 || total != 2 {
+		t.Errorf("restored Siblings(2) = %d/%d, want 1/2", cur, total)
+	}
+
+	t.Run("invalid active index", func(t *testing.T) {
+		s := &Snapshot{Active: 5, Branches: []Branch{{Message: Message{Role: RoleUser, Content: "q"}, Active: 3}}}
+		h := NewChatHistoryFromSnapshot(s)
+		if h.Len() != 0 {
+			t.Errorf("invalid active index should produce empty path, Len() = %d", h.Len())
+		}
+	})
+}
+
+func TestChatHistory_CopyBranches(t *testing.T) {
+	h := newDialog()
+	h.Regenerate()
+	h.AddAssistant("a2 v2")
+
+	c := h.Copy()
+	c.SwitchBranch(3, 1)
+
+	if h.LastAssistantMessage().Content != "a2 v2" {
+		t.Errorf("switching branch in copy should not affect original")
+	}
+	if c.LastAssistantMessage().Content != "a2" {
+		t.Errorf("copy should keep alternative branches")
+	}
+}
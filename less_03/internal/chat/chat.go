@@ -16,6 +16,8 @@ const (
 	RoleUser Role = "user"
 	// RoleAssistant - сообщение от ассистента (модели)
 	RoleAssistant Role = "assistant"
+	// RoleTool - результат вызова инструмента
+	RoleTool Role = "tool"
 )
 
 // Все возможные роли для валидации
@@ -23,6 +25,7 @@ var validRoles = map[Role]bool{
 	RoleSystem:    true,
 	RoleUser:      true,
 	RoleAssistant: true,
+	RoleTool:      true,
 }
 
 // IsValid проверяет является ли роль валидной
@@ -35,10 +38,32 @@ func (r Role) String() string {
 	return string(r)
 }
 
+// ToolCall представляет вызов инструмента, запрошенный моделью (OpenAI формат)
+type ToolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"`
+	Function FunctionCall `json:"function"`
+}
+
+// FunctionCall содержит имя функции и её аргументы в виде JSON строки
+type FunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
 // Message представляет одно сообщение в диалоге
 type Message struct {
 	Role    Role   `json:"role"`
 	Content string `json:"content"`
+	// Parts - вложения после текста Content (изображения, файлы); в JSON content
+	// передаётся массивом частей, если они есть (см. MarshalJSON)
+	Parts []ContentPart `json:"-"`
+	// ToolCalls - вызовы инструментов в ответе ассистента
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// ToolCallID - идентификатор вызова, на который отвечает сообщение с ролью tool
+	ToolCallID string `json:"tool_call_id,omitempty"`
+	// Name - имя инструмента для сообщений с ролью tool
+	Name string `json:"name,omitempty"`
 }
 
 // NewMessage создаёт новое сообщение с валидацией
@@ -52,25 +77,39 @@ func NewMessage(role Role, content string) (Message, error) {
 	}, nil
 }
 
-// ChatHistory хранит историю диалога для поддержания контекста
+// ChatHistory хранит историю диалога для поддержания контекста.
+// Сообщения образуют дерево: редактирование и повторная генерация создают
+// соседние ветки, а методы чтения возвращают только активный путь от корня.
 // Потокобезопасная реализация с использованием мьютекса
 type ChatHistory struct {
-	mu           sync.RWMutex
-	messages     []Message
+	mu   sync.RWMutex
+	root *node
+
 	systemPrompt string
+	// hasSystem - выводится ли системное сообщение в начале истории
+	hasSystem bool
 }
 
 // NewChatHistory создаёт новую историю диалога с системным промптом
 func NewChatHistory(systemPrompt string) *ChatHistory {
-	h := &ChatHistory{
-		messages:     make([]Message, 0),
+	return &ChatHistory{
+		root:         newNode(Message{}, nil),
 		systemPrompt: systemPrompt,
+		hasSystem:    systemPrompt != "",
 	}
-	if systemPrompt != "" {
-		h.messages = append(h.messages, Message{
-			Role:    RoleSystem,
-			Content: systemPrompt,
-		})
+}
+
+// NewChatHistoryFromMessages восстанавливает историю из сохранённых сообщений.
+// Системный промпт берётся из первого сообщения с ролью system.
+func NewChatHistoryFromMessages(messages []Message) *ChatHistory {
+	h := NewChatHistory("")
+	if len(messages) > 0 && messages[0].Role == RoleSystem {
+		h.systemPrompt = messages[0].Content
+		h.hasSystem = true
+		messages = messages[1:]
+	}
+	for _, msg := range messages {
+		h.appendLocked(msg)
 	}
 	return h
 }
@@ -80,9 +119,21 @@ func (h *ChatHistory) AddUser(content string) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	h.messages = append(h.messages, Message{
+	h.appendLocked(Message{
+		Role:    RoleUser,
+		Content: content,
+	})
+}
+
+// AddUserParts добавляет сообщение пользователя с вложениями
+func (h *ChatHistory) AddUserParts(content string, parts []ContentPart) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.appendLocked(Message{
 		Role:    RoleUser,
 		Content: content,
+		Parts:   parts,
 	})
 }
 
@@ -91,12 +142,37 @@ func (h *ChatHistory) AddAssistant(content string) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	h.messages = append(h.messages, Message{
+	h.appendLocked(Message{
 		Role:    RoleAssistant,
 		Content: content,
 	})
 }
 
+// AddAssistantToolCalls добавляет ответ ассистента с запросом вызова инструментов
+func (h *ChatHistory) AddAssistantToolCalls(content string, calls []ToolCall) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.appendLocked(Message{
+		Role:      RoleAssistant,
+		Content:   content,
+		ToolCalls: append([]ToolCall(nil), calls...),
+	})
+}
+
+// AddToolResult добавляет результат выполнения инструмента
+func (h *ChatHistory) AddToolResult(callID, name, content string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.appendLocked(Message{
+		Role:       RoleTool,
+		Content:    content,
+		ToolCallID: callID,
+		Name:       name,
+	})
+}
+
 // UpdateLastAssistant обновляет последнее сообщение ассистента
 // Используется при потоковом получении ответа
 // Возвращает false если не удалось обновить (нет сообщений ассистента)
@@ -104,22 +180,20 @@ func (h *ChatHistory) UpdateLastAssistant(content string) bool {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	if len(h.messages) > 0 && h.messages[len(h.messages)-1].Role == RoleAssistant {
-		h.messages[len(h.messages)-1].Content = content
+	leaf := h.root.leaf()
+	if leaf != h.root && leaf.msg.Role == RoleAssistant {
+		leaf.msg.Content = content
 		return true
 	}
 	return false
 }
 
-// GetMessages возвращает все сообщения истории (копия для безопасности)
+// GetMessages возвращает сообщения активной ветки (копия для безопасности)
 func (h *ChatHistory) GetMessages() []Message {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
-	// Возвращаем копию слайса для безопасности
-	result := make([]Message, len(h.messages))
-	copy(result, h.messages)
-	return result
+	return h.messagesLocked()
 }
 
 // GetDisplayMessages возвращает сообщения для отображения (без системных)
@@ -128,9 +202,9 @@ func (h *ChatHistory) GetDisplayMessages() []Message {
 	defer h.mu.RUnlock()
 
 	result := make([]Message, 0)
-	for _, msg := range h.messages {
-		if msg.Role != RoleSystem {
-			result = append(result, msg)
+	for _, n := range h.root.path() {
+		if n.msg.Role != RoleSystem {
+			result = append(result, n.msg)
 		}
 	}
 	return result
@@ -142,20 +216,15 @@ func (h *ChatHistory) Clear(systemPrompt string) {
 	defer h.mu.Unlock()
 
 	h.systemPrompt = systemPrompt
-	h.messages = make([]Message, 0)
-	if systemPrompt != "" {
-		h.messages = append(h.messages, Message{
-			Role:    RoleSystem,
-			Content: systemPrompt,
-		})
-	}
+	h.hasSystem = systemPrompt != ""
+	h.root = newNode(Message{}, nil)
 }
 
-// Len возвращает количество сообщений в истории
+// Len возвращает количество сообщений в активной ветке
 func (h *ChatHistory) Len() int {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
-	return len(h.messages)
+	return h.lenLocked()
 }
 
 // LastUserMessage возвращает последнее сообщение пользователя
@@ -163,14 +232,7 @@ func (h *ChatHistory) LastUserMessage() *Message {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
-	for i := len(h.messages) - 1; i >= 0; i-- {
-		if h.messages[i].Role == RoleUser {
-			// Возвращаем копию
-			msg := h.messages[i]
-			return &msg
-		}
-	}
-	return nil
+	return h.lastWithRole(RoleUser)
 }
 
 // LastAssistantMessage возвращает последнее сообщение ассистента
@@ -178,13 +240,7 @@ func (h *ChatHistory) LastAssistantMessage() *Message {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
-	for i := len(h.messages) - 1; i >= 0; i-- {
-		if h.messages[i].Role == RoleAssistant {
-			msg := h.messages[i]
-			return &msg
-		}
-	}
-	return nil
+	return h.lastWithRole(RoleAssistant)
 }
 
 // SetSystemPrompt устанавливает или обновляет системный промпт
@@ -193,19 +249,9 @@ func (h *ChatHistory) SetSystemPrompt(prompt string) {
 	defer h.mu.Unlock()
 
 	h.systemPrompt = prompt
-
-	// Если есть системное сообщение, обновляем его
-	if len(h.messages) > 0 && h.messages[0].Role == RoleSystem {
-		h.messages[0].Content = prompt
-	} else if prompt != "" {
-		// Вставляем системное сообщение в начало
-		newMessages := make([]Message, 0, len(h.messages)+1)
-		newMessages = append(newMessages, Message{
-			Role:    RoleSystem,
-			Content: prompt,
-		})
-		newMessages = append(newMessages, h.messages...)
-		h.messages = newMessages
+	// Существующее системное сообщение обновляется, новое добавляется только для непустого промпта
+	if prompt != "" {
+		h.hasSystem = true
 	}
 }
 
@@ -216,44 +262,90 @@ func (h *ChatHistory) GetSystemPrompt() string {
 	return h.systemPrompt
 }
 
-// RemoveLast удаляет последнее сообщение из истории
+// RemoveLast удаляет последнее сообщение активной ветки.
+// Альтернативные ветки этого сообщения сохраняются.
 // Возвращает false если история пуста или содержит только системный промпт
 func (h *ChatHistory) RemoveLast() bool {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
 	// Не удаляем системный промпт
-	if len(h.messages) <= 1 {
+	if h.lenLocked() <= 1 {
 		return false
 	}
 
-	h.messages = h.messages[:len(h.messages)-1]
+	leaf := h.root.leaf()
+	leaf.parent.removeChild(leaf)
 	return true
 }
 
 // TokenEstimate возвращает приблизительную оценку количества токенов
 // Использует простую эвристику: 1 токен ≈ 4 символа
 func (h *ChatHistory) TokenEstimate() int {
+	return h.TokenCount(nil)
+}
+
+// TokenCount возвращает количество токенов активной ветки, подсчитанное count
+// (при nil - эвристикой EstimateTokens)
+func (h *ChatHistory) TokenCount(count TokenCounter) int {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
-	totalChars := 0
-	for _, msg := range h.messages {
-		totalChars += len(msg.Content)
-	}
-	// Примерно 4 символа на токен + накладные расходы
-	return totalChars/4 + 100
+	return countTokens(count, h.messagesLocked())
 }
 
-// Copy создаёт глубокую копию истории
+// Copy создаёт глубокую копию истории, включая все ветки
 func (h *ChatHistory) Copy() *ChatHistory {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
-	newHistory := &ChatHistory{
-		messages:     make([]Message, len(h.messages)),
+	return &ChatHistory{
+		root:         h.root.clone(nil),
 		systemPrompt: h.systemPrompt,
+		hasSystem:    h.hasSystem,
+	}
+}
+
+// appendLocked добавляет сообщение в конец активной ветки
+func (h *ChatHistory) appendLocked(msg Message) {
+	h.root.leaf().addChild(msg)
+}
+
+// messagesLocked собирает сообщения активной ветки вместе с системным промптом
+func (h *ChatHistory) messagesLocked() []Message {
+	path := h.root.path()
+
+	result := make([]Message, 0, len(path)+1)
+	if h.hasSystem {
+		result = append(result, Message{
+			Role:    RoleSystem,
+			Content: h.systemPrompt,
+		})
 	}
-	copy(newHistory.messages, h.messages)
-	return newHistory
+	for _, n := range path {
+		result = append(result, n.msg)
+	}
+	return result
+}
+
+// lenLocked возвращает длину активной ветки с учётом системного промпта
+func (h *ChatHistory) lenLocked() int {
+	n := len(h.root.path())
+	if h.hasSystem {
+		n++
+	}
+	return n
+}
+
+// lastWithRole возвращает копию последнего сообщения с заданной ролью в активной ветке
+func (h *ChatHistory) lastWithRole(role Role) *Message {
+	path := h.root.path()
+	for i := len(path) - 1; i >= 0; i-- {
+		if path[i].msg.Role == role {
+			// Возвращаем копию
+			msg := path[i].msg
+			return &msg
+		}
+	}
+	return nil
 }
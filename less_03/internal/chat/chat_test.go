@@ -1,6 +1,8 @@
 package chat
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
 )
 
@@ -13,6 +15,7 @@ func TestRole_IsValid(t *testing.T) {
 		{"system is valid", RoleSystem, true},
 		{"user is valid", RoleUser, true},
 		{"assistant is valid", RoleAssistant, true},
+		{"tool is valid", RoleTool, true},
 		{"empty is invalid", Role(""), false},
 		{"unknown is invalid", Role("unknown"), false},
 	}
@@ -101,6 +104,32 @@ func TestNewChatHistory(t *testing.T) {
 	})
 }
 
+func TestNewChatHistoryFromMessages(t *testing.T) {
+	messages := []Message{
+		{Role: RoleSystem, Content: "system"},
+		{Role: RoleUser, Content: "Hello"},
+		{Role: RoleAssistant, Content: "Hi"},
+	}
+
+	h := NewChatHistoryFromMessages(messages)
+	if h.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", h.Len())
+	}
+	if h.GetSystemPrompt() != "system" {
+		t.Errorf("GetSystemPrompt() = %q, want %q", h.GetSystemPrompt(), "system")
+	}
+
+	// Изменение исходного слайса не должно влиять на историю
+	messages[1].Content = "changed"
+	if h.LastUserMessage().Content != "Hello" {
+		t.Errorf("history should not share memory with source slice")
+	}
+
+	if NewChatHistoryFromMessages(nil).Len() != 0 {
+		t.Errorf("empty history expected for nil messages")
+	}
+}
+
 func TestChatHistory_AddUser(t *testing.T) {
 	h := NewChatHistory("system")
 	h.AddUser("Hello")
@@ -137,6 +166,58 @@ func TestChatHistory_AddAssistant(t *testing.T) {
 	}
 }
 
+func TestChatHistory_ToolCalls(t *testing.T) {
+	h := NewChatHistory("system")
+	h.AddUser("Который час?")
+	h.AddAssistantToolCalls("", []ToolCall{{
+		ID:       "call_1",
+		Type:     "function",
+		Function: FunctionCall{Name: "get_current_time", Arguments: `{}`},
+	}})
+	h.AddToolResult("call_1", "get_current_time", "12:00")
+
+	messages := h.GetMessages()
+	if len(messages) != 4 {
+		t.Fatalf("len(messages) = %d, want 4", len(messages))
+	}
+
+	assistant := messages[2]
+	if len(assistant.ToolCalls) != 1 || assistant.ToolCalls[0].Function.Name != "get_current_time" {
+		t.Errorf("assistant.ToolCalls = %+v, want get_current_time call", assistant.ToolCalls)
+	}
+
+	tool := messages[3]
+	if tool.Role != RoleTool || tool.ToolCallID != "call_1" || tool.Content != "12:00" {
+		t.Errorf("tool message = %+v", tool)
+	}
+
+	t.Run("wire format", func(t *testing.T) {
+		data, err := json.Marshal(messages)
+		if err != nil {
+			t.Fatalf("json.Marshal() error = %v", err)
+		}
+		got := string(data)
+		for _, want := range []string{`"tool_calls":[{"id":"call_1","type":"function"`, `"tool_call_id":"call_1"`, `"role":"tool"`} {
+			if !strings.Contains(got, want) {
+				t.Errorf("JSON %s should contain %s", got, want)
+			}
+		}
+		// Обычные сообщения не должны получать новые поля
+		if strings.Contains(string(mustMarshal(t, messages[1])), "tool") {
+			t.Errorf("user message should not contain tool fields")
+		}
+	})
+}
+
+func mustMarshal(t *testing.T, v any) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	return data
+}
+
 func TestChatHistory_UpdateLastAssistant(t *testing.T) {
 	t.Run("update existing", func(t *testing.T) {
 		h := NewChatHistory("")
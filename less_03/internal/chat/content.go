@@ -0,0 +1,153 @@
+package chat
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	// PartText - текстовая часть содержимого
+	PartText = "text"
+	// PartImageURL - изображение по URL или в виде data URL
+	PartImageURL = "image_url"
+)
+
+// ContentPart - часть содержимого сообщения в формате OpenAI
+// (content: [{"type": "text", ...}, {"type": "image_url", ...}])
+type ContentPart struct {
+	Type     string    `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	ImageURL *ImageURL `json:"image_url,omitempty"`
+}
+
+// ImageURL - адрес изображения: https или data:<mime>;base64,<данные>
+type ImageURL struct {
+	URL string `json:"url"`
+	// Detail - детализация для моделей OpenAI: auto, low, high
+	Detail string `json:"detail,omitempty"`
+}
+
+// TextPart создаёт текстовую часть
+func TextPart(text string) ContentPart {
+	return ContentPart{Type: PartText, Text: text}
+}
+
+// ImagePart создаёт изображение с данными в base64 data URL
+func ImagePart(mimeType string, data []byte) ContentPart {
+	url := "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+	return ContentPart{Type: PartImageURL, ImageURL: &ImageURL{URL: url}}
+}
+
+// InlineImage возвращает MIME тип и данные base64 изображения из data URL.
+// false - часть не изображение или изображение задано внешним URL.
+func (p ContentPart) InlineImage() (mimeType, data string, ok bool) {
+	if p.Type != PartImageURL || p.ImageURL == nil {
+		return "", "", false
+	}
+	header, data, found := strings.Cut(p.ImageURL.URL, ",")
+	mimeType, found2 := strings.CutPrefix(header, "data:")
+	if !found || !found2 || !strings.HasSuffix(mimeType, ";base64") {
+		return "", "", false
+	}
+	return strings.TrimSuffix(mimeType, ";base64"), data, true
+}
+
+// Summary возвращает текст части для отображения: изображение заменяется отметкой
+// вместо base64
+func (p ContentPart) Summary() string {
+	switch p.Type {
+	case PartText:
+		return p.Text
+	case PartImageURL:
+		if mimeType, data, ok := p.InlineImage(); ok {
+			return fmt.Sprintf("[📎 изображение %s, %s]", mimeType, formatSize(base64.StdEncoding.DecodedLen(len(data))))
+		}
+		if p.ImageURL != nil {
+			return "[📎 изображение " + p.ImageURL.URL + "]"
+		}
+	}
+	return "[📎 " + p.Type + "]"
+}
+
+// formatSize форматирует размер в байтах: 512 B, 12.3 KB, 1.5 MB
+func formatSize(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+// ContentParts возвращает содержимое сообщения частями: текст Content, затем вложения
+func (m Message) ContentParts() []ContentPart {
+	parts := make([]ContentPart, 0, len(m.Parts)+1)
+	if m.Content != "" {
+		parts = append(parts, TextPart(m.Content))
+	}
+	return append(parts, m.Parts...)
+}
+
+// DisplayContent возвращает текст сообщения с вложениями для отображения
+func (m Message) DisplayContent() string {
+	if len(m.Parts) == 0 {
+		return m.Content
+	}
+	texts := make([]string, 0, len(m.Parts)+1)
+	for _, part := range m.ContentParts() {
+		texts = append(texts, part.Summary())
+	}
+	return strings.Join(texts, "\n")
+}
+
+// MarshalJSON сериализует content строкой, а при наличии вложений - массивом частей
+func (m Message) MarshalJSON() ([]byte, error) {
+	type plain Message
+	if len(m.Parts) == 0 {
+		return json.Marshal(plain(m))
+	}
+	return json.Marshal(struct {
+		plain
+		Content []ContentPart `json:"content"`
+	}{plain(m), m.ContentParts()})
+}
+
+// UnmarshalJSON принимает content строкой или массивом частей.
+// Первая текстовая часть массива становится Content, остальные - Parts.
+func (m *Message) UnmarshalJSON(data []byte) error {
+	type plain Message
+	var raw struct {
+		plain
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*m = Message(raw.plain)
+
+	content := bytes.TrimSpace(raw.Content)
+	switch {
+	case len(content) == 0 || bytes.Equal(content, []byte("null")):
+		return nil
+	case content[0] == '"':
+		return json.Unmarshal(content, &m.Content)
+	}
+
+	var parts []ContentPart
+	if err := json.Unmarshal(content, &parts); err != nil {
+		return err
+	}
+	if len(parts) > 0 && parts[0].Type == PartText {
+		m.Content = parts[0].Text
+		parts = parts[1:]
+	}
+	if len(parts) > 0 {
+		m.Parts = parts
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package chat
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMessage_MarshalJSON(t *testing.T) {
+	t.Run("plain text stays a string", func(t *testing.T) {
+		data, err := json.Marshal(Message{Role: RoleUser, Content: "Привет"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != `{"role":"user","content":"Привет"}` {
+			t.Errorf("Marshal() = %s", data)
+		}
+	})
+
+	t.Run("parts become an array", func(t *testing.T) {
+		msg := Message{Role: RoleUser, Content: "Что на картинке?", Parts: []ContentPart{ImagePart("image/png", []byte("png"))}}
+		data, err := json.Marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := `{"role":"user","content":[{"type":"text","text":"Что на картинке?"},` +
+			`{"type":"image_url","image_url":{"url":"data:image/png;base64,cG5n"}}]}`
+		if string(data) != want {
+			t.Errorf("Marshal() = %s, want %s", data, want)
+		}
+
+		var decoded Message
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if decoded.Content != msg.Content || len(decoded.Parts) != 1 || decoded.Parts[0].ImageURL.URL != msg.Parts[0].ImageURL.URL {
+			t.Errorf("round trip = %+v", decoded)
+		}
+	})
+}
+
+func TestMessage_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		content string
+		parts   int
+	}{
+		{"string", `{"role":"user","content":"q"}`, "q", 0},
+		{"null", `{"role":"assistant","content":null,"tool_calls":[]}`, "", 0},
+		{"text parts", `{"role":"user","content":[{"type":"text","text":"q"}]}`, "q", 0},
+		{"image first", `{"role":"user","content":[{"type":"image_url","image_url":{"url":"https://example.com/a.png"}},{"type":"text","text":"q"}]}`, "", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var msg Message
+			if err := json.Unmarshal([]byte(tt.data), &msg); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if msg.Content != tt.content || len(msg.Parts) != tt.parts {
+				t.Errorf("Unmarshal() = %+v, want content %q and %d parts", msg, tt.content, tt.parts)
+			}
+		})
+	}
+
+	var msg Message
+	if err := json.Unmarshal([]byte(`{"role":"user","content":42}`), &msg); err == nil {
+		t.Errorf("Unmarshal() should reject non-string, non-array content")
+	}
+}
+
+func TestContentPart_Summary(t *testing.T) {
+	image := ImagePart("image/jpeg", make([]byte, 2048))
+	if mimeType, _, ok := image.InlineImage(); !ok || mimeType != "image/jpeg" {
+		t.Errorf("InlineImage() = %q, %v", mimeType, ok)
+	}
+
+	msg := Message{Role: RoleUser, Content: "Сравни", Parts: []ContentPart{image, TextPart("Файл a.go")}}
+	got := msg.DisplayContent()
+	if got != "Сравни\n[📎 изображение image/jpeg, 2.0 KB]\nФайл a.go" {
+		t.Errorf("DisplayContent() = %q", got)
+	}
+	if strings.Contains(got, "base64") {
+		t.Errorf("DisplayContent() should not contain image data")
+	}
+
+	external := ContentPart{Type: PartImageURL, ImageURL: &ImageURL{URL: "https://example.com/a.png"}}
+	if _, _, ok := external.InlineImage(); ok || external.Summary() != "[📎 изображение https://example.com/a.png]" {
+		t.Errorf("external image summary = %q", external.Summary())
+	}
+}
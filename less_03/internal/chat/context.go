@@ -0,0 +1,235 @@
+package chat
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+)
+
+// SummaryPrefix - начало системного сообщения с кратким содержанием ранней части диалога
+const SummaryPrefix = "Краткое содержание предыдущей части диалога:\n"
+
+// ImageTokens - оценка токенов одного изображения (OpenAI: 85 + 170 за каждый из 4 фрагментов 512x512)
+const ImageTokens = 765
+
+// TokenCounter подсчитывает количество токенов в наборе сообщений
+type TokenCounter func(messages []Message) int
+
+// EstimateTokens возвращает приблизительную оценку количества токенов.
+// Использует простую эвристику: 1 токен ≈ 4 символа
+func EstimateTokens(messages []Message) int {
+	totalChars := 0
+	for _, msg := range messages {
+		totalChars += len(msg.Content)
+		for _, part := range msg.Parts {
+			if part.Type == PartText {
+				totalChars += len(part.Text)
+			} else {
+				totalChars += ImageTokens * 4
+			}
+		}
+		for _, call := range msg.ToolCalls {
+			totalChars += len(call.Function.Name) + len(call.Function.Arguments)
+		}
+	}
+	// Примерно 4 символа на токен + накладные расходы
+	return totalChars/4 + 100
+}
+
+// Trimmer сокращает сообщения запроса так, чтобы они уложились в бюджет токенов.
+// Сообщения передаются в формате GetMessages: системный промпт (если есть) идёт первым.
+// Системный промпт и последний обмен репликами не удаляются никогда.
+type Trimmer interface {
+	Trim(ctx context.Context, messages []Message, budget int) ([]Message, error)
+}
+
+// NoTrim не изменяет историю
+type NoTrim struct{}
+
+// Trim возвращает сообщения без изменений
+func (NoTrim) Trim(_ context.Context, messages []Message, _ int) ([]Message, error) {
+	return messages, nil
+}
+
+// DropOldest удаляет самые старые обмены репликами, пока история не уложится в бюджет
+type DropOldest struct {
+	// Count - функция подсчёта токенов (по умолчанию EstimateTokens)
+	Count TokenCounter
+}
+
+// Trim реализует Trimmer
+func (d DropOldest) Trim(_ context.Context, messages []Message, budget int) ([]Message, error) {
+	return dropOldest(messages, budget, d.Count), nil
+}
+
+// LastN оставляет системный промпт и N последних обменов репликами.
+// Если и они не укладываются в бюджет, удаляются самые старые из них.
+type LastN struct {
+	// N - количество сохраняемых обменов репликами
+	N int
+	// Count - функция подсчёта токенов (по умолчанию EstimateTokens)
+	Count TokenCounter
+}
+
+// Trim реализует Trimmer
+func (l LastN) Trim(_ context.Context, messages []Message, budget int) ([]Message, error) {
+	system, turns := splitTurns(messages)
+	if l.N > 0 && len(turns) > l.N {
+		turns = turns[len(turns)-l.N:]
+	}
+	return dropOldest(joinTurns(system, turns), budget, l.Count), nil
+}
+
+// SummarizeFunc составляет краткое содержание сообщений.
+// previous - содержание более ранней части диалога (пустое при первом вызове),
+// которое должно войти в результат.
+type SummarizeFunc func(ctx context.Context, previous string, messages []Message) (string, error)
+
+// Summarizer заменяет старые обмены репликами их кратким содержанием,
+// полученным отдельным запросом к модели. Содержание обновляется инкрементально:
+// при следующем вызове суммаризуются только реплики, вышедшие за окно KeepLast.
+type Summarizer struct {
+	summarize SummarizeFunc
+	keepLast  int
+	count     TokenCounter
+
+	mu    sync.Mutex
+	cache summaryCache
+}
+
+// summaryCache хранит последнее содержание и отпечаток суммаризованных сообщений
+type summaryCache struct {
+	count   int
+	key     string
+	summary string
+}
+
+// NewSummarizer создаёт стратегию суммаризации, сохраняющую keepLast последних обменов
+func NewSummarizer(summarize SummarizeFunc, keepLast int, count TokenCounter) *Summarizer {
+	if keepLast < 1 {
+		keepLast = 1
+	}
+	return &Summarizer{summarize: summarize, keepLast: keepLast, count: count}
+}
+
+// Trim реализует Trimmer. При ошибке суммаризации возвращает результат DropOldest
+// вместе с ошибкой, чтобы запрос всё равно можно было отправить.
+func (s *Summarizer) Trim(ctx context.Context, messages []Message, budget int) ([]Message, error) {
+	if budget <= 0 || countTokens(s.count, messages) <= budget {
+		return messages, nil
+	}
+
+	system, turns := splitTurns(messages)
+	keep := s.keepLast
+	if keep >= len(turns) {
+		keep = len(turns) - 1
+	}
+	if keep < 1 {
+		return dropOldest(messages, budget, s.count), nil
+	}
+
+	var old []Message
+	for _, turn := range turns[:len(turns)-keep] {
+		old = append(old, turn...)
+	}
+
+	summary, err := s.summarizeRolling(ctx, old)
+	if err != nil {
+		return dropOldest(messages, budget, s.count), err
+	}
+
+	system = append(system, Message{Role: RoleSystem, Content: SummaryPrefix + summary})
+	return dropOldest(joinTurns(system, turns[len(turns)-keep:]), budget, s.count), nil
+}
+
+// summarizeRolling суммаризует сообщения, переиспользуя предыдущее содержание,
+// если оно было получено для начала того же набора сообщений
+func (s *Summarizer) summarizeRolling(ctx context.Context, old []Message) (string, error) {
+	s.mu.Lock()
+	prev := s.cache
+	s.mu.Unlock()
+
+	previous, pending := "", old
+	if prev.count > 0 && prev.count <= len(old) && fingerprint(old[:prev.count]) == prev.key {
+		if prev.count == len(old) {
+			return prev.summary, nil
+		}
+		previous, pending = prev.summary, old[prev.count:]
+	}
+
+	summary, err := s.summarize(ctx, previous, pending)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	s.cache = summaryCache{count: len(old), key: fingerprint(old), summary: summary}
+	s.mu.Unlock()
+	return summary, nil
+}
+
+// dropOldest удаляет самые старые обмены репликами, пока сообщения не уложатся в бюджет
+func dropOldest(messages []Message, budget int, count TokenCounter) []Message {
+	if budget <= 0 || countTokens(count, messages) <= budget {
+		return messages
+	}
+
+	system, turns := splitTurns(messages)
+	for len(turns) > 1 {
+		turns = turns[1:]
+		if countTokens(count, joinTurns(system, turns)) <= budget {
+			break
+		}
+	}
+	return joinTurns(system, turns)
+}
+
+// splitTurns отделяет системные сообщения в начале и разбивает остальные на обмены репликами.
+// Каждый обмен начинается с сообщения пользователя и включает ответы и вызовы инструментов,
+// поэтому удаление целых обменов не разрывает пары tool_calls/tool.
+func splitTurns(messages []Message) (system []Message, turns [][]Message) {
+	i := 0
+	for i < len(messages) && messages[i].Role == RoleSystem {
+		i++
+	}
+	system = append(system, messages[:i]...)
+
+	for _, msg := range messages[i:] {
+		if msg.Role == RoleUser || len(turns) == 0 {
+			turns = append(turns, nil)
+		}
+		turns[len(turns)-1] = append(turns[len(turns)-1], msg)
+	}
+	return system, turns
+}
+
+// joinTurns собирает системные сообщения и обмены репликами в один список
+func joinTurns(system []Message, turns [][]Message) []Message {
+	result := make([]Message, 0, len(system)+len(turns)*2)
+	result = append(result, system...)
+	for _, turn := range turns {
+		result = append(result, turn...)
+	}
+	return result
+}
+
+// countTokens подсчитывает токены, используя оценку по умолчанию, если счётчик не задан
+func countTokens(count TokenCounter, messages []Message) int {
+	if count == nil {
+		return EstimateTokens(messages)
+	}
+	return count(messages)
+}
+
+// fingerprint возвращает отпечаток сообщений для проверки актуальности содержания
+func fingerprint(messages []Message) string {
+	h := sha256.New()
+	for _, msg := range messages {
+		h.Write([]byte(msg.Role))
+		h.Write([]byte{0})
+		h.Write([]byte(msg.DisplayContent()))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
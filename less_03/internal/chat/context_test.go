@@ -0,0 +1,142 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// countMessages - счётчик «токенов» для тестов: одно сообщение = один токен
+func countMessages(messages []Message) int {
+	return len(messages)
+}
+
+// longDialog возвращает системный промпт и n обменов репликами q1/a1 ... qn/an
+func longDialog(n int) []Message {
+	h := NewChatHistory("system")
+	for i := 1; i <= n; i++ {
+		h.AddUser("q" + string(rune('0'+i)))
+		h.AddAssistant("a" + string(rune('0'+i)))
+	}
+	return h.GetMessages()
+}
+
+func messageContents(messages []Message) []string {
+	var result []string
+	for _, msg := range messages {
+		result = append(result, msg.Content)
+	}
+	return result
+}
+
+func TestDropOldest(t *testing.T) {
+	trimmer := DropOldest{Count: countMessages}
+
+	got, err := trimmer.Trim(context.Background(), longDialog(4), 5)
+	if err != nil {
+		t.Fatalf("Trim() error = %v", err)
+	}
+	if want := []string{"system", "q3", "a3", "q4", "a4"}; !reflect.DeepEqual(messageContents(got), want) {
+		t.Errorf("Trim() = %v, want %v", messageContents(got), want)
+	}
+
+	t.Run("fits budget", func(t *testing.T) {
+		messages := longDialog(2)
+		got, _ := trimmer.Trim(context.Background(), messages, 100)
+		if len(got) != len(messages) {
+			t.Errorf("history within budget should not change, got %d messages", len(got))
+		}
+	})
+
+	t.Run("last turn is always kept", func(t *testing.T) {
+		got, _ := trimmer.Trim(context.Background(), longDialog(3), 1)
+		if want := []string{"system", "q3", "a3"}; !reflect.DeepEqual(messageContents(got), want) {
+			t.Errorf("Trim() = %v, want %v", messageContents(got), want)
+		}
+	})
+
+	t.Run("tool round is dropped as a whole", func(t *testing.T) {
+		h := NewChatHistory("")
+		h.AddUser("time?")
+		h.AddAssistantToolCalls("", []ToolCall{{ID: "c1"}})
+		h.AddToolResult("c1", "get_current_time", "12:00")
+		h.AddAssistant("noon")
+		h.AddUser("thanks")
+
+		got, _ := trimmer.Trim(context.Background(), h.GetMessages(), 2)
+		if len(got) != 1 || got[0].Content != "thanks" {
+			t.Errorf("Trim() = %+v, want only last question", got)
+		}
+	})
+}
+
+func TestLastN(t *testing.T) {
+	trimmer := LastN{N: 2, Count: countMessages}
+
+	got, _ := trimmer.Trim(context.Background(), longDialog(4), 0)
+	if want := []string{"system", "q3", "a3", "q4", "a4"}; !reflect.DeepEqual(messageContents(got), want) {
+		t.Errorf("Trim() = %v, want %v", messageContents(got), want)
+	}
+
+	got, _ = trimmer.Trim(context.Background(), longDialog(4), 3)
+	if want := []string{"system", "q4", "a4"}; !reflect.DeepEqual(messageContents(got), want) {
+		t.Errorf("Trim() over budget = %v, want %v", messageContents(got), want)
+	}
+}
+
+func TestSummarizer(t *testing.T) {
+	var calls []string
+	summarize := func(_ context.Context, previous string, messages []Message) (string, error) {
+		summary := strings.TrimSpace(previous + " " + strings.Join(messageContents(messages), " "))
+		calls = append(calls, summary)
+		return summary, nil
+	}
+	s := NewSummarizer(summarize, 1, countMessages)
+
+	got, err := s.Trim(context.Background(), longDialog(3), 5)
+	if err != nil {
+		t.Fatalf("Trim() error = %v", err)
+	}
+	want := []string{"system", SummaryPrefix + "q1 a1 q2 a2", "q3", "a3"}
+	if !reflect.DeepEqual(messageContents(got), want) {
+		t.Errorf("Trim() = %v, want %v", messageContents(got), want)
+	}
+	if got[1].Role != RoleSystem {
+		t.Errorf("summary role = %q, want %q", got[1].Role, RoleSystem)
+	}
+
+	// Следующий запрос суммаризует только новый обмен поверх предыдущего содержания
+	s.Trim(context.Background(), longDialog(4), 5)
+	if len(calls) != 2 || calls[1] != "q1 a1 q2 a2 q3 a3" {
+		t.Errorf("summarize calls = %q", calls)
+	}
+
+	// Та же история не вызывает модель повторно
+	s.Trim(context.Background(), longDialog(4), 5)
+	if len(calls) != 2 {
+		t.Errorf("cached summary should be reused, calls = %d", len(calls))
+	}
+
+	t.Run("error falls back to dropping", func(t *testing.T) {
+		failing := NewSummarizer(func(context.Context, string, []Message) (string, error) {
+			return "", errors.New("boom")
+		}, 1, countMessages)
+
+		got, err := failing.Trim(context.Background(), longDialog(3), 5)
+		if err == nil {
+			t.Errorf("Trim() should return summarization error")
+		}
+		if want := []string{"system", "q2", "a2", "q3", "a3"}; !reflect.DeepEqual(messageContents(got), want) {
+			t.Errorf("fallback = %v, want %v", messageContents(got), want)
+		}
+	})
+}
+
+func TestEstimateTokens(t *testing.T) {
+	messages := []Message{{Role: RoleUser, Content: strings.Repeat("a", 400)}}
+	if got := EstimateTokens(messages); got != 200 {
+		t.Errorf("EstimateTokens() = %d, want 200", got)
+	}
+}
@@ -0,0 +1,371 @@
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"llm-client/internal/chat"
+	"llm-client/internal/config"
+	apperrors "llm-client/internal/errors"
+)
+
+const (
+	// anthropicVersion - версия Messages API в заголовке anthropic-version
+	anthropicVersion = "2023-06-01"
+	// anthropicDefaultMaxTokens - max_tokens по умолчанию: в Messages API поле обязательно
+	anthropicDefaultMaxTokens = 4096
+)
+
+// anthropicProvider - адаптер Anthropic Messages API
+type anthropicProvider struct{}
+
+// NewAnthropicProvider создаёт адаптер Anthropic Messages API
+func NewAnthropicProvider() Provider {
+	return anthropicProvider{}
+}
+
+// anthropicRequest - тело запроса /v1/messages.
+// top_p не передаётся: современные модели не принимают его вместе с temperature.
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	MaxTokens   int                `json:"max_tokens"`
+	Temperature float64            `json:"temperature"`
+	Stream      bool               `json:"stream,omitempty"`
+	Tools       []anthropicTool    `json:"tools,omitempty"`
+	ToolChoice  map[string]string  `json:"tool_choice,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string           `json:"role"`
+	Content []anthropicBlock `json:"content"`
+}
+
+// anthropicBlock - блок содержимого: text, image, tool_use или tool_result
+type anthropicBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	Source    *anthropicImage `json:"source,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+}
+
+// anthropicImage - источник изображения: base64 или url
+type anthropicImage struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+type anthropicTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// usage преобразует расход токенов к формату OpenAI
+func (u anthropicUsage) usage() *Usage {
+	return &Usage{
+		PromptTokens:     u.InputTokens,
+		CompletionTokens: u.OutputTokens,
+		TotalTokens:      u.InputTokens + u.OutputTokens,
+	}
+}
+
+type anthropicResponse struct {
+	Content    []anthropicBlock `json:"content"`
+	StopReason string           `json:"stop_reason"`
+	Usage      anthropicUsage   `json:"usage"`
+}
+
+func (anthropicProvider) Name() string {
+	return config.ProviderAnthropic
+}
+
+func (anthropicProvider) Endpoint(string, bool) string {
+	return "v1/messages"
+}
+
+func (anthropicProvider) SetHeaders(h http.Header, apiKey string) {
+	h.Set("anthropic-version", anthropicVersion)
+	if apiKey != "" {
+		h.Set("x-api-key", apiKey)
+	}
+}
+
+func (anthropicProvider) EncodeRequest(req *ChatRequest) ([]byte, error) {
+	body := anthropicRequest{
+		Model:     req.Model,
+		MaxTokens: req.MaxTokens,
+		// Диапазон temperature в Messages API - 0.0-1.0
+		Temperature: min(req.Temperature, 1),
+		Stream:      req.Stream,
+	}
+	if body.MaxTokens <= 0 {
+		body.MaxTokens = anthropicDefaultMaxTokens
+	}
+
+	var system []string
+	for _, msg := range req.Messages {
+		switch msg.Role {
+		case chat.RoleSystem:
+			// Системные сообщения, в том числе содержание сокращённой истории, передаются отдельно
+			system = append(system, msg.Content)
+		case chat.RoleTool:
+			body.Messages = appendAnthropic(body.Messages, "user", anthropicBlock{
+				Type:      "tool_result",
+				ToolUseID: msg.ToolCallID,
+				Content:   msg.Content,
+			})
+		case chat.RoleAssistant:
+			var blocks []anthropicBlock
+			if msg.Content != "" {
+				blocks = append(blocks, anthropicBlock{Type: "text", Text: msg.Content})
+			}
+			for _, call := range msg.ToolCalls {
+				blocks = append(blocks, anthropicBlock{
+					Type:  "tool_use",
+					ID:    call.ID,
+					Name:  call.Function.Name,
+					Input: objectArguments(call.Function.Arguments),
+				})
+			}
+			body.Messages = appendAnthropic(body.Messages, "assistant", blocks...)
+		default:
+			body.Messages = appendAnthropic(body.Messages, "user", anthropicBlocks(msg)...)
+		}
+	}
+	body.System = strings.Join(system, "\n\n")
+
+	for _, tool := range req.Tools {
+		schema := tool.Function.Parameters
+		if schema == nil {
+			schema = map[string]any{"type": "object"}
+		}
+		body.Tools = append(body.Tools, anthropicTool{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			InputSchema: schema,
+		})
+	}
+	if len(body.Tools) > 0 {
+		switch mode, name := toolChoiceMode(req.ToolChoice); mode {
+		case toolChoiceNone:
+			body.ToolChoice = map[string]string{"type": "none"}
+		case toolChoiceRequired:
+			body.ToolChoice = map[string]string{"type": "any"}
+		case toolChoiceFunction:
+			body.ToolChoice = map[string]string{"type": "tool", "name": name}
+		}
+	}
+
+	return json.Marshal(body)
+}
+
+// anthropicBlocks преобразует текст и вложения сообщения пользователя в блоки
+func anthropicBlocks(msg chat.Message) []anthropicBlock {
+	blocks := make([]anthropicBlock, 0, len(msg.Parts)+1)
+	for _, part := range msg.ContentParts() {
+		switch {
+		case part.Type == chat.PartText:
+			blocks = append(blocks, anthropicBlock{Type: "text", Text: part.Text})
+		case part.ImageURL != nil:
+			source := &anthropicImage{Type: "url", URL: part.ImageURL.URL}
+			if mimeType, data, ok := part.InlineImage(); ok {
+				source = &anthropicImage{Type: "base64", MediaType: mimeType, Data: data}
+			}
+			blocks = append(blocks, anthropicBlock{Type: "image", Source: source})
+		}
+	}
+	return blocks
+}
+
+// appendAnthropic добавляет блоки к последнему сообщению той же роли или начинает новое:
+// результаты нескольких вызовов инструментов должны приходить одним сообщением пользователя
+func appendAnthropic(messages []anthropicMessage, role string, blocks ...anthropicBlock) []anthropicMessage {
+	var filtered []anthropicBlock
+	for _, b := range blocks {
+		// Пустые текстовые блоки API отклоняет
+		if b.Type == "text" && b.Text == "" {
+			continue
+		}
+		filtered = append(filtered, b)
+	}
+	if len(filtered) == 0 {
+		return messages
+	}
+	if n := len(messages); n > 0 && messages[n-1].Role == role {
+		messages[n-1].Content = append(messages[n-1].Content, filtered...)
+		return messages
+	}
+	return append(messages, anthropicMessage{Role: role, Content: filtered})
+}
+
+func (anthropicProvider) DecodeResponse(body []byte) (*Completion, error) {
+	var resp anthropicResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, apperrors.NewInternalError("UNMARSHAL_ERROR", "failed to decode response", err)
+	}
+
+	message := chat.Message{Role: chat.RoleAssistant}
+	for _, block := range resp.Content {
+		switch block.Type {
+		case "text":
+			message.Content += block.Text
+		case "tool_use":
+			message.ToolCalls = append(message.ToolCalls, chat.ToolCall{
+				ID:       block.ID,
+				Type:     ToolTypeFunction,
+				Function: chat.FunctionCall{Name: block.Name, Arguments: toolArguments(block.Input)},
+			})
+		}
+	}
+
+	return &Completion{
+		Message:      message,
+		FinishReason: anthropicFinishReason(resp.StopReason),
+		Usage:        resp.Usage.usage(),
+	}, nil
+}
+
+func (anthropicProvider) NewStreamDecoder(r io.Reader) StreamDecoder {
+	return &anthropicStreamDecoder{events: newSSEDecoder(r)}
+}
+
+func (anthropicProvider) ErrorMessage(body []byte) string {
+	// {"type": "error", "error": {"type": "...", "message": "..."}}
+	return errorMessage(body)
+}
+
+func (anthropicProvider) ModelsEndpoint() string {
+	// По умолчанию API отдаёт 20 моделей на страницу
+	return "v1/models?limit=1000"
+}
+
+func (anthropicProvider) DecodeModels(body []byte) ([]ModelInfo, error) {
+	var list struct {
+		Data []struct {
+			ID          string `json:"id"`
+			DisplayName string `json:"display_name"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, apperrors.NewInternalError("UNMARSHAL_ERROR", "failed to decode model list", err)
+	}
+
+	models := make([]ModelInfo, 0, len(list.Data))
+	for _, m := range list.Data {
+		models = append(models, ModelInfo{ID: m.ID, Description: m.DisplayName})
+	}
+	return models, nil
+}
+
+// anthropicFinishReason приводит stop_reason к значениям finish_reason OpenAI
+func anthropicFinishReason(reason string) string {
+	switch reason {
+	case "end_turn", "stop_sequence", "pause_turn":
+		return "stop"
+	case "max_tokens":
+		return "length"
+	case "tool_use":
+		return "tool_calls"
+	case "refusal":
+		return "content_filter"
+	default:
+		return reason
+	}
+}
+
+// anthropicStreamDecoder разбирает события Messages API: message_start, content_block_start,
+// content_block_delta, message_delta, message_stop, ping и error
+type anthropicStreamDecoder struct {
+	events *sseDecoder
+	// inputTokens приходит в message_start, output_tokens - в message_delta
+	inputTokens int
+}
+
+// anthropicEvent - данные события потока; используемые поля зависят от типа
+type anthropicEvent struct {
+	Type    string `json:"type"`
+	Index   int    `json:"index"`
+	Message struct {
+		Usage anthropicUsage `json:"usage"`
+	} `json:"message"`
+	ContentBlock anthropicBlock `json:"content_block"`
+	Delta        struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+		StopReason  string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage anthropicUsage `json:"usage"`
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (d *anthropicStreamDecoder) Next() ([]StreamChunk, error) {
+	ev, err := d.events.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	var data anthropicEvent
+	if err := json.Unmarshal([]byte(ev.Data), &data); err != nil {
+		return []StreamChunk{malformedChunk(ev.Data, err)}, nil
+	}
+	// Тип события дублируется в поле type данных
+	if data.Type == "" {
+		data.Type = ev.Event
+	}
+
+	switch data.Type {
+	case "message_start":
+		d.inputTokens = data.Message.Usage.InputTokens
+	case "content_block_start":
+		if data.ContentBlock.Type == "tool_use" {
+			delta := toolCallDelta{Index: data.Index, ID: data.ContentBlock.ID, Type: ToolTypeFunction}
+			delta.Function.Name = data.ContentBlock.Name
+			return []StreamChunk{{toolCallDeltas: []toolCallDelta{delta}}}, nil
+		}
+		if data.ContentBlock.Text != "" {
+			return []StreamChunk{{Content: data.ContentBlock.Text}}, nil
+		}
+	case "content_block_delta":
+		switch data.Delta.Type {
+		case "text_delta":
+			if data.Delta.Text != "" {
+				return []StreamChunk{{Content: data.Delta.Text}}, nil
+			}
+		case "input_json_delta":
+			delta := toolCallDelta{Index: data.Index}
+			delta.Function.Arguments = data.Delta.PartialJSON
+			return []StreamChunk{{toolCallDeltas: []toolCallDelta{delta}}}, nil
+		}
+	case "message_delta":
+		usage := anthropicUsage{InputTokens: d.inputTokens, OutputTokens: data.Usage.OutputTokens}
+		chunks := []StreamChunk{{Usage: usage.usage()}}
+		if data.Delta.StopReason != "" {
+			chunks = append(chunks, StreamChunk{Done: true, FinishReason: anthropicFinishReason(data.Delta.StopReason)})
+		}
+		return chunks, nil
+	case "message_stop":
+		return []StreamChunk{{Done: true}}, nil
+	case "error":
+		return []StreamChunk{providerError(data.Error.Message, ev.Data)}, nil
+	}
+	// ping, content_block_stop и неизвестные события не несут данных
+	return nil, nil
+}
@@ -1,5 +1,6 @@
 // Package client предоставляет HTTP-клиент для взаимодействия с LLM API.
 // Поддерживает как обычный режим, так и потоковый (streaming) режим получения ответов.
+// Формат запросов и ответов конкретного API реализуется адаптером Provider.
 package client
 
 import (
@@ -14,6 +15,7 @@ import (
 	"time"
 
 	"llm-client/internal/chat"
+	"llm-client/internal/config"
 	apperrors "llm-client/internal/errors"
 	"llm-client/internal/logger"
 )
@@ -26,6 +28,12 @@ type ChatRequest struct {
 	Temperature float64        `json:"temperature"`
 	TopP        float64        `json:"top_p"`
 	MaxTokens   int            `json:"max_tokens,omitempty"`
+	// Tools - инструменты, доступные модели
+	Tools []Tool `json:"tools,omitempty"`
+	// ToolChoice - "auto", "none", "required" или ToolChoiceFunction(name)
+	ToolChoice any `json:"tool_choice,omitempty"`
+	// StreamOptions - параметры стрима; ChatStream запрашивает usage автоматически
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
 }
 
 // ChatResponse представляет ответ от LLM API
@@ -40,6 +48,32 @@ type ChatResponse struct {
 		Message      chat.Message `json:"message"`
 		FinishReason string       `json:"finish_reason"`
 	} `json:"choices"`
+	Usage *Usage `json:"usage,omitempty"`
+}
+
+// streamResponse представляет один чанк потокового ответа.
+// В отличие от ChatResponse, delta содержит фрагменты вызовов инструментов с индексами.
+type streamResponse struct {
+	Choices []struct {
+		Delta struct {
+			Content   string          `json:"content"`
+			ToolCalls []toolCallDelta `json:"tool_calls"`
+		} `json:"delta"`
+		Message      chat.Message `json:"message"`
+		FinishReason string       `json:"finish_reason"`
+	} `json:"choices"`
+	// Usage приходит в последнем чанке (с пустым choices) при stream_options.include_usage
+	Usage *Usage `json:"usage,omitempty"`
+}
+
+// Completion представляет полный ответ модели
+type Completion struct {
+	// Message - сообщение ассистента, включая запрошенные вызовы инструментов
+	Message chat.Message
+	// FinishReason - причина завершения генерации (stop, length, tool_calls...)
+	FinishReason string
+	// Usage - расход токенов (nil, если провайдер его не вернул)
+	Usage *Usage
 }
 
 // StreamChunk представляет один чанк данных при стриминге
@@ -47,6 +81,15 @@ type StreamChunk struct {
 	Content string
 	Done    bool
 	Error   error
+	// FinishReason - причина завершения генерации, заполняется в чанке Done
+	FinishReason string
+	// ToolCalls - собранные вызовы инструментов, заполняются в чанке Done
+	ToolCalls []chat.ToolCall
+	// Usage - расход токенов, заполняется в чанке Done, если провайдер его прислал
+	Usage *Usage
+
+	// toolCallDeltas - фрагменты вызовов инструментов для сборки в readStream
+	toolCallDeltas []toolCallDelta
 }
 
 // ClientOption - функция опция для настройки клиента
@@ -80,6 +123,13 @@ func WithAPIKey(apiKey string) ClientOption {
 	}
 }
 
+// WithProvider устанавливает адаптер API провайдера (по умолчанию OpenAI-compatible)
+func WithProvider(provider Provider) ClientOption {
+	return func(c *Client) {
+		c.provider = provider
+	}
+}
+
 // Client - HTTP клиент для взаимодействия с LLM
 type Client struct {
 	baseURL     string
@@ -88,6 +138,9 @@ type Client struct {
 	timeout     time.Duration
 	apiKey      string
 	logger      *logger.Logger
+	retry       RetryPolicy
+	onUsage     UsageHandler
+	provider    Provider
 }
 
 // NewClient создаёт новый клиент для подключения к LLM
@@ -103,7 +156,9 @@ func NewClient(baseURL, apiEndpoint string, opts ...ClientOption) *Client {
 		httpClient: &http.Client{
 			Timeout: 0, // По умолчанию без таймаута для стриминга
 		},
-		logger: logger.DefaultLogger,
+		logger:   logger.DefaultLogger,
+		retry:    NoRetryPolicy(),
+		provider: NewOpenAIProvider(apiEndpoint),
 	}
 
 	// Применяем опции
@@ -113,7 +168,7 @@ func NewClient(baseURL, apiEndpoint string, opts ...ClientOption) *Client {
 
 	// Если API ключ не установлен через опцию, пробуем получить из окружения
 	if client.apiKey == "" {
-		client.apiKey = getAPIKey()
+		client.apiKey = getAPIKey(client.provider.Name())
 	}
 
 	// Если таймаут установлен, применяем его к HTTP клиенту
@@ -121,311 +176,526 @@ func NewClient(baseURL, apiEndpoint string, opts ...ClientOption) *Client {
 		client.httpClient.Timeout = client.timeout
 	}
 
+	// Хотя бы одна попытка выполняется всегда
+	if client.retry.MaxAttempts < 1 {
+		client.retry.MaxAttempts = 1
+	}
+
 	return client
 }
 
-// getAPIKey получает API ключ из переменной окружения
-func getAPIKey() string {
-	return os.Getenv("ROUTERAI_API_KEY")
+// NewClientFromConfig создаёт клиент по конфигурации приложения
+func NewClientFromConfig(cfg *config.Config, opts ...ClientOption) *Client {
+	retry := cfg.Server.Retry
+	provider, err := NewProvider(cfg.Server.Provider, cfg.Server.APIEndpoint)
+	if err != nil {
+		// Имя провайдера проверяется при валидации конфигурации
+		provider = NewOpenAIProvider(cfg.Server.APIEndpoint)
+	}
+	baseOpts := []ClientOption{
+		WithProvider(provider),
+		WithRetryPolicy(RetryPolicy{
+			MaxAttempts: retry.MaxAttempts,
+			BaseDelay:   retry.BaseDelay(),
+			MaxDelay:    retry.MaxDelay(),
+			Jitter:      retry.Jitter,
+		}),
+	}
+	// Ключ из server.api_key (config.ResolveAPIKey); без него используются переменные провайдера
+	if cfg.Server.Key != "" {
+		baseOpts = append(baseOpts, WithAPIKey(cfg.Server.Key.Reveal()))
+	}
+	return NewClient(cfg.Server.Address, cfg.Server.APIEndpoint, append(baseOpts, opts...)...)
 }
 
-// getEndpoint возвращает полный URL эндпоинта
-func (c *Client) getEndpoint() string {
-	return c.baseURL + "/" + c.apiEndpoint
+// apiKeyEnv - переменные окружения с API ключом для провайдеров, в порядке приоритета
+var apiKeyEnv = map[string][]string{
+	config.ProviderOpenAI:    {"ROUTERAI_API_KEY", "OPENAI_API_KEY"},
+	config.ProviderAnthropic: {"ANTHROPIC_API_KEY"},
+	config.ProviderOllama:    {"OLLAMA_API_KEY"},
+	config.ProviderGemini:    {"GEMINI_API_KEY", "GOOGLE_API_KEY"},
 }
 
-// Chat отправляет запрос к LLM и возвращает полный ответ (без стриминга)
+// getAPIKey получает API ключ провайдера из переменной окружения
+func getAPIKey(provider string) string {
+	for _, name := range apiKeyEnv[provider] {
+		if key := os.Getenv(name); key != "" {
+			return key
+		}
+	}
+	return ""
+}
+
+// getEndpoint возвращает полный URL эндпоинта для модели
+func (c *Client) getEndpoint(model string, stream bool) string {
+	return c.baseURL + "/" + strings.TrimPrefix(c.provider.Endpoint(model, stream), "/")
+}
+
+// Chat отправляет запрос к LLM и возвращает полный ответ (без стриминга).
+// Временные ошибки повторяются согласно политике повторов клиента.
 func (c *Client) Chat(ctx context.Context, req *ChatRequest) (string, error) {
+	completion, err := c.ChatCompletion(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return completion.Message.Content, nil
+}
+
+// ChatCompletion отправляет запрос без стриминга и возвращает полное сообщение ассистента,
+// включая запрошенные вызовы инструментов.
+func (c *Client) ChatCompletion(ctx context.Context, req *ChatRequest) (*Completion, error) {
 	req.Stream = false
+	ctx = ensureRequestID(ctx)
 
-	jsonData, err := json.Marshal(req)
+	jsonData, err := c.provider.EncodeRequest(req)
 	if err != nil {
-		c.logger.Error("Failed to marshal chat request", "error", err)
-		return "", apperrors.NewInternalError("MARSHAL_ERROR", "failed to marshal request", err)
+		c.logger.ErrorContext(ctx, "Failed to marshal chat request", "error", err)
+		return nil, apperrors.NewInternalError("MARSHAL_ERROR", "failed to marshal request", err)
 	}
 
-	c.logRequest(req, jsonData)
+	for attempt := 1; ; attempt++ {
+		c.logRequest(ctx, req, jsonData, attempt)
+
+		span := Span{Name: SpanChat, Model: req.Model, Attempt: attempt, Start: time.Now()}
+		completion, err := c.chatOnce(ctx, c.getEndpoint(req.Model, false), jsonData)
+		span.Duration, span.Err = time.Since(span.Start), err
+		if completion != nil && completion.Usage != nil {
+			span.CompletionTokens = completion.Usage.CompletionTokens
+		}
+		c.recordSpan(ctx, span)
+
+		if err == nil {
+			c.reportUsage(req.Model, completion.Usage)
+			return completion, nil
+		}
+		if !c.shouldRetry(ctx, attempt, err) {
+			return nil, err
+		}
+		if waitErr := c.waitRetry(ctx, attempt, err); waitErr != nil {
+			return nil, err
+		}
+	}
+}
 
-	resp, body, err := c.doRequest(ctx, jsonData)
+// chatOnce выполняет одну попытку обычного запроса
+func (c *Client) chatOnce(ctx context.Context, endpoint string, jsonData []byte) (*Completion, error) {
+	resp, body, err := c.doRequest(ctx, endpoint, jsonData)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	c.logResponse(resp, body)
+	c.logResponse(ctx, resp, body)
 
 	if resp.StatusCode != http.StatusOK {
-		return "", c.handleErrorResponse(resp, body)
-	}
-
-	var chatResp ChatResponse
-	if err := json.Unmarshal(body, &chatResp); err != nil {
-		c.logger.Error("Failed to decode API response", "error", err)
-		return "", apperrors.NewInternalError("UNMARSHAL_ERROR", "failed to decode response", err)
+		return nil, c.handleErrorResponse(ctx, resp, body)
 	}
 
-	if len(chatResp.Choices) == 0 {
-		c.logger.Error("API returned empty choices")
-		return "", apperrors.NewAPIError("EMPTY_CHOICES", "empty response from API", nil, resp.StatusCode)
+	completion, err := c.provider.DecodeResponse(body)
+	if err != nil {
+		c.logger.ErrorContext(ctx, "Failed to decode API response", "error", err)
+		return nil, err
 	}
-
-	// Получаем контент из ответа
-	content := chatResp.Choices[0].Delta.Content
-	if content == "" {
-		content = chatResp.Choices[0].Message.Content
+	if completion.Message.Role == "" {
+		completion.Message.Role = chat.RoleAssistant
 	}
 
-	c.logger.Debug("Received response", "content_length", len(content))
-	return content, nil
+	c.logger.DebugContext(ctx, "Received response",
+		"content_length", len(completion.Message.Content),
+		"tool_calls", len(completion.Message.ToolCalls),
+		"finish_reason", completion.FinishReason,
+	)
+	return completion, nil
 }
 
-// ChatStream отправляет запрос к LLM и возвращает канал для потокового получения токенов
+// ChatStream отправляет запрос к LLM и возвращает канал для потокового получения токенов.
+// Подключение выполняется в фоне; при временной ошибке запрос повторяется,
+// но только пока в канал не был отправлен ни один токен.
 func (c *Client) ChatStream(ctx context.Context, req *ChatRequest) <-chan StreamChunk {
 	ch := make(chan StreamChunk, 64)
+	ctx = ensureRequestID(ctx)
 
-	jsonData, err := json.Marshal(req)
+	if req.Stream && req.StreamOptions == nil {
+		// Просим провайдера прислать расход токенов в конце потока
+		withUsage := *req
+		withUsage.StreamOptions = &StreamOptions{IncludeUsage: true}
+		req = &withUsage
+	}
+
+	jsonData, err := c.provider.EncodeRequest(req)
 	if err != nil {
-		c.logger.Error("Failed to marshal stream request", "error", err)
+		c.logger.ErrorContext(ctx, "Failed to marshal stream request", "error", err)
 		ch <- StreamChunk{Error: apperrors.NewInternalError("MARSHAL_ERROR", "failed to marshal request", err)}
 		close(ch)
 		return ch
 	}
 
-	c.logRequest(req, jsonData)
+	// Запускаем горутину для подключения и чтения стрима
+	go func() {
+		defer close(ch)
+
+		for attempt := 1; ; attempt++ {
+			c.logRequest(ctx, req, jsonData, attempt)
 
+			emitted, err := c.streamOnce(ctx, req.Model, attempt, jsonData, ch)
+			if err == nil {
+				return
+			}
+			if emitted == 0 && c.shouldRetry(ctx, attempt, err) {
+				if waitErr := c.waitRetry(ctx, attempt, err); waitErr == nil {
+					continue
+				}
+			}
+			ch <- StreamChunk{Error: err}
+			return
+		}
+	}()
+
+	return ch
+}
+
+// streamOnce выполняет одну попытку потокового запроса и записывает её интервал.
+// Возвращает количество отправленных в канал токенов и ошибку попытки.
+func (c *Client) streamOnce(ctx context.Context, model string, attempt int, jsonData []byte, ch chan<- StreamChunk) (int, error) {
+	span := Span{Name: SpanStream, Model: model, Attempt: attempt, Start: time.Now()}
+	defer func() {
+		// Завершённый поток записывает интервал сам, до отправки итогового чанка
+		if span.Duration == 0 {
+			span.Duration = time.Since(span.Start)
+			c.recordSpan(ctx, span)
+		}
+	}()
+
+	resp, err := c.openStream(ctx, c.getEndpoint(model, true), jsonData)
+	if err != nil {
+		span.Err = err
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Читаем поток данных
+	emitted, err := c.readStream(ctx, model, resp.Body, ch, &span)
+	span.Err = err
+	return emitted, err
+}
+
+// openStream отправляет потоковый запрос и возвращает ответ с открытым телом.
+// Ошибочный статус провайдера возвращается как ошибка, тело ответа при этом закрывается.
+func (c *Client) openStream(ctx context.Context, endpoint string, jsonData []byte) (*http.Response, error) {
 	// Создаем HTTP запрос с контекстом
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.getEndpoint(), bytes.NewReader(jsonData))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(jsonData))
 	if err != nil {
-		c.logger.Error("Failed to create HTTP request", "error", err)
-		ch <- StreamChunk{Error: apperrors.NewInternalError("REQUEST_ERROR", "failed to create request", err)}
-		close(ch)
-		return ch
+		c.logger.ErrorContext(ctx, "Failed to create HTTP request", "error", err)
+		return nil, apperrors.NewInternalError("REQUEST_ERROR", "failed to create request", err)
 	}
 
-	c.setHeaders(httpReq)
+	c.setHeaders(ctx, httpReq)
 
 	// Выполняем запрос
 	startTime := time.Now()
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		c.logger.Error("HTTP stream request failed", "error", err, "duration", time.Since(startTime))
-		ch <- StreamChunk{Error: apperrors.NewNetworkError("REQUEST_FAILED", "request failed", err)}
-		close(ch)
-		return ch
+		c.logger.ErrorContext(ctx, "HTTP stream request failed", "error", err, "duration", time.Since(startTime))
+		return nil, apperrors.NewNetworkError("REQUEST_FAILED", "request failed", err)
 	}
 
-	// Запускаем горутину для чтения стрима
-	go func() {
-		defer resp.Body.Close()
-		defer close(ch)
-
-		c.logResponse(resp, nil)
-
-		if resp.StatusCode != http.StatusOK {
-			body, _ := io.ReadAll(resp.Body)
-			c.logger.Error("Stream API returned error status", "status", resp.StatusCode, "body", string(body))
-			ch <- StreamChunk{Error: c.handleErrorResponse(resp, body)}
-			return
-		}
-
-		c.logger.Debug("Stream connection established")
+	c.logResponse(ctx, resp, nil)
 
-		// Читаем поток данных
-		c.readStream(ctx, resp.Body, ch)
-	}()
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		c.logger.ErrorContext(ctx, "Stream API returned error status", "status", resp.StatusCode, "body", string(body))
+		return nil, c.handleErrorResponse(ctx, resp, body)
+	}
 
-	return ch
+	c.logger.DebugContext(ctx, "Stream connection established")
+	return resp, nil
 }
 
-// readStream читает поток данных из ответа
-func (c *Client) readStream(ctx context.Context, reader io.Reader, ch chan<- StreamChunk) {
-	buf := make([]byte, 4096)
-	bytesRead := 0
-	chunksReceived := 0
+// readStream читает потоковый ответ через декодер провайдера и отправляет чанки в канал.
+// После finish_reason поток дочитывается до [DONE]: в последнем чанке провайдер присылает usage.
+// Время первого токена и число токенов ответа записываются в span.
+// Возвращает количество отправленных токенов и ошибку потока (nil при нормальном завершении).
+func (c *Client) readStream(ctx context.Context, model string, reader io.Reader, ch chan<- StreamChunk, span *Span) (int, error) {
+	decoder := c.provider.NewStreamDecoder(reader)
+	eventsReceived := 0
+	emitted := 0
 	var fullResponse strings.Builder
+	var toolCalls toolCallAccumulator
+	var finishReason string
+	var usage *Usage
+
+	// endSpan записывает интервал до итогового чанка, чтобы получатель видел его в трассировке
+	endSpan := func() {
+		span.Duration = time.Since(span.Start)
+		c.recordSpan(ctx, *span)
+	}
+
+	// finish завершает поток итоговым чанком
+	finish := func() {
+		final := StreamChunk{
+			Done:         true,
+			FinishReason: finishReason,
+			ToolCalls:    toolCalls.result(),
+			Usage:        usage,
+		}
+		c.logger.InfoContext(ctx, "Stream completed",
+			"events", eventsReceived,
+			"response_length", fullResponse.Len(),
+			"finish_reason", final.FinishReason,
+			"tool_calls", len(final.ToolCalls),
+		)
+		if fullResponse.Len() > 0 {
+			c.logFullResponse(ctx, fullResponse.String())
+		}
+		if usage != nil {
+			span.CompletionTokens = usage.CompletionTokens
+		}
+		c.reportUsage(model, usage)
+		endSpan()
+		ch <- final
+	}
 
 	for {
 		select {
 		case <-ctx.Done():
-			c.logger.Info("Stream cancelled by context", "bytes_read", bytesRead, "chunks", chunksReceived)
+			c.logger.InfoContext(ctx, "Stream cancelled by context", "events", eventsReceived)
+			endSpan()
 			ch <- StreamChunk{Done: true}
-			return
+			return emitted, nil
 		default:
 		}
 
-		n, err := reader.Read(buf)
-		if n > 0 {
-			bytesRead += n
-			chunks := c.parseStreamData(buf[:n])
-			for _, chunk := range chunks {
-				chunksReceived++
-
-				if chunk.Done {
-					c.logger.Info("Stream completed", "bytes", bytesRead, "chunks", chunksReceived, "response_length", fullResponse.Len())
-					if fullResponse.Len() > 0 {
-						c.logFullResponse(fullResponse.String())
-					}
-					ch <- chunk
-					return
-				}
-				if chunk.Error != nil {
-					c.logger.Error("Stream chunk error", "error", chunk.Error)
-					ch <- chunk
-					return
-				}
-				if chunk.Content != "" {
-					fullResponse.WriteString(chunk.Content)
-					ch <- chunk
-				}
+		chunks, err := decoder.Next()
+		if err != nil {
+			switch {
+			case ctx.Err() != nil:
+				c.logger.InfoContext(ctx, "Stream cancelled by context", "events", eventsReceived)
+				endSpan()
+				ch <- StreamChunk{Done: true}
+				return emitted, nil
+			case err != io.EOF:
+				c.logger.ErrorContext(ctx, "Stream read error", "error", err, "emitted", emitted)
+				return emitted, apperrors.NewStreamError("READ_ERROR", "read error", err).MarkRetryable()
+			default:
+				// Сервер закрыл соединение без [DONE] - считаем поток завершённым
+				c.logger.DebugContext(ctx, "Stream ended (EOF)", "events", eventsReceived)
+				finish()
+				return emitted, nil
 			}
 		}
+		eventsReceived++
 
-		if err != nil {
-			if err != io.EOF {
-				c.logger.Error("Stream read error", "error", err)
-				ch <- StreamChunk{Error: apperrors.NewStreamError("READ_ERROR", "read error", err)}
-			} else {
-				c.logger.Debug("Stream ended (EOF)", "bytes", bytesRead, "chunks", chunksReceived)
-				if fullResponse.Len() > 0 {
-					c.logFullResponse(fullResponse.String())
+		for _, chunk := range chunks {
+			if len(chunk.toolCallDeltas) > 0 {
+				toolCalls.add(chunk.toolCallDeltas)
+				continue
+			}
+			if chunk.Usage != nil {
+				usage = chunk.Usage
+				continue
+			}
+			if chunk.Done {
+				if chunk.FinishReason != "" {
+					// Генерация окончена, но за ней может прийти чанк с usage
+					finishReason = chunk.FinishReason
+					continue
 				}
+				finish()
+				return emitted, nil
+			}
+			if chunk.Error != nil {
+				c.logger.ErrorContext(ctx, "Stream chunk error", "error", chunk.Error)
+				return emitted, chunk.Error
+			}
+			if chunk.Content != "" {
+				if emitted == 0 {
+					span.FirstToken = time.Since(span.Start)
+				}
+				fullResponse.WriteString(chunk.Content)
+				emitted++
+				span.CompletionTokens = emitted
+				ch <- chunk
 			}
-			return
 		}
 	}
 }
 
-// setHeaders устанавливает необходимые HTTP заголовки
-func (c *Client) setHeaders(req *http.Request) {
+// setHeaders устанавливает необходимые HTTP заголовки; аутентификацию задаёт провайдер
+func (c *Client) setHeaders(ctx context.Context, req *http.Request) {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
 
+	if id := logger.RequestID(ctx); id != "" {
+		req.Header.Set(RequestIDHeader, id)
+	}
+
+	c.provider.SetHeaders(req.Header, c.apiKey)
 	if c.apiKey != "" {
-		req.Header.Set("Authorization", "Bearer "+c.apiKey)
-		c.logger.Debug("Authorization header set")
+		c.logger.DebugContext(ctx, "Authorization header set", "provider", c.provider.Name())
 	} else {
-		c.logger.Debug("No API key provided")
+		c.logger.DebugContext(ctx, "No API key provided")
 	}
 }
 
 // doRequest выполняет HTTP запрос и возвращает ответ
-func (c *Client) doRequest(ctx context.Context, jsonData []byte) (*http.Response, []byte, error) {
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.getEndpoint(), bytes.NewReader(jsonData))
+func (c *Client) doRequest(ctx context.Context, endpoint string, jsonData []byte) (*http.Response, []byte, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(jsonData))
 	if err != nil {
-		c.logger.Error("Failed to create HTTP request", "error", err)
+		c.logger.ErrorContext(ctx, "Failed to create HTTP request", "error", err)
 		return nil, nil, apperrors.NewInternalError("REQUEST_ERROR", "failed to create request", err)
 	}
 
-	c.setHeaders(httpReq)
+	c.setHeaders(ctx, httpReq)
 
 	startTime := time.Now()
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		c.logger.Error("HTTP request failed", "error", err, "duration", time.Since(startTime))
+		c.logger.ErrorContext(ctx, "HTTP request failed", "error", err, "duration", time.Since(startTime))
 		return nil, nil, apperrors.NewNetworkError("REQUEST_FAILED", "request failed", err)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		c.logger.Error("Failed to read response body", "error", err)
+		c.logger.ErrorContext(ctx, "Failed to read response body", "error", err)
 		return resp, nil, apperrors.NewInternalError("READ_ERROR", "failed to read response", err)
 	}
 
 	return resp, body, nil
 }
 
-// handleErrorResponse обрабатывает ошибку от API
-func (c *Client) handleErrorResponse(resp *http.Response, body []byte) error {
-	c.logger.Error("API error", "status", resp.StatusCode, "body", string(body))
-	return apperrors.NewAPIError(
-		"API_ERROR",
-		fmt.Sprintf("API error (status %d)", resp.StatusCode),
-		nil,
-		resp.StatusCode,
-	).WithContext("body", string(body))
+// handleErrorResponse обрабатывает ошибку от API.
+// Текст ошибки из тела ответа извлекается провайдером и добавляется к сообщению.
+func (c *Client) handleErrorResponse(ctx context.Context, resp *http.Response, body []byte) error {
+	c.logger.ErrorContext(ctx, "API error", "status", resp.StatusCode, "body", string(body))
+	message := fmt.Sprintf("API error (status %d)", resp.StatusCode)
+	if detail := c.provider.ErrorMessage(body); detail != "" {
+		message += ": " + detail
+	}
+	appErr := apperrors.NewAPIError("API_ERROR", message, nil, resp.StatusCode).
+		WithContext("body", string(body)).
+		WithContext("provider", c.provider.Name())
+
+	if retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); retryAfter > 0 {
+		appErr.WithContext("retry_after", retryAfter)
+	}
+	return appErr
 }
 
-// parseStreamData парсит данные Server-Sent Events формата
+// parseStreamData парсит полный буфер в формате Server-Sent Events.
+// Буфер считается завершённым, поэтому его конец закрывает последнее событие.
+// Разбор останавливается на первом чанке завершения или ошибки.
 func (c *Client) parseStreamData(data []byte) []StreamChunk {
 	var chunks []StreamChunk
 
-	lines := bytes.Split(data, []byte("\n"))
-	for _, line := range lines {
-		line = bytes.TrimSpace(line)
-		if len(line) == 0 {
-			continue
+	decoder := newSSEDecoder(io.MultiReader(bytes.NewReader(data), strings.NewReader("\n\n")))
+	for {
+		ev, err := decoder.Next()
+		if err != nil {
+			return chunks
 		}
-
-		// Пропускаем комментарии
-		if bytes.HasPrefix(line, []byte(":")) {
-			continue
+		for _, chunk := range parseStreamEvent(ev) {
+			chunks = append(chunks, chunk)
+			if chunk.Done || chunk.Error != nil {
+				return chunks
+			}
 		}
+	}
+}
 
-		// Ожидаем формат "data: {...}"
-		if !bytes.HasPrefix(line, []byte("data: ")) {
-			continue
-		}
+// parseStreamEvent преобразует одно событие SSE OpenAI-compatible API в чанки стрима.
+// Некорректный JSON возвращается как чанк с ошибкой KindStream.
+func parseStreamEvent(ev *sseEvent) []StreamChunk {
+	// Некоторые провайдеры сообщают об ошибке отдельным типом события
+	if ev.Event == "error" {
+		return []StreamChunk{{
+			Error: apperrors.NewStreamError("PROVIDER_ERROR", "provider reported stream error", nil).
+				WithContext("data", ev.Data),
+		}}
+	}
 
-		jsonData := bytes.TrimPrefix(line, []byte("data: "))
+	data := strings.TrimSpace(ev.Data)
+	if data == "" {
+		return nil
+	}
 
-		// Проверяем сигнал конца потока
-		if string(jsonData) == "[DONE]" {
-			chunks = append(chunks, StreamChunk{Done: true})
-			return chunks
-		}
+	// Проверяем сигнал конца потока
+	if data == "[DONE]" {
+		return []StreamChunk{{Done: true}}
+	}
 
-		// Парсим JSON ответа
-		var resp ChatResponse
-		if err := json.Unmarshal(jsonData, &resp); err != nil {
-			// Игнорируем ошибки парсинга отдельных чанков
-			continue
-		}
+	// Парсим JSON ответа
+	var resp streamResponse
+	if err := json.Unmarshal([]byte(data), &resp); err != nil {
+		return []StreamChunk{{
+			Error: apperrors.NewStreamError("PARSE_ERROR", "malformed stream chunk", err).
+				WithContext("data", data).
+				WithContext("event_id", ev.ID),
+		}}
+	}
 
-		// Извлекаем контент из чанка
-		if len(resp.Choices) > 0 {
-			content := resp.Choices[0].Delta.Content
-			if content == "" {
-				content = resp.Choices[0].Message.Content
-			}
-			if content != "" {
-				chunks = append(chunks, StreamChunk{Content: content})
-			}
-			// Проверяем завершение генерации
-			if resp.Choices[0].FinishReason != "" && resp.Choices[0].FinishReason != "null" {
-				chunks = append(chunks, StreamChunk{Done: true})
-			}
-		}
+	var chunks []StreamChunk
+
+	// Расход токенов приходит отдельным чанком (обычно с пустым choices)
+	if resp.Usage != nil {
+		chunks = append(chunks, StreamChunk{Usage: resp.Usage})
+	}
+
+	if len(resp.Choices) == 0 {
+		return chunks
+	}
+
+	// Извлекаем контент из чанка
+	content := resp.Choices[0].Delta.Content
+	if content == "" {
+		content = resp.Choices[0].Message.Content
+	}
+	if content != "" {
+		chunks = append(chunks, StreamChunk{Content: content})
+	}
+
+	// Фрагменты вызовов инструментов собираются в readStream
+	if deltas := resp.Choices[0].Delta.ToolCalls; len(deltas) > 0 {
+		chunks = append(chunks, StreamChunk{toolCallDeltas: deltas})
+	}
+
+	// Проверяем завершение генерации
+	if reason := resp.Choices[0].FinishReason; reason != "" && reason != "null" {
+		chunks = append(chunks, StreamChunk{Done: true, FinishReason: reason})
 	}
 
 	return chunks
 }
 
 // logRequest записывает детали запроса в лог
-func (c *Client) logRequest(req *ChatRequest, jsonData []byte) {
-	c.logger.Info("Sending request",
-		"endpoint", c.getEndpoint(),
+func (c *Client) logRequest(ctx context.Context, req *ChatRequest, jsonData []byte, attempt int) {
+	c.logger.InfoContext(ctx, "Sending request",
+		"provider", c.provider.Name(),
+		"endpoint", c.getEndpoint(req.Model, req.Stream),
 		"model", req.Model,
 		"messages_count", len(req.Messages),
 		"stream", req.Stream,
+		"attempt", attempt,
+		"max_attempts", c.retry.MaxAttempts,
 	)
 
-	c.logger.Debug("Request body", "body", string(jsonData))
+	c.logger.DebugContext(ctx, "Request body", "body", string(jsonData))
 }
 
 // logResponse записывает детали ответа в лог
-func (c *Client) logResponse(resp *http.Response, body []byte) {
-	c.logger.Info("Received response",
+func (c *Client) logResponse(ctx context.Context, resp *http.Response, body []byte) {
+	c.logger.InfoContext(ctx, "Received response",
 		"status", resp.StatusCode,
 		"content_length", resp.ContentLength,
 	)
 
 	if body != nil {
-		c.logger.Debug("Response body", "body", string(body))
+		c.logger.DebugContext(ctx, "Response body", "body", string(body))
 	}
 }
 
 // logFullResponse записывает полный ответ ассистента в лог
-func (c *Client) logFullResponse(content string) {
+func (c *Client) logFullResponse(ctx context.Context, content string) {
 	response := map[string]interface{}{
 		"object": "chat.completion",
 		"choices": []map[string]interface{}{
@@ -442,10 +712,10 @@ func (c *Client) logFullResponse(content string) {
 
 	jsonData, err := json.MarshalIndent(response, "", "  ")
 	if err != nil {
-		c.logger.Error("Failed to marshal full response", "error", err)
+		c.logger.ErrorContext(ctx, "Failed to marshal full response", "error", err)
 		return
 	}
-	c.logger.Info("Full response", "body", string(jsonData))
+	c.logger.InfoContext(ctx, "Full response", "body", string(jsonData))
 }
 
 // GetBaseURL возвращает базовый URL клиента
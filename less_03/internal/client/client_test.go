@@ -4,12 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
-	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
 	"llm-client/internal/chat"
+	"llm-client/internal/fakellm"
 	"llm-client/internal/logger"
 )
 
@@ -85,41 +85,7 @@ func TestNewClient_Options(t *testing.T) {
 
 func TestClient_Chat_Success(t *testing.T) {
 	expectedResponse := "Hello! How can I help you?"
-
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			t.Errorf("Expected POST, got %s", r.Method)
-		}
-		if r.Header.Get("Content-Type") != "application/json" {
-			t.Errorf("Expected Content-Type: application/json")
-		}
-
-		resp := ChatResponse{
-			ID:      "test-id",
-			Object:  "chat.completion",
-			Created: time.Now().Unix(),
-			Model:   "test-model",
-			Choices: []struct {
-				Index        int          `json:"index"`
-				Delta        chat.Message `json:"delta"`
-				Message      chat.Message `json:"message"`
-				FinishReason string       `json:"finish_reason"`
-			}{
-				{
-					Index: 0,
-					Delta: chat.Message{
-						Role:    chat.RoleAssistant,
-						Content: expectedResponse,
-					},
-					FinishReason: "stop",
-				},
-			},
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(resp)
-	}))
-	defer server.Close()
+	server := fakellm.New(t, fakellm.WithReplies(fakellm.Text(expectedResponse)))
 
 	c := NewClient(server.URL, "/v1/chat/completions")
 
@@ -140,27 +106,23 @@ func TestClient_Chat_Success(t *testing.T) {
 	if response != expectedResponse {
 		t.Errorf("response = %q, want %q", response, expectedResponse)
 	}
+
+	sent := server.LastRequest()
+	if sent.Method != http.MethodPost {
+		t.Errorf("Expected POST, got %s", sent.Method)
+	}
+	if sent.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Expected Content-Type: application/json")
+	}
+	if sent.Model != "test-model" || sent.Param("temperature") != 0.7 || sent.Stream {
+		t.Errorf("request = %+v", sent)
+	}
 }
 
 func TestClient_Chat_EmptyChoices(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		resp := ChatResponse{
-			ID:      "test-id",
-			Object:  "chat.completion",
-			Created: time.Now().Unix(),
-			Model:   "test-model",
-			Choices: []struct {
-				Index        int          `json:"index"`
-				Delta        chat.Message `json:"delta"`
-				Message      chat.Message `json:"message"`
-				FinishReason string       `json:"finish_reason"`
-			}{},
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(resp)
-	}))
-	defer server.Close()
+	server := fakellm.New(t, fakellm.WithReplies(
+		fakellm.Raw(http.StatusOK, "application/json", `{"id":"test-id","object":"chat.completion","model":"test-model","choices":[]}`),
+	))
 
 	c := NewClient(server.URL, "/v1/chat/completions")
 
@@ -177,11 +139,7 @@ func TestClient_Chat_EmptyChoices(t *testing.T) {
 }
 
 func TestClient_Chat_ErrorStatus(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusUnauthorized)
-		w.Write([]byte(`{"error": "Invalid API key"}`))
-	}))
-	defer server.Close()
+	server := fakellm.New(t, fakellm.WithReplies(fakellm.Error(http.StatusUnauthorized, "Invalid API key")))
 
 	c := NewClient(server.URL, "/v1/chat/completions")
 
@@ -198,11 +156,8 @@ func TestClient_Chat_ErrorStatus(t *testing.T) {
 }
 
 func TestClient_Chat_ContextCancellation(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Имитируем долгий запрос
-		<-r.Context().Done()
-	}))
-	defer server.Close()
+	// Имитируем долгий запрос
+	server := fakellm.New(t, fakellm.WithReplies(fakellm.Reply{Content: "late", Delay: time.Minute}))
 
 	c := NewClient(server.URL, "/v1/chat/completions", WithTimeout(100*time.Millisecond))
 
@@ -221,37 +176,7 @@ func TestClient_Chat_ContextCancellation(t *testing.T) {
 }
 
 func TestClient_ChatStream_Success(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "text/event-stream")
-		w.Header().Set("Connection", "keep-alive")
-
-		// Отправляем несколько чанков
-		chunks := []string{"Hello", " ", "world", "!"}
-		for _, chunk := range chunks {
-			resp := ChatResponse{
-				Choices: []struct {
-					Index        int          `json:"index"`
-					Delta        chat.Message `json:"delta"`
-					Message      chat.Message `json:"message"`
-					FinishReason string       `json:"finish_reason"`
-				}{
-					{
-						Delta: chat.Message{
-							Role:    chat.RoleAssistant,
-							Content: chunk,
-						},
-					},
-				},
-			}
-			data, _ := json.Marshal(resp)
-			w.Write([]byte("data: " + string(data) + "\n\n"))
-			w.(http.Flusher).Flush()
-		}
-
-		// Сигнал конца
-		w.Write([]byte("data: [DONE]\n\n"))
-	}))
-	defer server.Close()
+	server := fakellm.New(t, fakellm.WithReplies(fakellm.Tokens("Hello", " ", "world", "!")))
 
 	c := NewClient(server.URL, "/v1/chat/completions")
 
@@ -289,28 +214,11 @@ func TestClient_ChatStream_Success(t *testing.T) {
 }
 
 func TestClient_ChatStream_ContextCancellation(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Отправляем один чанк и ждём
-		resp := ChatResponse{
-			Choices: []struct {
-				Index        int          `json:"index"`
-				Delta        chat.Message `json:"delta"`
-				Message      chat.Message `json:"message"`
-				FinishReason string       `json:"finish_reason"`
-			}{
-				{
-					Delta: chat.Message{Content: "test"},
-				},
-			},
-		}
-		data, _ := json.Marshal(resp)
-		w.Write([]byte("data: " + string(data) + "\n\n"))
-		w.(http.Flusher).Flush()
-
-		// Ждём отмены контекста
-		<-r.Context().Done()
+	// Второй токен придёт только через минуту
+	server := fakellm.New(t, fakellm.WithReplies(fakellm.Reply{
+		Tokens:     []string{"test", "never"},
+		TokenDelay: time.Minute,
 	}))
-	defer server.Close()
 
 	c := NewClient(server.URL, "/v1/chat/completions")
 
@@ -323,8 +231,8 @@ func TestClient_ChatStream_ContextCancellation(t *testing.T) {
 
 	ch := c.ChatStream(ctx, req)
 
-	// Получаем первый чанк
-	<-ch
+	// Ждём отправки запроса
+	server.Await(1)
 
 	// Отменяем контекст
 	cancel()
@@ -400,8 +308,8 @@ func TestClient_SetHeaders(t *testing.T) {
 	t.Run("with API key", func(t *testing.T) {
 		c := NewClient("http://localhost:11434", "/v1/chat", WithAPIKey("test-key"))
 
-		req, _ := http.NewRequest("POST", c.getEndpoint(), nil)
-		c.setHeaders(req)
+		req, _ := http.NewRequest("POST", c.getEndpoint("", false), nil)
+		c.setHeaders(context.Background(), req)
 
 		if req.Header.Get("Content-Type") != "application/json" {
 			t.Errorf("Content-Type = %q, want %q", req.Header.Get("Content-Type"), "application/json")
@@ -414,8 +322,8 @@ func TestClient_SetHeaders(t *testing.T) {
 	t.Run("without API key", func(t *testing.T) {
 		c := NewClient("http://localhost:11434", "/v1/chat")
 
-		req, _ := http.NewRequest("POST", c.getEndpoint(), nil)
-		c.setHeaders(req)
+		req, _ := http.NewRequest("POST", c.getEndpoint("", false), nil)
+		c.setHeaders(context.Background(), req)
 
 		if req.Header.Get("Authorization") != "" {
 			t.Errorf("Authorization should be empty")
@@ -0,0 +1,174 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"llm-client/internal/config"
+	apperrors "llm-client/internal/errors"
+)
+
+// StreamEvent - событие потокового ответа провайдера в исходном виде
+type StreamEvent struct {
+	// Event - тип события (поле event:)
+	Event string
+	// ID - идентификатор события, если поле id: было в самом событии
+	ID string
+	// Data - данные события без изменений
+	Data string
+}
+
+// ForwardCompletion отправляет готовое тело запроса без стриминга и возвращает тело ответа
+// провайдера без изменений. Поля, неизвестные ChatRequest, сохраняются.
+// Ошибочный статус провайдера возвращается как ошибка KindAPI с телом ответа в контексте "body".
+func (c *Client) ForwardCompletion(ctx context.Context, model string, body []byte) ([]byte, error) {
+	ctx = ensureRequestID(ctx)
+	if err := c.checkPassthrough(); err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		c.logForward(ctx, model, body, false, attempt)
+
+		respBody, err := c.forwardOnce(ctx, body)
+		if err == nil {
+			var resp ChatResponse
+			if json.Unmarshal(respBody, &resp) == nil {
+				c.reportUsage(model, resp.Usage)
+			}
+			return respBody, nil
+		}
+		if !c.shouldRetry(ctx, attempt, err) {
+			return nil, err
+		}
+		if waitErr := c.waitRetry(ctx, attempt, err); waitErr != nil {
+			return nil, err
+		}
+	}
+}
+
+// forwardOnce выполняет одну попытку пересылки обычного запроса
+func (c *Client) forwardOnce(ctx context.Context, body []byte) ([]byte, error) {
+	resp, respBody, err := c.doRequest(ctx, c.getEndpoint("", false), body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	c.logResponse(ctx, resp, respBody)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(ctx, resp, respBody)
+	}
+	return respBody, nil
+}
+
+// ForwardStream отправляет готовое тело потокового запроса и передаёт события ответа в emit
+// в исходном виде, включая [DONE]. Временные ошибки повторяются, пока не передано ни одного события.
+// Ошибка emit (например, клиент отключился) прерывает поток и возвращается без повторов.
+func (c *Client) ForwardStream(ctx context.Context, model string, body []byte, emit func(StreamEvent) error) error {
+	ctx = ensureRequestID(ctx)
+	if err := c.checkPassthrough(); err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		c.logForward(ctx, model, body, true, attempt)
+
+		emitted, err := c.forwardStreamOnce(ctx, model, body, emit)
+		if err == nil {
+			return nil
+		}
+		if emitted == 0 && c.shouldRetry(ctx, attempt, err) {
+			if waitErr := c.waitRetry(ctx, attempt, err); waitErr == nil {
+				continue
+			}
+		}
+		return err
+	}
+}
+
+// forwardStreamOnce выполняет одну попытку пересылки потокового запроса.
+// Возвращает количество переданных событий и ошибку попытки.
+func (c *Client) forwardStreamOnce(ctx context.Context, model string, body []byte, emit func(StreamEvent) error) (int, error) {
+	resp, err := c.openStream(ctx, c.getEndpoint(model, true), body)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	decoder := newSSEDecoder(resp.Body)
+	emitted := 0
+	var usage *Usage
+
+	for {
+		ev, err := decoder.Next()
+		if err != nil {
+			if ctx.Err() != nil {
+				c.logger.InfoContext(ctx, "Forwarded stream cancelled by context", "events", emitted)
+				return emitted, ctx.Err()
+			}
+			if err != io.EOF {
+				c.logger.ErrorContext(ctx, "Stream read error", "error", err, "emitted", emitted)
+				return emitted, apperrors.NewStreamError("READ_ERROR", "read error", err).MarkRetryable()
+			}
+			break
+		}
+
+		// Расход токенов учитывается так же, как в ChatStream
+		for _, chunk := range parseStreamEvent(ev) {
+			if chunk.Usage != nil {
+				usage = chunk.Usage
+			}
+		}
+
+		forwarded := StreamEvent{Event: ev.Event, Data: ev.Data}
+		if ev.OwnID {
+			forwarded.ID = ev.ID
+		}
+		if err := emit(forwarded); err != nil {
+			c.logger.WarnContext(ctx, "Forwarded stream aborted", "error", err, "events", emitted)
+			return emitted, err
+		}
+		emitted++
+
+		if strings.TrimSpace(ev.Data) == "[DONE]" {
+			break
+		}
+	}
+
+	c.logger.InfoContext(ctx, "Forwarded stream completed", "events", emitted)
+	c.reportUsage(model, usage)
+	return emitted, nil
+}
+
+// SupportsPassthrough сообщает, можно ли пересылать провайдеру запросы OpenAI-compatible API без преобразования
+func (c *Client) SupportsPassthrough() bool {
+	return c.provider.Name() == config.ProviderOpenAI
+}
+
+// checkPassthrough возвращает ошибку, если провайдер использует другой формат API
+func (c *Client) checkPassthrough() error {
+	if c.SupportsPassthrough() {
+		return nil
+	}
+	return apperrors.NewConfigError("UNSUPPORTED_PROVIDER",
+		fmt.Sprintf("provider %q does not accept OpenAI-compatible requests", c.provider.Name()), nil)
+}
+
+// logForward записывает в лог пересылаемый запрос
+func (c *Client) logForward(ctx context.Context, model string, body []byte, stream bool, attempt int) {
+	c.logger.InfoContext(ctx, "Forwarding request",
+		"endpoint", c.getEndpoint(model, stream),
+		"model", model,
+		"stream", stream,
+		"attempt", attempt,
+		"max_attempts", c.retry.MaxAttempts,
+	)
+
+	c.logger.DebugContext(ctx, "Request body", "body", string(body))
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	apperrors "llm-client/internal/errors"
+	"llm-client/internal/fakellm"
+)
+
+func TestClient_ForwardCompletion(t *testing.T) {
+	const response = `{"custom":1,"choices":[],"usage":{"prompt_tokens":3,"completion_tokens":2}}`
+	server := fakellm.New(t, fakellm.WithReplies(
+		fakellm.Error(http.StatusServiceUnavailable, "overloaded"),
+		fakellm.Raw(http.StatusOK, "application/json", response),
+	))
+
+	var reported Usage
+	c := NewClient(server.URL, "/v1/chat/completions",
+		WithRetryPolicy(fastRetryPolicy(2)),
+		WithUsageHandler(func(model string, u Usage) { reported = u }),
+	)
+
+	body := `{"model":"m","messages":[],"seed":42}`
+	got, err := c.ForwardCompletion(context.Background(), "m", []byte(body))
+	if err != nil {
+		t.Fatalf("ForwardCompletion() error = %v", err)
+	}
+	if string(got) != response {
+		t.Errorf("response body changed: %s", got)
+	}
+	server.AssertRequestCount(2)
+	if received := server.LastRequest().Body; string(received) != body {
+		t.Errorf("request body changed: %s", received)
+	}
+	if reported.TotalTokens != 5 {
+		t.Errorf("reported usage = %+v", reported)
+	}
+
+	t.Run("api error keeps body", func(t *testing.T) {
+		server := fakellm.New(t, fakellm.WithReplies(fakellm.Raw(http.StatusBadRequest, "application/json", `{"error":{"message":"bad"}}`)))
+
+		c := NewClient(server.URL, "/v1/chat/completions")
+		_, err := c.ForwardCompletion(context.Background(), "m", []byte(body))
+		var appErr *apperrors.AppError
+		if !errors.As(err, &appErr) || apperrors.GetStatusCode(err) != http.StatusBadRequest {
+			t.Fatalf("error = %v, want API error 400", err)
+		}
+		if appErr.Context["body"] != `{"error":{"message":"bad"}}` {
+			t.Errorf("error body = %v", appErr.Context["body"])
+		}
+	})
+}
+
+func TestClient_ForwardStream(t *testing.T) {
+	// Комментарии, id и event fakellm не генерирует - поток задан как есть
+	server := fakellm.New(t, fakellm.WithDefaultReply(fakellm.Raw(http.StatusOK, "text/event-stream",
+		": keep-alive\n\n"+
+			"id: 1\ndata: {\"choices\":[{\"delta\":{\"content\":\"Hi\"}}]}\n\n"+
+			"event: custom\ndata: a\ndata: b\n\n"+
+			"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":1,\"completion_tokens\":1}}\n\n"+
+			"data: [DONE]\n\n"+
+			"data: ignored\n\n")))
+
+	var reported Usage
+	c := NewClient(server.URL, "/v1/chat/completions",
+		WithUsageHandler(func(model string, u Usage) { reported = u }),
+	)
+
+	var events []StreamEvent
+	err := c.ForwardStream(context.Background(), "m", []byte(`{}`), func(ev StreamEvent) error {
+		events = append(events, ev)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ForwardStream() error = %v", err)
+	}
+	if len(events) != 4 {
+		t.Fatalf("events = %+v, want 4 events up to [DONE]", events)
+	}
+	if events[0].ID != "1" || events[1].Event != "custom" || events[1].Data != "a\nb" || events[3].Data != "[DONE]" {
+		t.Errorf("events = %+v", events)
+	}
+	if reported.TotalTokens != 2 {
+		t.Errorf("reported usage = %+v", reported)
+	}
+
+	t.Run("emit error stops stream", func(t *testing.T) {
+		stop := errors.New("client gone")
+		calls := 0
+		err := c.ForwardStream(context.Background(), "m", []byte(`{}`), func(StreamEvent) error {
+			calls++
+			return stop
+		})
+		if !errors.Is(err, stop) || calls != 1 {
+			t.Errorf("ForwardStream() error = %v, calls = %d", err, calls)
+		}
+	})
+}
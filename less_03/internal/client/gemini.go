@@ -0,0 +1,398 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"llm-client/internal/chat"
+	"llm-client/internal/config"
+	apperrors "llm-client/internal/errors"
+)
+
+// geminiProvider - адаптер Google Gemini API (generateContent, streamGenerateContent)
+type geminiProvider struct{}
+
+// NewGeminiProvider создаёт адаптер Google Gemini API
+func NewGeminiProvider() Provider {
+	return geminiProvider{}
+}
+
+type geminiRequest struct {
+	Contents          []geminiContent        `json:"contents"`
+	SystemInstruction *geminiContent         `json:"systemInstruction,omitempty"`
+	GenerationConfig  geminiGenerationConfig `json:"generationConfig"`
+	Tools             []geminiTool           `json:"tools,omitempty"`
+	ToolConfig        *geminiToolConfig      `json:"toolConfig,omitempty"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text             string                  `json:"text,omitempty"`
+	InlineData       *geminiBlob             `json:"inlineData,omitempty"`
+	FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *geminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+// geminiBlob - данные вложения в base64
+type geminiBlob struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+type geminiFunctionCall struct {
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+type geminiFunctionResponse struct {
+	Name     string          `json:"name"`
+	Response json.RawMessage `json:"response"`
+}
+
+type geminiGenerationConfig struct {
+	Temperature     float64 `json:"temperature"`
+	TopP            float64 `json:"topP"`
+	MaxOutputTokens int     `json:"maxOutputTokens,omitempty"`
+}
+
+type geminiTool struct {
+	FunctionDeclarations []FunctionDefinition `json:"functionDeclarations"`
+}
+
+type geminiToolConfig struct {
+	FunctionCallingConfig struct {
+		Mode                 string   `json:"mode"`
+		AllowedFunctionNames []string `json:"allowedFunctionNames,omitempty"`
+	} `json:"functionCallingConfig"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content      geminiContent `json:"content"`
+		FinishReason string        `json:"finishReason"`
+	} `json:"candidates"`
+	UsageMetadata *struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+		TotalTokenCount      int `json:"totalTokenCount"`
+	} `json:"usageMetadata"`
+	PromptFeedback *struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// usage возвращает расход токенов из usageMetadata
+func (r *geminiResponse) usage() *Usage {
+	if r.UsageMetadata == nil {
+		return nil
+	}
+	return &Usage{
+		PromptTokens:     r.UsageMetadata.PromptTokenCount,
+		CompletionTokens: r.UsageMetadata.CandidatesTokenCount,
+		TotalTokens:      r.UsageMetadata.TotalTokenCount,
+	}
+}
+
+func (geminiProvider) Name() string {
+	return config.ProviderGemini
+}
+
+func (geminiProvider) Endpoint(model string, stream bool) string {
+	path := "v1beta/models/" + strings.TrimPrefix(model, "models/")
+	if stream {
+		return path + ":streamGenerateContent?alt=sse"
+	}
+	return path + ":generateContent"
+}
+
+func (geminiProvider) SetHeaders(h http.Header, apiKey string) {
+	if apiKey != "" {
+		h.Set("x-goog-api-key", apiKey)
+	}
+}
+
+func (geminiProvider) EncodeRequest(req *ChatRequest) ([]byte, error) {
+	body := geminiRequest{
+		GenerationConfig: geminiGenerationConfig{
+			Temperature:     req.Temperature,
+			TopP:            req.TopP,
+			MaxOutputTokens: req.MaxTokens,
+		},
+	}
+
+	// Результат вызова передаётся с именем функции, которое есть только в исходном вызове
+	callNames := make(map[string]string)
+	var system []geminiPart
+	for _, msg := range req.Messages {
+		switch msg.Role {
+		case chat.RoleSystem:
+			system = append(system, geminiPart{Text: msg.Content})
+		case chat.RoleTool:
+			name := msg.Name
+			if name == "" {
+				name = callNames[msg.ToolCallID]
+			}
+			body.Contents = appendGemini(body.Contents, "user", geminiPart{
+				FunctionResponse: &geminiFunctionResponse{Name: name, Response: geminiToolResult(msg.Content)},
+			})
+		case chat.RoleAssistant:
+			var parts []geminiPart
+			if msg.Content != "" {
+				parts = append(parts, geminiPart{Text: msg.Content})
+			}
+			for _, call := range msg.ToolCalls {
+				callNames[call.ID] = call.Function.Name
+				parts = append(parts, geminiPart{FunctionCall: &geminiFunctionCall{
+					Name: call.Function.Name,
+					Args: objectArguments(call.Function.Arguments),
+				}})
+			}
+			body.Contents = appendGemini(body.Contents, "model", parts...)
+		default:
+			body.Contents = appendGemini(body.Contents, "user", geminiParts(msg)...)
+		}
+	}
+	if len(system) > 0 {
+		body.SystemInstruction = &geminiContent{Parts: system}
+	}
+
+	if len(req.Tools) > 0 {
+		var declarations []FunctionDefinition
+		for _, tool := range req.Tools {
+			declarations = append(declarations, tool.Function)
+		}
+		body.Tools = []geminiTool{{FunctionDeclarations: declarations}}
+
+		cfg := &geminiToolConfig{}
+		switch mode, name := toolChoiceMode(req.ToolChoice); mode {
+		case toolChoiceNone:
+			cfg.FunctionCallingConfig.Mode = "NONE"
+		case toolChoiceRequired:
+			cfg.FunctionCallingConfig.Mode = "ANY"
+		case toolChoiceFunction:
+			cfg.FunctionCallingConfig.Mode = "ANY"
+			cfg.FunctionCallingConfig.AllowedFunctionNames = []string{name}
+		default:
+			cfg.FunctionCallingConfig.Mode = "AUTO"
+		}
+		body.ToolConfig = cfg
+	}
+
+	return json.Marshal(body)
+}
+
+// geminiParts преобразует текст и вложения сообщения пользователя в части.
+// Внешние URL изображений API не загружает, они передаются текстом.
+func geminiParts(msg chat.Message) []geminiPart {
+	parts := make([]geminiPart, 0, len(msg.Parts)+1)
+	for _, part := range msg.ContentParts() {
+		if mimeType, data, ok := part.InlineImage(); ok {
+			parts = append(parts, geminiPart{InlineData: &geminiBlob{MimeType: mimeType, Data: data}})
+			continue
+		}
+		text := part.Text
+		if part.Type != chat.PartText && part.ImageURL != nil {
+			text = part.ImageURL.URL
+		}
+		parts = append(parts, geminiPart{Text: text})
+	}
+	return parts
+}
+
+// appendGemini добавляет части к последнему сообщению той же роли или начинает новое:
+// API требует чередования ролей user и model
+func appendGemini(contents []geminiContent, role string, parts ...geminiPart) []geminiContent {
+	var filtered []geminiPart
+	for _, p := range parts {
+		if p.Text == "" && p.InlineData == nil && p.FunctionCall == nil && p.FunctionResponse == nil {
+			continue
+		}
+		filtered = append(filtered, p)
+	}
+	if len(filtered) == 0 {
+		return contents
+	}
+	if n := len(contents); n > 0 && contents[n-1].Role == role {
+		contents[n-1].Parts = append(contents[n-1].Parts, filtered...)
+		return contents
+	}
+	return append(contents, geminiContent{Role: role, Parts: filtered})
+}
+
+// geminiToolResult оборачивает результат инструмента в объект: functionResponse.response
+// должен быть JSON-объектом
+func geminiToolResult(content string) json.RawMessage {
+	trimmed := strings.TrimSpace(content)
+	if strings.HasPrefix(trimmed, "{") && json.Valid([]byte(trimmed)) {
+		return json.RawMessage(trimmed)
+	}
+	data, _ := json.Marshal(map[string]string{"content": content})
+	return data
+}
+
+func (geminiProvider) DecodeResponse(body []byte) (*Completion, error) {
+	var resp geminiResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, apperrors.NewInternalError("UNMARSHAL_ERROR", "failed to decode response", err)
+	}
+	if len(resp.Candidates) == 0 {
+		err := apperrors.NewAPIError("EMPTY_CHOICES", "empty response from API", nil, http.StatusOK)
+		if resp.PromptFeedback != nil && resp.PromptFeedback.BlockReason != "" {
+			err.WithContext("block_reason", resp.PromptFeedback.BlockReason)
+		}
+		return nil, err
+	}
+
+	candidate := resp.Candidates[0]
+	message := chat.Message{Role: chat.RoleAssistant}
+	for _, part := range candidate.Content.Parts {
+		message.Content += part.Text
+		if part.FunctionCall != nil {
+			message.ToolCalls = append(message.ToolCalls, geminiToolCallOf(len(message.ToolCalls), part.FunctionCall))
+		}
+	}
+
+	return &Completion{
+		Message:      message,
+		FinishReason: geminiFinishReason(candidate.FinishReason, len(message.ToolCalls) > 0),
+		Usage:        resp.usage(),
+	}, nil
+}
+
+func (geminiProvider) NewStreamDecoder(r io.Reader) StreamDecoder {
+	return &geminiStreamDecoder{events: newSSEDecoder(r)}
+}
+
+func (geminiProvider) ErrorMessage(body []byte) string {
+	// {"error": {"code": 400, "message": "...", "status": "..."}}, иногда внутри массива
+	return errorMessage(body)
+}
+
+func (geminiProvider) ModelsEndpoint() string {
+	return "v1beta/models?pageSize=1000"
+}
+
+// DecodeModels разбирает список моделей; модели без generateContent (эмбеддинги) пропускаются
+func (geminiProvider) DecodeModels(body []byte) ([]ModelInfo, error) {
+	var list struct {
+		Models []struct {
+			Name             string   `json:"name"`
+			DisplayName      string   `json:"displayName"`
+			InputTokenLimit  int      `json:"inputTokenLimit"`
+			GenerationMethod []string `json:"supportedGenerationMethods"`
+		} `json:"models"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, apperrors.NewInternalError("UNMARSHAL_ERROR", "failed to decode model list", err)
+	}
+
+	models := make([]ModelInfo, 0, len(list.Models))
+	for _, m := range list.Models {
+		if !slices.Contains(m.GenerationMethod, "generateContent") {
+			continue
+		}
+		models = append(models, ModelInfo{
+			ID:            strings.TrimPrefix(m.Name, "models/"),
+			Description:   m.DisplayName,
+			ContextLength: m.InputTokenLimit,
+		})
+	}
+	return models, nil
+}
+
+// geminiToolCallOf преобразует вызов функции; Gemini не присваивает вызовам идентификаторы
+func geminiToolCallOf(index int, call *geminiFunctionCall) chat.ToolCall {
+	return chat.ToolCall{
+		ID:       fmt.Sprintf("call_%d", index),
+		Type:     ToolTypeFunction,
+		Function: chat.FunctionCall{Name: call.Name, Arguments: toolArguments(call.Args)},
+	}
+}
+
+// geminiFinishReason приводит finishReason к значениям finish_reason OpenAI
+func geminiFinishReason(reason string, toolCalls bool) string {
+	switch reason {
+	case "":
+		return ""
+	case "STOP":
+		if toolCalls {
+			return "tool_calls"
+		}
+		return "stop"
+	case "MAX_TOKENS":
+		return "length"
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII":
+		return "content_filter"
+	default:
+		return strings.ToLower(reason)
+	}
+}
+
+// geminiStreamDecoder разбирает поток SSE streamGenerateContent: каждое событие - частичный
+// GenerateContentResponse; маркера [DONE] нет, поток завершается закрытием соединения
+type geminiStreamDecoder struct {
+	events *sseDecoder
+	// toolCalls - количество полученных вызовов функций, задаёт их индексы
+	toolCalls int
+}
+
+func (d *geminiStreamDecoder) Next() ([]StreamChunk, error) {
+	ev, err := d.events.Next()
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(ev.Data) == "" {
+		return nil, nil
+	}
+
+	var resp geminiResponse
+	if err := json.Unmarshal([]byte(ev.Data), &resp); err != nil {
+		return []StreamChunk{malformedChunk(ev.Data, err)}, nil
+	}
+	if resp.Error != nil {
+		return []StreamChunk{providerError(resp.Error.Message, ev.Data)}, nil
+	}
+
+	var chunks []StreamChunk
+	// usageMetadata содержит накопленный расход и приходит в каждом событии
+	if usage := resp.usage(); usage != nil {
+		chunks = append(chunks, StreamChunk{Usage: usage})
+	}
+	if len(resp.Candidates) == 0 {
+		return chunks, nil
+	}
+
+	candidate := resp.Candidates[0]
+	var deltas []toolCallDelta
+	for _, part := range candidate.Content.Parts {
+		if part.Text != "" {
+			chunks = append(chunks, StreamChunk{Content: part.Text})
+		}
+		if part.FunctionCall != nil {
+			tc := geminiToolCallOf(d.toolCalls, part.FunctionCall)
+			delta := toolCallDelta{Index: d.toolCalls, ID: tc.ID, Type: tc.Type}
+			delta.Function.Name = tc.Function.Name
+			delta.Function.Arguments = tc.Function.Arguments
+			deltas = append(deltas, delta)
+			d.toolCalls++
+		}
+	}
+	if len(deltas) > 0 {
+		chunks = append(chunks, StreamChunk{toolCallDeltas: deltas})
+	}
+	if reason := geminiFinishReason(candidate.FinishReason, d.toolCalls > 0); reason != "" {
+		chunks = append(chunks, StreamChunk{Done: true, FinishReason: reason})
+	}
+	return chunks, nil
+}
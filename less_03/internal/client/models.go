@@ -0,0 +1,127 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"llm-client/internal/config"
+	apperrors "llm-client/internal/errors"
+)
+
+// modelsCacheTTL - сколько список моделей сервера считается актуальным
+const modelsCacheTTL = 10 * time.Minute
+
+// ModelInfo описывает модель из списка сервера
+type ModelInfo struct {
+	// ID - имя модели для ChatRequest.Model
+	ID string
+	// Description - отображаемое имя или размер модели, если сервер их вернул
+	Description string
+	// ContextLength - размер контекстного окна в токенах (0 - неизвестен)
+	ContextLength int
+	// Pricing - цена за 1 млн токенов (nil - сервер её не вернул)
+	Pricing *config.ModelPrice
+}
+
+// modelsCache хранит списки моделей по адресу сервера; общий для всех клиентов,
+// поэтому список переживает пересоздание клиента при смене профиля
+var modelsCache = struct {
+	sync.Mutex
+	entries map[string]modelsCacheEntry
+}{entries: make(map[string]modelsCacheEntry)}
+
+type modelsCacheEntry struct {
+	models  []ModelInfo
+	fetched time.Time
+}
+
+// modelsURL возвращает полный URL списка моделей; он же ключ кэша
+func (c *Client) modelsURL() string {
+	return c.baseURL + "/" + strings.TrimPrefix(c.provider.ModelsEndpoint(), "/")
+}
+
+// CachedModels возвращает список моделей сервера из кэша без запроса.
+// false - список ещё не загружался или устарел.
+func (c *Client) CachedModels() ([]ModelInfo, bool) {
+	modelsCache.Lock()
+	defer modelsCache.Unlock()
+
+	entry, ok := modelsCache.entries[c.modelsURL()]
+	if !ok || time.Since(entry.fetched) > modelsCacheTTL {
+		return nil, false
+	}
+	return slices.Clone(entry.models), true
+}
+
+// ListModels возвращает модели сервера, отсортированные по имени.
+// Список кэшируется по адресу сервера на modelsCacheTTL.
+func (c *Client) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	if models, ok := c.CachedModels(); ok {
+		return models, nil
+	}
+	ctx = ensureRequestID(ctx)
+
+	endpoint := c.modelsURL()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, apperrors.NewInternalError("REQUEST_ERROR", "failed to create request", err)
+	}
+	c.setHeaders(ctx, httpReq)
+
+	c.logger.DebugContext(ctx, "Listing models", "url", endpoint)
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		c.logger.ErrorContext(ctx, "Model list request failed", "error", err)
+		return nil, apperrors.NewNetworkError("REQUEST_FAILED", "request failed", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, apperrors.NewInternalError("READ_ERROR", "failed to read response", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(ctx, resp, body)
+	}
+
+	models, err := c.provider.DecodeModels(body)
+	if err != nil {
+		c.logger.ErrorContext(ctx, "Failed to decode model list", "error", err)
+		return nil, err
+	}
+	slices.SortFunc(models, func(a, b ModelInfo) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	c.logger.InfoContext(ctx, "Model list loaded", "url", endpoint, "count", len(models))
+
+	modelsCache.Lock()
+	modelsCache.entries[endpoint] = modelsCacheEntry{models: models, fetched: time.Now()}
+	modelsCache.Unlock()
+	return slices.Clone(models), nil
+}
+
+// perTokenPrice переводит цену за токен (строка или число) в цену за 1 млн токенов.
+// Отрицательная цена (OpenRouter отмечает так модели с переменной ценой) не учитывается.
+func perTokenPrice(raw json.RawMessage) (float64, bool) {
+	if len(raw) == 0 {
+		return 0, false
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		s = string(raw)
+	}
+	price, err := strconv.ParseFloat(s, 64)
+	if err != nil || price < 0 {
+		return 0, false
+	}
+	// Округление убирает погрешность умножения: 0.0000025 * 1e6 = 2.4999999999999996
+	return math.Round(price*1e6*1e6) / 1e6, true
+}
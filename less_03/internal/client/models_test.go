@@ -0,0 +1,200 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	apperrors "llm-client/internal/errors"
+	"llm-client/internal/fakellm"
+	"llm-client/internal/logger"
+)
+
+// modelsServer отдаёт body по пути path и считает обращения.
+// Нужен только для форматов списка моделей, которые отличаются от OpenAI: их fakellm не умеет.
+func modelsServer(t *testing.T, path, body string, calls *atomic.Int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.Method != http.MethodGet || r.URL.RequestURI() != path {
+			t.Errorf("request = %s %s, want GET %s", r.Method, r.URL.RequestURI(), path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient_ListModels_OpenAI(t *testing.T) {
+	s := fakellm.New(t, fakellm.WithModelInfo(
+		fakellm.Model{ID: "openai/gpt-4o", Fields: map[string]any{
+			"name": "OpenAI: GPT-4o", "context_length": 128000,
+			"pricing": map[string]any{"prompt": "0.0000025", "completion": "0.00001"},
+		}},
+		fakellm.Model{ID: "openrouter/auto", Fields: map[string]any{
+			"name": "Auto Router", "context_length": 2000000,
+			"pricing": map[string]any{"prompt": "-1", "completion": "-1"},
+		}},
+		// vLLM возвращает размер окна в max_model_len
+		fakellm.Model{ID: "qwen2.5", Fields: map[string]any{"max_model_len": 32768}},
+	))
+	c := NewClient(s.URL, fakellm.ChatPath, WithAPIKey("test-key"),
+		WithLogger(logger.NewLogger(logger.Config{Enabled: false})))
+
+	models, err := c.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels() error = %v", err)
+	}
+	want := []ModelInfo{
+		{ID: "openai/gpt-4o", Description: "OpenAI: GPT-4o", ContextLength: 128000},
+		{ID: "openrouter/auto", Description: "Auto Router", ContextLength: 2000000},
+		{ID: "qwen2.5", ContextLength: 32768},
+	}
+	if len(models) != len(want) {
+		t.Fatalf("ListModels() = %+v, want %+v", models, want)
+	}
+	for i := range want {
+		got := models[i]
+		got.Pricing = nil
+		if got != want[i] {
+			t.Errorf("models[%d] = %+v, want %+v", i, got, want[i])
+		}
+	}
+}
+
+func TestOpenAIProvider_ModelsEndpoint(t *testing.T) {
+	tests := map[string]string{
+		"api/v1/chat/completions": "api/v1/models",
+		"v1/chat/completions":     "v1/models",
+		"custom/endpoint":         "v1/models",
+	}
+	for endpoint, want := range tests {
+		if got := NewOpenAIProvider(endpoint).ModelsEndpoint(); got != want {
+			t.Errorf("ModelsEndpoint() for %q = %q, want %q", endpoint, got, want)
+		}
+	}
+}
+
+func TestClient_ListModels_Providers(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider Provider
+		path     string
+		body     string
+		want     []ModelInfo
+	}{
+		{
+			name:     "ollama tags",
+			provider: NewOllamaProvider(),
+			path:     "/api/tags",
+			body: `{"models":[
+				{"name":"qwen2.5:7b","details":{"parameter_size":"7.6B","quantization_level":"Q4_K_M"}},
+				{"name":"llama3:latest","details":{"parameter_size":"8.0B"}}
+			]}`,
+			want: []ModelInfo{
+				{ID: "llama3:latest", Description: "8.0B"},
+				{ID: "qwen2.5:7b", Description: "7.6B Q4_K_M"},
+			},
+		},
+		{
+			name:     "anthropic",
+			provider: NewAnthropicProvider(),
+			path:     "/v1/models?limit=1000",
+			body:     `{"data":[{"id":"claude-sonnet-4-5","display_name":"Claude Sonnet 4.5","type":"model"}],"has_more":false}`,
+			want:     []ModelInfo{{ID: "claude-sonnet-4-5", Description: "Claude Sonnet 4.5"}},
+		},
+		{
+			name:     "gemini skips embeddings",
+			provider: NewGeminiProvider(),
+			path:     "/v1beta/models?pageSize=1000",
+			body: `{"models":[
+				{"name":"models/gemini-2.0-flash","displayName":"Gemini 2.0 Flash","inputTokenLimit":1048576,"supportedGenerationMethods":["generateContent","countTokens"]},
+				{"name":"models/text-embedding-004","supportedGenerationMethods":["embedContent"]}
+			]}`,
+			want: []ModelInfo{{ID: "gemini-2.0-flash", Description: "Gemini 2.0 Flash", ContextLength: 1048576}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := modelsServer(t, tt.path, tt.body, &calls)
+			c := NewClient(server.URL, "", WithProvider(tt.provider), WithAPIKey("test-key"),
+				WithLogger(logger.NewLogger(logger.Config{Enabled: false})))
+
+			models, err := c.ListModels(context.Background())
+			if err != nil {
+				t.Fatalf("ListModels() error = %v", err)
+			}
+			if len(models) != len(tt.want) {
+				t.Fatalf("ListModels() = %+v, want %+v", models, tt.want)
+			}
+			for i, want := range tt.want {
+				got := models[i]
+				got.Pricing = nil
+				if got != want {
+					t.Errorf("models[%d] = %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_ListModels_Pricing(t *testing.T) {
+	s := fakellm.New(t, fakellm.WithModelInfo(
+		fakellm.Model{ID: "a", Fields: map[string]any{"pricing": map[string]any{"prompt": "0.0000025", "completion": 0.00001}}},
+		fakellm.Model{ID: "b", Fields: map[string]any{"pricing": map[string]any{"prompt": "-1", "completion": "0"}}},
+	))
+	c := NewClient(s.URL, fakellm.ChatPath, WithAPIKey("test-key"))
+
+	models, err := c.ListModels(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := models[0].Pricing; p == nil || p.Prompt != 2.5 || p.Completion != 10 {
+		t.Errorf("pricing = %+v, want per 1M tokens", p)
+	}
+	if models[1].Pricing != nil {
+		t.Errorf("variable pricing should be dropped, got %+v", models[1].Pricing)
+	}
+}
+
+func TestClient_ListModels_Cache(t *testing.T) {
+	s := fakellm.New(t, fakellm.WithModels("b-model", "a-model"))
+	c := NewClient(s.URL, fakellm.ChatPath, WithAPIKey("test-key"))
+
+	if _, ok := c.CachedModels(); ok {
+		t.Fatalf("cache should be empty before ListModels")
+	}
+	models, err := c.ListModels(context.Background())
+	if err != nil || len(models) != 2 || models[0].ID != "a-model" {
+		t.Fatalf("ListModels() = %+v, %v, want sorted list", models, err)
+	}
+
+	// Новый клиент того же сервера использует кэш
+	other := NewClient(s.URL, fakellm.ChatPath, WithAPIKey("test-key"))
+	cached, ok := other.CachedModels()
+	if !ok || len(cached) != 2 {
+		t.Errorf("CachedModels() = %+v, %v", cached, ok)
+	}
+	s.Close()
+	if _, err := other.ListModels(context.Background()); err != nil {
+		t.Errorf("ListModels() should not hit the server again: %v", err)
+	}
+}
+
+func TestClient_ListModels_Error(t *testing.T) {
+	s := fakellm.New(t, fakellm.WithModelsError(http.StatusUnauthorized, "invalid api key"))
+	c := NewClient(s.URL, fakellm.ChatPath, WithAPIKey("test-key"))
+
+	_, err := c.ListModels(context.Background())
+	if apperrors.GetStatusCode(err) != http.StatusUnauthorized {
+		t.Fatalf("error = %v, want 401", err)
+	}
+	if _, ok := c.CachedModels(); ok {
+		t.Errorf("failed request should not be cached")
+	}
+}
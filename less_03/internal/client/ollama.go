@@ -0,0 +1,259 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"llm-client/internal/chat"
+	"llm-client/internal/config"
+	apperrors "llm-client/internal/errors"
+)
+
+// ollamaProvider - адаптер нативного API Ollama (/api/chat) с потоком NDJSON
+type ollamaProvider struct{}
+
+// NewOllamaProvider создаёт адаптер нативного API Ollama
+func NewOllamaProvider() Provider {
+	return ollamaProvider{}
+}
+
+// ollamaRequest - тело запроса /api/chat.
+// tool_choice не поддерживается API и не передаётся.
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	// Stream передаётся всегда: по умолчанию Ollama отвечает потоком
+	Stream  bool          `json:"stream"`
+	Tools   []Tool        `json:"tools,omitempty"`
+	Options ollamaOptions `json:"options"`
+}
+
+type ollamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+	// Images - изображения в base64 без префикса data URL
+	Images    []string         `json:"images,omitempty"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	// ToolName - имя инструмента в сообщении с результатом вызова
+	ToolName string `json:"tool_name,omitempty"`
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaOptions struct {
+	Temperature float64 `json:"temperature"`
+	TopP        float64 `json:"top_p"`
+	NumPredict  int     `json:"num_predict,omitempty"`
+}
+
+// ollamaChunk - строка потока или ответ без стриминга
+type ollamaChunk struct {
+	Message         ollamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	DoneReason      string        `json:"done_reason"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+	Error           string        `json:"error"`
+}
+
+// usage возвращает расход токенов из счётчиков prompt_eval_count и eval_count
+func (c *ollamaChunk) usage() *Usage {
+	return &Usage{
+		PromptTokens:     c.PromptEvalCount,
+		CompletionTokens: c.EvalCount,
+		TotalTokens:      c.PromptEvalCount + c.EvalCount,
+	}
+}
+
+func (ollamaProvider) Name() string {
+	return config.ProviderOllama
+}
+
+func (ollamaProvider) Endpoint(string, bool) string {
+	return "api/chat"
+}
+
+func (ollamaProvider) SetHeaders(h http.Header, apiKey string) {
+	// Локальному серверу ключ не нужен, облачный Ollama принимает Bearer
+	if apiKey != "" {
+		h.Set("Authorization", "Bearer "+apiKey)
+	}
+}
+
+func (ollamaProvider) EncodeRequest(req *ChatRequest) ([]byte, error) {
+	body := ollamaRequest{
+		Model:  req.Model,
+		Stream: req.Stream,
+		Tools:  req.Tools,
+		Options: ollamaOptions{
+			Temperature: req.Temperature,
+			TopP:        req.TopP,
+			NumPredict:  req.MaxTokens,
+		},
+	}
+
+	for _, msg := range req.Messages {
+		m := ollamaMessage{Role: string(msg.Role), Content: msg.Content}
+		for _, part := range msg.Parts {
+			// Текстовые вложения дописываются к сообщению, внешние URL изображений не поддерживаются
+			if _, data, ok := part.InlineImage(); ok {
+				m.Images = append(m.Images, data)
+			} else if part.Type == chat.PartText {
+				m.Content = strings.TrimPrefix(m.Content+"\n\n"+part.Text, "\n\n")
+			}
+		}
+		if msg.Role == chat.RoleTool {
+			m.ToolName = msg.Name
+		}
+		for _, call := range msg.ToolCalls {
+			var tc ollamaToolCall
+			tc.Function.Name = call.Function.Name
+			tc.Function.Arguments = objectArguments(call.Function.Arguments)
+			m.ToolCalls = append(m.ToolCalls, tc)
+		}
+		body.Messages = append(body.Messages, m)
+	}
+
+	return json.Marshal(body)
+}
+
+func (ollamaProvider) DecodeResponse(body []byte) (*Completion, error) {
+	var resp ollamaChunk
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, apperrors.NewInternalError("UNMARSHAL_ERROR", "failed to decode response", err)
+	}
+	if resp.Error != "" {
+		return nil, apperrors.NewAPIError("API_ERROR", resp.Error, nil, http.StatusOK)
+	}
+
+	message := chat.Message{Role: chat.RoleAssistant, Content: resp.Message.Content}
+	for i, call := range resp.Message.ToolCalls {
+		message.ToolCalls = append(message.ToolCalls, ollamaToolCallOf(i, call))
+	}
+
+	return &Completion{
+		Message:      message,
+		FinishReason: ollamaFinishReason(resp.DoneReason, len(message.ToolCalls) > 0),
+		Usage:        resp.usage(),
+	}, nil
+}
+
+func (ollamaProvider) NewStreamDecoder(r io.Reader) StreamDecoder {
+	return &ollamaStreamDecoder{reader: bufio.NewReader(r)}
+}
+
+func (ollamaProvider) ErrorMessage(body []byte) string {
+	// {"error": "..."}
+	return errorMessage(body)
+}
+
+func (ollamaProvider) ModelsEndpoint() string {
+	return "api/tags"
+}
+
+// DecodeModels разбирает список локальных моделей; размер контекста /api/tags не возвращает
+func (ollamaProvider) DecodeModels(body []byte) ([]ModelInfo, error) {
+	var list struct {
+		Models []struct {
+			Name    string `json:"name"`
+			Details struct {
+				ParameterSize     string `json:"parameter_size"`
+				QuantizationLevel string `json:"quantization_level"`
+			} `json:"details"`
+		} `json:"models"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, apperrors.NewInternalError("UNMARSHAL_ERROR", "failed to decode model list", err)
+	}
+
+	models := make([]ModelInfo, 0, len(list.Models))
+	for _, m := range list.Models {
+		var details []string
+		for _, detail := range []string{m.Details.ParameterSize, m.Details.QuantizationLevel} {
+			if detail != "" {
+				details = append(details, detail)
+			}
+		}
+		models = append(models, ModelInfo{ID: m.Name, Description: strings.Join(details, " ")})
+	}
+	return models, nil
+}
+
+// ollamaToolCallOf преобразует вызов инструмента; Ollama не присваивает вызовам идентификаторы
+func ollamaToolCallOf(index int, call ollamaToolCall) chat.ToolCall {
+	return chat.ToolCall{
+		ID:       fmt.Sprintf("call_%d", index),
+		Type:     ToolTypeFunction,
+		Function: chat.FunctionCall{Name: call.Function.Name, Arguments: toolArguments(call.Function.Arguments)},
+	}
+}
+
+// ollamaFinishReason приводит done_reason к значениям finish_reason OpenAI.
+// При вызове инструментов Ollama сообщает stop.
+func ollamaFinishReason(reason string, toolCalls bool) string {
+	if toolCalls && (reason == "stop" || reason == "") {
+		return "tool_calls"
+	}
+	return reason
+}
+
+// ollamaStreamDecoder разбирает поток NDJSON: по JSON-объекту в строке, последний - с done: true
+type ollamaStreamDecoder struct {
+	reader *bufio.Reader
+	// toolCalls - количество полученных вызовов инструментов, задаёт их индексы
+	toolCalls int
+}
+
+func (d *ollamaStreamDecoder) Next() ([]StreamChunk, error) {
+	line, err := d.reader.ReadBytes('\n')
+	if err != nil && (err != io.EOF || len(bytes.TrimSpace(line)) == 0) {
+		return nil, err
+	}
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return nil, nil
+	}
+
+	var data ollamaChunk
+	if err := json.Unmarshal(line, &data); err != nil {
+		return []StreamChunk{malformedChunk(string(line), err)}, nil
+	}
+	if data.Error != "" {
+		return []StreamChunk{providerError(data.Error, string(line))}, nil
+	}
+
+	var chunks []StreamChunk
+	if data.Message.Content != "" {
+		chunks = append(chunks, StreamChunk{Content: data.Message.Content})
+	}
+	if len(data.Message.ToolCalls) > 0 {
+		var deltas []toolCallDelta
+		for _, call := range data.Message.ToolCalls {
+			tc := ollamaToolCallOf(d.toolCalls, call)
+			delta := toolCallDelta{Index: d.toolCalls, ID: tc.ID, Type: tc.Type}
+			delta.Function.Name = tc.Function.Name
+			delta.Function.Arguments = tc.Function.Arguments
+			deltas = append(deltas, delta)
+			d.toolCalls++
+		}
+		chunks = append(chunks, StreamChunk{toolCallDeltas: deltas})
+	}
+	if data.Done {
+		chunks = append(chunks,
+			StreamChunk{Usage: data.usage()},
+			StreamChunk{Done: true, FinishReason: ollamaFinishReason(data.DoneReason, d.toolCalls > 0)},
+			StreamChunk{Done: true},
+		)
+	}
+	return chunks, nil
+}
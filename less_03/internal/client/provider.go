@@ -0,0 +1,236 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"llm-client/internal/config"
+	apperrors "llm-client/internal/errors"
+)
+
+// Provider преобразует запросы и ответы между общими типами клиента
+// и форматом API конкретного провайдера
+type Provider interface {
+	// Name возвращает имя провайдера, как в server.provider
+	Name() string
+	// Endpoint возвращает путь эндпоинта относительно адреса сервера
+	Endpoint(model string, stream bool) string
+	// SetHeaders устанавливает заголовки аутентификации и версии API
+	SetHeaders(h http.Header, apiKey string)
+	// EncodeRequest сериализует запрос в формат провайдера
+	EncodeRequest(req *ChatRequest) ([]byte, error)
+	// DecodeResponse разбирает ответ без стриминга
+	DecodeResponse(body []byte) (*Completion, error)
+	// NewStreamDecoder создаёт разборщик потокового ответа
+	NewStreamDecoder(r io.Reader) StreamDecoder
+	// ErrorMessage извлекает текст ошибки из тела ответа с ошибочным статусом
+	ErrorMessage(body []byte) string
+	// ModelsEndpoint возвращает путь списка моделей относительно адреса сервера
+	ModelsEndpoint() string
+	// DecodeModels разбирает ответ со списком моделей
+	DecodeModels(body []byte) ([]ModelInfo, error)
+}
+
+// StreamDecoder разбирает потоковый ответ провайдера на чанки.
+// Next возвращает чанки очередного события или io.EOF при завершении потока;
+// событие без полезных данных возвращает пустой срез.
+type StreamDecoder interface {
+	Next() ([]StreamChunk, error)
+}
+
+// NewProvider создаёт адаптер по имени из server.provider.
+// endpoint используется только OpenAI-compatible провайдером.
+func NewProvider(name, endpoint string) (Provider, error) {
+	switch name {
+	case config.ProviderOpenAI, "":
+		return NewOpenAIProvider(endpoint), nil
+	case config.ProviderAnthropic:
+		return NewAnthropicProvider(), nil
+	case config.ProviderOllama:
+		return NewOllamaProvider(), nil
+	case config.ProviderGemini:
+		return NewGeminiProvider(), nil
+	default:
+		return nil, apperrors.NewConfigError("UNKNOWN_PROVIDER", fmt.Sprintf("unknown provider %q", name), nil)
+	}
+}
+
+// openAIProvider - адаптер OpenAI-compatible Chat Completions API (OpenAI, vLLM, OpenRouter, Ollama /v1)
+type openAIProvider struct {
+	endpoint string
+}
+
+// NewOpenAIProvider создаёт адаптер OpenAI-compatible API с заданным эндпоинтом
+func NewOpenAIProvider(endpoint string) Provider {
+	return &openAIProvider{endpoint: strings.TrimPrefix(endpoint, "/")}
+}
+
+func (p *openAIProvider) Name() string {
+	return config.ProviderOpenAI
+}
+
+func (p *openAIProvider) Endpoint(string, bool) string {
+	return p.endpoint
+}
+
+func (p *openAIProvider) SetHeaders(h http.Header, apiKey string) {
+	if apiKey != "" {
+		h.Set("Authorization", "Bearer "+apiKey)
+	}
+}
+
+func (p *openAIProvider) EncodeRequest(req *ChatRequest) ([]byte, error) {
+	return json.Marshal(req)
+}
+
+func (p *openAIProvider) DecodeResponse(body []byte) (*Completion, error) {
+	var chatResp ChatResponse
+	if err := json.Unmarshal(body, &chatResp); err != nil {
+		return nil, apperrors.NewInternalError("UNMARSHAL_ERROR", "failed to decode response", err)
+	}
+
+	if len(chatResp.Choices) == 0 {
+		return nil, apperrors.NewAPIError("EMPTY_CHOICES", "empty response from API", nil, http.StatusOK)
+	}
+
+	choice := chatResp.Choices[0]
+	message := choice.Message
+	// Некоторые серверы возвращают контент в delta даже без стриминга
+	if message.Content == "" {
+		message.Content = choice.Delta.Content
+	}
+	return &Completion{Message: message, FinishReason: choice.FinishReason, Usage: chatResp.Usage}, nil
+}
+
+func (p *openAIProvider) NewStreamDecoder(r io.Reader) StreamDecoder {
+	return &openAIStreamDecoder{events: newSSEDecoder(r)}
+}
+
+func (p *openAIProvider) ErrorMessage(body []byte) string {
+	return errorMessage(body)
+}
+
+// ModelsEndpoint выводит путь списка моделей из эндпоинта чата:
+// "api/v1/chat/completions" -> "api/v1/models"
+func (p *openAIProvider) ModelsEndpoint() string {
+	if prefix, ok := strings.CutSuffix(p.endpoint, "chat/completions"); ok {
+		return prefix + "models"
+	}
+	return "v1/models"
+}
+
+// openAIModel - элемент списка /v1/models. context_length и pricing возвращает OpenRouter,
+// max_model_len - vLLM; цены указаны за один токен
+type openAIModel struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	ContextLength int    `json:"context_length"`
+	MaxModelLen   int    `json:"max_model_len"`
+	Pricing       *struct {
+		Prompt     json.RawMessage `json:"prompt"`
+		Completion json.RawMessage `json:"completion"`
+	} `json:"pricing"`
+}
+
+func (p *openAIProvider) DecodeModels(body []byte) ([]ModelInfo, error) {
+	var list struct {
+		Data []openAIModel `json:"data"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, apperrors.NewInternalError("UNMARSHAL_ERROR", "failed to decode model list", err)
+	}
+
+	models := make([]ModelInfo, 0, len(list.Data))
+	for _, m := range list.Data {
+		info := ModelInfo{ID: m.ID, ContextLength: max(m.ContextLength, m.MaxModelLen)}
+		if m.Name != m.ID {
+			info.Description = m.Name
+		}
+		if m.Pricing != nil {
+			prompt, okPrompt := perTokenPrice(m.Pricing.Prompt)
+			completion, okCompletion := perTokenPrice(m.Pricing.Completion)
+			if okPrompt && okCompletion {
+				info.Pricing = &config.ModelPrice{Prompt: prompt, Completion: completion}
+			}
+		}
+		models = append(models, info)
+	}
+	return models, nil
+}
+
+// openAIStreamDecoder разбирает поток SSE с чанками chat.completion.chunk
+type openAIStreamDecoder struct {
+	events *sseDecoder
+}
+
+func (d *openAIStreamDecoder) Next() ([]StreamChunk, error) {
+	ev, err := d.events.Next()
+	if err != nil {
+		return nil, err
+	}
+	return parseStreamEvent(ev), nil
+}
+
+// providerError создаёт ошибку, о которой провайдер сообщил внутри потока
+func providerError(message, data string) StreamChunk {
+	return StreamChunk{
+		Error: apperrors.NewStreamError("PROVIDER_ERROR", "provider reported stream error", nil).
+			WithContext("message", message).
+			WithContext("data", data),
+	}
+}
+
+// malformedChunk создаёт чанк с ошибкой разбора данных потока
+func malformedChunk(data string, err error) StreamChunk {
+	return StreamChunk{
+		Error: apperrors.NewStreamError("PARSE_ERROR", "malformed stream chunk", err).
+			WithContext("data", data),
+	}
+}
+
+// errorMessage извлекает текст ошибки из тел вида {"error": {"message": "..."}} (OpenAI, Anthropic, Gemini,
+// в том числе обёрнутых в массив) и {"error": "..."} (Ollama)
+func errorMessage(body []byte) string {
+	var resp struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(body, &resp) != nil {
+		var list []json.RawMessage
+		if json.Unmarshal(body, &list) != nil || len(list) == 0 {
+			return ""
+		}
+		return errorMessage(list[0])
+	}
+	if len(resp.Error) == 0 {
+		return ""
+	}
+	var detail struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(resp.Error, &detail) == nil {
+		return detail.Message
+	}
+	var message string
+	json.Unmarshal(resp.Error, &message)
+	return message
+}
+
+// toolArguments преобразует аргументы вызова из JSON-объекта в строку, как в OpenAI API
+func toolArguments(args json.RawMessage) string {
+	if len(args) == 0 || string(args) == "null" {
+		return "{}"
+	}
+	return string(args)
+}
+
+// objectArguments разбирает аргументы вызова из строки JSON в объект для API,
+// которые принимают аргументы объектом
+func objectArguments(arguments string) json.RawMessage {
+	if strings.TrimSpace(arguments) == "" || !json.Valid([]byte(arguments)) {
+		return json.RawMessage("{}")
+	}
+	return json.RawMessage(arguments)
+}
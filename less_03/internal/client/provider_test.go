@@ -0,0 +1,318 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"llm-client/internal/chat"
+	apperrors "llm-client/internal/errors"
+)
+
+// recordedRequest - запрос, полученный сервером с записанным ответом
+type recordedRequest struct {
+	Path   string
+	Header http.Header
+	Body   []byte
+}
+
+// fixtureServer отдаёт записанный ответ провайдера из testdata/providers и сохраняет полученный запрос
+func fixtureServer(t *testing.T, status int, file, contentType string) (*httptest.Server, *recordedRequest) {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", "providers", file))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	rec := &recordedRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec.Path = r.URL.RequestURI()
+		rec.Header = r.Header.Clone()
+		rec.Body, _ = io.ReadAll(r.Body)
+
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(status)
+		w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+	return server, rec
+}
+
+// toolDialog - диалог с системным сообщением и вызовом инструмента, задействующий все преобразования адаптеров
+func toolDialog() *ChatRequest {
+	return &ChatRequest{
+		Model:       "test-model",
+		Temperature: 0.7,
+		TopP:        0.9,
+		MaxTokens:   256,
+		Messages: []chat.Message{
+			{Role: chat.RoleSystem, Content: "Be brief."},
+			{Role: chat.RoleUser, Content: "What time is it in London?"},
+			{Role: chat.RoleAssistant, ToolCalls: []chat.ToolCall{{
+				ID:       "call_1",
+				Type:     ToolTypeFunction,
+				Function: chat.FunctionCall{Name: "get_current_time", Arguments: `{"timezone":"Europe/London"}`},
+			}}},
+			{Role: chat.RoleTool, ToolCallID: "call_1", Name: "get_current_time", Content: "12:00"},
+		},
+		Tools: []Tool{NewFunctionTool("get_current_time", "Returns current time", map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"timezone": map[string]any{"type": "string"}},
+		})},
+		ToolChoice: ToolChoiceFunction("get_current_time"),
+	}
+}
+
+// assertJSONEqual сравнивает JSON без учёта форматирования и порядка ключей
+func assertJSONEqual(t *testing.T, got []byte, wantFile string) {
+	t.Helper()
+
+	want, err := os.ReadFile(filepath.Join("testdata", "providers", wantFile))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	var gotValue, wantValue any
+	if err := json.Unmarshal(got, &gotValue); err != nil {
+		t.Fatalf("request body is not JSON: %v\n%s", err, got)
+	}
+	if err := json.Unmarshal(want, &wantValue); err != nil {
+		t.Fatalf("fixture %s is not JSON: %v", wantFile, err)
+	}
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Errorf("request body differs from %s:\n%s", wantFile, got)
+	}
+}
+
+func TestProviders_Fixtures(t *testing.T) {
+	tests := []struct {
+		name           string
+		provider       Provider
+		authHeader     string
+		authValue      string
+		completionPath string
+		streamPath     string
+		streamFile     string
+		streamType     string
+		toolCallID     string
+		streamUsage    Usage
+		errorMessage   string
+	}{
+		{
+			name:           "openai",
+			provider:       NewOpenAIProvider("/v1/chat/completions"),
+			authHeader:     "Authorization",
+			authValue:      "Bearer test-key",
+			completionPath: "/v1/chat/completions",
+			streamPath:     "/v1/chat/completions",
+			streamFile:     "openai_stream.sse",
+			streamType:     "text/event-stream",
+			toolCallID:     "call_abc",
+			streamUsage:    Usage{PromptTokens: 52, CompletionTokens: 17, TotalTokens: 69},
+			errorMessage:   "The model `gpt-unknown` does not exist",
+		},
+		{
+			name:           "anthropic",
+			provider:       NewAnthropicProvider(),
+			authHeader:     "X-Api-Key",
+			authValue:      "test-key",
+			completionPath: "/v1/messages",
+			streamPath:     "/v1/messages",
+			streamFile:     "anthropic_stream.sse",
+			streamType:     "text/event-stream",
+			toolCallID:     "toolu_01",
+			streamUsage:    Usage{PromptTokens: 52, CompletionTokens: 17, TotalTokens: 69},
+			errorMessage:   "model: claude-unknown",
+		},
+		{
+			name:           "ollama",
+			provider:       NewOllamaProvider(),
+			authHeader:     "Authorization",
+			authValue:      "Bearer test-key",
+			completionPath: "/api/chat",
+			streamPath:     "/api/chat",
+			streamFile:     "ollama_stream.ndjson",
+			streamType:     "application/x-ndjson",
+			toolCallID:     "call_0",
+			streamUsage:    Usage{PromptTokens: 52, CompletionTokens: 17, TotalTokens: 69},
+			errorMessage:   `model "llama-unknown" not found`,
+		},
+		{
+			name:           "gemini",
+			provider:       NewGeminiProvider(),
+			authHeader:     "X-Goog-Api-Key",
+			authValue:      "test-key",
+			completionPath: "/v1beta/models/test-model:generateContent",
+			streamPath:     "/v1beta/models/test-model:streamGenerateContent?alt=sse",
+			streamFile:     "gemini_stream.sse",
+			streamType:     "text/event-stream",
+			toolCallID:     "call_0",
+			streamUsage:    Usage{PromptTokens: 52, CompletionTokens: 17, TotalTokens: 69},
+			errorMessage:   "models/gemini-unknown is not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+"/completion", func(t *testing.T) {
+			server, rec := fixtureServer(t, http.StatusOK, tt.name+"_completion.json", "application/json")
+			c := NewClient(server.URL, "", WithProvider(tt.provider), WithAPIKey("test-key"))
+
+			completion, err := c.ChatCompletion(context.Background(), toolDialog())
+			if err != nil {
+				t.Fatalf("ChatCompletion() error = %v", err)
+			}
+
+			if rec.Path != tt.completionPath {
+				t.Errorf("path = %q, want %q", rec.Path, tt.completionPath)
+			}
+			if got := rec.Header.Get(tt.authHeader); got != tt.authValue {
+				t.Errorf("%s = %q, want %q", tt.authHeader, got, tt.authValue)
+			}
+			assertJSONEqual(t, rec.Body, tt.name+"_request.json")
+
+			if completion.Message.Content != "Hello! How can I help?" {
+				t.Errorf("Content = %q", completion.Message.Content)
+			}
+			if completion.Message.Role != chat.RoleAssistant {
+				t.Errorf("Role = %q, want assistant", completion.Message.Role)
+			}
+			if completion.FinishReason != "stop" {
+				t.Errorf("FinishReason = %q, want stop", completion.FinishReason)
+			}
+			want := Usage{PromptTokens: 12, CompletionTokens: 7, TotalTokens: 19}
+			if completion.Usage == nil || *completion.Usage != want {
+				t.Errorf("Usage = %+v, want %+v", completion.Usage, want)
+			}
+		})
+
+		t.Run(tt.name+"/stream", func(t *testing.T) {
+			server, rec := fixtureServer(t, http.StatusOK, tt.streamFile, tt.streamType)
+			c := NewClient(server.URL, "", WithProvider(tt.provider), WithAPIKey("test-key"))
+
+			req := toolDialog()
+			req.Stream = true
+
+			var content strings.Builder
+			var final *StreamChunk
+			for chunk := range c.ChatStream(context.Background(), req) {
+				if chunk.Error != nil {
+					t.Fatalf("stream error: %v", chunk.Error)
+				}
+				if chunk.Done {
+					final = &chunk
+					continue
+				}
+				content.WriteString(chunk.Content)
+			}
+
+			if rec.Path != tt.streamPath {
+				t.Errorf("path = %q, want %q", rec.Path, tt.streamPath)
+			}
+			if content.String() != "Let me check." {
+				t.Errorf("content = %q, want %q", content.String(), "Let me check.")
+			}
+			if final == nil {
+				t.Fatal("stream should end with Done chunk")
+			}
+			if final.FinishReason != "tool_calls" {
+				t.Errorf("FinishReason = %q, want tool_calls", final.FinishReason)
+			}
+			if final.Usage == nil || *final.Usage != tt.streamUsage {
+				t.Errorf("Usage = %+v, want %+v", final.Usage, tt.streamUsage)
+			}
+			if len(final.ToolCalls) != 1 {
+				t.Fatalf("ToolCalls = %+v, want 1 call", final.ToolCalls)
+			}
+			call := final.ToolCalls[0]
+			if call.ID != tt.toolCallID || call.Function.Name != "get_current_time" {
+				t.Errorf("tool call = %+v", call)
+			}
+			var args map[string]string
+			if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil || args["timezone"] != "UTC" {
+				t.Errorf("Arguments = %q, want timezone UTC", call.Function.Arguments)
+			}
+		})
+
+		t.Run(tt.name+"/error", func(t *testing.T) {
+			server, _ := fixtureServer(t, http.StatusNotFound, tt.name+"_error.json", "application/json")
+			c := NewClient(server.URL, "", WithProvider(tt.provider), WithAPIKey("test-key"))
+
+			_, err := c.ChatCompletion(context.Background(), toolDialog())
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !apperrors.IsAPIError(err) || apperrors.GetStatusCode(err) != http.StatusNotFound {
+				t.Errorf("error = %v, want API error with status 404", err)
+			}
+			if !strings.Contains(err.Error(), tt.errorMessage) {
+				t.Errorf("error %q should contain provider message %q", err.Error(), tt.errorMessage)
+			}
+		})
+	}
+}
+
+func TestProviders_EncodeImages(t *testing.T) {
+	req := &ChatRequest{
+		Model:       "test-model",
+		Temperature: 0.7,
+		TopP:        0.9,
+		Messages: []chat.Message{{
+			Role:    chat.RoleUser,
+			Content: "What is on the picture?",
+			Parts:   []chat.ContentPart{chat.ImagePart("image/png", []byte("png")), chat.TextPart("notes.txt: hello")},
+		}},
+	}
+
+	for _, provider := range []Provider{NewOpenAIProvider("v1/chat/completions"), NewAnthropicProvider(), NewGeminiProvider(), NewOllamaProvider()} {
+		t.Run(provider.Name(), func(t *testing.T) {
+			body, err := provider.EncodeRequest(req)
+			if err != nil {
+				t.Fatalf("EncodeRequest() error = %v", err)
+			}
+			assertJSONEqual(t, body, provider.Name()+"_image_request.json")
+		})
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	for _, name := range []string{"", "openai", "anthropic", "ollama", "gemini"} {
+		p, err := NewProvider(name, "v1/chat/completions")
+		if err != nil {
+			t.Fatalf("NewProvider(%q) error = %v", name, err)
+		}
+		want := name
+		if want == "" {
+			want = "openai"
+		}
+		if p.Name() != want {
+			t.Errorf("NewProvider(%q).Name() = %q", name, p.Name())
+		}
+	}
+
+	_, err := NewProvider("cohere", "")
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) || appErr.Kind != apperrors.KindConfig {
+		t.Errorf("NewProvider(unknown) error = %v, want KindConfig", err)
+	}
+}
+
+func TestClient_Forward_UnsupportedProvider(t *testing.T) {
+	c := NewClient("http://127.0.0.1:0", "", WithProvider(NewAnthropicProvider()))
+
+	if c.SupportsPassthrough() {
+		t.Error("anthropic provider should not support passthrough")
+	}
+	_, err := c.ForwardCompletion(context.Background(), "m", []byte(`{}`))
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) || appErr.Kind != apperrors.KindConfig {
+		t.Errorf("ForwardCompletion() error = %v, want KindConfig", err)
+	}
+}
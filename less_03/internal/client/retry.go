@@ -0,0 +1,133 @@
+package client
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	apperrors "llm-client/internal/errors"
+)
+
+// RetryPolicy описывает политику повторных попыток при временных ошибках
+type RetryPolicy struct {
+	// MaxAttempts - максимальное количество попыток, включая первую
+	MaxAttempts int
+	// BaseDelay - задержка перед второй попыткой, далее удваивается
+	BaseDelay time.Duration
+	// MaxDelay - верхняя граница задержки экспоненциального backoff
+	MaxDelay time.Duration
+	// Jitter - доля случайного уменьшения задержки (0.0-1.0)
+	Jitter float64
+}
+
+// DefaultRetryPolicy возвращает политику повторов по умолчанию
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    10 * time.Second,
+		Jitter:      0.2,
+	}
+}
+
+// NoRetryPolicy возвращает политику без повторных попыток
+func NoRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 1}
+}
+
+// WithRetryPolicy устанавливает политику повторных попыток
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// backoff вычисляет задержку экспоненциального backoff перед попыткой attempt+1
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			break
+		}
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	if p.Jitter > 0 && delay > 0 {
+		// Уменьшаем задержку на случайную долю, чтобы клиенты не повторяли запросы синхронно
+		delay -= time.Duration(float64(delay) * p.Jitter * rand.Float64())
+	}
+	return delay
+}
+
+// delay возвращает задержку перед следующей попыткой с учётом Retry-After.
+// Retry-After ограничивается MaxDelay, чтобы сервер не мог заблокировать запрос на часы.
+func (p RetryPolicy) delay(attempt int, err error) time.Duration {
+	if retryAfter := apperrors.GetRetryAfter(err); retryAfter > 0 {
+		if p.MaxDelay > 0 && retryAfter > p.MaxDelay {
+			return p.MaxDelay
+		}
+		return retryAfter
+	}
+	return p.backoff(attempt)
+}
+
+// shouldRetry определяет нужно ли повторять запрос после ошибки
+func (c *Client) shouldRetry(ctx context.Context, attempt int, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	return attempt < c.retry.MaxAttempts && apperrors.IsRetryable(err)
+}
+
+// waitRetry ожидает перед следующей попыткой.
+// Возвращает ошибку контекста, если ожидание было прервано.
+func (c *Client) waitRetry(ctx context.Context, attempt int, err error) error {
+	delay := c.retry.delay(attempt, err)
+
+	c.logger.WarnContext(ctx, "Retrying request",
+		"attempt", attempt,
+		"next_attempt", attempt+1,
+		"max_attempts", c.retry.MaxAttempts,
+		"delay", delay,
+		"error", err,
+	)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// parseRetryAfter разбирает значение заголовка Retry-After.
+// Поддерживаются оба формата: количество секунд и HTTP дата.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
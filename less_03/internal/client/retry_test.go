@@ -0,0 +1,273 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"llm-client/internal/chat"
+	"llm-client/internal/config"
+	apperrors "llm-client/internal/errors"
+	"llm-client/internal/fakellm"
+)
+
+// fastRetryPolicy - политика с минимальными задержками для тестов
+func fastRetryPolicy(attempts int) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: attempts,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    5 * time.Millisecond,
+	}
+}
+
+func testRequest() *ChatRequest {
+	return &ChatRequest{
+		Model:    "test-model",
+		Messages: []chat.Message{{Role: chat.RoleUser, Content: "Hello"}},
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Duration
+	}{
+		{"empty", "", 0},
+		{"seconds", "5", 5 * time.Second},
+		{"seconds with spaces", " 2 ", 2 * time.Second},
+		{"negative seconds", "-1", 0},
+		{"http date", now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second},
+		{"http date in past", now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"garbage", "soon", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseRetryAfter(tt.value, now)
+			if got != tt.expected {
+				t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond}
+	for i, want := range expected {
+		if got := p.backoff(i + 1); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, want)
+		}
+	}
+
+	t.Run("zero max delay means no cap", func(t *testing.T) {
+		uncapped := RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond}
+		expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond}
+		for i, want := range expected {
+			if got := uncapped.backoff(i + 1); got != want {
+				t.Errorf("backoff(%d) = %v, want %v", i+1, got, want)
+			}
+		}
+	})
+
+	t.Run("jitter reduces delay", func(t *testing.T) {
+		p.Jitter = 0.5
+		for i := 0; i < 100; i++ {
+			got := p.backoff(1)
+			if got < 50*time.Millisecond || got > 100*time.Millisecond {
+				t.Fatalf("backoff with jitter = %v, want within [50ms, 100ms]", got)
+			}
+		}
+	})
+
+	t.Run("retry after takes precedence", func(t *testing.T) {
+		err := apperrors.NewAPIError("API_ERROR", "rate limited", nil, 429).WithContext("retry_after", 7*time.Second)
+		long := RetryPolicy{MaxAttempts: 3, BaseDelay: 100 * time.Millisecond, MaxDelay: 10 * time.Second}
+		if got := long.delay(1, err); got != 7*time.Second {
+			t.Errorf("delay() = %v, want %v", got, 7*time.Second)
+		}
+	})
+
+	t.Run("retry after is clamped to max delay", func(t *testing.T) {
+		err := apperrors.NewAPIError("API_ERROR", "rate limited", nil, 429).WithContext("retry_after", time.Hour)
+		if got := p.delay(1, err); got != p.MaxDelay {
+			t.Errorf("delay() = %v, want %v", got, p.MaxDelay)
+		}
+	})
+}
+
+func TestNewClientFromConfig_RetryPolicy(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Server.Retry = config.RetryConfig{MaxAttempts: 4, BaseDelayMs: 250, MaxDelayMs: 2000, Jitter: 0.1}
+
+	c := NewClientFromConfig(cfg)
+
+	expected := RetryPolicy{MaxAttempts: 4, BaseDelay: 250 * time.Millisecond, MaxDelay: 2 * time.Second, Jitter: 0.1}
+	if c.retry != expected {
+		t.Errorf("retry = %+v, want %+v", c.retry, expected)
+	}
+	if c.GetBaseURL() != cfg.Server.Address {
+		t.Errorf("GetBaseURL() = %q, want %q", c.GetBaseURL(), cfg.Server.Address)
+	}
+}
+
+func TestClient_Chat_RetriesTransientErrors(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			server := fakellm.New(t, fakellm.WithReplies(fakellm.Error(status, "try again"), fakellm.Text("ok")))
+
+			c := NewClient(server.URL, "/v1/chat/completions", WithRetryPolicy(fastRetryPolicy(3)))
+
+			content, err := c.Chat(context.Background(), testRequest())
+			if err != nil {
+				t.Fatalf("Chat() error = %v", err)
+			}
+			if content != "ok" {
+				t.Errorf("content = %q, want %q", content, "ok")
+			}
+			server.AssertRequestCount(2)
+		})
+	}
+}
+
+func TestClient_Chat_NoRetryOnClientError(t *testing.T) {
+	server := fakellm.New(t, fakellm.WithDefaultReply(fakellm.Error(http.StatusBadRequest, "bad request")))
+
+	c := NewClient(server.URL, "/v1/chat/completions", WithRetryPolicy(fastRetryPolicy(3)))
+
+	if _, err := c.Chat(context.Background(), testRequest()); err == nil {
+		t.Fatalf("Chat() should return error for 400")
+	}
+	server.AssertRequestCount(1)
+}
+
+func TestClient_Chat_GivesUpAfterMaxAttempts(t *testing.T) {
+	server := fakellm.New(t, fakellm.WithDefaultReply(fakellm.Error(http.StatusServiceUnavailable, "overloaded")))
+
+	c := NewClient(server.URL, "/v1/chat/completions", WithRetryPolicy(fastRetryPolicy(3)))
+
+	_, err := c.Chat(context.Background(), testRequest())
+	if apperrors.GetStatusCode(err) != http.StatusServiceUnavailable {
+		t.Errorf("GetStatusCode() = %d, want %d", apperrors.GetStatusCode(err), http.StatusServiceUnavailable)
+	}
+	server.AssertRequestCount(3)
+}
+
+func TestClient_HandleErrorResponse_RetryAfter(t *testing.T) {
+	c := NewClient("http://localhost:11434", "/v1/chat")
+
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	resp.Header.Set("Retry-After", "12")
+
+	err := c.handleErrorResponse(context.Background(), resp, []byte(`{"error":"slow down"}`))
+	if got := apperrors.GetRetryAfter(err); got != 12*time.Second {
+		t.Errorf("GetRetryAfter() = %v, want %v", got, 12*time.Second)
+	}
+	if !apperrors.IsRetryable(err) {
+		t.Errorf("429 should be retryable")
+	}
+}
+
+func TestClient_Chat_CancelledDuringBackoff(t *testing.T) {
+	server := fakellm.New(t, fakellm.WithDefaultReply(fakellm.RateLimited(time.Minute)))
+
+	// MaxDelay не ограничивает Retry-After: клиент ждёт минуту, пока контекст не истечёт
+	policy := fastRetryPolicy(3)
+	policy.MaxDelay = time.Minute
+	c := NewClient(server.URL, "/v1/chat/completions", WithRetryPolicy(policy))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := c.Chat(ctx, testRequest()); err == nil {
+		t.Fatalf("Chat() should return error")
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("Chat() should stop waiting when context is cancelled")
+	}
+}
+
+func TestClient_Chat_OversizedRetryAfter(t *testing.T) {
+	server := fakellm.New(t, fakellm.WithDefaultReply(fakellm.RateLimited(time.Hour)))
+
+	c := NewClient(server.URL, "/v1/chat/completions", WithRetryPolicy(fastRetryPolicy(3)))
+
+	start := time.Now()
+	_, err := c.Chat(context.Background(), testRequest())
+	if err == nil {
+		t.Fatalf("Chat() should return error")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Chat() waited %v, Retry-After should be clamped to MaxDelay", elapsed)
+	}
+	if got := apperrors.GetRetryAfter(err); got != time.Hour {
+		t.Errorf("GetRetryAfter() = %v, want %v", got, time.Hour)
+	}
+	server.AssertRequestCount(3)
+}
+
+func TestClient_ChatStream_RetriesBeforeFirstToken(t *testing.T) {
+	server := fakellm.New(t, fakellm.WithReplies(
+		fakellm.RateLimited(0),
+		fakellm.DropAfter(0, "hi"),
+		fakellm.Text("hi"),
+	))
+
+	c := NewClient(server.URL, "/v1/chat/completions", WithRetryPolicy(fastRetryPolicy(3)))
+
+	req := testRequest()
+	req.Stream = true
+	var content string
+	for chunk := range c.ChatStream(context.Background(), req) {
+		if chunk.Error != nil {
+			t.Fatalf("unexpected stream error: %v", chunk.Error)
+		}
+		content += chunk.Content
+	}
+
+	if content != "hi" {
+		t.Errorf("content = %q, want %q", content, "hi")
+	}
+	server.AssertRequestCount(3)
+}
+
+func TestClient_ChatStream_NoRetryAfterTokens(t *testing.T) {
+	// Отправляем один полный токен, затем обрываем соединение
+	server := fakellm.New(t, fakellm.WithReplies(fakellm.Reply{
+		Tokens:     []string{"partial", " rest"},
+		Fault:      fakellm.FaultDrop,
+		FaultAfter: 1,
+	}))
+
+	c := NewClient(server.URL, "/v1/chat/completions", WithRetryPolicy(fastRetryPolicy(3)))
+
+	req := testRequest()
+	req.Stream = true
+	var content string
+	var streamErr error
+	for chunk := range c.ChatStream(context.Background(), req) {
+		if chunk.Error != nil {
+			streamErr = chunk.Error
+		}
+		content += chunk.Content
+	}
+
+	if content != "partial" {
+		t.Errorf("content = %q, want %q", content, "partial")
+	}
+	if streamErr == nil {
+		t.Fatalf("stream should report read error")
+	}
+	var appErr *apperrors.AppError
+	if !errors.As(streamErr, &appErr) || appErr.Kind != apperrors.KindStream {
+		t.Errorf("error should be KindStream AppError, got %v", streamErr)
+	}
+	server.AssertRequestCount(1)
+}
@@ -0,0 +1,168 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+)
+
+// sseEvent представляет одно событие Server-Sent Events
+type sseEvent struct {
+	// Event - тип события (поле event:), пустой для событий по умолчанию
+	Event string
+	// ID - идентификатор последнего события (поле id:)
+	ID string
+	// OwnID - поле id: присутствовало в самом событии, а не унаследовано от предыдущего
+	OwnID bool
+	// Data - данные события, несколько полей data: склеиваются через \n
+	Data string
+	// Retry - рекомендованная задержка переподключения в миллисекундах (поле retry:)
+	Retry int
+}
+
+// sseDecoder инкрементально разбирает поток Server-Sent Events.
+// Строки, разорванные между вызовами Read, буферизуются до получения
+// полного окончания строки (\n, \r\n или \r).
+type sseDecoder struct {
+	reader *bufio.Reader
+
+	// Состояние текущего (ещё не отправленного) события
+	event   string
+	data    bytes.Buffer
+	hasData bool
+	hasID   bool
+
+	// lastID сохраняется между событиями согласно спецификации
+	lastID string
+	// retry - последнее значение поля retry:
+	retry int
+
+	// pendingCR - предыдущая строка завершилась \r, следующий \n нужно пропустить
+	pendingCR bool
+}
+
+// newSSEDecoder создаёт декодер поверх потока данных
+func newSSEDecoder(r io.Reader) *sseDecoder {
+	return &sseDecoder{reader: bufio.NewReader(r)}
+}
+
+// Next возвращает следующее событие из потока.
+// При завершении потока возвращает io.EOF. Событие, не завершённое пустой строкой,
+// по спецификации отбрасывается: при обрыве соединения его данные могут быть неполными.
+func (d *sseDecoder) Next() (*sseEvent, error) {
+	for {
+		line, err := d.readLine()
+		if err != nil {
+			if err == io.EOF {
+				d.reset()
+			}
+			return nil, err
+		}
+
+		// Пустая строка завершает событие
+		if len(line) == 0 {
+			if !d.hasData {
+				// Событие без данных не отправляется, но его тип сбрасывается
+				d.event = ""
+				d.hasID = false
+				continue
+			}
+			return d.dispatch(), nil
+		}
+
+		d.processLine(line)
+	}
+}
+
+// readLine читает одну строку без символов окончания строки
+func (d *sseDecoder) readLine() ([]byte, error) {
+	var line []byte
+	for {
+		b, err := d.reader.ReadByte()
+		if err != nil {
+			if err == io.EOF && len(line) > 0 {
+				// Последняя строка без перевода строки
+				return line, nil
+			}
+			return nil, err
+		}
+
+		if d.pendingCR {
+			d.pendingCR = false
+			if b == '\n' {
+				continue
+			}
+		}
+
+		switch b {
+		case '\n':
+			return line, nil
+		case '\r':
+			d.pendingCR = true
+			return line, nil
+		default:
+			line = append(line, b)
+		}
+	}
+}
+
+// processLine разбирает одну непустую строку события
+func (d *sseDecoder) processLine(line []byte) {
+	// Строки, начинающиеся с двоеточия, являются комментариями
+	if line[0] == ':' {
+		return
+	}
+
+	field, value := line, []byte(nil)
+	if i := bytes.IndexByte(line, ':'); i >= 0 {
+		field = line[:i]
+		value = line[i+1:]
+		// Один пробел после двоеточия не входит в значение
+		value = bytes.TrimPrefix(value, []byte(" "))
+	}
+
+	switch string(field) {
+	case "data":
+		if d.hasData {
+			d.data.WriteByte('\n')
+		}
+		d.data.Write(value)
+		d.hasData = true
+	case "event":
+		d.event = string(value)
+	case "id":
+		// Идентификатор с нулевым байтом игнорируется по спецификации
+		if bytes.IndexByte(value, 0) < 0 {
+			d.lastID = string(value)
+			d.hasID = true
+		}
+	case "retry":
+		if v, err := strconv.Atoi(string(value)); err == nil && v >= 0 {
+			d.retry = v
+		}
+	default:
+		// Неизвестные поля игнорируются
+	}
+}
+
+// dispatch формирует событие из накопленного состояния и сбрасывает его
+func (d *sseDecoder) dispatch() *sseEvent {
+	ev := &sseEvent{
+		Event: d.event,
+		ID:    d.lastID,
+		OwnID: d.hasID,
+		Data:  d.data.String(),
+		Retry: d.retry,
+	}
+	d.reset()
+	return ev
+}
+
+// reset сбрасывает состояние текущего события
+func (d *sseDecoder) reset() {
+	d.event = ""
+	d.hasID = false
+	d.data.Reset()
+	d.hasData = false
+}
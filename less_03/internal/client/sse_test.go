@@ -0,0 +1,264 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	apperrors "llm-client/internal/errors"
+)
+
+// decodeAll читает все события из потока
+func decodeAll(t testing.TB, r io.Reader) []sseEvent {
+	t.Helper()
+
+	var events []sseEvent
+	decoder := newSSEDecoder(r)
+	for {
+		ev, err := decoder.Next()
+		if err == io.EOF {
+			return events
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		events = append(events, *ev)
+	}
+}
+
+// collectStream прогоняет reader через readStream и собирает результат
+func collectStream(c *Client, r io.Reader) (string, bool, error) {
+	ch := make(chan StreamChunk, 64)
+	var streamErr error
+	go func() {
+		defer close(ch)
+		_, streamErr = c.readStream(context.Background(), "test-model", r, ch, &Span{})
+	}()
+
+	var content strings.Builder
+	done := false
+	for chunk := range ch {
+		if chunk.Done {
+			done = true
+			continue
+		}
+		content.WriteString(chunk.Content)
+	}
+	return content.String(), done, streamErr
+}
+
+func TestSSEDecoder_Fields(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []sseEvent
+	}{
+		{
+			name:     "single data line",
+			input:    "data: hello\n\n",
+			expected: []sseEvent{{Data: "hello"}},
+		},
+		{
+			name:     "data without space after colon",
+			input:    "data:hello\n\n",
+			expected: []sseEvent{{Data: "hello"}},
+		},
+		{
+			name:     "multi-line data joined with newline",
+			input:    "data: first\ndata: second\n\n",
+			expected: []sseEvent{{Data: "first\nsecond"}},
+		},
+		{
+			name:     "event id and retry fields",
+			input:    "event: update\nid: 42\nretry: 1500\ndata: x\n\n",
+			expected: []sseEvent{{Event: "update", ID: "42", OwnID: true, Retry: 1500, Data: "x"}},
+		},
+		{
+			name:     "id persists between events",
+			input:    "id: 7\ndata: a\n\ndata: b\n\n",
+			expected: []sseEvent{{ID: "7", OwnID: true, Data: "a"}, {ID: "7", Data: "b"}},
+		},
+		{
+			name:     "CRLF line endings",
+			input:    "data: a\r\n\r\ndata: b\r\n\r\n",
+			expected: []sseEvent{{Data: "a"}, {Data: "b"}},
+		},
+		{
+			name:     "CR line endings",
+			input:    "data: a\r\rdata: b\r\r",
+			expected: []sseEvent{{Data: "a"}, {Data: "b"}},
+		},
+		{
+			name:     "comments are ignored",
+			input:    ": keep-alive\ndata: a\n\n",
+			expected: []sseEvent{{Data: "a"}},
+		},
+		{
+			name:     "event without data is not dispatched",
+			input:    "event: ping\n\ndata: a\n\n",
+			expected: []sseEvent{{Data: "a"}},
+		},
+		{
+			name:     "invalid retry is ignored",
+			input:    "retry: soon\ndata: a\n\n",
+			expected: []sseEvent{{Data: "a"}},
+		},
+		{
+			name:     "unterminated event discarded at EOF",
+			input:    "data: a\n\ndata: tail",
+			expected: []sseEvent{{Data: "a"}},
+		},
+		{
+			name:     "event cut before blank line discarded at EOF",
+			input:    "data: {\"choices\":[{\"delta\":\n",
+			expected: nil,
+		},
+		{
+			name:     "empty stream",
+			input:    "",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := decodeAll(t, strings.NewReader(tt.input))
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("events = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestClient_ReadStream_SplitTranscripts(t *testing.T) {
+	tests := []struct {
+		file     string
+		expected string
+	}{
+		{file: "openai_stream.sse", expected: "Привет, мир! Как дела?"},
+		{file: "routerai_crlf.sse", expected: "Hello from RouterAI"},
+		{file: "multiline_data.sse", expected: "multi-line data"},
+	}
+
+	c := NewClient("http://localhost:11434", "/v1/chat")
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+
+			// Разрезаем транскрипт на две части по каждому байту
+			for offset := 0; offset <= len(data); offset++ {
+				r := io.MultiReader(bytes.NewReader(data[:offset]), bytes.NewReader(data[offset:]))
+
+				content, done, err := collectStream(c, r)
+				if err != nil {
+					t.Fatalf("offset %d: unexpected error %v", offset, err)
+				}
+				if !done {
+					t.Fatalf("offset %d: stream should end with Done", offset)
+				}
+				if content != tt.expected {
+					t.Fatalf("offset %d: content = %q, want %q", offset, content, tt.expected)
+				}
+			}
+
+			// Побайтовое чтение
+			content, done, err := collectStream(c, iotest.OneByteReader(bytes.NewReader(data)))
+			if err != nil || !done || content != tt.expected {
+				t.Errorf("one byte reader: content = %q, done = %v, err = %v", content, done, err)
+			}
+		})
+	}
+}
+
+func TestClient_ReadStream_MalformedChunk(t *testing.T) {
+	c := NewClient("http://localhost:11434", "/v1/chat")
+
+	input := "data: {\"choices\":[{\"delta\":{\"content\":\"ok\"}}]}\n\ndata: {\"choices\":[{\n\n"
+	content, _, err := collectStream(c, strings.NewReader(input))
+
+	if content != "ok" {
+		t.Errorf("content = %q, want %q", content, "ok")
+	}
+	if err == nil {
+		t.Fatalf("malformed chunk should produce an error")
+	}
+
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) {
+		t.Fatalf("error should be *AppError, got %T", err)
+	}
+	if appErr.Kind != apperrors.KindStream {
+		t.Errorf("Kind = %v, want %v", appErr.Kind, apperrors.KindStream)
+	}
+	if appErr.Code != "PARSE_ERROR" {
+		t.Errorf("Code = %q, want %q", appErr.Code, "PARSE_ERROR")
+	}
+}
+
+func TestClient_ReadStream_TruncatedEvent(t *testing.T) {
+	c := NewClient("http://localhost:11434", "/v1/chat")
+
+	// Соединение оборвалось посреди события: обрезанный JSON не должен разбираться
+	input := "data: {\"choices\":[{\"delta\":{\"content\":\"ok\"}}]}\n\ndata: {\"choices\":[{\"delta\":{\"con"
+	content, _, err := collectStream(c, strings.NewReader(input))
+
+	if content != "ok" {
+		t.Errorf("content = %q, want %q", content, "ok")
+	}
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) && appErr.Code == "PARSE_ERROR" {
+		t.Errorf("truncated event should be discarded, got %v", err)
+	}
+}
+
+func TestClient_ReadStream_ErrorEvent(t *testing.T) {
+	c := NewClient("http://localhost:11434", "/v1/chat")
+
+	input := "event: error\ndata: {\"error\":{\"message\":\"overloaded\"}}\n\n"
+	_, _, err := collectStream(c, strings.NewReader(input))
+
+	if err == nil {
+		t.Fatalf("error event should produce an error")
+	}
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) || appErr.Kind != apperrors.KindStream {
+		t.Errorf("error should be KindStream AppError, got %v", err)
+	}
+}
+
+func FuzzSSEDecoder(f *testing.F) {
+	for _, name := range []string{"openai_stream.sse", "routerai_crlf.sse", "multiline_data.sse"} {
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			f.Fatalf("ReadFile() error = %v", err)
+		}
+		f.Add(data)
+	}
+	f.Add([]byte("data: a\r\ndata: b\r\r\n\nid: 1\x00\nretry: -5\n:comment"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		whole := decodeAll(t, bytes.NewReader(data))
+		byteWise := decodeAll(t, iotest.OneByteReader(bytes.NewReader(data)))
+
+		// Результат не должен зависеть от того, как поток разбит на чтения
+		if !reflect.DeepEqual(whole, byteWise) {
+			t.Fatalf("decoding depends on read boundaries:\nwhole: %+v\nbytes: %+v", whole, byteWise)
+		}
+
+		// Разбор чанков не должен паниковать на произвольных данных
+		for i := range whole {
+			parseStreamEvent(&whole[i])
+		}
+	})
+}
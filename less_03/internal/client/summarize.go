@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"llm-client/internal/chat"
+)
+
+// summaryPrompt - системный промпт запроса суммаризации истории
+const summaryPrompt = "You compress chat transcripts. Write a concise summary of the conversation below " +
+	"that preserves facts, decisions, names, code identifiers and open questions needed to continue it. " +
+	"Write in the language of the conversation. Output only the summary."
+
+// Summarizer возвращает функцию суммаризации истории для chat.NewSummarizer.
+// Содержание составляется отдельным непотоковым запросом к указанной модели.
+func (c *Client) Summarizer(model string) chat.SummarizeFunc {
+	return func(ctx context.Context, previous string, messages []chat.Message) (string, error) {
+		req := &ChatRequest{
+			Model: model,
+			Messages: []chat.Message{
+				{Role: chat.RoleSystem, Content: summaryPrompt},
+				{Role: chat.RoleUser, Content: formatTranscript(previous, messages)},
+			},
+			Temperature: 0.2,
+			TopP:        1,
+		}
+
+		c.logger.InfoContext(ctx, "Summarizing chat history", "model", model, "messages", len(messages))
+		summary, err := c.Chat(ctx, req)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(summary), nil
+	}
+}
+
+// formatTranscript превращает сообщения в текстовую стенограмму для суммаризации
+func formatTranscript(previous string, messages []chat.Message) string {
+	var b strings.Builder
+	if previous != "" {
+		fmt.Fprintf(&b, "Summary of the earlier conversation:\n%s\n\nContinuation:\n", previous)
+	}
+	for _, msg := range messages {
+		switch {
+		case msg.Role == chat.RoleTool:
+			fmt.Fprintf(&b, "tool %s: %s\n", msg.Name, msg.Content)
+		case len(msg.ToolCalls) > 0:
+			for _, call := range msg.ToolCalls {
+				fmt.Fprintf(&b, "assistant called %s(%s)\n", call.Function.Name, call.Function.Arguments)
+			}
+			if msg.Content != "" {
+				fmt.Fprintf(&b, "assistant: %s\n", msg.Content)
+			}
+		default:
+			// Вложения попадают в пересказ отметками, без base64
+			fmt.Fprintf(&b, "%s: %s\n", msg.Role, msg.DisplayContent())
+		}
+	}
+	return b.String()
+}
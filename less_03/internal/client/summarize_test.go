@@ -0,0 +1,38 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"llm-client/internal/chat"
+	"llm-client/internal/fakellm"
+)
+
+func TestClient_Summarizer(t *testing.T) {
+	server := fakellm.New(t, fakellm.WithReplies(fakellm.Text("  краткое содержание \n")))
+
+	c := NewClient(server.URL, "v1/chat/completions")
+	summary, err := c.Summarizer("llama3")(context.Background(), "ранее: приветствие", []chat.Message{
+		{Role: chat.RoleUser, Content: "Который час?"},
+		{Role: chat.RoleAssistant, ToolCalls: []chat.ToolCall{{ID: "c1", Function: chat.FunctionCall{Name: "get_current_time", Arguments: "{}"}}}},
+		{Role: chat.RoleTool, Name: "get_current_time", Content: "12:00"},
+	})
+	if err != nil {
+		t.Fatalf("Summarizer() error = %v", err)
+	}
+	if summary != "краткое содержание" {
+		t.Errorf("summary = %q", summary)
+	}
+
+	got := server.LastRequest()
+	if got.Model != "llama3" || got.Stream || len(got.Messages) != 2 {
+		t.Fatalf("request = %+v", got)
+	}
+	transcript := got.Messages[1].Content
+	for _, want := range []string{"ранее: приветствие", "user: Который час?", "assistant called get_current_time({})", "tool get_current_time: 12:00"} {
+		if !strings.Contains(transcript, want) {
+			t.Errorf("transcript %q should contain %q", transcript, want)
+		}
+	}
+}
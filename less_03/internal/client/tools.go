@@ -0,0 +1,131 @@
+package client
+
+import (
+	"sort"
+
+	"llm-client/internal/chat"
+)
+
+// ToolTypeFunction - единственный тип инструментов в OpenAI-compatible API
+const ToolTypeFunction = "function"
+
+// Tool описывает инструмент, который модель может вызвать
+type Tool struct {
+	Type     string             `json:"type"`
+	Function FunctionDefinition `json:"function"`
+}
+
+// FunctionDefinition описывает функцию: имя, назначение и JSON Schema аргументов
+type FunctionDefinition struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+}
+
+// NewFunctionTool создаёт описание инструмента-функции
+func NewFunctionTool(name, description string, parameters map[string]any) Tool {
+	return Tool{
+		Type: ToolTypeFunction,
+		Function: FunctionDefinition{
+			Name:        name,
+			Description: description,
+			Parameters:  parameters,
+		},
+	}
+}
+
+// ToolChoiceFunction возвращает значение tool_choice, требующее вызвать конкретную функцию
+func ToolChoiceFunction(name string) map[string]any {
+	return map[string]any{
+		"type":     ToolTypeFunction,
+		"function": map[string]string{"name": name},
+	}
+}
+
+// Режимы tool_choice
+const (
+	toolChoiceAuto     = "auto"
+	toolChoiceNone     = "none"
+	toolChoiceRequired = "required"
+	toolChoiceFunction = "function"
+)
+
+// toolChoiceMode разбирает значение tool_choice для провайдеров с другим форматом:
+// возвращает режим и имя функции для ToolChoiceFunction. Пустое значение означает auto.
+func toolChoiceMode(choice any) (mode, name string) {
+	switch v := choice.(type) {
+	case string:
+		if v == toolChoiceNone || v == toolChoiceRequired {
+			return v, ""
+		}
+	case map[string]any:
+		switch fn := v["function"].(type) {
+		case map[string]string:
+			return toolChoiceFunction, fn["name"]
+		case map[string]any:
+			name, _ := fn["name"].(string)
+			return toolChoiceFunction, name
+		}
+	}
+	return toolChoiceAuto, ""
+}
+
+// toolCallDelta - фрагмент вызова инструмента в потоковом ответе.
+// Фрагменты одного вызова объединяются по Index.
+type toolCallDelta struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// toolCallAccumulator собирает вызовы инструментов из потоковых фрагментов
+type toolCallAccumulator struct {
+	calls map[int]*chat.ToolCall
+}
+
+// add добавляет фрагменты к накопленным вызовам
+func (a *toolCallAccumulator) add(deltas []toolCallDelta) {
+	if a.calls == nil {
+		a.calls = make(map[int]*chat.ToolCall)
+	}
+
+	for _, d := range deltas {
+		call, ok := a.calls[d.Index]
+		if !ok {
+			call = &chat.ToolCall{Type: ToolTypeFunction}
+			a.calls[d.Index] = call
+		}
+		if d.ID != "" {
+			call.ID = d.ID
+		}
+		if d.Type != "" {
+			call.Type = d.Type
+		}
+		// Имя обычно приходит целиком в первом фрагменте, аргументы - по частям
+		call.Function.Name += d.Function.Name
+		call.Function.Arguments += d.Function.Arguments
+	}
+}
+
+// result возвращает собранные вызовы в порядке индексов
+func (a *toolCallAccumulator) result() []chat.ToolCall {
+	if len(a.calls) == 0 {
+		return nil
+	}
+
+	indexes := make([]int, 0, len(a.calls))
+	for i := range a.calls {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	calls := make([]chat.ToolCall, 0, len(indexes))
+	for _, i := range indexes {
+		calls = append(calls, *a.calls[i])
+	}
+	return calls
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"llm-client/internal/chat"
+	"llm-client/internal/fakellm"
+)
+
+func TestChatRequest_MarshalTools(t *testing.T) {
+	req := testRequest()
+	req.Tools = []Tool{NewFunctionTool("get_weather", "Погода в городе", map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"city": map[string]any{"type": "string"},
+		},
+		"required": []string{"city"},
+	})}
+	req.ToolChoice = ToolChoiceFunction("get_weather")
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	got := string(data)
+	for _, want := range []string{
+		`"tools":[{"type":"function","function":{"name":"get_weather","description":"Погода в городе"`,
+		`"tool_choice":{"function":{"name":"get_weather"},"type":"function"}`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("JSON %s should contain %s", got, want)
+		}
+	}
+
+	t.Run("omitted without tools", func(t *testing.T) {
+		data, _ := json.Marshal(testRequest())
+		if strings.Contains(string(data), "tool") {
+			t.Errorf("JSON %s should not contain tool fields", data)
+		}
+	})
+}
+
+func TestToolCallAccumulator(t *testing.T) {
+	delta := func(index int, id, name, args string) toolCallDelta {
+		d := toolCallDelta{Index: index, ID: id}
+		d.Function.Name = name
+		d.Function.Arguments = args
+		return d
+	}
+
+	var acc toolCallAccumulator
+	if acc.result() != nil {
+		t.Errorf("empty accumulator should return nil")
+	}
+
+	acc.add([]toolCallDelta{delta(1, "call_b", "get_time", "")})
+	acc.add([]toolCallDelta{delta(0, "call_a", "get_weather", `{"ci`)})
+	acc.add([]toolCallDelta{delta(0, "", "", `ty":"Moscow"}`), delta(1, "", "", `{}`)})
+
+	expected := []chat.ToolCall{
+		{ID: "call_a", Type: ToolTypeFunction, Function: chat.FunctionCall{Name: "get_weather", Arguments: `{"city":"Moscow"}`}},
+		{ID: "call_b", Type: ToolTypeFunction, Function: chat.FunctionCall{Name: "get_time", Arguments: `{}`}},
+	}
+	if got := acc.result(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("result() = %+v, want %+v", got, expected)
+	}
+}
+
+func TestClient_ChatStream_ToolCalls(t *testing.T) {
+	// fakellm передаёт аргументы вызова по частям, как OpenAI
+	server := fakellm.New(t, fakellm.WithReplies(fakellm.ToolCalls(chat.ToolCall{
+		ID:       "call_1",
+		Type:     ToolTypeFunction,
+		Function: chat.FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`},
+	})))
+
+	c := NewClient(server.URL, "/v1/chat/completions")
+
+	req := testRequest()
+	req.Stream = true
+	var last StreamChunk
+	for chunk := range c.ChatStream(context.Background(), req) {
+		if chunk.Error != nil {
+			t.Fatalf("unexpected stream error: %v", chunk.Error)
+		}
+		if chunk.Content != "" {
+			t.Errorf("unexpected content %q", chunk.Content)
+		}
+		last = chunk
+	}
+
+	if !last.Done {
+		t.Fatalf("stream should end with Done")
+	}
+	if last.FinishReason != "tool_calls" {
+		t.Errorf("FinishReason = %q, want %q", last.FinishReason, "tool_calls")
+	}
+
+	expected := []chat.ToolCall{{
+		ID:       "call_1",
+		Type:     ToolTypeFunction,
+		Function: chat.FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`},
+	}}
+	if !reflect.DeepEqual(last.ToolCalls, expected) {
+		t.Errorf("ToolCalls = %+v, want %+v", last.ToolCalls, expected)
+	}
+}
+
+func TestClient_ChatCompletion_ToolCalls(t *testing.T) {
+	server := fakellm.New(t, fakellm.WithReplies(fakellm.ToolCalls(chat.ToolCall{
+		ID:       "call_9",
+		Type:     ToolTypeFunction,
+		Function: chat.FunctionCall{Name: "get_time", Arguments: "{}"},
+	})))
+
+	c := NewClient(server.URL, "/v1/chat/completions")
+
+	req := testRequest()
+	req.Tools = []Tool{NewFunctionTool("get_time", "Текущее время", nil)}
+
+	completion, err := c.ChatCompletion(context.Background(), req)
+	if err != nil {
+		t.Fatalf("ChatCompletion() error = %v", err)
+	}
+	var sent ChatRequest
+	if err := json.Unmarshal(server.LastRequest().Body, &sent); err != nil {
+		t.Fatalf("decode request: %v", err)
+	}
+	if len(sent.Tools) != 1 || sent.Tools[0].Function.Name != "get_time" {
+		t.Errorf("request tools = %+v", sent.Tools)
+	}
+	if completion.FinishReason != "tool_calls" {
+		t.Errorf("FinishReason = %q, want %q", completion.FinishReason, "tool_calls")
+	}
+	if completion.Message.Role != chat.RoleAssistant {
+		t.Errorf("Role = %q, want %q", completion.Message.Role, chat.RoleAssistant)
+	}
+	calls := completion.Message.ToolCalls
+	if len(calls) != 1 || calls[0].ID != "call_9" || calls[0].Function.Name != "get_time" {
+		t.Errorf("ToolCalls = %+v", calls)
+	}
+}
@@ -0,0 +1,153 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"llm-client/internal/logger"
+)
+
+// RequestIDHeader - заголовок, в котором провайдеру передаётся идентификатор запроса
+const RequestIDHeader = "X-Request-ID"
+
+// Названия интервалов трассировки
+const (
+	// SpanChat - запрос без стриминга
+	SpanChat = "chat"
+	// SpanStream - потоковый запрос
+	SpanStream = "stream"
+)
+
+// Span - время одной попытки запроса к провайдеру
+type Span struct {
+	// Name - вид запроса: chat или stream
+	Name  string
+	Model string
+	// Attempt - номер попытки (повторы при временных ошибках - отдельные интервалы)
+	Attempt int
+	Start   time.Time
+	// FirstToken - время до первого токена от начала попытки (только stream)
+	FirstToken time.Duration
+	// Duration - полная длительность попытки
+	Duration time.Duration
+	// CompletionTokens - токены ответа из usage; без usage - количество полученных чанков
+	CompletionTokens int
+	// Err - ошибка попытки
+	Err error
+}
+
+// TokensPerSecond возвращает скорость генерации: токены ответа за время после первого токена
+// (для chat, где первый токен не измеряется, - за всю длительность)
+func (s Span) TokensPerSecond() float64 {
+	generation := s.Duration - s.FirstToken
+	if s.CompletionTokens == 0 || generation <= 0 {
+		return 0
+	}
+	return float64(s.CompletionTokens) / generation.Seconds()
+}
+
+// Trace собирает интервалы запросов одного хода диалога: ответ модели, повторы,
+// продолжения после вызовов инструментов и суммаризацию истории.
+// Безопасен для использования из нескольких горутин.
+type Trace struct {
+	// ID - идентификатор запроса, он же X-Request-ID и request_id в логе
+	ID    string
+	Start time.Time
+
+	mu    sync.Mutex
+	spans []Span
+	end   time.Time
+}
+
+// traceKey - ключ трассировки в context.Context
+type traceKey struct{}
+
+// StartTrace начинает трассировку хода. Идентификатор берётся из контекста
+// или создаётся новый; возвращённый контекст содержит и идентификатор, и трассировку.
+func StartTrace(ctx context.Context) (context.Context, *Trace) {
+	id := logger.RequestID(ctx)
+	if id == "" {
+		id = logger.NewRequestID()
+		ctx = logger.WithRequestID(ctx, id)
+	}
+	t := &Trace{ID: id, Start: time.Now()}
+	return context.WithValue(ctx, traceKey{}, t), t
+}
+
+// TraceFromContext возвращает трассировку из контекста (nil - не начата)
+func TraceFromContext(ctx context.Context) *Trace {
+	t, _ := ctx.Value(traceKey{}).(*Trace)
+	return t
+}
+
+// Add добавляет интервал
+func (t *Trace) Add(span Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.spans = append(t.spans, span)
+}
+
+// Spans возвращает копию интервалов в порядке добавления
+func (t *Trace) Spans() []Span {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Span(nil), t.spans...)
+}
+
+// Finish отмечает завершение хода; повторный вызов ничего не меняет
+func (t *Trace) Finish() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.end.IsZero() {
+		t.end = time.Now()
+	}
+}
+
+// Finished сообщает, завершён ли ход
+func (t *Trace) Finished() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return !t.end.IsZero()
+}
+
+// Duration возвращает длительность хода (для незавершённого - на текущий момент)
+func (t *Trace) Duration() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.end.IsZero() {
+		return time.Since(t.Start)
+	}
+	return t.end.Sub(t.Start)
+}
+
+// ensureRequestID добавляет идентификатор запроса в контекст, если его нет,
+// чтобы X-Request-ID и записи лога совпадали и у запросов вне хода диалога
+func ensureRequestID(ctx context.Context) context.Context {
+	if logger.RequestID(ctx) != "" {
+		return ctx
+	}
+	return logger.WithRequestID(ctx, logger.NewRequestID())
+}
+
+// recordSpan добавляет интервал в трассировку из контекста и записывает его в лог
+func (c *Client) recordSpan(ctx context.Context, span Span) {
+	if t := TraceFromContext(ctx); t != nil {
+		t.Add(span)
+	}
+	attrs := []any{
+		"span", span.Name,
+		"model", span.Model,
+		"attempt", span.Attempt,
+		"duration_ms", span.Duration.Milliseconds(),
+		"completion_tokens", span.CompletionTokens,
+		"tokens_per_second", span.TokensPerSecond(),
+	}
+	if span.Name == SpanStream {
+		attrs = append(attrs, "first_token_ms", span.FirstToken.Milliseconds())
+	}
+	if span.Err != nil {
+		attrs = append(attrs, "error", span.Err)
+	}
+	c.logger.InfoContext(ctx, "Request timing", attrs...)
+}
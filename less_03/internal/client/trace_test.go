@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"llm-client/internal/fakellm"
+	"llm-client/internal/logger"
+)
+
+func TestClient_ChatStream_RequestIDAndTrace(t *testing.T) {
+	server := fakellm.New(t, fakellm.WithReplies(
+		fakellm.Error(http.StatusServiceUnavailable, "overloaded"),
+		fakellm.Reply{Tokens: []string{"Hello", " world"}, TokenDelay: 20 * time.Millisecond},
+	))
+
+	c := NewClient(server.URL, "/v1/chat/completions", WithRetryPolicy(fastRetryPolicy(2)))
+	ctx, trace := StartTrace(context.Background())
+
+	req := testRequest()
+	req.Stream = true
+	for chunk := range c.ChatStream(ctx, req) {
+		if chunk.Error != nil {
+			t.Fatalf("stream error: %v", chunk.Error)
+		}
+	}
+
+	var ids []string
+	for _, r := range server.Requests() {
+		ids = append(ids, r.Header.Get(RequestIDHeader))
+	}
+	if len(ids) != 2 || ids[0] != trace.ID || ids[1] != trace.ID {
+		t.Errorf("X-Request-ID = %v, want %q on every attempt", ids, trace.ID)
+	}
+	if !strings.HasPrefix(trace.ID, "req_") {
+		t.Errorf("trace ID = %q", trace.ID)
+	}
+
+	spans := trace.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("spans = %+v, want failed attempt and stream", spans)
+	}
+	if spans[0].Err == nil || spans[0].Attempt != 1 {
+		t.Errorf("first span = %+v, want failed attempt 1", spans[0])
+	}
+	stream := spans[1]
+	if stream.Name != SpanStream || stream.Attempt != 2 || stream.Err != nil {
+		t.Errorf("stream span = %+v", stream)
+	}
+	if stream.FirstToken < 20*time.Millisecond || stream.Duration < stream.FirstToken+20*time.Millisecond {
+		t.Errorf("first token = %v, duration = %v", stream.FirstToken, stream.Duration)
+	}
+	if stream.CompletionTokens != 2 || stream.TokensPerSecond() <= 0 {
+		t.Errorf("tokens = %d, tps = %f, want usage tokens", stream.CompletionTokens, stream.TokensPerSecond())
+	}
+}
+
+func TestClient_ChatCompletion_Span(t *testing.T) {
+	server := fakellm.New(t)
+
+	c := NewClient(server.URL, "/v1/chat/completions")
+
+	// Идентификатор из контекста сохраняется
+	ctx, trace := StartTrace(logger.WithRequestID(context.Background(), "turn-42"))
+	if _, err := c.Chat(ctx, testRequest()); err != nil {
+		t.Fatal(err)
+	}
+	if header := server.LastRequest().Header.Get(RequestIDHeader); header != "turn-42" || trace.ID != "turn-42" {
+		t.Errorf("X-Request-ID = %q, trace ID = %q, want turn-42", header, trace.ID)
+	}
+	spans := trace.Spans()
+	if len(spans) != 1 || spans[0].Name != SpanChat || spans[0].CompletionTokens != 1 || spans[0].FirstToken != 0 {
+		t.Errorf("spans = %+v", spans)
+	}
+
+	// Без трассировки идентификатор всё равно создаётся
+	if _, err := c.Chat(context.Background(), testRequest()); err != nil {
+		t.Fatal(err)
+	}
+	if header := server.LastRequest().Header.Get(RequestIDHeader); !strings.HasPrefix(header, "req_") {
+		t.Errorf("X-Request-ID = %q, want generated ID", header)
+	}
+}
+
+func TestTrace_Finish(t *testing.T) {
+	_, trace := StartTrace(context.Background())
+	if trace.Finished() {
+		t.Fatal("new trace should not be finished")
+	}
+	trace.Finish()
+	d := trace.Duration()
+	time.Sleep(2 * time.Millisecond)
+	trace.Finish()
+	if !trace.Finished() || trace.Duration() != d {
+		t.Errorf("Duration() changed after Finish: %v -> %v", d, trace.Duration())
+	}
+
+	span := Span{CompletionTokens: 10, FirstToken: time.Second, Duration: 3 * time.Second}
+	if got := span.TokensPerSecond(); got != 5 {
+		t.Errorf("TokensPerSecond() = %f, want 5", got)
+	}
+}
@@ -0,0 +1,44 @@
+package client
+
+// Usage - расход токенов на запрос из поля usage ответа
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// StreamOptions - параметры потокового ответа
+type StreamOptions struct {
+	// IncludeUsage - прислать расход токенов отдельным чанком перед [DONE]
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// UsageHandler получает расход токенов каждого успешного запроса.
+// Вызывается из горутины клиента, поэтому должен быть потокобезопасным.
+type UsageHandler func(model string, usage Usage)
+
+// WithUsageHandler устанавливает обработчик расхода токенов
+func WithUsageHandler(handler UsageHandler) ClientOption {
+	return func(c *Client) {
+		c.onUsage = handler
+	}
+}
+
+// reportUsage передаёт расход токенов обработчику, если провайдер его вернул
+func (c *Client) reportUsage(model string, usage *Usage) {
+	if usage == nil {
+		return
+	}
+	if usage.TotalTokens == 0 {
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	}
+	c.logger.Debug("Token usage",
+		"model", model,
+		"prompt_tokens", usage.PromptTokens,
+		"completion_tokens", usage.CompletionTokens,
+		"total_tokens", usage.TotalTokens,
+	)
+	if c.onUsage != nil {
+		c.onUsage(model, *usage)
+	}
+}
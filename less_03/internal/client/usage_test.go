@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"llm-client/internal/fakellm"
+)
+
+func TestClient_ChatStream_Usage(t *testing.T) {
+	var got Usage
+	// fakellm отправляет usage отдельным чанком после finish_reason с пустым choices
+	server := fakellm.New(t, fakellm.WithReplies(fakellm.Tokens("Hi", " there", "!")))
+
+	c := NewClient(server.URL, "/v1/chat/completions", WithUsageHandler(func(model string, u Usage) {
+		if model != "test-model" {
+			t.Errorf("usage model = %q", model)
+		}
+		got = u
+	}))
+
+	req := testRequest()
+	req.Stream = true
+	var last StreamChunk
+	for chunk := range c.ChatStream(context.Background(), req) {
+		if chunk.Error != nil {
+			t.Fatalf("unexpected stream error: %v", chunk.Error)
+		}
+		last = chunk
+	}
+
+	if sent := server.LastRequest(); !sent.IncludeUsage {
+		t.Errorf("stream request should ask for usage, got %s", sent.Body)
+	}
+	// fakellm считает слова запроса и токены ответа
+	want := Usage{PromptTokens: 1, CompletionTokens: 3, TotalTokens: 4}
+	if !last.Done || last.FinishReason != "stop" {
+		t.Errorf("last chunk = %+v, want Done with finish reason", last)
+	}
+	if last.Usage == nil || *last.Usage != want {
+		t.Errorf("chunk Usage = %+v, want %+v", last.Usage, want)
+	}
+	if got != want {
+		t.Errorf("usage handler got %+v, want %+v", got, want)
+	}
+	if req.StreamOptions != nil {
+		t.Errorf("ChatStream should not modify caller request")
+	}
+}
+
+func TestClient_ChatCompletion_Usage(t *testing.T) {
+	// Провайдер не прислал total_tokens
+	server := fakellm.New(t, fakellm.WithReplies(fakellm.Raw(http.StatusOK, "application/json",
+		`{"choices":[{"index":0,"message":{"role":"assistant","content":"ok"}}],`+
+			`"usage":{"prompt_tokens":7,"completion_tokens":1}}`)))
+
+	calls := 0
+	c := NewClient(server.URL, "/v1/chat/completions", WithUsageHandler(func(string, Usage) { calls++ }))
+
+	completion, err := c.ChatCompletion(context.Background(), testRequest())
+	if err != nil {
+		t.Fatalf("ChatCompletion() error = %v", err)
+	}
+	// total_tokens вычисляется, если провайдер его не прислал
+	if completion.Usage == nil || completion.Usage.TotalTokens != 8 {
+		t.Errorf("Usage = %+v, want total 8", completion.Usage)
+	}
+	if calls != 1 {
+		t.Errorf("usage handler calls = %d, want 1", calls)
+	}
+}
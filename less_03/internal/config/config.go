@@ -7,8 +7,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	apperrors "llm-client/internal/errors"
 )
@@ -17,8 +20,64 @@ import (
 type ServerConfig struct {
 	// Address - адрес LLM сервера
 	Address string `mapstructure:"address" json:"address"`
-	// APIEndpoint - эндпоинт для запросов
+	// Provider - формат API провайдера (openai/anthropic/ollama/gemini)
+	Provider string `mapstructure:"provider" json:"provider"`
+	// APIEndpoint - эндпоинт для запросов (только для провайдера openai)
 	APIEndpoint string `mapstructure:"api_endpoint" json:"api_endpoint"`
+	// APIKey - источник API ключа: env:NAME, file:/path, cmd:command или keystore:name
+	// (по умолчанию - переменные окружения провайдера). Сам ключ в конфигурации не хранится.
+	APIKey string `mapstructure:"api_key" json:"api_key,omitempty"`
+	// Key - ключ, полученный из APIKey (ResolveAPIKey); никогда не сериализуется
+	Key Secret `json:"-"`
+	// Retry - политика повторных попыток запросов
+	Retry RetryConfig `mapstructure:"retry" json:"retry"`
+}
+
+// Провайдеры API
+const (
+	// ProviderOpenAI - OpenAI-compatible Chat Completions API
+	ProviderOpenAI = "openai"
+	// ProviderAnthropic - Anthropic Messages API
+	ProviderAnthropic = "anthropic"
+	// ProviderOllama - нативный API Ollama (/api/chat)
+	ProviderOllama = "ollama"
+	// ProviderGemini - Google Gemini API
+	ProviderGemini = "gemini"
+)
+
+// Providers - допустимые значения server.provider
+var Providers = []string{ProviderOpenAI, ProviderAnthropic, ProviderOllama, ProviderGemini}
+
+// isValidProvider проверяет имя провайдера
+func isValidProvider(name string) bool {
+	for _, p := range Providers {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RetryConfig содержит настройки повторных попыток при временных ошибках
+type RetryConfig struct {
+	// MaxAttempts - максимальное количество попыток (1 = без повторов)
+	MaxAttempts int `mapstructure:"max_attempts" json:"max_attempts"`
+	// BaseDelayMs - начальная задержка между попытками в миллисекундах
+	BaseDelayMs int `mapstructure:"base_delay_ms" json:"base_delay_ms"`
+	// MaxDelayMs - максимальная задержка между попытками в миллисекундах
+	MaxDelayMs int `mapstructure:"max_delay_ms" json:"max_delay_ms"`
+	// Jitter - доля случайного разброса задержки (0.0-1.0)
+	Jitter float64 `mapstructure:"jitter" json:"jitter"`
+}
+
+// BaseDelay возвращает начальную задержку как time.Duration
+func (r RetryConfig) BaseDelay() time.Duration {
+	return time.Duration(r.BaseDelayMs) * time.Millisecond
+}
+
+// MaxDelay возвращает максимальную задержку как time.Duration
+func (r RetryConfig) MaxDelay() time.Duration {
+	return time.Duration(r.MaxDelayMs) * time.Millisecond
 }
 
 // ModelConfig содержит настройки модели
@@ -35,6 +94,72 @@ type ModelConfig struct {
 	MaxTokens int `mapstructure:"max_tokens" json:"max_tokens"`
 	// Stream - использовать ли потоковый режим
 	Stream bool `mapstructure:"stream" json:"stream"`
+	// EnableTools - передавать ли модели встроенные инструменты (function calling)
+	EnableTools bool `mapstructure:"enable_tools" json:"enable_tools"`
+	// ContextWindow - размер контекстного окна модели в токенах (0 - без ограничения)
+	ContextWindow int `mapstructure:"context_window" json:"context_window"`
+	// ContextWindows - размеры контекстного окна для отдельных моделей (переопределяют ContextWindow)
+	ContextWindows map[string]int `mapstructure:"context_windows" json:"context_windows,omitempty"`
+	// ContextStrategy - стратегия сокращения истории (none/drop_oldest/last_n/summarize)
+	ContextStrategy string `mapstructure:"context_strategy" json:"context_strategy"`
+	// KeepLastTurns - сколько последних обменов репликами сохранять для last_n и summarize
+	KeepLastTurns int `mapstructure:"keep_last_turns" json:"keep_last_turns"`
+	// TokenizerDir - каталог словарей токенизатора (*.tiktoken), по умолчанию ~/.llm-client/tokenizers
+	TokenizerDir string `mapstructure:"tokenizer_dir" json:"tokenizer_dir,omitempty"`
+	// TokenizerEncodings - кодировки токенизатора для отдельных моделей (cl100k_base/o200k_base)
+	TokenizerEncodings map[string]string `mapstructure:"tokenizer_encodings" json:"tokenizer_encodings,omitempty"`
+}
+
+// Стратегии сокращения истории при приближении к пределу контекстного окна
+const (
+	ContextStrategyNone       = "none"
+	ContextStrategyDropOldest = "drop_oldest"
+	ContextStrategyLastN      = "last_n"
+	ContextStrategySummarize  = "summarize"
+)
+
+// ContextStrategies - допустимые значения model.context_strategy
+var ContextStrategies = []string{
+	ContextStrategyNone,
+	ContextStrategyDropOldest,
+	ContextStrategyLastN,
+	ContextStrategySummarize,
+}
+
+// ContextWindowFor возвращает размер контекстного окна для указанной модели
+func (m ModelConfig) ContextWindowFor(model string) int {
+	if window, ok := m.ContextWindows[model]; ok {
+		return window
+	}
+	return m.ContextWindow
+}
+
+// ContextBudget возвращает количество токенов, доступное для истории запроса:
+// контекстное окно за вычетом резерва под ответ (max_tokens или 1/8 окна).
+// 0 означает отсутствие ограничения.
+func (m ModelConfig) ContextBudget(model string) int {
+	window := m.ContextWindowFor(model)
+	if window <= 0 {
+		return 0
+	}
+	reserve := m.MaxTokens
+	if reserve <= 0 {
+		reserve = window / 8
+	}
+	if budget := window - reserve; budget > 0 {
+		return budget
+	}
+	return window / 2
+}
+
+// isValidContextStrategy проверяет название стратегии сокращения истории
+func isValidContextStrategy(name string) bool {
+	for _, s := range ContextStrategies {
+		if s == name {
+			return true
+		}
+	}
+	return false
 }
 
 // UIConfig содержит настройки пользовательского интерфейса
@@ -45,6 +170,22 @@ type UIConfig struct {
 	Theme string `mapstructure:"theme" json:"theme"`
 	// ScrollSpeed - скорость скролла
 	ScrollSpeed int `mapstructure:"scroll_speed" json:"scroll_speed"`
+	// Render - отображение ответов: markdown или plain (исходный текст)
+	Render string `mapstructure:"render" json:"render"`
+	// HistoryLimit - сколько последних запросов и команд хранить в истории ввода
+	// (отдельно для каждого раздела, 0 - история не ведётся)
+	HistoryLimit int `mapstructure:"history_limit" json:"history_limit"`
+}
+
+// Режимы отображения ответов (ui.render)
+const (
+	RenderPlain    = "plain"
+	RenderMarkdown = "markdown"
+)
+
+// isValidRender проверяет режим отображения ответов
+func isValidRender(mode string) bool {
+	return mode == RenderPlain || mode == RenderMarkdown
 }
 
 // LogConfig содержит настройки логирования
@@ -61,6 +202,222 @@ type LogConfig struct {
 	LogResponses bool `mapstructure:"log_responses" json:"log_responses"`
 	// LogStreamChunks - логировать чанки стрима
 	LogStreamChunks bool `mapstructure:"log_stream_chunks" json:"log_stream_chunks"`
+	// Redact - скрытие учётных и персональных данных в логе
+	Redact RedactConfig `mapstructure:"redact" json:"redact"`
+	// Format - формат записей: text или json
+	Format string `mapstructure:"format" json:"format"`
+	// MaxSizeMB - размер файла в мегабайтах, после которого начинается новый (0 - без ротации по размеру)
+	MaxSizeMB int `mapstructure:"max_size_mb" json:"max_size_mb"`
+	// MaxAgeHours - через сколько часов начинается новый файл (0 - без ротации по времени)
+	MaxAgeHours int `mapstructure:"max_age_hours" json:"max_age_hours"`
+	// MaxBackups - сколько архивных файлов хранить (0 - все)
+	MaxBackups int `mapstructure:"max_backups" json:"max_backups"`
+	// Compress - сжимать архивные файлы gzip
+	Compress bool `mapstructure:"compress" json:"compress"`
+}
+
+// Форматы файла логов (log.format)
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// Validate проверяет формат и настройки ротации
+func (l LogConfig) Validate() error {
+	if l.Format != "" && l.Format != LogFormatText && l.Format != LogFormatJSON {
+		return fmt.Errorf("log.format must be %q or %q, got %q", LogFormatText, LogFormatJSON, l.Format)
+	}
+	if l.MaxSizeMB < 0 {
+		return fmt.Errorf("log.max_size_mb cannot be negative, got %d", l.MaxSizeMB)
+	}
+	if l.MaxAgeHours < 0 {
+		return fmt.Errorf("log.max_age_hours cannot be negative, got %d", l.MaxAgeHours)
+	}
+	if l.MaxBackups < 0 {
+		return fmt.Errorf("log.max_backups cannot be negative, got %d", l.MaxBackups)
+	}
+	return l.Redact.Validate()
+}
+
+// Режимы скрытия данных в логе (log.redact.mode)
+const (
+	// RedactMask заменяет значение меткой [REDACTED:<правило>]
+	RedactMask = "mask"
+	// RedactHash заменяет значение началом SHA-256
+	RedactHash = "hash"
+	// RedactDrop удаляет атрибут с найденным значением
+	RedactDrop = "drop"
+)
+
+// RedactModes - допустимые режимы скрытия
+var RedactModes = []string{RedactMask, RedactHash, RedactDrop}
+
+// RedactConfig содержит настройки скрытия данных в логе.
+// Встроенные правила (токены, API ключи, e-mail, телефоны, номера карт) действуют всегда.
+type RedactConfig struct {
+	// Mode - режим скрытия: mask, hash, drop
+	Mode string `mapstructure:"mode" json:"mode"`
+	// Rules - дополнительные правила
+	Rules []RedactRule `mapstructure:"rules" json:"rules,omitempty"`
+}
+
+// RedactRule - пользовательское правило скрытия
+type RedactRule struct {
+	// Name - имя правила в метке замены
+	Name string `mapstructure:"name" json:"name"`
+	// Pattern - регулярное выражение; если в нём есть группа, скрывается только она
+	Pattern string `mapstructure:"pattern" json:"pattern"`
+}
+
+// isValidRedactMode проверяет режим скрытия данных
+func isValidRedactMode(mode string) bool {
+	for _, m := range RedactModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate проверяет режим и регулярные выражения правил
+func (r RedactConfig) Validate() error {
+	if r.Mode != "" && !isValidRedactMode(r.Mode) {
+		return fmt.Errorf("log.redact.mode must be one of: %s, got %q", strings.Join(RedactModes, ", "), r.Mode)
+	}
+	for i, rule := range r.Rules {
+		if rule.Pattern == "" {
+			return fmt.Errorf("log.redact.rules[%d].pattern cannot be empty", i)
+		}
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("log.redact.rules[%d].pattern: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// PricingConfig содержит цены моделей для подсчёта стоимости запросов
+type PricingConfig struct {
+	// Currency - валюта цен (только для отображения)
+	Currency string `mapstructure:"currency" json:"currency"`
+	// Models - цены по именам моделей; имя можно указывать без префикса провайдера
+	Models map[string]ModelPrice `mapstructure:"models" json:"models,omitempty"`
+}
+
+// ModelPrice - цена модели за 1 млн токенов
+type ModelPrice struct {
+	// Prompt - цена входных токенов
+	Prompt float64 `mapstructure:"prompt" json:"prompt"`
+	// Completion - цена выходных токенов
+	Completion float64 `mapstructure:"completion" json:"completion"`
+}
+
+// Price возвращает цену модели; имя сравнивается целиком, затем без префикса провайдера
+// ("openai/gpt-4o" -> "gpt-4o")
+func (p PricingConfig) Price(model string) (ModelPrice, bool) {
+	if price, ok := p.Models[model]; ok {
+		return price, true
+	}
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		price, ok := p.Models[model[i+1:]]
+		return price, ok
+	}
+	return ModelPrice{}, false
+}
+
+// Cost возвращает стоимость запроса; false, если цена модели не задана
+func (p PricingConfig) Cost(model string, promptTokens, completionTokens int) (float64, bool) {
+	price, ok := p.Price(model)
+	if !ok {
+		return 0, false
+	}
+	return (float64(promptTokens)*price.Prompt + float64(completionTokens)*price.Completion) / 1e6, true
+}
+
+// Validate проверяет таблицу цен
+func (p PricingConfig) Validate() error {
+	for name, price := range p.Models {
+		if price.Prompt < 0 || price.Completion < 0 {
+			return fmt.Errorf("pricing.models[%q] prices cannot be negative", name)
+		}
+	}
+	return nil
+}
+
+// Profile - именованный профиль подключения: сервер, источник ключа, модель и её параметры.
+// Нулевые параметры модели не переопределяют значения из model.
+type Profile struct {
+	// Address - адрес LLM сервера
+	Address string `mapstructure:"address" json:"address"`
+	// Provider - формат API провайдера (по умолчанию openai)
+	Provider string `mapstructure:"provider" json:"provider,omitempty"`
+	// APIEndpoint - эндпоинт для провайдера openai (по умолчанию /v1/chat/completions)
+	APIEndpoint string `mapstructure:"api_endpoint" json:"api_endpoint,omitempty"`
+	// APIKey - источник API ключа, как в server.api_key
+	APIKey string `mapstructure:"api_key" json:"api_key,omitempty"`
+	// Model - имя модели
+	Model string `mapstructure:"model" json:"model,omitempty"`
+	// SystemPrompt - системный промпт
+	SystemPrompt string `mapstructure:"system_prompt" json:"system_prompt,omitempty"`
+	// Temperature - температура генерации (0.0-2.0)
+	Temperature float64 `mapstructure:"temperature" json:"temperature,omitempty"`
+	// TopP - параметр top_p (0.0-1.0)
+	TopP float64 `mapstructure:"top_p" json:"top_p,omitempty"`
+	// MaxTokens - максимальное количество токенов в ответе
+	MaxTokens int `mapstructure:"max_tokens" json:"max_tokens,omitempty"`
+}
+
+// Validate проверяет профиль; имя профиля добавляет вызывающий код
+func (p Profile) Validate() error {
+	if p.Address == "" {
+		return fmt.Errorf("address cannot be empty")
+	}
+	if !strings.HasPrefix(p.Address, "http://") && !strings.HasPrefix(p.Address, "https://") {
+		return fmt.Errorf("address must start with http:// or https://")
+	}
+	if p.Provider != "" && !isValidProvider(p.Provider) {
+		return fmt.Errorf("provider must be one of: %s, got %q", strings.Join(Providers, ", "), p.Provider)
+	}
+	if p.APIKey != "" {
+		if _, _, err := ParseKeySource(p.APIKey); err != nil {
+			return fmt.Errorf("api_key %w", err)
+		}
+	}
+	if p.Temperature < 0 || p.Temperature > 2 {
+		return fmt.Errorf("temperature must be between 0.0 and 2.0, got %f", p.Temperature)
+	}
+	if p.TopP < 0 || p.TopP > 1 {
+		return fmt.Errorf("top_p must be between 0.0 and 1.0, got %f", p.TopP)
+	}
+	if p.MaxTokens < 0 {
+		return fmt.Errorf("max_tokens cannot be negative, got %d", p.MaxTokens)
+	}
+	return nil
+}
+
+// ServeConfig содержит настройки прокси-сервера (подкоманда serve)
+type ServeConfig struct {
+	// Listen - адрес, на котором прокси принимает запросы
+	Listen string `mapstructure:"listen" json:"listen"`
+	// APIKeys - ключи клиентов прокси по именам; без ключей доступ открыт
+	APIKeys map[string]string `mapstructure:"api_keys" json:"api_keys,omitempty"`
+}
+
+// Validate проверяет настройки прокси-сервера
+func (s ServeConfig) Validate() error {
+	if s.Listen == "" {
+		return fmt.Errorf("serve.listen cannot be empty")
+	}
+	owners := make(map[string]string, len(s.APIKeys))
+	for name, key := range s.APIKeys {
+		if key == "" {
+			return fmt.Errorf("serve.api_keys[%q] cannot be empty", name)
+		}
+		if other, ok := owners[key]; ok {
+			return fmt.Errorf("serve.api_keys[%q] duplicates key of %q", name, other)
+		}
+		owners[key] = name
+	}
+	return nil
 }
 
 // Config содержит полную конфигурацию приложения
@@ -73,6 +430,67 @@ type Config struct {
 	UI UIConfig `mapstructure:"ui" json:"ui"`
 	// Log - настройки логирования
 	Log LogConfig `mapstructure:"log" json:"log"`
+	// Pricing - цены моделей
+	Pricing PricingConfig `mapstructure:"pricing" json:"pricing"`
+	// Serve - настройки прокси-сервера
+	Serve ServeConfig `mapstructure:"serve" json:"serve"`
+	// Profiles - именованные профили подключения
+	Profiles map[string]Profile `mapstructure:"profiles" json:"profiles,omitempty"`
+
+	// ActiveProfile - применённый профиль (не сохраняется: его настройки уже в server и model)
+	ActiveProfile string `json:"-"`
+}
+
+// ProfileNames возвращает имена профилей в алфавитном порядке
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyProfile переносит настройки профиля в server и model.
+// Настройки сервера заменяются целиком, параметры модели - только заданные в профиле.
+func (c *Config) ApplyProfile(name string) error {
+	p, ok := c.Profiles[name]
+	if !ok {
+		return apperrors.NewConfigError("UNKNOWN_PROFILE", fmt.Sprintf("unknown profile %q", name), nil).
+			WithContext("profiles", strings.Join(c.ProfileNames(), ", "))
+	}
+
+	defaults := DefaultConfig().Server
+	c.Server.Address = p.Address
+	c.Server.Provider = p.Provider
+	if c.Server.Provider == "" {
+		c.Server.Provider = defaults.Provider
+	}
+	c.Server.APIEndpoint = p.APIEndpoint
+	if c.Server.APIEndpoint == "" {
+		c.Server.APIEndpoint = defaults.APIEndpoint
+	}
+	c.Server.APIKey = p.APIKey
+	c.Server.Key = ""
+
+	if p.Model != "" {
+		c.Model.Name = p.Model
+	}
+	if p.SystemPrompt != "" {
+		c.Model.SystemPrompt = p.SystemPrompt
+	}
+	if p.Temperature != 0 {
+		c.Model.Temperature = p.Temperature
+	}
+	if p.TopP != 0 {
+		c.Model.TopP = p.TopP
+	}
+	if p.MaxTokens != 0 {
+		c.Model.MaxTokens = p.MaxTokens
+	}
+
+	c.ActiveProfile = name
+	return nil
 }
 
 // EnvConfigPrefix префикс для переменных окружения
@@ -83,7 +501,14 @@ func DefaultConfig() *Config {
 	return &Config{
 		Server: ServerConfig{
 			Address:     "http://localhost:11434",
+			Provider:    ProviderOpenAI,
 			APIEndpoint: "/v1/chat/completions",
+			Retry: RetryConfig{
+				MaxAttempts: 3,
+				BaseDelayMs: 500,
+				MaxDelayMs:  10000,
+				Jitter:      0.2,
+			},
 		},
 		Model: ModelConfig{
 			Name:         "llama3",
@@ -92,11 +517,19 @@ func DefaultConfig() *Config {
 			TopP:         0.9,
 			MaxTokens:    0,
 			Stream:       true,
+			EnableTools:  false,
+
+			// Без заданного окна история не сокращается: лимит модели неизвестен
+			ContextWindow:   0,
+			ContextStrategy: ContextStrategyDropOldest,
+			KeepLastTurns:   10,
 		},
 		UI: UIConfig{
 			ShowTimestamps: false,
 			Theme:          "dark",
 			ScrollSpeed:    10,
+			Render:         RenderMarkdown,
+			HistoryLimit:   1000,
 		},
 		Log: LogConfig{
 			Enabled:         false,
@@ -105,12 +538,29 @@ func DefaultConfig() *Config {
 			LogRequests:     true,
 			LogResponses:    true,
 			LogStreamChunks: false,
+			Redact:          RedactConfig{Mode: RedactMask},
+			Format:          LogFormatText,
+			MaxSizeMB:       10,
+			MaxBackups:      5,
+		},
+		Pricing: PricingConfig{
+			Currency: "USD",
+		},
+		Serve: ServeConfig{
+			Listen: "127.0.0.1:8080",
 		},
 	}
 }
 
-// Load загружает конфигурацию из файла и переменных окружения
+// Load загружает конфигурацию из файла и переменных окружения.
+// Профиль выбирается переменной LLM_CLIENT_PROFILE.
 func Load(path string) (*Config, error) {
+	return LoadProfile(path, "")
+}
+
+// LoadProfile загружает конфигурацию и применяет профиль (пустое имя - профиль из LLM_CLIENT_PROFILE).
+// Профиль применяется до переменных окружения, поэтому они переопределяют его настройки.
+func LoadProfile(path, profile string) (*Config, error) {
 	cfg := DefaultConfig()
 
 	// Если путь не указан, ищем в стандартных местах
@@ -125,6 +575,15 @@ func Load(path string) (*Config, error) {
 		}
 	}
 
+	if profile == "" {
+		profile = os.Getenv(EnvConfigPrefix + "_PROFILE")
+	}
+	if profile != "" {
+		if err := cfg.ApplyProfile(profile); err != nil {
+			return nil, err
+		}
+	}
+
 	// Переопределяем из переменных окружения
 	if err := loadFromEnv(cfg); err != nil {
 		return nil, err
@@ -186,9 +645,17 @@ func loadFromEnv(cfg *Config) error {
 	if val := os.Getenv(EnvConfigPrefix + "_ADDRESS"); val != "" {
 		cfg.Server.Address = val
 	}
+	if val := os.Getenv(EnvConfigPrefix + "_PROVIDER"); val != "" {
+		cfg.Server.Provider = val
+	}
 	if val := os.Getenv(EnvConfigPrefix + "_API_ENDPOINT"); val != "" {
 		cfg.Server.APIEndpoint = val
 	}
+	if val := os.Getenv(EnvConfigPrefix + "_RETRY_MAX_ATTEMPTS"); val != "" {
+		if v, err := strconv.Atoi(val); err == nil {
+			cfg.Server.Retry.MaxAttempts = v
+		}
+	}
 	if val := os.Getenv(EnvConfigPrefix + "_MODEL"); val != "" {
 		cfg.Model.Name = val
 	}
@@ -213,6 +680,20 @@ func loadFromEnv(cfg *Config) error {
 	if val := os.Getenv(EnvConfigPrefix + "_STREAM"); val != "" {
 		cfg.Model.Stream = strings.ToLower(val) == "true" || val == "1"
 	}
+	if val := os.Getenv(EnvConfigPrefix + "_ENABLE_TOOLS"); val != "" {
+		cfg.Model.EnableTools = strings.ToLower(val) == "true" || val == "1"
+	}
+	if val := os.Getenv(EnvConfigPrefix + "_CONTEXT_WINDOW"); val != "" {
+		if v, err := strconv.Atoi(val); err == nil {
+			cfg.Model.ContextWindow = v
+		}
+	}
+	if val := os.Getenv(EnvConfigPrefix + "_CONTEXT_STRATEGY"); val != "" {
+		cfg.Model.ContextStrategy = val
+	}
+	if val := os.Getenv(EnvConfigPrefix + "_TOKENIZER_DIR"); val != "" {
+		cfg.Model.TokenizerDir = val
+	}
 	if val := os.Getenv(EnvConfigPrefix + "_THEME"); val != "" {
 		cfg.UI.Theme = val
 	}
@@ -221,6 +702,17 @@ func loadFromEnv(cfg *Config) error {
 			cfg.UI.ScrollSpeed = v
 		}
 	}
+	if val := os.Getenv(EnvConfigPrefix + "_RENDER"); val != "" {
+		cfg.UI.Render = val
+	}
+	if val := os.Getenv(EnvConfigPrefix + "_HISTORY_LIMIT"); val != "" {
+		if v, err := strconv.Atoi(val); err == nil {
+			cfg.UI.HistoryLimit = v
+		}
+	}
+	if val := os.Getenv(EnvConfigPrefix + "_SERVE_LISTEN"); val != "" {
+		cfg.Serve.Listen = val
+	}
 	if val := os.Getenv(EnvConfigPrefix + "_LOG_ENABLED"); val != "" {
 		cfg.Log.Enabled = strings.ToLower(val) == "true" || val == "1"
 	}
@@ -230,6 +722,9 @@ func loadFromEnv(cfg *Config) error {
 	if val := os.Getenv(EnvConfigPrefix + "_LOG_LEVEL"); val != "" {
 		cfg.Log.Level = val
 	}
+	if val := os.Getenv(EnvConfigPrefix + "_LOG_FORMAT"); val != "" {
+		cfg.Log.Format = val
+	}
 
 	// Специальная обработка переменной LLM_CLIENT_LOG
 	if logPath := os.Getenv("LLM_CLIENT_LOG"); logPath != "" {
@@ -251,6 +746,20 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("server.address must start with http:// or https://")
 	}
 
+	if !isValidProvider(c.Server.Provider) {
+		return fmt.Errorf("server.provider must be one of: %s, got %q", strings.Join(Providers, ", "), c.Server.Provider)
+	}
+
+	if c.Server.APIKey != "" {
+		if _, _, err := ParseKeySource(c.Server.APIKey); err != nil {
+			return fmt.Errorf("server.api_key %w", err)
+		}
+	}
+
+	if err := c.Server.Retry.Validate(); err != nil {
+		return err
+	}
+
 	if c.Model.Temperature < 0 || c.Model.Temperature > 2 {
 		return fmt.Errorf("model.temperature must be between 0.0 and 2.0, got %f", c.Model.Temperature)
 	}
@@ -263,11 +772,33 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("model.name cannot be empty")
 	}
 
+	if err := c.Model.validateContext(); err != nil {
+		return err
+	}
+
+	if err := c.Pricing.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Serve.Validate(); err != nil {
+		return err
+	}
+
+	for _, name := range c.ProfileNames() {
+		if err := c.Profiles[name].Validate(); err != nil {
+			return fmt.Errorf("profiles[%q]: %w", name, err)
+		}
+	}
+
 	validLevels := map[string]bool{"debug": true, "info": true, "warn": true, "error": true}
 	if !validLevels[c.Log.Level] {
 		return fmt.Errorf("log.level must be one of: debug, info, warn, error, got %q", c.Log.Level)
 	}
 
+	if err := c.Log.Validate(); err != nil {
+		return err
+	}
+
 	if c.UI.Theme != "" && c.UI.Theme != "light" && c.UI.Theme != "dark" {
 		return fmt.Errorf("ui.theme must be 'light' or 'dark', got %q", c.UI.Theme)
 	}
@@ -276,6 +807,51 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("ui.scroll_speed must be between 1 and 100, got %d", c.UI.ScrollSpeed)
 	}
 
+	if !isValidRender(c.UI.Render) {
+		return fmt.Errorf("ui.render must be %q or %q, got %q", RenderPlain, RenderMarkdown, c.UI.Render)
+	}
+
+	if c.UI.HistoryLimit < 0 {
+		return fmt.Errorf("ui.history_limit must be non-negative, got %d", c.UI.HistoryLimit)
+	}
+
+	return nil
+}
+
+// Validate проверяет настройки повторных попыток
+func (r RetryConfig) Validate() error {
+	if r.MaxAttempts < 1 || r.MaxAttempts > 10 {
+		return fmt.Errorf("server.retry.max_attempts must be between 1 and 10, got %d", r.MaxAttempts)
+	}
+	if r.BaseDelayMs < 0 {
+		return fmt.Errorf("server.retry.base_delay_ms cannot be negative, got %d", r.BaseDelayMs)
+	}
+	if r.MaxDelayMs < r.BaseDelayMs {
+		return fmt.Errorf("server.retry.max_delay_ms must be >= base_delay_ms, got %d", r.MaxDelayMs)
+	}
+	if r.Jitter < 0 || r.Jitter > 1 {
+		return fmt.Errorf("server.retry.jitter must be between 0.0 and 1.0, got %f", r.Jitter)
+	}
+	return nil
+}
+
+// validateContext проверяет настройки контекстного окна
+func (m ModelConfig) validateContext() error {
+	if m.ContextWindow < 0 {
+		return fmt.Errorf("model.context_window cannot be negative, got %d", m.ContextWindow)
+	}
+	for name, window := range m.ContextWindows {
+		if window < 0 {
+			return fmt.Errorf("model.context_windows[%q] cannot be negative, got %d", name, window)
+		}
+	}
+	if !isValidContextStrategy(m.ContextStrategy) {
+		return fmt.Errorf("model.context_strategy must be one of: %s, got %q",
+			strings.Join(ContextStrategies, ", "), m.ContextStrategy)
+	}
+	if m.KeepLastTurns < 1 {
+		return fmt.Errorf("model.keep_last_turns must be at least 1, got %d", m.KeepLastTurns)
+	}
 	return nil
 }
 
@@ -327,6 +903,10 @@ type RuntimeConfig struct {
 	Temperature  float64
 	TopP         float64
 	Stream       bool
+	// ContextStrategy - стратегия сокращения истории
+	ContextStrategy string
+	// Render - отображение ответов (plain/markdown)
+	Render string
 }
 
 // NewRuntimeConfig создаёт RuntimeConfig из Config
@@ -337,6 +917,9 @@ func NewRuntimeConfig(cfg *Config) *RuntimeConfig {
 		Temperature:  cfg.Model.Temperature,
 		TopP:         cfg.Model.TopP,
 		Stream:       cfg.Model.Stream,
+
+		ContextStrategy: cfg.Model.ContextStrategy,
+		Render:          cfg.UI.Render,
 	}
 }
 
@@ -382,6 +965,18 @@ func (c *RuntimeConfig) SetParam(name, value string) error {
 			return fmt.Errorf("invalid stream value: %s (use true/false)", value)
 		}
 
+	case "context_strategy", "context":
+		if !isValidContextStrategy(value) {
+			return fmt.Errorf("context_strategy must be one of: %s", strings.Join(ContextStrategies, ", "))
+		}
+		c.ContextStrategy = value
+
+	case "render":
+		if !isValidRender(value) {
+			return fmt.Errorf("render must be %q or %q", RenderPlain, RenderMarkdown)
+		}
+		c.Render = value
+
 	default:
 		return fmt.Errorf("unknown parameter: %s", name)
 	}
@@ -413,4 +1008,6 @@ func (c *RuntimeConfig) ApplyToConfig(cfg *Config) {
 	cfg.Model.Temperature = c.Temperature
 	cfg.Model.TopP = c.TopP
 	cfg.Model.Stream = c.Stream
+	cfg.Model.ContextStrategy = c.ContextStrategy
+	cfg.UI.Render = c.Render
 }
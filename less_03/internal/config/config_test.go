@@ -3,7 +3,9 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -27,9 +29,18 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.Model.Stream != true {
 		t.Errorf("Model.Stream = %v, want true", cfg.Model.Stream)
 	}
+	if cfg.Model.EnableTools {
+		t.Errorf("Model.EnableTools should be disabled by default")
+	}
 	if cfg.Log.Level != "info" {
 		t.Errorf("Log.Level = %q, want %q", cfg.Log.Level, "info")
 	}
+	if cfg.Server.Retry.MaxAttempts != 3 {
+		t.Errorf("Server.Retry.MaxAttempts = %d, want %d", cfg.Server.Retry.MaxAttempts, 3)
+	}
+	if cfg.Server.Retry.BaseDelay() != 500*time.Millisecond {
+		t.Errorf("Server.Retry.BaseDelay() = %v, want %v", cfg.Server.Retry.BaseDelay(), 500*time.Millisecond)
+	}
 }
 
 func TestConfig_Validate(t *testing.T) {
@@ -99,6 +110,57 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "redact hash mode with custom rule",
+			modify: func(c *Config) {
+				c.Log.Redact = RedactConfig{Mode: RedactHash, Rules: []RedactRule{{Name: "inn", Pattern: `\b\d{12}\b`}}}
+			},
+			wantErr: false,
+		},
+		{
+			name: "json log with rotation",
+			modify: func(c *Config) {
+				c.Log.Format = LogFormatJSON
+				c.Log.MaxAgeHours = 24
+				c.Log.Compress = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid log format",
+			modify: func(c *Config) {
+				c.Log.Format = "xml"
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative log max size",
+			modify: func(c *Config) {
+				c.Log.MaxSizeMB = -1
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative log max backups",
+			modify: func(c *Config) {
+				c.Log.MaxBackups = -1
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid redact mode",
+			modify: func(c *Config) {
+				c.Log.Redact.Mode = "blur"
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid redact pattern",
+			modify: func(c *Config) {
+				c.Log.Redact.Rules = []RedactRule{{Name: "broken", Pattern: `(\d+`}}
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalid theme",
 			modify: func(c *Config) {
@@ -106,6 +168,27 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "invalid render mode",
+			modify: func(c *Config) {
+				c.UI.Render = "html"
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative history limit",
+			modify: func(c *Config) {
+				c.UI.HistoryLimit = -1
+			},
+			wantErr: true,
+		},
+		{
+			name: "history disabled",
+			modify: func(c *Config) {
+				c.UI.HistoryLimit = 0
+			},
+			wantErr: false,
+		},
 		{
 			name: "scroll speed too low",
 			modify: func(c *Config) {
@@ -120,6 +203,120 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "retry disabled",
+			modify: func(c *Config) {
+				c.Server.Retry.MaxAttempts = 1
+			},
+			wantErr: false,
+		},
+		{
+			name: "retry attempts zero",
+			modify: func(c *Config) {
+				c.Server.Retry.MaxAttempts = 0
+			},
+			wantErr: true,
+		},
+		{
+			name: "retry max delay below base",
+			modify: func(c *Config) {
+				c.Server.Retry.BaseDelayMs = 2000
+				c.Server.Retry.MaxDelayMs = 1000
+			},
+			wantErr: true,
+		},
+		{
+			name: "retry jitter too high",
+			modify: func(c *Config) {
+				c.Server.Retry.Jitter = 1.5
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative context window",
+			modify: func(c *Config) {
+				c.Model.ContextWindow = -1
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative per-model context window",
+			modify: func(c *Config) {
+				c.Model.ContextWindows = map[string]int{"gpt-4o": -1}
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown context strategy",
+			modify: func(c *Config) {
+				c.Model.ContextStrategy = "forget_all"
+			},
+			wantErr: true,
+		},
+		{
+			name: "keep last turns zero",
+			modify: func(c *Config) {
+				c.Model.ContextStrategy = ContextStrategyLastN
+				c.Model.KeepLastTurns = 0
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative price",
+			modify: func(c *Config) {
+				c.Pricing.Models = map[string]ModelPrice{"gpt-4o": {Prompt: -1}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown provider",
+			modify: func(c *Config) {
+				c.Server.Provider = "azure"
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty serve listen",
+			modify: func(c *Config) {
+				c.Serve.Listen = ""
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty proxy key",
+			modify: func(c *Config) {
+				c.Serve.APIKeys = map[string]string{"ci": ""}
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicate proxy key",
+			modify: func(c *Config) {
+				c.Serve.APIKeys = map[string]string{"ci": "secret", "ide": "secret"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid profile",
+			modify: func(c *Config) {
+				c.Profiles = map[string]Profile{"local": {Address: "http://localhost:11434", Model: "llama3"}}
+			},
+			wantErr: false,
+		},
+		{
+			name: "profile without address",
+			modify: func(c *Config) {
+				c.Profiles = map[string]Profile{"local": {Model: "llama3"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "profile with unknown provider",
+			modify: func(c *Config) {
+				c.Profiles = map[string]Profile{"cloud": {Address: "https://api.example.com", Provider: "azure"}}
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -135,6 +332,96 @@ func TestConfig_Validate(t *testing.T) {
 	}
 }
 
+func TestConfig_Validate_NamesBrokenProfile(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Profiles = map[string]Profile{
+		"local": {Address: "http://localhost:11434"},
+		"vllm":  {Address: "vllm.internal:8000"},
+	}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() should fail for profile without scheme")
+	}
+	if !strings.Contains(err.Error(), `profiles["vllm"]`) {
+		t.Errorf("error %q should name the broken profile", err)
+	}
+}
+
+func TestConfig_ApplyProfile(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Server.APIEndpoint = "/custom"
+	cfg.Profiles = map[string]Profile{
+		"routerai": {
+			Address:     "https://routerai.example.com",
+			APIKey:      "env:ROUTERAI_API_KEY",
+			Model:       "openai/gpt-4o",
+			Temperature: 0.2,
+		},
+	}
+
+	if err := cfg.ApplyProfile("routerai"); err != nil {
+		t.Fatalf("ApplyProfile() error = %v", err)
+	}
+	if cfg.Server.Address != "https://routerai.example.com" || cfg.Server.APIKey != "env:ROUTERAI_API_KEY" {
+		t.Errorf("Server = %+v", cfg.Server)
+	}
+	// Настройки сервера заменяются целиком, незаданные - значениями по умолчанию
+	if cfg.Server.APIEndpoint != "/v1/chat/completions" || cfg.Server.Provider != ProviderOpenAI {
+		t.Errorf("APIEndpoint = %q, Provider = %q, want defaults", cfg.Server.APIEndpoint, cfg.Server.Provider)
+	}
+	if cfg.Model.Name != "openai/gpt-4o" || cfg.Model.Temperature != 0.2 {
+		t.Errorf("Model = %q, Temperature = %f", cfg.Model.Name, cfg.Model.Temperature)
+	}
+	// Незаданные параметры модели не меняются
+	if cfg.Model.TopP != 0.9 {
+		t.Errorf("TopP = %f, want 0.9", cfg.Model.TopP)
+	}
+	if cfg.ActiveProfile != "routerai" {
+		t.Errorf("ActiveProfile = %q", cfg.ActiveProfile)
+	}
+
+	if err := cfg.ApplyProfile("missing"); err == nil {
+		t.Error("ApplyProfile() should fail for unknown profile")
+	}
+}
+
+func TestLoadProfile(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	configContent := `{
+		"profiles": {
+			"local": {"address": "http://localhost:11434", "model": "llama3"},
+			"vllm": {"address": "http://vllm.internal:8000", "model": "qwen2.5"}
+		}
+	}`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+
+	t.Setenv("LLM_CLIENT_PROFILE", "local")
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.ActiveProfile != "local" || cfg.Model.Name != "llama3" {
+		t.Errorf("profile from env: ActiveProfile = %q, Model = %q", cfg.ActiveProfile, cfg.Model.Name)
+	}
+
+	// Явно заданный профиль важнее переменной окружения
+	cfg, err = LoadProfile(configPath, "vllm")
+	if err != nil {
+		t.Fatalf("LoadProfile() error = %v", err)
+	}
+	if cfg.Server.Address != "http://vllm.internal:8000" || cfg.Model.Name != "qwen2.5" {
+		t.Errorf("Address = %q, Model = %q", cfg.Server.Address, cfg.Model.Name)
+	}
+
+	if _, err := LoadProfile(configPath, "missing"); err == nil {
+		t.Error("LoadProfile() should fail for unknown profile")
+	}
+}
+
 func TestLoadFromFile(t *testing.T) {
 	// Создаём временный файл конфигурации
 	tmpDir := t.TempDir()
@@ -411,6 +698,34 @@ func TestRuntimeConfig_SetParam(t *testing.T) {
 			wantErr: true,
 			check:   func(c *RuntimeConfig) bool { return true },
 		},
+		{
+			name:    "set context strategy",
+			param:   "context_strategy",
+			value:   "summarize",
+			wantErr: false,
+			check:   func(c *RuntimeConfig) bool { return c.ContextStrategy == ContextStrategySummarize },
+		},
+		{
+			name:    "set render",
+			param:   "render",
+			value:   "plain",
+			wantErr: false,
+			check:   func(c *RuntimeConfig) bool { return c.Render == RenderPlain },
+		},
+		{
+			name:    "invalid render",
+			param:   "render",
+			value:   "html",
+			wantErr: true,
+			check:   func(c *RuntimeConfig) bool { return true },
+		},
+		{
+			name:    "invalid context strategy",
+			param:   "context",
+			value:   "forget_all",
+			wantErr: true,
+			check:   func(c *RuntimeConfig) bool { return true },
+		},
 		{
 			name:    "unknown parameter",
 			param:   "unknown",
@@ -442,6 +757,58 @@ func TestRuntimeConfig_SetParam(t *testing.T) {
 	}
 }
 
+func TestDefaultConfig_NoContextLimit(t *testing.T) {
+	m := DefaultConfig().Model
+	if got := m.ContextBudget("deepseek/deepseek-v3.2"); got != 0 {
+		t.Errorf("ContextBudget() = %d, want 0: history must not be trimmed without a configured window", got)
+	}
+}
+
+func TestModelConfig_ContextBudget(t *testing.T) {
+	m := DefaultConfig().Model
+	m.ContextWindow = 8192
+	m.ContextWindows = map[string]int{"gpt-4o": 128000, "unlimited": 0}
+
+	tests := []struct {
+		name      string
+		model     string
+		maxTokens int
+		want      int
+	}{
+		{"default window reserves 1/8", "llama3", 0, 8192 - 1024},
+		{"per-model window", "gpt-4o", 0, 128000 - 16000},
+		{"max_tokens reserve", "llama3", 2000, 8192 - 2000},
+		{"reserve larger than window", "llama3", 10000, 4096},
+		{"unlimited", "unlimited", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m.MaxTokens = tt.maxTokens
+			if got := m.ContextBudget(tt.model); got != tt.want {
+				t.Errorf("ContextBudget(%q) = %d, want %d", tt.model, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPricingConfig_Cost(t *testing.T) {
+	p := PricingConfig{Models: map[string]ModelPrice{
+		"gpt-4o": {Prompt: 2.5, Completion: 10},
+	}}
+
+	cost, ok := p.Cost("gpt-4o", 1000, 500)
+	if !ok || cost != 0.0075 {
+		t.Errorf("Cost() = %v, %v, want 0.0075, true", cost, ok)
+	}
+	if _, ok := p.Cost("openai/gpt-4o", 1, 1); !ok {
+		t.Errorf("Cost() should match model name without provider prefix")
+	}
+	if _, ok := p.Cost("llama3", 1, 1); ok {
+		t.Errorf("Cost() should report unknown price")
+	}
+}
+
 func TestRuntimeConfig_String(t *testing.T) {
 	rc := &RuntimeConfig{
 		Model:       "test-model",
@@ -0,0 +1,297 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	apperrors "llm-client/internal/errors"
+)
+
+// Типы источников API ключа (server.api_key, profiles.*.api_key)
+const (
+	// KeySourceEnv - переменная окружения: env:ROUTERAI_API_KEY
+	KeySourceEnv = "env"
+	// KeySourceFile - файл, доступный только владельцу: file:~/.config/routerai.key
+	KeySourceFile = "file"
+	// KeySourceCmd - вывод команды: cmd:pass show routerai
+	KeySourceCmd = "cmd"
+	// KeySourceKeystore - запись зашифрованного хранилища: keystore:routerai
+	KeySourceKeystore = "keystore"
+)
+
+// KeySources - допустимые типы источников ключа
+var KeySources = []string{KeySourceEnv, KeySourceFile, KeySourceCmd, KeySourceKeystore}
+
+// EnvKeystorePassphrase - переменная окружения с паролем хранилища ключей
+const EnvKeystorePassphrase = EnvConfigPrefix + "_KEYSTORE_PASSPHRASE"
+
+// keyCommandTimeout ограничивает время выполнения команды cmd:
+const keyCommandTimeout = 10 * time.Second
+
+// redacted заменяет значение ключа при выводе
+const redacted = "[REDACTED]"
+
+// Secret - значение API ключа. Не сериализуется в JSON и скрывается при выводе через fmt и slog.
+type Secret string
+
+// Reveal возвращает значение ключа для передачи провайдеру
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+// String скрывает значение ключа
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// GoString скрывает значение ключа в выводе %#v
+func (s Secret) GoString() string {
+	return s.String()
+}
+
+// LogValue скрывает значение ключа в логе
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
+
+// MarshalJSON никогда не записывает значение ключа
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`""`), nil
+}
+
+// ParseKeySource разбирает ссылку на ключ вида "<тип>:<значение>"
+func ParseKeySource(source string) (kind, ref string, err error) {
+	kind, ref, ok := strings.Cut(source, ":")
+	if !ok || !isValidKeySource(kind) {
+		return "", "", fmt.Errorf("must be a key source (%s), plain keys are not stored in config",
+			strings.Join(keySourceExamples, ", "))
+	}
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return "", "", fmt.Errorf("must specify a value after %q", kind+":")
+	}
+	return kind, ref, nil
+}
+
+// keySourceExamples - примеры источников для сообщений об ошибках
+var keySourceExamples = []string{"env:NAME", "file:/path", "cmd:command", "keystore:name"}
+
+// isValidKeySource проверяет тип источника ключа
+func isValidKeySource(kind string) bool {
+	for _, k := range KeySources {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// usesKeystore проверяет, ссылается ли источник на хранилище ключей
+func usesKeystore(source string) bool {
+	kind, _, err := ParseKeySource(source)
+	return err == nil && kind == KeySourceKeystore
+}
+
+// UsesKeystore сообщает, ссылаются ли server.api_key или профили на хранилище ключей
+func (c *Config) UsesKeystore() bool {
+	if usesKeystore(c.Server.APIKey) {
+		return true
+	}
+	for _, p := range c.Profiles {
+		if usesKeystore(p.APIKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveAPIKey получает ключ из server.api_key и сохраняет его в Server.Key.
+// Без server.api_key ключ остаётся пустым: клиент возьмёт его из переменных провайдера.
+func (c *Config) ResolveAPIKey(r *KeyResolver) error {
+	c.Server.Key = ""
+	if c.Server.APIKey == "" {
+		return nil
+	}
+	key, err := r.Resolve(c.Server.APIKey)
+	if err != nil {
+		return err
+	}
+	c.Server.Key = Secret(key)
+	return nil
+}
+
+// KeyResolver получает API ключи из источников. Хранилище ключей открывается
+// при первом обращении и остаётся открытым до завершения программы.
+type KeyResolver struct {
+	// KeystorePath - путь к хранилищу ключей
+	KeystorePath string
+	// Passphrase возвращает пароль хранилища; вызывается один раз при его открытии
+	Passphrase func() (string, error)
+
+	mu       sync.Mutex
+	keystore *Keystore
+}
+
+// NewKeyResolver создаёт resolver с хранилищем по умолчанию и паролем из LLM_CLIENT_KEYSTORE_PASSPHRASE
+func NewKeyResolver() *KeyResolver {
+	path, _ := DefaultKeystorePath()
+	return &KeyResolver{
+		KeystorePath: path,
+		Passphrase:   PassphraseFromEnv,
+	}
+}
+
+// PassphraseFromEnv читает пароль хранилища из LLM_CLIENT_KEYSTORE_PASSPHRASE
+func PassphraseFromEnv() (string, error) {
+	if passphrase := os.Getenv(EnvKeystorePassphrase); passphrase != "" {
+		return passphrase, nil
+	}
+	return "", fmt.Errorf("keystore passphrase is not set (%s)", EnvKeystorePassphrase)
+}
+
+// Resolve возвращает ключ из источника
+func (r *KeyResolver) Resolve(source string) (string, error) {
+	kind, ref, err := ParseKeySource(source)
+	if err != nil {
+		return "", apperrors.NewConfigError("INVALID_KEY_SOURCE", "invalid API key source", err)
+	}
+
+	var key string
+	switch kind {
+	case KeySourceEnv:
+		key = os.Getenv(ref)
+		if key == "" {
+			err = fmt.Errorf("environment variable %s is empty", ref)
+		}
+	case KeySourceFile:
+		key, err = readKeyFile(ref)
+	case KeySourceCmd:
+		key, err = runKeyCommand(ref)
+	case KeySourceKeystore:
+		key, err = r.fromKeystore(ref)
+	}
+	if err != nil {
+		return "", apperrors.NewConfigError("KEY_SOURCE_ERROR", "failed to get API key", err).
+			WithContext("source", kind)
+	}
+	return key, nil
+}
+
+// Unlock открывает хранилище ключей заранее, например до запуска интерфейса
+func (r *KeyResolver) Unlock() error {
+	_, err := r.openKeystore()
+	return err
+}
+
+// fromKeystore возвращает ключ из хранилища по имени
+func (r *KeyResolver) fromKeystore(name string) (string, error) {
+	ks, err := r.openKeystore()
+	if err != nil {
+		return "", err
+	}
+	key, ok := ks.Get(name)
+	if !ok {
+		return "", fmt.Errorf("key %q not found in keystore %s", name, r.KeystorePath)
+	}
+	return key, nil
+}
+
+// openKeystore открывает хранилище при первом обращении
+func (r *KeyResolver) openKeystore() (*Keystore, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.keystore != nil {
+		return r.keystore, nil
+	}
+	if r.KeystorePath == "" {
+		return nil, fmt.Errorf("keystore path is not set")
+	}
+	if _, err := os.Stat(r.KeystorePath); err != nil {
+		return nil, fmt.Errorf("keystore %s not found (create it with 'llm-client keys set')", r.KeystorePath)
+	}
+	if r.Passphrase == nil {
+		return nil, fmt.Errorf("keystore passphrase is not available")
+	}
+	passphrase, err := r.Passphrase()
+	if err != nil {
+		return nil, err
+	}
+	ks, err := OpenKeystore(r.KeystorePath, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	r.keystore = ks
+	return ks, nil
+}
+
+// readKeyFile читает ключ из файла, недоступного другим пользователям
+func readKeyFile(path string) (string, error) {
+	path = expandHome(path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	// На Windows права доступа не отражаются в битах режима
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		return "", fmt.Errorf("key file %s is accessible by other users (mode %04o), run chmod 600", path, info.Mode().Perm())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return "", fmt.Errorf("key file %s is empty", path)
+	}
+	return key, nil
+}
+
+// runKeyCommand выполняет команду без оболочки и возвращает первую строку вывода
+// (как pass show, где следующие строки - метаданные)
+func runKeyCommand(command string) (string, error) {
+	args := strings.Fields(command)
+	ctx, cancel := context.WithTimeout(context.Background(), keyCommandTimeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("command %q failed: %w: %s", args[0], err, msg)
+		}
+		return "", fmt.Errorf("command %q failed: %w", args[0], err)
+	}
+	key, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", fmt.Errorf("command %q returned empty output", args[0])
+	}
+	return key, nil
+}
+
+// expandHome раскрывает ~ в начале пути
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	apperrors "llm-client/internal/errors"
+)
+
+func init() {
+	// Полное количество итераций PBKDF2 делает тесты хранилища слишком медленными
+	keystoreIterations = 1000
+}
+
+func TestParseKeySource(t *testing.T) {
+	tests := []struct {
+		source   string
+		wantKind string
+		wantRef  string
+		wantErr  bool
+	}{
+		{"env:ROUTERAI_API_KEY", KeySourceEnv, "ROUTERAI_API_KEY", false},
+		{"file:~/.config/key", KeySourceFile, "~/.config/key", false},
+		{"cmd:pass show routerai", KeySourceCmd, "pass show routerai", false},
+		{"keystore:routerai", KeySourceKeystore, "routerai", false},
+		{"sk-plain-key", "", "", true},
+		{"vault:secret", "", "", true},
+		{"env:", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			kind, ref, err := ParseKeySource(tt.source)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseKeySource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if kind != tt.wantKind || ref != tt.wantRef {
+				t.Errorf("ParseKeySource() = %q, %q, want %q, %q", kind, ref, tt.wantKind, tt.wantRef)
+			}
+		})
+	}
+}
+
+func TestKeyResolver_Resolve(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	if err := os.WriteFile(keyFile, []byte("file-key\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_KEY_SOURCE", "env-key")
+
+	r := &KeyResolver{}
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"env:TEST_KEY_SOURCE", "env-key"},
+		{"file:" + keyFile, "file-key"},
+	}
+	if runtime.GOOS != "windows" {
+		tests = append(tests, struct {
+			source string
+			want   string
+		}{"cmd:printf cmd-key\\nmetadata", "cmd-key"})
+	}
+
+	for _, tt := range tests {
+		got, err := r.Resolve(tt.source)
+		if err != nil {
+			t.Errorf("Resolve(%q) error = %v", tt.source, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Resolve(%q) = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestKeyResolver_ResolveErrors(t *testing.T) {
+	dir := t.TempDir()
+	shared := filepath.Join(dir, "shared")
+	if err := os.WriteFile(shared, []byte("key"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	sources := []string{
+		"sk-plain-key",
+		"env:TEST_KEY_SOURCE_UNSET",
+		"file:" + filepath.Join(dir, "missing"),
+		"cmd:llm-client-no-such-command",
+		"keystore:routerai",
+	}
+	if runtime.GOOS != "windows" {
+		sources = append(sources, "file:"+shared)
+	}
+
+	r := &KeyResolver{KeystorePath: filepath.Join(dir, "keystore.json")}
+	for _, source := range sources {
+		_, err := r.Resolve(source)
+		if !apperrors.IsConfigError(err) {
+			t.Errorf("Resolve(%q) error = %v, want config error", source, err)
+		}
+	}
+}
+
+func TestKeystore_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", "keystore.json")
+
+	ks, err := OpenKeystore(path, "secret")
+	if err != nil {
+		t.Fatalf("OpenKeystore() new error = %v", err)
+	}
+	ks.Set("routerai", "sk-routerai")
+	ks.Set("anthropic", "sk-ant")
+	if err := ks.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "sk-routerai") || strings.Contains(string(data), "routerai") {
+		t.Errorf("keystore file contains plain text: %s", data)
+	}
+	if info, _ := os.Stat(path); runtime.GOOS != "windows" && info.Mode().Perm() != 0o600 {
+		t.Errorf("keystore mode = %04o, want 0600", info.Mode().Perm())
+	}
+
+	reopened, err := OpenKeystore(path, "secret")
+	if err != nil {
+		t.Fatalf("OpenKeystore() existing error = %v", err)
+	}
+	if got := fmt.Sprint(reopened.Names()); got != "[anthropic routerai]" {
+		t.Errorf("Names() = %s", got)
+	}
+	if key, ok := reopened.Get("routerai"); !ok || key != "sk-routerai" {
+		t.Errorf("Get(routerai) = %q, %v", key, ok)
+	}
+	if !reopened.Delete("anthropic") || reopened.Delete("anthropic") {
+		t.Errorf("Delete() should remove the key once")
+	}
+
+	if _, err := OpenKeystore(path, "wrong"); err == nil {
+		t.Errorf("OpenKeystore() with wrong passphrase should fail")
+	}
+}
+
+func TestKeyResolver_Keystore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keystore.json")
+	ks, err := OpenKeystore(path, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks.Set("routerai", "sk-routerai")
+	if err := ks.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	r := &KeyResolver{
+		KeystorePath: path,
+		Passphrase: func() (string, error) {
+			calls++
+			return "secret", nil
+		},
+	}
+	for i := 0; i < 2; i++ {
+		key, err := r.Resolve("keystore:routerai")
+		if err != nil || key != "sk-routerai" {
+			t.Fatalf("Resolve() = %q, %v", key, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("passphrase requested %d times, want 1", calls)
+	}
+	if _, err := r.Resolve("keystore:missing"); err == nil {
+		t.Errorf("Resolve() of missing key should fail")
+	}
+}
+
+func TestConfig_KeyIsNeverSerialized(t *testing.T) {
+	t.Setenv("TEST_KEY_SOURCE", "sk-secret-value")
+	cfg := DefaultConfig()
+	cfg.Server.APIKey = "env:TEST_KEY_SOURCE"
+	if err := cfg.ResolveAPIKey(&KeyResolver{}); err != nil {
+		t.Fatalf("ResolveAPIKey() error = %v", err)
+	}
+	if cfg.Server.Key.Reveal() != "sk-secret-value" {
+		t.Fatalf("Server.Key = %q", cfg.Server.Key.Reveal())
+	}
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := cfg.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	saved, _ := os.ReadFile(path)
+	data, _ := cfg.ToJSON()
+	for name, out := range map[string]string{
+		"ToJSON": string(data),
+		"Save":   string(saved),
+		"String": cfg.String(),
+		"%+v":    fmt.Sprintf("%+v", cfg.Server),
+		"%#v":    fmt.Sprintf("%#v", cfg.Server),
+	} {
+		if strings.Contains(out, "sk-secret-value") {
+			t.Errorf("%s leaks the API key: %s", name, out)
+		}
+	}
+	if !strings.Contains(string(saved), `"api_key": "env:TEST_KEY_SOURCE"`) {
+		t.Errorf("Save should keep the key source, got %s", saved)
+	}
+}
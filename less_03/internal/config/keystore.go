@@ -0,0 +1,195 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	// keystoreVersion - версия формата файла хранилища
+	keystoreVersion = 1
+	// keystoreKDF - функция получения ключа шифрования из пароля
+	keystoreKDF = "pbkdf2-sha256"
+	// keystoreKeyLen - длина ключа AES-256
+	keystoreKeyLen = 32
+	// keystoreSaltLen - длина соли PBKDF2
+	keystoreSaltLen = 16
+)
+
+// keystoreIterations - количество итераций PBKDF2 для новых хранилищ.
+// Существующее хранилище открывается с количеством итераций из файла.
+var keystoreIterations = 600_000
+
+// keystoreFile - формат файла хранилища: ключи в JSON, зашифрованные AES-256-GCM
+type keystoreFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Data       []byte `json:"data"`
+}
+
+// Keystore - локальное хранилище API ключей, зашифрованное паролем.
+// Имена ключей тоже зашифрованы; файл доступен только владельцу.
+type Keystore struct {
+	path string
+	salt []byte
+	// iterations и aead соответствуют соли и паролю, с которыми открыто хранилище
+	iterations int
+	aead       cipher.AEAD
+	keys       map[string]string
+}
+
+// DefaultKeystorePath возвращает путь к хранилищу по умолчанию (~/.llm-client/keystore.json)
+func DefaultKeystorePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".llm-client", "keystore.json"), nil
+}
+
+// OpenKeystore открывает хранилище паролем; если файла нет, создаёт пустое хранилище в памяти
+func OpenKeystore(path, passphrase string) (*Keystore, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("keystore passphrase cannot be empty")
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		salt := make([]byte, keystoreSaltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		ks := &Keystore{path: path, salt: salt, iterations: keystoreIterations, keys: map[string]string{}}
+		if err := ks.derive(passphrase); err != nil {
+			return nil, err
+		}
+		return ks, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read keystore: %w", err)
+	}
+
+	var file keystoreFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse keystore %s: %w", path, err)
+	}
+	if file.Version != keystoreVersion || file.KDF != keystoreKDF || file.Iterations < 1 {
+		return nil, fmt.Errorf("unsupported keystore format in %s", path)
+	}
+
+	ks := &Keystore{path: path, salt: file.Salt, iterations: file.Iterations}
+	if err := ks.derive(passphrase); err != nil {
+		return nil, err
+	}
+	plain, err := ks.aead.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		// GCM не различает неверный пароль и повреждённый файл
+		return nil, fmt.Errorf("wrong passphrase or corrupted keystore %s", path)
+	}
+	if err := json.Unmarshal(plain, &ks.keys); err != nil {
+		return nil, fmt.Errorf("parse keystore %s: %w", path, err)
+	}
+	if ks.keys == nil {
+		ks.keys = map[string]string{}
+	}
+	return ks, nil
+}
+
+// derive получает ключ шифрования из пароля
+func (k *Keystore) derive(passphrase string) error {
+	key, err := pbkdf2.Key(sha256.New, passphrase, k.salt, k.iterations, keystoreKeyLen)
+	if err != nil {
+		return err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	k.aead, err = cipher.NewGCM(block)
+	return err
+}
+
+// Get возвращает ключ по имени
+func (k *Keystore) Get(name string) (string, bool) {
+	key, ok := k.keys[name]
+	return key, ok
+}
+
+// Set добавляет или заменяет ключ; изменения записываются Save
+func (k *Keystore) Set(name, key string) {
+	k.keys[name] = key
+}
+
+// Delete удаляет ключ; false, если ключа не было
+func (k *Keystore) Delete(name string) bool {
+	if _, ok := k.keys[name]; !ok {
+		return false
+	}
+	delete(k.keys, name)
+	return true
+}
+
+// Names возвращает имена ключей в алфавитном порядке
+func (k *Keystore) Names() []string {
+	names := make([]string, 0, len(k.keys))
+	for name := range k.keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Save шифрует ключи и атомарно записывает хранилище с правами 0600
+func (k *Keystore) Save() error {
+	plain, err := json.Marshal(k.keys)
+	if err != nil {
+		return err
+	}
+	// Одноразовое значение GCM не должно повторяться для одного ключа шифрования
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(keystoreFile{
+		Version:    keystoreVersion,
+		KDF:        keystoreKDF,
+		Iterations: k.iterations,
+		Salt:       k.salt,
+		Nonce:      nonce,
+		Data:       k.aead.Seal(nil, nonce, plain, nil),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(k.path), 0o700); err != nil {
+		return fmt.Errorf("create keystore directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(k.path), ".keystore-*")
+	if err != nil {
+		return fmt.Errorf("write keystore: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write keystore: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write keystore: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), k.path); err != nil {
+		return fmt.Errorf("write keystore: %w", err)
+	}
+	return nil
+}
@@ -2,8 +2,11 @@
 package errors
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"time"
 )
 
 // Типы ошибок приложения
@@ -40,6 +43,9 @@ var (
 
 	// ErrLoggerNotInitialized возвращается когда логгер не инициализирован
 	ErrLoggerNotInitialized = errors.New("logger not initialized")
+
+	// ErrSessionNotFound возвращается когда сохранённая сессия не найдена
+	ErrSessionNotFound = errors.New("session not found")
 )
 
 // ErrorKind определяет категорию ошибки
@@ -91,6 +97,11 @@ func (e *AppError) WithContext(key string, value any) *AppError {
 	return e
 }
 
+// MarkRetryable явно помечает ошибку как допускающую повторную попытку
+func (e *AppError) MarkRetryable() *AppError {
+	return e.WithContext("retryable", true)
+}
+
 // NewConfigError создаёт ошибку конфигурации
 func NewConfigError(code, message string, err error) *AppError {
 	return &AppError{
@@ -194,3 +205,127 @@ func GetStatusCode(err error) int {
 	}
 	return 0
 }
+
+// retryableStatusCodes - HTTP статусы, при которых запрос имеет смысл повторить
+var retryableStatusCodes = map[int]bool{
+	http.StatusRequestTimeout:     true,
+	http.StatusTooManyRequests:    true,
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+	// 529 - перегрузка Anthropic API (overloaded_error)
+	529: true,
+}
+
+// IsRetryable проверяет можно ли повторить операцию, завершившуюся ошибкой.
+// Повторяются сетевые ошибки, ошибки API с временными статусами (408, 429, 502, 503, 504, 529)
+// и ошибки, явно помеченные через MarkRetryable. Отмена контекста не повторяется.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var appErr *AppError
+	if !errors.As(err, &appErr) {
+		return false
+	}
+
+	if retryable, ok := appErr.Context["retryable"].(bool); ok {
+		return retryable
+	}
+
+	switch appErr.Kind {
+	case KindNetwork:
+		return true
+	case KindAPI:
+		return retryableStatusCodes[GetStatusCode(err)]
+	default:
+		return false
+	}
+}
+
+// GetRetryAfter извлекает задержку из заголовка Retry-After, сохранённую в ошибке
+func GetRetryAfter(err error) time.Duration {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		if d, ok := appErr.Context["retry_after"].(time.Duration); ok {
+			return d
+		}
+	}
+	return 0
+}
+
+// Коды завершения процесса для неинтерактивного режима
+const (
+	// ExitOK - успешное завершение
+	ExitOK = 0
+	// ExitInternal - внутренняя или неклассифицированная ошибка
+	ExitInternal = 1
+	// ExitUsage - неверные аргументы командной строки
+	ExitUsage = 2
+	// ExitConfig - ошибка конфигурации
+	ExitConfig = 3
+	// ExitValidation - невалидные входные данные
+	ExitValidation = 4
+	// ExitNetwork - сервер недоступен
+	ExitNetwork = 5
+	// ExitAPI - сервер вернул ошибку
+	ExitAPI = 6
+	// ExitStream - поток ответа прерван
+	ExitStream = 7
+	// ExitInterrupted - выполнение прервано (Ctrl+C), как принято в shell: 128 + SIGINT
+	ExitInterrupted = 130
+)
+
+// exitCodes сопоставляет категории ошибок кодам завершения
+var exitCodes = map[ErrorKind]int{
+	KindConfig:     ExitConfig,
+	KindValidation: ExitValidation,
+	KindNetwork:    ExitNetwork,
+	KindAPI:        ExitAPI,
+	KindStream:     ExitStream,
+	KindInternal:   ExitInternal,
+}
+
+// ExitCode возвращает код завершения процесса для ошибки по её категории (ErrorKind)
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	if errors.Is(err, context.Canceled) {
+		return ExitInterrupted
+	}
+
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		if code, ok := exitCodes[appErr.Kind]; ok {
+			return code
+		}
+	}
+	return ExitInternal
+}
+
+// Details - описание ошибки для машиночитаемого вывода (JSON)
+type Details struct {
+	Kind       string `json:"kind"`
+	Code       string `json:"code,omitempty"`
+	Message    string `json:"message"`
+	StatusCode int    `json:"status_code,omitempty"`
+}
+
+// Describe возвращает описание ошибки; отмена контекста описывается категорией "interrupted"
+func Describe(err error) Details {
+	if errors.Is(err, context.Canceled) {
+		return Details{Kind: "interrupted", Message: err.Error()}
+	}
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return Details{
+			Kind:       string(appErr.Kind),
+			Code:       appErr.Code,
+			Message:    appErr.Error(),
+			StatusCode: GetStatusCode(err),
+		}
+	}
+	return Details{Kind: string(KindInternal), Message: err.Error()}
+}
@@ -1,9 +1,11 @@
 package errors
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 )
 
 func TestAppError_Error(t *testing.T) {
@@ -231,3 +233,75 @@ func TestErrorWrapping(t *testing.T) {
 		t.Errorf("errors.As() should extract AppError")
 	}
 }
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"nil error", nil, false},
+		{"plain error", fmt.Errorf("plain error"), false},
+		{"network error", NewNetworkError("REQUEST_FAILED", "request failed", nil), true},
+		{"API 429", NewAPIError("API_ERROR", "rate limited", nil, 429), true},
+		{"API 502", NewAPIError("API_ERROR", "bad gateway", nil, 502), true},
+		{"API 503", NewAPIError("API_ERROR", "unavailable", nil, 503), true},
+		{"API 529 overloaded", NewAPIError("API_ERROR", "overloaded", nil, 529), true},
+		{"API 400", NewAPIError("API_ERROR", "bad request", nil, 400), false},
+		{"API 401", NewAPIError("API_ERROR", "unauthorized", nil, 401), false},
+		{"stream error", NewStreamError("PARSE_ERROR", "bad chunk", nil), false},
+		{"marked stream error", NewStreamError("READ_ERROR", "read error", nil).MarkRetryable(), true},
+		{"config error", NewConfigError("PARSE_ERROR", "bad config", nil), false},
+		{"wrapped network error", fmt.Errorf("wrap: %w", NewNetworkError("X", "x", nil)), true},
+		{"context cancelled", NewNetworkError("REQUEST_FAILED", "request failed", context.Canceled), false},
+		{"deadline exceeded", NewNetworkError("REQUEST_FAILED", "request failed", context.DeadlineExceeded), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := IsRetryable(tt.err)
+			if got != tt.expected {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetRetryAfter(t *testing.T) {
+	err := NewAPIError("API_ERROR", "rate limited", nil, 429).WithContext("retry_after", 3*time.Second)
+	if got := GetRetryAfter(err); got != 3*time.Second {
+		t.Errorf("GetRetryAfter() = %v, want %v", got, 3*time.Second)
+	}
+
+	if got := GetRetryAfter(NewAPIError("API_ERROR", "x", nil, 429)); got != 0 {
+		t.Errorf("GetRetryAfter() without header = %v, want 0", got)
+	}
+	if got := GetRetryAfter(fmt.Errorf("plain")); got != 0 {
+		t.Errorf("GetRetryAfter() for plain error = %v, want 0", got)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"nil", nil, ExitOK},
+		{"config error", NewConfigError("PARSE_ERROR", "bad config", nil), ExitConfig},
+		{"validation error", NewValidationError("NO_PROMPT", "empty", nil), ExitValidation},
+		{"wrapped network error", fmt.Errorf("wrap: %w", NewNetworkError("X", "x", nil)), ExitNetwork},
+		{"api error", NewAPIError("API_ERROR", "bad request", nil, 400), ExitAPI},
+		{"stream error", NewStreamError("READ_ERROR", "read error", nil), ExitStream},
+		{"cancelled request", NewNetworkError("REQUEST_FAILED", "request failed", context.Canceled), ExitInterrupted},
+		{"plain error", fmt.Errorf("plain"), ExitInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.expected {
+				t.Errorf("ExitCode() = %d, want %d", got, tt.expected)
+			}
+		})
+	}
+}
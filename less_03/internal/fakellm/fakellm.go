@@ -0,0 +1,357 @@
+// Package fakellm запускает в процессе теста OpenAI-совместимый сервер:
+// ответы по сценарию, стриминг по токенам с задержками, сбои (429, 500, обрыв
+// соединения, некорректный JSON) и проверки полученных запросов.
+package fakellm
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"llm-client/internal/chat"
+)
+
+const (
+	// ChatPath - эндпоинт chat completions
+	ChatPath = "/v1/chat/completions"
+	// ModelsPath - эндпоинт списка моделей
+	ModelsPath = "/v1/models"
+	// DefaultModel - модель в ответах, если запрос её не указал
+	DefaultModel = "fake-model"
+
+	// awaitTimeout - сколько Await ждёт запросов
+	awaitTimeout = 5 * time.Second
+)
+
+// Option - функция опция для настройки Server
+type Option func(*Server)
+
+// WithReplies задаёт ответы на запросы по порядку
+func WithReplies(replies ...Reply) Option {
+	return func(s *Server) {
+		s.replies = append(s.replies, replies...)
+	}
+}
+
+// WithDefaultReply задаёт ответ после исчерпания сценария (по умолчанию Text("ok"))
+func WithDefaultReply(reply Reply) Option {
+	return func(s *Server) {
+		s.fallback = reply
+	}
+}
+
+// WithTokenDelay задаёт задержку перед каждым токеном потокового ответа
+func WithTokenDelay(d time.Duration) Option {
+	return func(s *Server) {
+		s.tokenDelay = d
+	}
+}
+
+// Model - модель в ответе /v1/models. Fields добавляются в объект модели как есть:
+// так задаются поля провайдеров вроде context_length и pricing (OpenRouter) или max_model_len (vLLM).
+type Model struct {
+	ID     string
+	Fields map[string]any
+}
+
+// WithModels задаёт список моделей для /v1/models (по умолчанию DefaultModel)
+func WithModels(models ...string) Option {
+	return func(s *Server) {
+		s.models = make([]Model, 0, len(models))
+		for _, id := range models {
+			s.models = append(s.models, Model{ID: id})
+		}
+	}
+}
+
+// WithModelInfo задаёт список моделей для /v1/models с дополнительными полями
+func WithModelInfo(models ...Model) Option {
+	return func(s *Server) {
+		s.models = models
+	}
+}
+
+// WithModelsError задаёт ошибку, которой /v1/models отвечает вместо списка
+func WithModelsError(status int, message string) Option {
+	return func(s *Server) {
+		s.modelsError = Error(status, message)
+	}
+}
+
+// WithAPIKey требует заголовок Authorization: Bearer <key>, иначе ответ 401
+func WithAPIKey(key string) Option {
+	return func(s *Server) {
+		s.apiKey = key
+	}
+}
+
+// Server - поддельный LLM сервер. Безопасен для использования из нескольких горутин.
+type Server struct {
+	// URL - адрес сервера для config.Server.Address и client.NewClient
+	URL string
+
+	t           testing.TB
+	server      *httptest.Server
+	tokenDelay  time.Duration
+	models      []Model
+	modelsError Reply
+	apiKey      string
+
+	mu       sync.Mutex
+	replies  []Reply
+	fallback Reply
+	requests []Request
+	// received сигнализирует Await о новом запросе
+	received chan struct{}
+}
+
+// New запускает сервер; он останавливается по завершении теста
+func New(t testing.TB, opts ...Option) *Server {
+	t.Helper()
+	s := &Server{
+		t:        t,
+		models:   []Model{{ID: DefaultModel}},
+		fallback: Text("ok"),
+		received: make(chan struct{}, 1),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+ChatPath, s.handleChat)
+	mux.HandleFunc("GET "+ModelsPath, s.handleModels)
+	s.server = httptest.NewServer(mux)
+	s.URL = s.server.URL
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Close останавливает сервер
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Enqueue добавляет ответы в конец сценария
+func (s *Server) Enqueue(replies ...Reply) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replies = append(s.replies, replies...)
+}
+
+// Requests возвращает полученные запросы chat completions по порядку
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// LastRequest возвращает последний запрос; без запросов тест завершается с ошибкой
+func (s *Server) LastRequest() Request {
+	s.t.Helper()
+	requests := s.Requests()
+	if len(requests) == 0 {
+		s.t.Fatalf("fakellm: no requests received")
+	}
+	return requests[len(requests)-1]
+}
+
+// Await ждёт, пока сервер получит n запросов, и возвращает их.
+// Нужен, когда запрос отправляется асинхронно (команды Bubble Tea, горутины стрима).
+func (s *Server) Await(n int) []Request {
+	s.t.Helper()
+	deadline := time.After(awaitTimeout)
+	for {
+		if requests := s.Requests(); len(requests) >= n {
+			return requests[:n]
+		}
+		select {
+		case <-s.received:
+		case <-deadline:
+			s.t.Fatalf("fakellm: received %d requests, want %d", len(s.Requests()), n)
+			return nil
+		}
+	}
+}
+
+// AssertRequestCount проверяет количество полученных запросов
+func (s *Server) AssertRequestCount(n int) {
+	s.t.Helper()
+	if got := len(s.Requests()); got != n {
+		s.t.Errorf("fakellm: received %d requests, want %d", got, n)
+	}
+}
+
+// handleChat записывает запрос и отвечает следующим ответом сценария
+func (s *Server) handleChat(w http.ResponseWriter, r *http.Request) {
+	if s.apiKey != "" && r.Header.Get("Authorization") != "Bearer "+s.apiKey {
+		writeError(w, http.StatusUnauthorized, "invalid api key")
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	req := newRequest(r, body)
+	reply := s.record(req)
+
+	if req.err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+req.err.Error())
+		return
+	}
+	reply.write(w, r, req, s.tokenDelay)
+}
+
+// record сохраняет запрос и выбирает ответ
+func (s *Server) record(req Request) Reply {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, req)
+	select {
+	case s.received <- struct{}{}:
+	default:
+	}
+
+	if len(s.replies) == 0 {
+		return s.fallback
+	}
+	reply := s.replies[0]
+	s.replies = s.replies[1:]
+	return reply
+}
+
+// handleModels отдаёт список моделей в формате OpenAI
+func (s *Server) handleModels(w http.ResponseWriter, r *http.Request) {
+	if s.modelsError.Status != 0 {
+		writeError(w, s.modelsError.Status, s.modelsError.Message)
+		return
+	}
+
+	list := struct {
+		Object string           `json:"object"`
+		Data   []map[string]any `json:"data"`
+	}{Object: "list", Data: []map[string]any{}}
+	for _, m := range s.models {
+		model := map[string]any{"id": m.ID, "object": "model", "owned_by": "fakellm"}
+		for name, value := range m.Fields {
+			model[name] = value
+		}
+		list.Data = append(list.Data, model)
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// Request - запрос, полученный сервером
+type Request struct {
+	Method string
+	Path   string
+	Header http.Header
+	// Body - тело запроса как есть
+	Body []byte
+
+	Model    string
+	Messages []chat.Message
+	Stream   bool
+	// IncludeUsage - запрошен ли usage в последнем чанке стрима (stream_options)
+	IncludeUsage bool
+
+	// params - все поля тела запроса
+	params map[string]json.RawMessage
+	err    error
+}
+
+// newRequest разбирает тело запроса chat completions
+func newRequest(r *http.Request, body []byte) Request {
+	req := Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Header: r.Header.Clone(),
+		Body:   body,
+	}
+	var decoded struct {
+		Model         string         `json:"model"`
+		Messages      []chat.Message `json:"messages"`
+		Stream        bool           `json:"stream"`
+		StreamOptions *struct {
+			IncludeUsage bool `json:"include_usage"`
+		} `json:"stream_options"`
+	}
+	if req.err = json.Unmarshal(body, &decoded); req.err != nil {
+		return req
+	}
+	json.Unmarshal(body, &req.params)
+
+	req.Model = decoded.Model
+	req.Messages = decoded.Messages
+	req.Stream = decoded.Stream
+	req.IncludeUsage = decoded.StreamOptions != nil && decoded.StreamOptions.IncludeUsage
+	return req
+}
+
+// Param возвращает поле тела запроса, декодированное в значение Go (nil - поля нет)
+func (r Request) Param(name string) any {
+	raw, ok := r.params[name]
+	if !ok {
+		return nil
+	}
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil
+	}
+	if n, ok := v.(json.Number); ok {
+		if f, err := n.Float64(); err == nil {
+			return f
+		}
+	}
+	return v
+}
+
+// HasParam сообщает, есть ли поле в теле запроса
+func (r Request) HasParam(name string) bool {
+	_, ok := r.params[name]
+	return ok
+}
+
+// LastUserMessage возвращает текст последнего сообщения пользователя
+func (r Request) LastUserMessage() string {
+	for i := len(r.Messages) - 1; i >= 0; i-- {
+		if r.Messages[i].Role == chat.RoleUser {
+			return r.Messages[i].Content
+		}
+	}
+	return ""
+}
+
+// Contents возвращает тексты сообщений через запятую: "system,q1,a1,q2"
+func (r Request) Contents() string {
+	contents := make([]string, len(r.Messages))
+	for i, msg := range r.Messages {
+		contents[i] = msg.Content
+	}
+	return strings.Join(contents, ",")
+}
+
+// writeJSON отправляет JSON ответ
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError отправляет ошибку в формате OpenAI
+func writeError(w http.ResponseWriter, status int, message string) {
+	type apiError struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	}
+	writeJSON(w, status, struct {
+		Error apiError `json:"error"`
+	}{apiError{Message: message, Type: "fakellm_error"}})
+}
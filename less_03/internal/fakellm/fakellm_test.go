@@ -0,0 +1,235 @@
+package fakellm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"llm-client/internal/chat"
+	"llm-client/internal/client"
+	apperrors "llm-client/internal/errors"
+	"llm-client/internal/logger"
+)
+
+func newClient(s *Server, opts ...client.ClientOption) *client.Client {
+	opts = append([]client.ClientOption{
+		client.WithAPIKey("test-key"),
+		client.WithLogger(logger.NewLogger(logger.Config{Enabled: false})),
+	}, opts...)
+	return client.NewClient(s.URL, ChatPath, opts...)
+}
+
+func request(content string) *client.ChatRequest {
+	return &client.ChatRequest{
+		Model:       "test-model",
+		Messages:    []chat.Message{{Role: chat.RoleUser, Content: content}},
+		Temperature: 0.5,
+	}
+}
+
+func streamRequest(content string) *client.ChatRequest {
+	req := request(content)
+	req.Stream = true
+	return req
+}
+
+// collect читает стрим до конца
+func collect(ch <-chan client.StreamChunk) (content string, last client.StreamChunk, err error) {
+	var b strings.Builder
+	for chunk := range ch {
+		b.WriteString(chunk.Content)
+		if chunk.Error != nil {
+			err = chunk.Error
+		}
+		last = chunk
+	}
+	return b.String(), last, err
+}
+
+func TestServer_ScriptedReplies(t *testing.T) {
+	s := New(t, WithReplies(Text("первый"), Text("второй")), WithDefaultReply(Text("дальше")))
+	c := newClient(s)
+
+	for _, want := range []string{"первый", "второй", "дальше", "дальше"} {
+		got, err := c.Chat(context.Background(), request("q"))
+		if err != nil || got != want {
+			t.Errorf("Chat() = %q, %v, want %q", got, err, want)
+		}
+	}
+	s.AssertRequestCount(4)
+
+	req := s.LastRequest()
+	if req.Model != "test-model" || req.LastUserMessage() != "q" || req.Stream || req.Param("temperature") != 0.5 {
+		t.Errorf("request = %+v", req)
+	}
+	if req.Header.Get("Authorization") != "Bearer test-key" {
+		t.Errorf("Authorization = %q", req.Header.Get("Authorization"))
+	}
+}
+
+func TestServer_StreamTokens(t *testing.T) {
+	s := New(t, WithReplies(Tokens("При", "вет", ", мир")), WithTokenDelay(10*time.Millisecond))
+	c := newClient(s)
+
+	start := time.Now()
+	content, last, err := collect(c.ChatStream(context.Background(), streamRequest("Привет")))
+	if err != nil || content != "Привет, мир" {
+		t.Fatalf("stream = %q, %v", content, err)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("stream took %v, want token delays", elapsed)
+	}
+	if !last.Done || last.FinishReason != "stop" || last.Usage == nil || last.Usage.CompletionTokens != 3 {
+		t.Errorf("last chunk = %+v", last)
+	}
+	if req := s.LastRequest(); !req.Stream || !req.IncludeUsage {
+		t.Errorf("request = %+v, want stream with usage", req)
+	}
+}
+
+func TestServer_ToolCalls(t *testing.T) {
+	call := chat.ToolCall{ID: "call_1", Type: "function", Function: chat.FunctionCall{Name: "echo", Arguments: `{"city":"Москва"}`}}
+	s := New(t, WithReplies(ToolCalls(call)))
+
+	_, last, err := collect(newClient(s).ChatStream(context.Background(), streamRequest("q")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.FinishReason != "tool_calls" || len(last.ToolCalls) != 1 || last.ToolCalls[0] != call {
+		t.Errorf("last chunk = %+v", last)
+	}
+}
+
+func TestServer_Faults(t *testing.T) {
+	retry := client.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+	t.Run("retries 429 and 503", func(t *testing.T) {
+		s := New(t, WithReplies(RateLimited(0), Error(http.StatusServiceUnavailable, "overloaded"), Text("ok")))
+		got, err := newClient(s, client.WithRetryPolicy(retry)).Chat(context.Background(), request("q"))
+		if err != nil || got != "ok" {
+			t.Errorf("Chat() = %q, %v", got, err)
+		}
+		s.AssertRequestCount(3)
+	})
+
+	t.Run("no retry on 500", func(t *testing.T) {
+		s := New(t, WithReplies(ServerError()))
+		if _, err := newClient(s, client.WithRetryPolicy(retry)).Chat(context.Background(), request("q")); apperrors.GetStatusCode(err) != http.StatusInternalServerError {
+			t.Errorf("error = %v, want 500", err)
+		}
+		s.AssertRequestCount(1)
+	})
+
+	t.Run("retry after", func(t *testing.T) {
+		s := New(t, WithReplies(RateLimited(7*time.Second)))
+		_, err := newClient(s).Chat(context.Background(), request("q"))
+		if apperrors.GetStatusCode(err) != http.StatusTooManyRequests || apperrors.GetRetryAfter(err) != 7*time.Second {
+			t.Errorf("error = %v, retry after = %v", err, apperrors.GetRetryAfter(err))
+		}
+	})
+
+	t.Run("drop mid-stream", func(t *testing.T) {
+		s := New(t, WithReplies(DropAfter(2, "one two three")))
+		content, _, err := collect(newClient(s, client.WithRetryPolicy(retry)).ChatStream(context.Background(), streamRequest("q")))
+		var appErr *apperrors.AppError
+		if content != "one two " || !errors.As(err, &appErr) || appErr.Kind != apperrors.KindStream {
+			t.Errorf("stream = %q, %v, want partial content and stream error", content, err)
+		}
+		s.AssertRequestCount(1)
+	})
+
+	t.Run("drop before response", func(t *testing.T) {
+		s := New(t, WithReplies(DropAfter(0, "")))
+		if _, err := newClient(s).Chat(context.Background(), request("q")); !apperrors.IsNetworkError(err) {
+			t.Errorf("error = %v, want network error", err)
+		}
+	})
+
+	t.Run("malformed chunk", func(t *testing.T) {
+		s := New(t, WithReplies(MalformedAfter(1, "one two")))
+		content, _, err := collect(newClient(s).ChatStream(context.Background(), streamRequest("q")))
+		if content != "one " || err == nil {
+			t.Errorf("stream = %q, %v, want parse error after first token", content, err)
+		}
+	})
+}
+
+func TestServer_Raw(t *testing.T) {
+	s := New(t, WithReplies(
+		Raw(http.StatusOK, "application/json", `{"choices":[]}`),
+		Raw(http.StatusBadRequest, "", `{"error":"bad"}`),
+	))
+	c := newClient(s)
+
+	if _, err := c.Chat(context.Background(), request("q")); err == nil {
+		t.Errorf("empty choices: want error")
+	}
+	if _, err := c.Chat(context.Background(), request("q")); apperrors.GetStatusCode(err) != http.StatusBadRequest {
+		t.Errorf("error = %v, want 400", err)
+	}
+	s.AssertRequestCount(2)
+}
+
+func TestServer_Delay(t *testing.T) {
+	s := New(t, WithReplies(Reply{Content: "late", Delay: time.Minute}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := newClient(s).Chat(ctx, request("q")); err == nil {
+		t.Errorf("Chat() should fail when context expires during delay")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Chat() took %v, delay should stop on cancel", elapsed)
+	}
+}
+
+func TestServer_Await(t *testing.T) {
+	s := New(t)
+	go newClient(s).Chat(context.Background(), request("async"))
+
+	if reqs := s.Await(1); reqs[0].LastUserMessage() != "async" || reqs[0].Contents() != "async" {
+		t.Errorf("requests = %+v", reqs)
+	}
+}
+
+func TestServer_ModelsAndAuth(t *testing.T) {
+	s := New(t, WithModels("a", "b"), WithAPIKey("secret"))
+
+	resp, err := http.Get(s.URL + ModelsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var list struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	json.NewDecoder(resp.Body).Decode(&list)
+	if len(list.Data) != 2 || list.Data[0].ID != "a" || list.Data[1].ID != "b" {
+		t.Errorf("models = %+v", list)
+	}
+
+	info := New(t, WithModelInfo(Model{ID: "c", Fields: map[string]any{"context_length": 4096}}))
+	models, err := newClient(info).ListModels(context.Background())
+	if err != nil || len(models) != 1 || models[0].ID != "c" || models[0].ContextLength != 4096 {
+		t.Errorf("ListModels() = %+v, %v", models, err)
+	}
+
+	failing := New(t, WithModelsError(http.StatusForbidden, "no access"))
+	if _, err := newClient(failing).ListModels(context.Background()); apperrors.GetStatusCode(err) != http.StatusForbidden {
+		t.Errorf("models error = %v, want 403", err)
+	}
+
+	if _, err := newClient(s).Chat(context.Background(), request("q")); apperrors.GetStatusCode(err) != http.StatusUnauthorized {
+		t.Errorf("wrong key: error = %v, want 401", err)
+	}
+	if _, err := newClient(s, client.WithAPIKey("secret")).Chat(context.Background(), request("q")); err != nil {
+		t.Errorf("valid key: error = %v", err)
+	}
+}
@@ -0,0 +1,401 @@
+package fakellm
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"llm-client/internal/chat"
+)
+
+// Fault - сбой, который сервер имитирует вместо нормального ответа
+type Fault int
+
+const (
+	// FaultNone - ответ без сбоя
+	FaultNone Fault = iota
+	// FaultDrop - соединение обрывается после FaultAfter токенов
+	FaultDrop
+	// FaultMalformed - после FaultAfter токенов приходит чанк с некорректным JSON
+	FaultMalformed
+)
+
+// Reply - ответ сервера на один запрос
+type Reply struct {
+	// Content - текст ответа
+	Content string
+	// Tokens - токены потокового ответа (по умолчанию Content по словам)
+	Tokens []string
+	// ToolCalls - вызовы инструментов в ответе
+	ToolCalls []chat.ToolCall
+	// FinishReason - причина завершения (по умолчанию stop или tool_calls)
+	FinishReason string
+	// TokenDelay - задержка перед каждым токеном (переопределяет WithTokenDelay)
+	TokenDelay time.Duration
+	// Delay - задержка перед ответом; отмена запроса клиентом её прерывает
+	Delay time.Duration
+
+	// Status - код ошибки вместо ответа; Message - текст ошибки
+	Status  int
+	Message string
+	// RetryAfter - значение заголовка Retry-After для ошибки
+	RetryAfter time.Duration
+
+	// Fault - сбой посреди ответа; FaultAfter - сколько токенов отправить до него
+	Fault      Fault
+	FaultAfter int
+
+	// Body - тело ответа как есть вместо сгенерированного (код Status, по умолчанию 200)
+	Body        string
+	ContentType string
+}
+
+// Text возвращает ответ с текстом
+func Text(content string) Reply {
+	return Reply{Content: content}
+}
+
+// Tokens возвращает потоковый ответ с заданным разбиением на токены
+func Tokens(tokens ...string) Reply {
+	return Reply{Content: strings.Join(tokens, ""), Tokens: tokens}
+}
+
+// ToolCalls возвращает ответ с вызовами инструментов
+func ToolCalls(calls ...chat.ToolCall) Reply {
+	return Reply{ToolCalls: calls}
+}
+
+// Error возвращает ошибку провайдера с кодом status
+func Error(status int, message string) Reply {
+	return Reply{Status: status, Message: message}
+}
+
+// RateLimited возвращает 429 с заголовком Retry-After
+func RateLimited(retryAfter time.Duration) Reply {
+	return Reply{Status: http.StatusTooManyRequests, Message: "rate limit exceeded", RetryAfter: retryAfter}
+}
+
+// ServerError возвращает 500
+func ServerError() Reply {
+	return Error(http.StatusInternalServerError, "internal server error")
+}
+
+// Raw возвращает ответ с телом body как есть.
+// Нужен для форматов, которые сервер не генерирует: нестандартные поля, пустой choices, события SSE с id и event.
+func Raw(status int, contentType, body string) Reply {
+	return Reply{Status: status, ContentType: contentType, Body: body}
+}
+
+// DropAfter возвращает ответ, соединение которого обрывается после n токенов content
+func DropAfter(n int, content string) Reply {
+	return Reply{Content: content, Fault: FaultDrop, FaultAfter: n}
+}
+
+// MalformedAfter возвращает ответ, в котором после n токенов content приходит некорректный JSON
+func MalformedAfter(n int, content string) Reply {
+	return Reply{Content: content, Fault: FaultMalformed, FaultAfter: n}
+}
+
+// malformedChunk - событие стрима с обрезанным JSON
+const malformedChunk = `{"choices":[{"index":0,"delta":{"content":`
+
+// argumentsChunk - размер части аргументов вызова инструмента в потоковом ответе
+const argumentsChunk = 8
+
+// tokens возвращает токены потокового ответа
+func (r Reply) tokens() []string {
+	if r.Tokens != nil {
+		return r.Tokens
+	}
+	var tokens []string
+	for _, word := range strings.SplitAfter(r.Content, " ") {
+		if word != "" {
+			tokens = append(tokens, word)
+		}
+	}
+	return tokens
+}
+
+// finishReason возвращает причину завершения ответа
+func (r Reply) finishReason() string {
+	switch {
+	case r.FinishReason != "":
+		return r.FinishReason
+	case len(r.ToolCalls) > 0:
+		return "tool_calls"
+	default:
+		return "stop"
+	}
+}
+
+// write отправляет ответ на запрос
+func (r Reply) write(w http.ResponseWriter, hr *http.Request, req Request, tokenDelay time.Duration) {
+	if !sleep(hr, r.Delay) {
+		return
+	}
+	if r.Body != "" {
+		r.writeRaw(w)
+		return
+	}
+	if r.Status != 0 {
+		if r.RetryAfter > 0 || r.Status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(r.RetryAfter.Seconds()))))
+		}
+		writeError(w, r.Status, r.Message)
+		return
+	}
+	if r.TokenDelay > 0 {
+		tokenDelay = r.TokenDelay
+	}
+
+	if !req.Stream {
+		r.writeCompletion(w, req)
+		return
+	}
+
+	var stream eventStream
+	if r.Fault == FaultDrop {
+		conn, err := hijack(w)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		stream = conn
+	} else {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		stream = &flushStream{w: w}
+	}
+	r.writeStream(stream, hr, req, tokenDelay)
+}
+
+// writeRaw отправляет Body как есть
+func (r Reply) writeRaw(w http.ResponseWriter) {
+	status := r.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if r.ContentType != "" {
+		w.Header().Set("Content-Type", r.ContentType)
+	}
+	w.WriteHeader(status)
+	w.Write([]byte(r.Body))
+}
+
+// writeCompletion отправляет ответ без стриминга
+func (r Reply) writeCompletion(w http.ResponseWriter, req Request) {
+	switch r.Fault {
+	case FaultDrop:
+		// Соединение закрывается без ответа
+		if conn, _, err := w.(http.Hijacker).Hijack(); err == nil {
+			conn.Close()
+		}
+		return
+	case FaultMalformed:
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(malformedChunk))
+		return
+	}
+
+	message := map[string]any{"role": chat.RoleAssistant, "content": r.Content}
+	if len(r.ToolCalls) > 0 {
+		message["tool_calls"] = r.ToolCalls
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"id":      "chatcmpl-fake",
+		"object":  "chat.completion",
+		"created": time.Now().Unix(),
+		"model":   model(req),
+		"choices": []map[string]any{{
+			"index":         0,
+			"message":       message,
+			"finish_reason": r.finishReason(),
+		}},
+		"usage": r.usage(req),
+	})
+}
+
+// writeStream отправляет потоковый ответ: токены, вызовы инструментов,
+// чанк завершения, usage (если запрошен) и [DONE]
+func (r Reply) writeStream(stream eventStream, hr *http.Request, req Request, tokenDelay time.Duration) {
+	chunk := func(delta map[string]any, finishReason any) string {
+		data, _ := json.Marshal(map[string]any{
+			"id":      "chatcmpl-fake",
+			"object":  "chat.completion.chunk",
+			"created": time.Now().Unix(),
+			"model":   model(req),
+			"choices": []map[string]any{{"index": 0, "delta": delta, "finish_reason": finishReason}},
+		})
+		return string(data)
+	}
+
+	for i, token := range r.tokens() {
+		if r.Fault != FaultNone && i == r.FaultAfter {
+			r.writeFault(stream)
+			return
+		}
+		if !sleep(hr, tokenDelay) {
+			return
+		}
+		delta := map[string]any{"content": token}
+		if i == 0 {
+			delta["role"] = chat.RoleAssistant
+		}
+		if stream.send(chunk(delta, nil)) != nil {
+			return
+		}
+	}
+	if r.Fault != FaultNone {
+		r.writeFault(stream)
+		return
+	}
+
+	// Как у OpenAI: сначала id и имя функции, затем аргументы по частям
+	for i, call := range r.ToolCalls {
+		head := map[string]any{"index": i, "id": call.ID, "type": call.Type,
+			"function": map[string]any{"name": call.Function.Name, "arguments": ""}}
+		stream.send(chunk(map[string]any{"tool_calls": []any{head}}, nil))
+		for _, part := range splitArguments(call.Function.Arguments) {
+			delta := map[string]any{"index": i, "function": map[string]any{"arguments": part}}
+			stream.send(chunk(map[string]any{"tool_calls": []any{delta}}, nil))
+		}
+	}
+	stream.send(chunk(map[string]any{}, r.finishReason()))
+
+	if req.IncludeUsage {
+		data, _ := json.Marshal(map[string]any{
+			"id":      "chatcmpl-fake",
+			"object":  "chat.completion.chunk",
+			"model":   model(req),
+			"choices": []any{},
+			"usage":   r.usage(req),
+		})
+		stream.send(string(data))
+	}
+	stream.send("[DONE]")
+}
+
+// splitArguments делит аргументы вызова на части по argumentsChunk символов
+func splitArguments(args string) []string {
+	var parts []string
+	runes := []rune(args)
+	for len(runes) > 0 {
+		n := min(argumentsChunk, len(runes))
+		parts = append(parts, string(runes[:n]))
+		runes = runes[n:]
+	}
+	return parts
+}
+
+// writeFault отправляет сбой посреди потока
+func (r Reply) writeFault(stream eventStream) {
+	switch r.Fault {
+	case FaultDrop:
+		stream.drop()
+	case FaultMalformed:
+		stream.send(malformedChunk)
+		stream.send("[DONE]")
+	}
+}
+
+// usage возвращает расход токенов: слова сообщений запроса и токены ответа
+func (r Reply) usage(req Request) map[string]int {
+	prompt := 0
+	for _, msg := range req.Messages {
+		prompt += len(strings.Fields(msg.Content))
+	}
+	completion := len(r.tokens()) + len(r.ToolCalls)
+	return map[string]int{
+		"prompt_tokens":     prompt,
+		"completion_tokens": completion,
+		"total_tokens":      prompt + completion,
+	}
+}
+
+// model возвращает модель для ответа
+func model(req Request) string {
+	if req.Model != "" {
+		return req.Model
+	}
+	return DefaultModel
+}
+
+// sleep ждёт d; false - запрос отменён клиентом
+func sleep(r *http.Request, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-r.Context().Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// eventStream отправляет события SSE
+type eventStream interface {
+	send(data string) error
+	drop()
+}
+
+// flushStream пишет события в ответ и сразу отправляет их клиенту
+type flushStream struct {
+	w http.ResponseWriter
+}
+
+func (s *flushStream) send(data string) error {
+	if _, err := fmt.Fprintf(s.w, "data: %s\n\n", data); err != nil {
+		return err
+	}
+	s.w.(http.Flusher).Flush()
+	return nil
+}
+
+func (s *flushStream) drop() {}
+
+// rawStream пишет потоковый ответ напрямую в соединение (chunked), чтобы его можно было оборвать
+type rawStream struct {
+	conn net.Conn
+	buf  *bufio.ReadWriter
+}
+
+// hijack забирает соединение и отправляет заголовки потокового ответа
+func hijack(w http.ResponseWriter) (*rawStream, error) {
+	conn, buf, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		return nil, err
+	}
+	buf.WriteString("HTTP/1.1 200 OK\r\nContent-Type: text/event-stream\r\nTransfer-Encoding: chunked\r\n\r\n")
+	if err := buf.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &rawStream{conn: conn, buf: buf}, nil
+}
+
+func (s *rawStream) send(data string) error {
+	event := "data: " + data + "\n\n"
+	fmt.Fprintf(s.buf, "%x\r\n%s\r\n", len(event), event)
+	return s.buf.Flush()
+}
+
+// drop отправляет начало chunk и закрывает соединение: клиент получает unexpected EOF
+func (s *rawStream) drop() {
+	s.buf.WriteString("100\r\ndata: {")
+	s.buf.Flush()
+	s.conn.Close()
+}
+
+// Close закрывает соединение
+func (s *rawStream) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,189 @@
+// Package inputhistory хранит историю ввода пользователя (запросы и команды отдельно)
+// с сохранением на диск между запусками и нечётким поиском по ней.
+package inputhistory
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	apperrors "llm-client/internal/errors"
+)
+
+// Kind - раздел истории
+type Kind int
+
+const (
+	// Prompts - сообщения модели
+	Prompts Kind = iota
+	// Commands - команды интерфейса (начинаются с "/")
+	Commands
+)
+
+// KindOf возвращает раздел истории для введённого текста
+func KindOf(text string) Kind {
+	if strings.HasPrefix(text, "/") {
+		return Commands
+	}
+	return Prompts
+}
+
+// file - содержимое файла истории; записи идут от старых к новым
+type file struct {
+	Prompts  []string `json:"prompts"`
+	Commands []string `json:"commands"`
+}
+
+// entries возвращает раздел истории
+func (f *file) entries(kind Kind) *[]string {
+	if kind == Commands {
+		return &f.Commands
+	}
+	return &f.Prompts
+}
+
+// History - история ввода. Безопасна для параллельного использования.
+type History struct {
+	mu    sync.Mutex
+	path  string
+	limit int
+	data  file
+}
+
+// New создаёт историю; path - файл истории ("" - только в памяти),
+// limit - максимум записей в каждом разделе (0 - история не ведётся)
+func New(path string, limit int) *History {
+	return &History{path: path, limit: limit}
+}
+
+// DefaultPath возвращает путь к файлу истории по умолчанию (~/.llm-client/input_history.json)
+func DefaultPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", apperrors.NewInternalError("HOME_DIR_ERROR", "failed to determine home directory", err)
+	}
+	return filepath.Join(homeDir, ".llm-client", "input_history.json"), nil
+}
+
+// Load читает историю из файла; отсутствующий файл означает пустую историю
+func (h *History) Load() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.path == "" || h.limit <= 0 {
+		return nil
+	}
+	data, err := h.load()
+	if err != nil {
+		return err
+	}
+	h.data = data
+	return nil
+}
+
+// Add добавляет запись в конец своего раздела и сохраняет историю.
+// Повтор уже сохранённой записи переносит её в конец. Ошибка означает только сбой записи файла.
+func (h *History) Add(text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" || h.limit <= 0 {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	kind := KindOf(text)
+	if h.path == "" {
+		h.push(&h.data, kind, text)
+		return nil
+	}
+
+	// Файл перечитывается перед записью, чтобы не потерять ввод других запущенных клиентов
+	data, err := h.load()
+	if err != nil {
+		// Повреждённый файл не мешает работе: история остаётся в памяти
+		h.push(&h.data, kind, text)
+		return err
+	}
+	h.push(&data, kind, text)
+	h.data = data
+	return h.save(data)
+}
+
+// push добавляет запись в раздел с удалением повтора и обрезкой по лимиту
+func (h *History) push(data *file, kind Kind, text string) {
+	entries := data.entries(kind)
+	kept := make([]string, 0, len(*entries)+1)
+	for _, entry := range *entries {
+		if entry != text {
+			kept = append(kept, entry)
+		}
+	}
+	kept = append(kept, text)
+	if len(kept) > h.limit {
+		kept = kept[len(kept)-h.limit:]
+	}
+	*entries = kept
+}
+
+// Entries возвращает копию раздела истории от старых записей к новым
+func (h *History) Entries(kind Kind) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), *h.data.entries(kind)...)
+}
+
+// load читает файл истории
+func (h *History) load() (file, error) {
+	raw, err := os.ReadFile(h.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return file{}, nil
+		}
+		return file{}, apperrors.NewInternalError("READ_ERROR", "failed to read input history", err).
+			WithContext("path", h.path)
+	}
+
+	var data file
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return file{}, apperrors.NewInternalError("PARSE_ERROR", "failed to parse input history", err).
+			WithContext("path", h.path)
+	}
+	return data, nil
+}
+
+// save атомарно записывает файл истории
+func (h *History) save(data file) error {
+	raw, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return apperrors.NewInternalError("MARSHAL_ERROR", "failed to marshal input history", err)
+	}
+
+	dir := filepath.Dir(h.path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return apperrors.NewInternalError("MKDIR_ERROR", "failed to create input history directory", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(h.path)+".*.tmp")
+	if err != nil {
+		return apperrors.NewInternalError("WRITE_ERROR", "failed to create input history file", err)
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return apperrors.NewInternalError("WRITE_ERROR", "failed to write input history", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return apperrors.NewInternalError("WRITE_ERROR", "failed to write input history", err)
+	}
+	if err := os.Rename(tmpPath, h.path); err != nil {
+		os.Remove(tmpPath)
+		return apperrors.NewInternalError("WRITE_ERROR", "failed to save input history", err)
+	}
+	return nil
+}
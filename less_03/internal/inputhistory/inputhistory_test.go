@@ -0,0 +1,108 @@
+package inputhistory
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestHistory_Add(t *testing.T) {
+	h := New("", 3)
+
+	for _, text := range []string{"первый", "/help", "второй", "  ", "первый", "третий", "четвёртый"} {
+		if err := h.Add(text); err != nil {
+			t.Fatalf("Add(%q) error = %v", text, err)
+		}
+	}
+
+	// Повтор переносится в конец, пустой ввод не сохраняется, лимит - на каждый раздел
+	if got, want := h.Entries(Prompts), []string{"первый", "третий", "четвёртый"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Entries(Prompts) = %q, want %q", got, want)
+	}
+	if got := h.Entries(Commands); !reflect.DeepEqual(got, []string{"/help"}) {
+		t.Errorf("Entries(Commands) = %q", got)
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		h := New("", 0)
+		h.Add("text")
+		if len(h.Entries(Prompts)) != 0 {
+			t.Errorf("history with zero limit should stay empty")
+		}
+	})
+}
+
+func TestHistory_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history", "input_history.json")
+
+	first := New(path, 10)
+	first.Add("вопрос")
+	first.Add("/usage")
+
+	// Второй клиент дописывает свой ввод, не теряя записи первого
+	second := New(path, 10)
+	if err := second.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := second.Add("ещё вопрос"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	reloaded := New(path, 10)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got, want := reloaded.Entries(Prompts), []string{"вопрос", "ещё вопрос"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Entries(Prompts) = %q, want %q", got, want)
+	}
+	if got := reloaded.Entries(Commands); !reflect.DeepEqual(got, []string{"/usage"}) {
+		t.Errorf("Entries(Commands) = %q", got)
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		h := New(filepath.Join(t.TempDir(), "none.json"), 10)
+		if err := h.Load(); err != nil {
+			t.Errorf("Load() error = %v", err)
+		}
+	})
+
+	t.Run("corrupted file", func(t *testing.T) {
+		bad := filepath.Join(t.TempDir(), "bad.json")
+		os.WriteFile(bad, []byte("{"), 0600)
+		h := New(bad, 10)
+		if err := h.Load(); err == nil {
+			t.Errorf("Load() should fail on invalid JSON")
+		}
+		if err := h.Add("text"); err == nil {
+			t.Errorf("Add() should report invalid file")
+		}
+		if got := h.Entries(Prompts); len(got) != 1 {
+			t.Errorf("entry should be kept in memory, got %q", got)
+		}
+	})
+}
+
+func TestSearch(t *testing.T) {
+	entries := []string{"explain goroutines", "Write a Go test", "translate to go", "list files"}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"list files", "translate to go", "Write a Go test", "explain goroutines"}},
+		// Совпадения подстроки идут раньше нечётких, внутри групп - от новых к старым
+		{"go", []string{"translate to go", "Write a Go test", "explain goroutines"}},
+		{"wgt", []string{"Write a Go test"}},
+		{"ex gor", []string{"explain goroutines"}},
+		{"zzz", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := Search(entries, tt.query); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package inputhistory
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Search ищет записи по запросу без учёта регистра и возвращает их от новых к старым.
+// Сначала идут записи, содержащие запрос целиком, затем записи, в которых символы
+// запроса встречаются по порядку (нечёткое совпадение). Пустой запрос возвращает все записи.
+func Search(entries []string, query string) []string {
+	query = strings.ToLower(query)
+
+	var exact, fuzzy []string
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := strings.ToLower(entries[i])
+		switch {
+		case strings.Contains(entry, query):
+			exact = append(exact, entries[i])
+		case isSubsequence(entry, query):
+			fuzzy = append(fuzzy, entries[i])
+		}
+	}
+	return append(exact, fuzzy...)
+}
+
+// isSubsequence проверяет, что символы query встречаются в s по порядку;
+// пробелы в запросе не учитываются
+func isSubsequence(s, query string) bool {
+	rest := []rune(strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, query))
+	for _, r := range s {
+		if len(rest) == 0 {
+			break
+		}
+		if r == rest[0] {
+			rest = rest[1:]
+		}
+	}
+	return len(rest) == 0
+}
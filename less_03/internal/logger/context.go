@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+)
+
+// RequestIDKey - атрибут записи с идентификатором запроса
+const RequestIDKey = "request_id"
+
+// requestIDKey - ключ идентификатора запроса в context.Context
+type requestIDKey struct{}
+
+// NewRequestID создаёт случайный идентификатор запроса: req_3f9a0c1d2e4b5a69
+func NewRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "req_" + hex.EncodeToString(b)
+}
+
+// WithRequestID сохраняет идентификатор запроса в контексте.
+// Записи *Context методов с этим контекстом получают атрибут request_id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID возвращает идентификатор запроса из контекста ("" - не задан)
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler добавляет к записям идентификатор запроса из контекста
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle добавляет request_id, если он есть в контексте
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String(RequestIDKey, id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs сохраняет обёртку для производных логгеров
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup сохраняет обёртку для производных логгеров
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -58,6 +58,16 @@ func (l Level) ToSlogLevel() slog.Level {
 	}
 }
 
+// Format определяет формат записей в файле логов
+type Format string
+
+const (
+	// FormatText - записи key=value (slog.TextHandler)
+	FormatText Format = "text"
+	// FormatJSON - по одному JSON объекту на строку (slog.JSONHandler)
+	FormatJSON Format = "json"
+)
+
 // Config содержит конфигурацию логгера
 type Config struct {
 	// Enabled указывает включено ли логирование
@@ -68,12 +78,20 @@ type Config struct {
 	Level Level
 	// AddSource добавлять ли информацию об исходном коде
 	AddSource bool
+	// Redact настройки скрытия учётных и персональных данных
+	Redact RedactConfig
+	// Format формат записей, по умолчанию text
+	Format Format
+	// Rotate настройки ротации файла логов
+	Rotate RotateConfig
 }
 
 // Logger обёртка над slog.Logger для удобства
 type Logger struct {
 	logger *slog.Logger
 	config Config
+	// file - файл логов; общий для производных логгеров (With, WithGroup)
+	file io.Closer
 }
 
 // DefaultLogger логгер по умолчанию (отключён)
@@ -90,6 +108,7 @@ func init() {
 func NewLogger(cfg Config) *Logger {
 	var handler slog.Handler
 	var output io.Writer = io.Discard
+	var file io.Closer
 
 	if cfg.Enabled && cfg.FilePath != "" {
 		// Если указан каталог, создаём файл в нём
@@ -104,33 +123,65 @@ func NewLogger(cfg Config) *Logger {
 			cfg.Enabled = false
 		} else {
 			// Открываем файл для записи
-			f, err := os.OpenFile(cfg.FilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+			f, err := openRotatingFile(cfg.FilePath, cfg.Rotate)
 			if err != nil {
 				cfg.Enabled = false
 			} else {
-				output = f
+				output, file = f, f
 			}
 		}
 	}
 
+	redactor, err := NewRedactor(cfg.Redact)
+	if err != nil {
+		// Ошибочные правила отклоняются при проверке конфигурации; встроенные действуют всегда
+		redactor, _ = NewRedactor(RedactConfig{})
+	}
+
 	opts := &slog.HandlerOptions{
 		Level:     cfg.Level.ToSlogLevel(),
 		AddSource: cfg.AddSource,
-		ReplaceAttr: formatJSONAttrs,
 	}
 
-	handler = slog.NewTextHandler(output, opts)
+	if cfg.Format == FormatJSON {
+		opts.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+			// Данные скрываются до форматирования
+			return embedJSONAttrs(groups, redactor.Attr(groups, a))
+		}
+		handler = slog.NewJSONHandler(output, opts)
+	} else {
+		opts.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+			return formatJSONAttrs(groups, redactor.Attr(groups, a))
+		}
+		handler = slog.NewTextHandler(output, opts)
+	}
 
 	return &Logger{
-		logger: slog.New(handler),
+		logger: slog.New(contextHandler{handler}),
 		config: cfg,
+		file:   file,
 	}
 }
 
+// isJSONAttr проверяет, содержит ли атрибут JSON тело запроса или ответа
+func isJSONAttr(a slog.Attr) bool {
+	return a.Key == "body" || a.Key == "json" || a.Key == "request" || a.Key == "response"
+}
+
+// embedJSONAttrs вставляет JSON тела в запись как вложенный объект, а не строку
+func embedJSONAttrs(groups []string, a slog.Attr) slog.Attr {
+	if isJSONAttr(a) && a.Value.Kind() == slog.KindString {
+		if raw := []byte(a.Value.String()); json.Valid(raw) {
+			return slog.Any(a.Key, json.RawMessage(raw))
+		}
+	}
+	return a
+}
+
 // formatJSONAttrs форматирует JSON атрибуты с отступами для красоты
 func formatJSONAttrs(groups []string, a slog.Attr) slog.Attr {
 	// Если ключ содержит "body" или "json", форматируем значение с отступами
-	if a.Key == "body" || a.Key == "json" || a.Key == "request" || a.Key == "response" {
+	if isJSONAttr(a) {
 		if a.Value.Kind() == slog.KindString {
 			jsonStr := a.Value.String()
 			// Пробуем отформатировать JSON
@@ -205,6 +256,7 @@ func (l *Logger) With(args ...any) *Logger {
 	return &Logger{
 		logger: l.logger.With(args...),
 		config: l.config,
+		file:   l.file,
 	}
 }
 
@@ -213,14 +265,17 @@ func (l *Logger) WithGroup(name string) *Logger {
 	return &Logger{
 		logger: l.logger.WithGroup(name),
 		config: l.config,
+		file:   l.file,
 	}
 }
 
-// Close закрывает логгер (освобождает ресурсы)
+// Close сбрасывает записи на диск и закрывает файл логов.
+// Файл общий для производных логгеров: после Close их записи отбрасываются.
 func (l *Logger) Close() error {
-	// В текущей реализации ничего не делаем
-	// Файлы закрываются автоматически при завершении программы
-	return nil
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Close()
 }
 
 // === Глобальные функции для удобства ===
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"log/slog"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
@@ -288,3 +289,87 @@ func TestLogger_Close(t *testing.T) {
 		t.Errorf("Close() should not return error: %v", err)
 	}
 }
+
+func TestLogger_RedactsCredentials(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "test.log")
+	l := NewLogger(Config{Enabled: true, FilePath: logFile, Level: LevelDebug})
+
+	l.Debug("request",
+		"Authorization", "Bearer sk-header-secret",
+		"api_key", "sk-attr-secret",
+		"curl", "curl -H 'Authorization: Bearer sk-curl-secret' http://localhost",
+		"headers", http.Header{"X-Api-Key": {"sk-ant-secret"}, "Content-Type": {"application/json"}},
+	)
+	l.With("x-goog-api-key", "gemini-secret").Info("with")
+	l.Close()
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	for _, secret := range []string{"sk-header-secret", "sk-attr-secret", "sk-curl-secret", "sk-ant-secret", "gemini-secret"} {
+		if strings.Contains(string(content), secret) {
+			t.Errorf("log contains %q: %s", secret, content)
+		}
+	}
+	if !strings.Contains(string(content), "application/json") {
+		t.Errorf("non-sensitive headers should be kept: %s", content)
+	}
+}
+
+func TestNewLogger_JSONFileFormat(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "test.log")
+	l := NewLogger(Config{Enabled: true, FilePath: logFile, Level: LevelDebug, Format: FormatJSON})
+
+	l.Debug("Request body", "body", `{"model":"llama3","stream":true}`, "attempt", 1)
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	var entry struct {
+		Msg  string `json:"msg"`
+		Body struct {
+			Model string `json:"model"`
+		} `json:"body"`
+		Attempt int `json:"attempt"`
+	}
+	if err := json.Unmarshal(content, &entry); err != nil {
+		t.Fatalf("log line should be a single JSON object: %v\n%s", err, content)
+	}
+	if entry.Msg != "Request body" || entry.Body.Model != "llama3" || entry.Attempt != 1 {
+		t.Errorf("entry = %+v, want body embedded as JSON object", entry)
+	}
+}
+
+func TestLogger_RequestIDFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	l := &Logger{
+		logger: slog.New(contextHandler{slog.NewJSONHandler(&buf, nil)}),
+		config: Config{Enabled: true, Level: LevelInfo},
+	}
+
+	ctx := WithRequestID(context.Background(), "req_0123456789abcdef")
+	l.With("component", "client").InfoContext(ctx, "with id")
+	l.InfoContext(context.Background(), "without id")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 log entries, got %d", len(lines))
+	}
+	var entry map[string]any
+	json.Unmarshal([]byte(lines[0]), &entry)
+	if entry[RequestIDKey] != "req_0123456789abcdef" || entry["component"] != "client" {
+		t.Errorf("entry = %v, want request_id and component", entry)
+	}
+	if strings.Contains(lines[1], RequestIDKey) {
+		t.Errorf("entry without id in context = %s", lines[1])
+	}
+
+	if id := NewRequestID(); !strings.HasPrefix(id, "req_") || len(id) != 20 || id == NewRequestID() {
+		t.Errorf("NewRequestID() = %q", id)
+	}
+}
@@ -0,0 +1,288 @@
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// RedactMode определяет, как скрываются найденные в логе значения
+type RedactMode string
+
+const (
+	// RedactMask заменяет значение меткой [REDACTED:<правило>]
+	RedactMask RedactMode = "mask"
+	// RedactHash заменяет значение началом SHA-256: одинаковые значения можно сопоставить
+	RedactHash RedactMode = "hash"
+	// RedactDrop удаляет из записи атрибут, в котором найдено значение
+	RedactDrop RedactMode = "drop"
+)
+
+// RedactModes - допустимые режимы скрытия
+var RedactModes = []RedactMode{RedactMask, RedactHash, RedactDrop}
+
+// RedactRule - пользовательское правило скрытия. Если в выражении есть группа,
+// скрывается только первая группа, иначе всё совпадение: `password=(\S+)`.
+type RedactRule struct {
+	// Name - имя правила в метке замены
+	Name string
+	// Pattern - регулярное выражение (синтаксис RE2)
+	Pattern string
+}
+
+// RedactConfig - настройки скрытия данных в логе
+type RedactConfig struct {
+	// Mode - режим скрытия, по умолчанию mask
+	Mode RedactMode
+	// Rules - правила в дополнение к встроенным
+	Rules []RedactRule
+}
+
+// redacted заменяет значения атрибутов с учётными данными
+const redacted = "[REDACTED]"
+
+// credentialRule - имя правила для атрибутов и заголовков с учётными данными
+const credentialRule = "credential"
+
+// hashLen - количество шестнадцатеричных символов SHA-256 в режиме hash
+const hashLen = 12
+
+// sensitiveKeys - атрибуты и заголовки с учётными данными; их значения скрываются целиком
+var sensitiveKeys = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"x-api-key":           true,
+	"x-goog-api-key":      true,
+	"api_key":             true,
+	"api-key":             true,
+	"apikey":              true,
+	"cookie":              true,
+	"set-cookie":          true,
+}
+
+// detector находит в строке значения, которые не должны попасть в лог
+type detector struct {
+	name string
+	re   *regexp.Regexp
+	// valid отсеивает ложные совпадения; nil - подходит любое совпадение
+	valid func(string) bool
+}
+
+// builtinDetectors - встроенные правила. Номера карт проверяются раньше телефонов,
+// чтобы длинные последовательности цифр не принимались за номер телефона.
+var builtinDetectors = []detector{
+	// Значения Authorization внутри строк: тела ошибок, curl-команды
+	{name: "bearer", re: regexp.MustCompile(`(?i)\b(?:bearer|basic)\s+([A-Za-z0-9\-._~+/]+=*)`)},
+	// Ключи OpenAI/Anthropic (sk-), Google (AIza), GitHub, Slack, AWS
+	{name: "api_key", re: regexp.MustCompile(
+		`\b(?:sk-[A-Za-z0-9_\-]{16,}|AIza[0-9A-Za-z_\-]{35}|gh[pousr]_[A-Za-z0-9]{36,}|xox[abposr]-[A-Za-z0-9\-]{10,}|AKIA[0-9A-Z]{16})`)},
+	{name: "email", re: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`)},
+	// Номера платёжных систем начинаются с 2-6, что отсекает метки времени в миллисекундах
+	{name: "card", re: regexp.MustCompile(`\b[2-6]\d{3}(?:[ \-]?\d){9,15}\b`), valid: isCardNumber},
+	// Международный формат (+7 999 123-45-67) и российский с 8
+	{name: "phone", re: regexp.MustCompile(`(?:\+\d{1,3}[\s\-]?|\b8[\s\-]?)\(?\d{3}\)?[\s\-]?\d{3}[\s\-]?\d{2}[\s\-]?\d{2}\b`)},
+}
+
+// Redactor скрывает учётные данные и персональные данные в записях лога
+type Redactor struct {
+	mode      RedactMode
+	detectors []detector
+}
+
+// NewRedactor создаёт Redactor со встроенными и пользовательскими правилами
+func NewRedactor(cfg RedactConfig) (*Redactor, error) {
+	mode := cfg.Mode
+	if mode == "" {
+		mode = RedactMask
+	}
+	if !isValidRedactMode(mode) {
+		return nil, fmt.Errorf("unknown redact mode %q", mode)
+	}
+
+	detectors := append([]detector(nil), builtinDetectors...)
+	for i, rule := range cfg.Rules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("redact rule %d: %w", i, err)
+		}
+		name := rule.Name
+		if name == "" {
+			name = "custom"
+		}
+		detectors = append(detectors, detector{name: name, re: re})
+	}
+	return &Redactor{mode: mode, detectors: detectors}, nil
+}
+
+// isValidRedactMode проверяет режим скрытия
+func isValidRedactMode(mode RedactMode) bool {
+	for _, m := range RedactModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// String скрывает найденные значения в строке; в режиме drop они удаляются
+func (r *Redactor) String(s string) string {
+	clean, _ := r.scrub(s)
+	return clean
+}
+
+// Attr скрывает значения атрибута записи; подходит для slog.HandlerOptions.ReplaceAttr.
+// В режиме drop атрибут с найденным значением удаляется, у сообщения удаляются только значения.
+func (r *Redactor) Attr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) == 0 {
+		switch a.Key {
+		case slog.TimeKey, slog.LevelKey, slog.SourceKey:
+			return a
+		}
+	}
+
+	if sensitiveKeys[strings.ToLower(a.Key)] {
+		if r.mode == RedactDrop {
+			return slog.Attr{}
+		}
+		return slog.String(a.Key, r.replacement(credentialRule, a.Value.String()))
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return r.scrubAttr(groups, a, a.Value.String())
+	case slog.KindAny:
+		switch v := a.Value.Any().(type) {
+		case http.Header:
+			return slog.Any(a.Key, r.header(v))
+		case error:
+			// Ошибки API содержат тело ответа
+			return r.scrubAttr(groups, a, v.Error())
+		}
+	}
+	return a
+}
+
+// scrubAttr заменяет строковое значение атрибута очищенным, если в нём что-то найдено
+func (r *Redactor) scrubAttr(groups []string, a slog.Attr, s string) slog.Attr {
+	clean, found := r.scrub(s)
+	if !found {
+		return a
+	}
+	if r.mode == RedactDrop && (len(groups) > 0 || a.Key != slog.MessageKey) {
+		return slog.Attr{}
+	}
+	return slog.String(a.Key, clean)
+}
+
+// scrub применяет правила к строке; found - найдено ли хотя бы одно значение
+func (r *Redactor) scrub(s string) (clean string, found bool) {
+	clean = s
+	for _, d := range r.detectors {
+		var ok bool
+		clean, ok = r.replaceAll(d, clean)
+		found = found || ok
+	}
+	return clean, found
+}
+
+// replaceAll заменяет совпадения правила
+func (r *Redactor) replaceAll(d detector, s string) (string, bool) {
+	matches := d.re.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return s, false
+	}
+
+	var b strings.Builder
+	last, found := 0, false
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		// Если в выражении есть группа, остальная часть совпадения (например, "Bearer ") сохраняется
+		if len(m) >= 4 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		value := s[start:end]
+		if value == "" || (d.valid != nil && !d.valid(value)) {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(r.replacement(d.name, value))
+		last = end
+		found = true
+	}
+	if !found {
+		return s, false
+	}
+	b.WriteString(s[last:])
+	return b.String(), true
+}
+
+// replacement возвращает замену значения в текущем режиме
+func (r *Redactor) replacement(rule, value string) string {
+	switch r.mode {
+	case RedactHash:
+		sum := sha256.Sum256([]byte(value))
+		return "[" + rule + ":" + hex.EncodeToString(sum[:])[:hashLen] + "]"
+	case RedactDrop:
+		return ""
+	default:
+		if rule == credentialRule {
+			return redacted
+		}
+		return "[REDACTED:" + rule + "]"
+	}
+}
+
+// header возвращает копию заголовков со скрытыми значениями аутентификации
+func (r *Redactor) header(h http.Header) http.Header {
+	clean := h.Clone()
+	for name, values := range clean {
+		if !sensitiveKeys[strings.ToLower(name)] {
+			continue
+		}
+		if r.mode == RedactDrop {
+			delete(clean, name)
+			continue
+		}
+		for i := range values {
+			values[i] = r.replacement(credentialRule, values[i])
+		}
+	}
+	return clean
+}
+
+// Header возвращает копию заголовков HTTP: значения аутентификации скрываются целиком,
+// в остальных - найденные правилами значения
+func (r *Redactor) Header(h http.Header) http.Header {
+	clean := r.header(h)
+	for _, values := range clean {
+		for i := range values {
+			values[i] = r.String(values[i])
+		}
+	}
+	return clean
+}
+
+// isCardNumber проверяет контрольную сумму номера карты (алгоритм Луна)
+func isCardNumber(s string) bool {
+	sum, digits := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if digits%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+	}
+	return digits >= 13 && digits <= 19 && sum%10 == 0
+}
@@ -0,0 +1,226 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat - метка времени в имени архивного файла: llm-client.log.20261016-095000.123
+const backupTimeFormat = "20060102-150405.000"
+
+// RotateConfig содержит настройки ротации файла логов
+type RotateConfig struct {
+	// MaxSize - размер файла в байтах, после которого начинается новый файл (0 - без ограничения)
+	MaxSize int64
+	// MaxAge - время, после которого начинается новый файл (0 - без ограничения).
+	// Отсчитывается от открытия файла или предыдущей ротации.
+	MaxAge time.Duration
+	// MaxBackups - сколько архивных файлов хранить (0 - все)
+	MaxBackups int
+	// Compress - сжимать ли архивные файлы gzip
+	Compress bool
+}
+
+// rotatingFile - файл логов с ротацией по размеру и времени.
+// Безопасен для одновременной записи из нескольких горутин.
+type rotatingFile struct {
+	path   string
+	config RotateConfig
+	// now - источник времени (подменяется в тестах)
+	now func() time.Time
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	closed   bool
+
+	// archiveMu упорядочивает сжатие и удаление архивных файлов, wg - ожидание при Close
+	archiveMu sync.Mutex
+	wg        sync.WaitGroup
+}
+
+// openRotatingFile открывает файл логов для дозаписи
+func openRotatingFile(path string, cfg RotateConfig) (*rotatingFile, error) {
+	f := &rotatingFile{path: path, config: cfg, now: time.Now}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// open открывает текущий файл; вызывается под mu или до начала записи
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = f.now()
+	return nil
+}
+
+// Write записывает запись целиком в текущий файл, при необходимости начиная новый
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+	if f.needsRotation(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// needsRotation проверяет, нужно ли начать новый файл перед записью n байт.
+// Пустой файл не ротируется, даже если одна запись больше MaxSize.
+func (f *rotatingFile) needsRotation(n int64) bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.config.MaxSize > 0 && f.size+n > f.config.MaxSize {
+		return true
+	}
+	return f.config.MaxAge > 0 && f.now().Sub(f.openedAt) >= f.config.MaxAge
+}
+
+// rotate переименовывает текущий файл в архивный и открывает новый; вызывается под mu
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	backup := f.backupName()
+	if err := os.Rename(f.path, backup); err != nil {
+		return fmt.Errorf("rotate log file: %w", err)
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	// Сжатие и удаление старых файлов не задерживают запись
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		f.archive(backup)
+	}()
+	return nil
+}
+
+// backupName возвращает свободное имя архивного файла
+func (f *rotatingFile) backupName() string {
+	stamp := f.now().Format(backupTimeFormat)
+	name := f.path + "." + stamp
+	for i := 1; fileExists(name) || fileExists(name+".gz"); i++ {
+		name = fmt.Sprintf("%s.%s-%d", f.path, stamp, i)
+	}
+	return name
+}
+
+// archive сжимает архивный файл и удаляет лишние
+func (f *rotatingFile) archive(backup string) {
+	f.archiveMu.Lock()
+	defer f.archiveMu.Unlock()
+
+	// Ошибки обслуживания архива не должны мешать логированию: файл остаётся несжатым
+	if f.config.Compress {
+		if err := compressFile(backup); err == nil {
+			os.Remove(backup)
+		}
+	}
+	if f.config.MaxBackups > 0 {
+		backups := f.backups()
+		for _, name := range backups[:max(0, len(backups)-f.config.MaxBackups)] {
+			os.Remove(name)
+		}
+	}
+}
+
+// backups возвращает архивные файлы от старых к новым
+func (f *rotatingFile) backups() []string {
+	matches, _ := filepath.Glob(f.path + ".*")
+	var backups []string
+	for _, name := range matches {
+		// Незавершённые .gz.tmp не считаются архивами
+		if !strings.HasSuffix(name, ".tmp") {
+			backups = append(backups, name)
+		}
+	}
+	// Метка времени в имени упорядочивает файлы по времени ротации
+	sort.Strings(backups)
+	return backups
+}
+
+// Close дожидается обслуживания архива, сбрасывает данные на диск и закрывает файл.
+// Запись после Close возвращает os.ErrClosed.
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return nil
+	}
+	f.closed = true
+	syncErr := f.file.Sync()
+	closeErr := f.file.Close()
+	f.mu.Unlock()
+
+	f.wg.Wait()
+	if closeErr != nil {
+		return closeErr
+	}
+	return syncErr
+}
+
+// compressFile записывает name.gz через временный файл, чтобы прерванное сжатие не оставило битый архив
+func compressFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp := name + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name+".gz")
+}
+
+// fileExists проверяет существование файла
+func fileExists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
@@ -0,0 +1,199 @@
+package logger
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countLines считает строки во всех файлах логов каталога, включая сжатые
+func countLines(t *testing.T, dir string) int {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := 0
+	for _, e := range entries {
+		f, err := os.Open(filepath.Join(dir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var r io.Reader = f
+		if strings.HasSuffix(e.Name(), ".gz") {
+			zr, err := gzip.NewReader(f)
+			if err != nil {
+				t.Fatalf("%s: %v", e.Name(), err)
+			}
+			r = zr
+		}
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			total++
+		}
+		f.Close()
+	}
+	return total
+}
+
+func TestRotatingFile_SizeRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	f, err := openRotatingFile(path, RotateConfig{MaxSize: 100, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	f.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+
+	line := strings.Repeat("x", 39) + "\n"
+	for i := 0; i < 10; i++ {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	backups := f.backups()
+	if len(backups) != 2 {
+		t.Fatalf("backups = %v, want 2 kept files", backups)
+	}
+	for _, name := range append(backups, path) {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() > 100 {
+			t.Errorf("%s size = %d, want <= 100", name, info.Size())
+		}
+	}
+	// Текущий файл и два последних архива: 2 + 2 + 2 записи
+	if got := countLines(t, dir); got != 6 {
+		t.Errorf("kept %d lines, want 6", got)
+	}
+}
+
+func TestRotatingFile_AgeRotationAndCompress(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	f, err := openRotatingFile(path, RotateConfig{MaxAge: time.Hour, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	f.now = func() time.Time { return clock }
+	f.openedAt = clock
+
+	f.Write([]byte("first\n"))
+	clock = clock.Add(30 * time.Minute)
+	f.Write([]byte("second\n"))
+	clock = clock.Add(31 * time.Minute)
+	f.Write([]byte("third\n"))
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	backups := f.backups()
+	if len(backups) != 1 || !strings.HasSuffix(backups[0], ".gz") {
+		t.Fatalf("backups = %v, want one compressed file", backups)
+	}
+	if !strings.Contains(backups[0], "20261016-100100.000") {
+		t.Errorf("backup name %q should contain rotation time", backups[0])
+	}
+	current, _ := os.ReadFile(path)
+	if string(current) != "third\n" {
+		t.Errorf("current file = %q, want only the record after rotation", current)
+	}
+	if got := countLines(t, dir); got != 3 {
+		t.Errorf("total lines = %d, want 3", got)
+	}
+}
+
+func TestRotatingFile_ConcurrentWrites(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	l := NewLogger(Config{
+		Enabled:  true,
+		FilePath: path,
+		Level:    LevelInfo,
+		Rotate:   RotateConfig{MaxSize: 2048, MaxBackups: 1000},
+	})
+
+	const goroutines, records = 8, 200
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < records; i++ {
+				l.Info("chunk", "goroutine", g, "seq", i)
+			}
+		}(g)
+	}
+	wg.Wait()
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if got := countLines(t, dir); got != goroutines*records {
+		t.Errorf("lines = %d, want %d: records lost or split during rotation", got, goroutines*records)
+	}
+}
+
+func TestRotatingFile_WriteAfterClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := openRotatingFile(path, RotateConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("before\n"))
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Errorf("second Close() error = %v", err)
+	}
+	if _, err := f.Write([]byte("after\n")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Write() after Close error = %v, want os.ErrClosed", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "before\n" {
+		t.Errorf("file = %q", data)
+	}
+}
+
+func TestRotatingFile_AppendsToExisting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte(strings.Repeat("x", 90)+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := openRotatingFile(path, RotateConfig{MaxSize: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte(fmt.Sprintf("%s\n", strings.Repeat("y", 20))))
+	f.Close()
+
+	// Размер существующего файла учитывается: запись уходит в новый файл
+	if backups := f.backups(); len(backups) != 1 {
+		t.Errorf("backups = %v, want existing file rotated", backups)
+	}
+}
@@ -0,0 +1,363 @@
+package markdown
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// tokenKind - класс лексемы при подсветке кода
+type tokenKind int
+
+const (
+	tokenPlain tokenKind = iota
+	tokenKeyword
+	tokenType
+	tokenFunc
+	tokenString
+	tokenNumber
+	tokenComment
+)
+
+// token - лексема исходного кода
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// language описывает лексику языка для подсветки.
+// Это не полноценный разбор: выделяются комментарии, строки, числа, ключевые слова
+// и встроенные типы, чего достаточно для фрагментов кода в ответах модели.
+type language struct {
+	keywords map[string]bool
+	types    map[string]bool
+	// lineComments - начала однострочных комментариев
+	lineComments []string
+	// blockComment - начало и конец многострочного комментария
+	blockComment [2]string
+	// quotes - символы кавычек; multiline - кавычки, строки в которых могут занимать несколько строк
+	quotes    string
+	multiline string
+	// tripleQuotes - строки в тройных кавычках (Python)
+	tripleQuotes bool
+	// keys - идентификатор или строка перед ':' подсвечивается как ключ (JSON, YAML)
+	keys bool
+	// ignoreCase - ключевые слова без учёта регистра (SQL)
+	ignoreCase bool
+}
+
+// languages - поддерживаемые языки по названию из заголовка блока кода
+var languages = map[string]*language{}
+
+func init() {
+	cLike := func(keywords, types string) *language {
+		return &language{
+			keywords:     wordSet(keywords),
+			types:        wordSet(types),
+			lineComments: []string{"//"},
+			blockComment: [2]string{"/*", "*/"},
+			quotes:       `"'`,
+		}
+	}
+
+	golang := cLike("break case chan const continue default defer else fallthrough for func go goto if "+
+		"import interface map package range return select struct switch type var nil true false iota",
+		"bool byte complex64 complex128 error float32 float64 int int8 int16 int32 int64 rune string "+
+			"uint uint8 uint16 uint32 uint64 uintptr any comparable append cap close copy delete len make new panic print println recover")
+	golang.quotes, golang.multiline = "\"'`", "`"
+	register(golang, "go", "golang")
+
+	js := cLike("async await break case catch class const continue debugger default delete do else export extends "+
+		"finally for from function if import in instanceof let new of return static super switch this throw try "+
+		"typeof var void while with yield null undefined true false interface type enum implements readonly as",
+		"Array Boolean Date Error Map Math Number Object Promise RegExp Set String Symbol JSON console "+
+			"string number boolean any unknown never void")
+	js.quotes, js.multiline = "\"'`", "`"
+	register(js, "javascript", "js", "jsx", "typescript", "ts", "tsx")
+
+	register(cLike("as break const continue crate else enum extern false fn for if impl in let loop match mod move mut "+
+		"pub ref return self Self static struct super trait true type unsafe use where while async await dyn",
+		"bool char f32 f64 i8 i16 i32 i64 i128 isize str u8 u16 u32 u64 u128 usize String Vec Option Result Box Some None Ok Err"),
+		"rust", "rs")
+
+	register(cLike("auto break case catch class const continue default delete do else enum explicit extern false for "+
+		"friend goto if inline namespace new nullptr operator private protected public return sizeof static struct "+
+		"switch template this throw true try typedef typename union using virtual volatile while #include #define",
+		"bool char double float int long short signed unsigned void size_t std string vector"),
+		"c", "cpp", "c++", "h", "hpp", "cc")
+
+	register(cLike("abstract break case catch class continue default do else enum extends final finally for if "+
+		"implements import instanceof interface new package private protected public return static super switch "+
+		"this throw throws try void while true false null var val fun when object override data",
+		"boolean byte char double float int long short String Integer List Map Object Int Boolean Unit Any"),
+		"java", "kotlin", "kt", "csharp", "cs", "c#", "swift")
+
+	register(&language{
+		keywords: wordSet("and as assert async await break class continue def del elif else except finally for from " +
+			"global if import in is lambda nonlocal not or pass raise return try while with yield None True False self"),
+		types:        wordSet("bool bytes dict float int list object set str tuple print len range open super isinstance"),
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+		tripleQuotes: true,
+	}, "python", "py", "python3")
+
+	register(&language{
+		keywords: wordSet("if then else elif fi for while until do done case esac in function return local export " +
+			"readonly unset shift exit source alias"),
+		types:        wordSet("echo cd ls cat grep sed awk curl git go make docker sudo mkdir rm cp mv chmod export printf"),
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+		multiline:    `"'`,
+	}, "bash", "sh", "shell", "zsh", "console")
+
+	register(&language{
+		keywords:     wordSet("true false null"),
+		lineComments: []string{"//"},
+		quotes:       `"`,
+		keys:         true,
+	}, "json", "jsonc")
+
+	register(&language{
+		keywords:     wordSet("true false null yes no on off"),
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+		keys:         true,
+	}, "yaml", "yml", "toml")
+
+	register(&language{
+		keywords: wordSet("select from where and or not in is null like join left right inner outer full on as " +
+			"group by order having limit offset insert into values update set delete create table index view drop " +
+			"alter add primary key foreign references distinct union all case when then else end exists asc desc"),
+		types:        wordSet("int integer bigint smallint text varchar char boolean date timestamp numeric decimal serial count sum avg min max"),
+		lineComments: []string{"--"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       `'"`,
+		ignoreCase:   true,
+	}, "sql", "postgresql", "mysql", "sqlite")
+}
+
+// register добавляет язык под несколькими названиями
+func register(lang *language, names ...string) {
+	for _, name := range names {
+		languages[name] = lang
+	}
+}
+
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(words) {
+		set[w] = true
+	}
+	return set
+}
+
+// highlight подсвечивает код и возвращает фрагменты по строкам.
+// Для неизвестного языка код выводится без подсветки.
+func (r *Renderer) highlight(lang, code string) [][]span {
+	l := languages[strings.ToLower(lang)]
+	if l == nil {
+		var lines [][]span
+		for _, line := range strings.Split(code, "\n") {
+			lines = append(lines, []span{{line, r.theme.CodeText}})
+		}
+		return lines
+	}
+
+	lines := [][]span{nil}
+	for _, tok := range l.lex(code) {
+		style := r.tokenStyle(tok.kind)
+		for i, part := range strings.Split(tok.text, "\n") {
+			if i > 0 {
+				lines = append(lines, nil)
+			}
+			if part != "" {
+				lines[len(lines)-1] = append(lines[len(lines)-1], span{part, style})
+			}
+		}
+	}
+	return lines
+}
+
+// tokenStyle возвращает стиль класса лексем
+func (r *Renderer) tokenStyle(kind tokenKind) lipgloss.Style {
+	switch kind {
+	case tokenKeyword:
+		return r.theme.Keyword
+	case tokenType:
+		return r.theme.Type
+	case tokenFunc:
+		return r.theme.Func
+	case tokenString:
+		return r.theme.String
+	case tokenNumber:
+		return r.theme.Number
+	case tokenComment:
+		return r.theme.Comment
+	default:
+		return r.theme.CodeText
+	}
+}
+
+// lex разбивает код на лексемы
+func (l *language) lex(code string) []token {
+	var tokens []token
+	var plain strings.Builder
+	push := func(kind tokenKind, text string) {
+		if plain.Len() > 0 {
+			tokens = append(tokens, token{tokenPlain, plain.String()})
+			plain.Reset()
+		}
+		tokens = append(tokens, token{kind, text})
+	}
+
+	for i := 0; i < len(code); {
+		rest := code[i:]
+
+		if n := l.matchComment(rest); n > 0 {
+			push(tokenComment, rest[:n])
+			i += n
+			continue
+		}
+		if n := l.matchString(rest); n > 0 {
+			kind := tokenString
+			if l.keys && isKey(code[i+n:]) {
+				kind = tokenType
+			}
+			push(kind, rest[:n])
+			i += n
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(rest)
+		switch {
+		case unicode.IsDigit(r) && (i == 0 || !isIdentByte(code[i-1])):
+			n := scanNumber(rest)
+			push(tokenNumber, rest[:n])
+			i += n
+		case isIdentStart(r) || r == '#' && l.keywords["#"+identAt(rest[1:])]:
+			word := identAt(rest)
+			if r == '#' {
+				// Директивы препроцессора C: #include, #define
+				word = "#" + identAt(rest[1:])
+			}
+			push(l.classify(word, code[i+len(word):]), word)
+			i += len(word)
+		default:
+			plain.WriteString(rest[:size])
+			i += size
+		}
+	}
+	if plain.Len() > 0 {
+		tokens = append(tokens, token{tokenPlain, plain.String()})
+	}
+	return tokens
+}
+
+// classify определяет класс идентификатора по словарям и следующему за ним тексту
+func (l *language) classify(word, rest string) tokenKind {
+	lookup := word
+	if l.ignoreCase {
+		lookup = strings.ToLower(word)
+	}
+	switch {
+	case l.keys && isKey(rest):
+		return tokenType
+	case l.keywords[lookup]:
+		return tokenKeyword
+	case l.types[lookup]:
+		return tokenType
+	case strings.HasPrefix(strings.TrimLeft(rest, " "), "("):
+		return tokenFunc
+	default:
+		return tokenPlain
+	}
+}
+
+// matchComment возвращает длину комментария в начале строки (0 если нет)
+func (l *language) matchComment(s string) int {
+	for _, prefix := range l.lineComments {
+		if strings.HasPrefix(s, prefix) {
+			if end := strings.IndexByte(s, '\n'); end >= 0 {
+				return end
+			}
+			return len(s)
+		}
+	}
+	if start, end := l.blockComment[0], l.blockComment[1]; start != "" && strings.HasPrefix(s, start) {
+		if n := strings.Index(s[len(start):], end); n >= 0 {
+			return len(start) + n + len(end)
+		}
+		// Незакрытый комментарий продолжается до конца блока
+		return len(s)
+	}
+	return 0
+}
+
+// matchString возвращает длину строкового литерала в начале строки (0 если нет)
+func (l *language) matchString(s string) int {
+	if s == "" || strings.IndexByte(l.quotes, s[0]) < 0 {
+		return 0
+	}
+	q := s[0]
+	if l.tripleQuotes && len(s) >= 3 && s[1] == q && s[2] == q {
+		if n := strings.Index(s[3:], s[:3]); n >= 0 {
+			return 3 + n + 3
+		}
+		return len(s)
+	}
+
+	multiline := strings.IndexByte(l.multiline, q) >= 0
+	for j := 1; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			if q != '`' {
+				j++
+			}
+		case q:
+			return j + 1
+		case '\n':
+			if !multiline {
+				return j
+			}
+		}
+	}
+	return len(s)
+}
+
+// isKey проверяет, что за лексемой следует ':' (ключ в JSON/YAML)
+func isKey(rest string) bool {
+	rest = strings.TrimLeft(rest, " \t")
+	return strings.HasPrefix(rest, ":") && !strings.HasPrefix(rest, "::")
+}
+
+// scanNumber возвращает длину числа: цифры, точка, шестнадцатеричные цифры, суффиксы и "_"
+func scanNumber(s string) int {
+	n := 0
+	for n < len(s) && (isIdentByte(s[n]) || s[n] == '.' && n+1 < len(s) && s[n+1] >= '0' && s[n+1] <= '9') {
+		n++
+	}
+	return n
+}
+
+// identAt возвращает идентификатор в начале строки
+func identAt(s string) string {
+	n := 0
+	for n < len(s) {
+		r, size := utf8.DecodeRuneInString(s[n:])
+		if !isIdentStart(r) && !unicode.IsDigit(r) {
+			break
+		}
+		n += size
+	}
+	return s[:n]
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r)
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,54 @@
+package markdown
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLanguage_Lex(t *testing.T) {
+	kinds := func(lang, code string) map[string]tokenKind {
+		result := make(map[string]tokenKind)
+		for _, tok := range languages[lang].lex(code) {
+			if tok.kind != tokenPlain {
+				result[tok.text] = tok.kind
+			}
+		}
+		return result
+	}
+
+	tests := []struct {
+		lang string
+		code string
+		want map[string]tokenKind
+	}{
+		{"go", "func main() { x := len(`a\nb`) // done\n}", map[string]tokenKind{
+			"func": tokenKeyword, "main": tokenFunc, "len": tokenType, "`a\nb`": tokenString, "// done": tokenComment,
+		}},
+		{"python", "def f(n=1.5):\n    return \"\"\"doc\"\"\"  # note", map[string]tokenKind{
+			"def": tokenKeyword, "f": tokenFunc, "1.5": tokenNumber, "return": tokenKeyword,
+			`"""doc"""`: tokenString, "# note": tokenComment,
+		}},
+		{"json", `{"name": "go", "n": 1, "ok": true}`, map[string]tokenKind{
+			`"name"`: tokenType, `"go"`: tokenString, `"n"`: tokenType, "1": tokenNumber, `"ok"`: tokenType, "true": tokenKeyword,
+		}},
+		{"sql", "SELECT id FROM users /* all */", map[string]tokenKind{
+			"SELECT": tokenKeyword, "FROM": tokenKeyword, "/* all */": tokenComment,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			if got := kinds(tt.lang, tt.code); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lex(%q) = %v, want %v", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderer_HighlightUnknownLanguage(t *testing.T) {
+	r := NewRenderer(40, DarkTheme())
+	lines := r.highlight("brainfuck", "+++\n---")
+	if len(lines) != 2 || lines[0][0].text != "+++" {
+		t.Errorf("highlight() = %+v", lines)
+	}
+}
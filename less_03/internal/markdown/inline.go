@@ -0,0 +1,346 @@
+package markdown
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// span - фрагмент текста с единым стилем
+type span struct {
+	text  string
+	style lipgloss.Style
+}
+
+// word - фрагменты текста между пробелами
+type word []span
+
+// parseInline разбирает строчную разметку: `код`, **жирный**, *курсив*, ~~зачёркнутый~~,
+// [ссылки](url) и <автоссылки>. Если open = true, незакрытые ** и ` действуют до конца
+// строки: так выделение не «мигает», пока закрывающий маркер ещё не получен.
+func (r *Renderer) parseInline(text string, style lipgloss.Style, open bool) []span {
+	var spans []span
+	var plain strings.Builder
+	flush := func() {
+		if plain.Len() > 0 {
+			spans = append(spans, span{plain.String(), style})
+			plain.Reset()
+		}
+	}
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch c {
+		case '\\':
+			if i+1 < len(text) && isASCIIPunct(text[i+1]) {
+				plain.WriteByte(text[i+1])
+				i += 2
+				continue
+			}
+
+		case '`':
+			n := runLength(text[i:], '`')
+			fence := text[i : i+n]
+			if end := strings.Index(text[i+n:], fence); end >= 0 {
+				flush()
+				spans = append(spans, span{trimCodeSpan(text[i+n : i+n+end]), r.theme.Code})
+				i += n + end + n
+				continue
+			}
+			if open {
+				flush()
+				return append(spans, span{text[i+n:], r.theme.Code})
+			}
+			plain.WriteString(fence)
+			i += n
+			continue
+
+		case '*', '_', '~':
+			n := runLength(text[i:], c)
+			k := min(n, 3)
+			if c == '~' {
+				k = 2
+			}
+			if n >= k && canOpen(text, i, k, c) {
+				marker := text[i : i+k]
+				if end := findClose(text, i+k, marker); end >= 0 {
+					flush()
+					spans = append(spans, r.parseInline(text[i+k:end], emphasis(style, marker), false)...)
+					i = end + k
+					continue
+				}
+				if open && k > 1 {
+					flush()
+					return append(spans, r.parseInline(text[i+k:], emphasis(style, marker), true)...)
+				}
+			}
+			// Маркер без пары выводится как есть
+			plain.WriteString(text[i : i+n])
+			i += n
+			continue
+
+		case '[', '!':
+			start := i
+			if c == '!' {
+				if i+1 >= len(text) || text[i+1] != '[' {
+					break
+				}
+				start++
+			}
+			if label, url, end, ok := parseLink(text, start); ok {
+				flush()
+				spans = append(spans, r.parseInline(label, r.theme.Link, false)...)
+				if url != label {
+					spans = append(spans, span{" (" + url + ")", r.theme.URL})
+				}
+				i = end
+				continue
+			}
+
+		case '<':
+			if end := strings.IndexByte(text[i:], '>'); end > 0 {
+				url := text[i+1 : i+end]
+				if strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") {
+					flush()
+					spans = append(spans, span{url, r.theme.Link})
+					i += end + 1
+					continue
+				}
+			}
+		}
+		plain.WriteByte(c)
+		i++
+	}
+	flush()
+	return spans
+}
+
+// emphasis возвращает стиль выделения для маркера
+func emphasis(style lipgloss.Style, marker string) lipgloss.Style {
+	switch marker {
+	case "~~":
+		return style.Strikethrough(true)
+	case "*", "_":
+		return style.Italic(true)
+	case "**", "__":
+		return style.Bold(true)
+	default:
+		return style.Bold(true).Italic(true)
+	}
+}
+
+// canOpen проверяет, что маркер выделения открывает фрагмент: за ним не пробел,
+// а "_" не стоит внутри слова (snake_case)
+func canOpen(text string, i, k int, c byte) bool {
+	if i+k >= len(text) || text[i+k] == ' ' {
+		return false
+	}
+	if c == '_' && i > 0 {
+		prev, _ := utf8.DecodeLastRuneInString(text[:i])
+		return !isWordRune(prev)
+	}
+	return true
+}
+
+// findClose ищет закрывающий маркер такой же длины; код в обратных кавычках пропускается
+func findClose(text string, from int, marker string) int {
+	c := marker[0]
+	for j := from; j < len(text); {
+		switch text[j] {
+		case '`':
+			n := runLength(text[j:], '`')
+			if end := strings.Index(text[j+n:], text[j:j+n]); end >= 0 {
+				j += n + end + n
+				continue
+			}
+			j += n
+		case c:
+			n := runLength(text[j:], c)
+			if n == len(marker) && j > from && text[j-1] != ' ' {
+				if c != '_' || j+n >= len(text) {
+					return j
+				}
+				if next, _ := utf8.DecodeRuneInString(text[j+n:]); !isWordRune(next) {
+					return j
+				}
+			}
+			j += n
+		default:
+			j++
+		}
+	}
+	return -1
+}
+
+// parseLink разбирает [текст](url) начиная с '['. Возвращает текст, адрес и позицию после ссылки.
+func parseLink(text string, start int) (string, string, int, bool) {
+	closeLabel := strings.Index(text[start:], "](")
+	if closeLabel < 0 {
+		return "", "", 0, false
+	}
+	labelEnd := start + closeLabel
+	closeURL := strings.IndexByte(text[labelEnd+2:], ')')
+	if closeURL < 0 {
+		return "", "", 0, false
+	}
+	urlEnd := labelEnd + 2 + closeURL
+	label := text[start+1 : labelEnd]
+	url := strings.TrimSpace(text[labelEnd+2 : urlEnd])
+	if label == "" {
+		label = url
+	}
+	return label, url, urlEnd + 1, true
+}
+
+// trimCodeSpan убирает по одному пробелу с краёв кода, если они есть с обеих сторон
+func trimCodeSpan(code string) string {
+	if len(code) >= 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+		return code[1 : len(code)-1]
+	}
+	return code
+}
+
+func runLength(s string, c byte) int {
+	n := 0
+	for n < len(s) && s[n] == c {
+		n++
+	}
+	return n
+}
+
+func isASCIIPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// === Перенос строк ===
+
+// splitWords разбивает фрагменты на слова по пробелам, сохраняя стили
+func splitWords(spans []span) []word {
+	var words []word
+	var current word
+	for _, s := range spans {
+		for i, part := range strings.Split(s.text, " ") {
+			if i > 0 && len(current) > 0 {
+				words = append(words, current)
+				current = nil
+			}
+			if part != "" {
+				current = append(current, span{part, s.style})
+			}
+		}
+	}
+	if len(current) > 0 {
+		words = append(words, current)
+	}
+	return words
+}
+
+// wrapSpans переносит текст по словам; слова длиннее строки разбиваются по символам
+func wrapSpans(spans []span, width int) []string {
+	var lines []string
+	var line strings.Builder
+	lineWidth := 0
+
+	for _, w := range splitWords(spans) {
+		ww := spansWidth(w)
+		if ww > width {
+			for _, part := range hardWrap(w, width) {
+				pw := spansWidth(part)
+				if lineWidth > 0 && lineWidth+1+pw > width {
+					lines = append(lines, line.String())
+					line.Reset()
+					lineWidth = 0
+				}
+				if lineWidth > 0 {
+					line.WriteString(" ")
+					lineWidth++
+				}
+				line.WriteString(renderSpans(part))
+				lineWidth += pw
+			}
+			continue
+		}
+		if lineWidth > 0 && lineWidth+1+ww > width {
+			lines = append(lines, line.String())
+			line.Reset()
+			lineWidth = 0
+		}
+		if lineWidth > 0 {
+			line.WriteString(" ")
+			lineWidth++
+		}
+		line.WriteString(renderSpans(w))
+		lineWidth += ww
+	}
+	if lineWidth > 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// hardWrap разбивает фрагменты на части не шире width, не разделяя символы
+func hardWrap(spans []span, width int) [][]span {
+	var parts [][]span
+	var part []span
+	partWidth := 0
+	for _, s := range spans {
+		var chunk strings.Builder
+		for _, r := range s.text {
+			rw := lipgloss.Width(string(r))
+			if partWidth+rw > width && partWidth > 0 {
+				if chunk.Len() > 0 {
+					part = append(part, span{chunk.String(), s.style})
+					chunk.Reset()
+				}
+				parts = append(parts, part)
+				part, partWidth = nil, 0
+			}
+			chunk.WriteRune(r)
+			partWidth += rw
+		}
+		if chunk.Len() > 0 {
+			part = append(part, span{chunk.String(), s.style})
+		}
+	}
+	if len(part) > 0 || len(parts) == 0 {
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// truncateSpans обрезает фрагменты до ширины width, добавляя "…"
+func truncateSpans(spans []span, width int) []span {
+	if spansWidth(spans) <= width {
+		return spans
+	}
+	if width <= 1 {
+		return []span{{"…", lipgloss.NewStyle()}}
+	}
+	parts := hardWrap(spans, width-1)
+	last := parts[0][len(parts[0])-1].style
+	return append(parts[0], span{"…", last})
+}
+
+// renderSpans применяет стили фрагментов
+func renderSpans(spans []span) string {
+	var b strings.Builder
+	for _, s := range spans {
+		b.WriteString(s.style.Render(s.text))
+	}
+	return b.String()
+}
+
+// spansWidth возвращает ширину текста фрагментов на экране
+func spansWidth(spans []span) int {
+	w := 0
+	for _, s := range spans {
+		w += lipgloss.Width(s.text)
+	}
+	return w
+}
@@ -0,0 +1,423 @@
+// Package markdown отображает Markdown ответов модели в терминале: заголовки, выделение,
+// списки, цитаты, таблицы и блоки кода с подсветкой синтаксиса.
+//
+// Разбор построчный: каждая строка исходного текста - отдельная строка вывода
+// (как в plain-режиме), блоки разделяются пустыми строками. Это позволяет
+// отображать ответ по мере получения: завершённые блоки не меняются при дописывании.
+package markdown
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// minWidth - минимальная ширина вывода
+const minWidth = 10
+
+var (
+	headingRe  = regexp.MustCompile(`^ {0,3}(#{1,6})(?:\s+(.*?))?\s*#*\s*$`)
+	ruleRe     = regexp.MustCompile(`^ {0,3}([-*_])(?:\s*([-*_])){2,}\s*$`)
+	quoteRe    = regexp.MustCompile(`^ {0,3}>\s?`)
+	listRe     = regexp.MustCompile(`^(\s*)([-*+]|\d{1,9}[.)])\s+(.*)$`)
+	taskRe     = regexp.MustCompile(`^\[([ xX])\]\s+`)
+	tableSepRe = regexp.MustCompile(`^\s*:?-+:?\s*$`)
+)
+
+// bullets - маркеры ненумерованных списков по уровням вложенности
+var bullets = []string{"•", "◦", "▪"}
+
+// Renderer отображает Markdown в строки терминала заданной ширины
+type Renderer struct {
+	width int
+	theme Theme
+	// text - стиль обычного текста (в цитатах - стиль цитаты)
+	text lipgloss.Style
+}
+
+// NewRenderer создаёт рендерер для ширины width (в колонках терминала)
+func NewRenderer(width int, theme Theme) *Renderer {
+	return &Renderer{width: max(width, minWidth), theme: theme, text: theme.Text}
+}
+
+// Width возвращает ширину вывода
+func (r *Renderer) Width() int {
+	return r.width
+}
+
+// Render отображает завершённый текст
+func (r *Renderer) Render(text string) []string {
+	return r.render(text, false)
+}
+
+// RenderPartial отображает текст, который ещё дописывается: незакрытые ** и `
+// на последней строке действуют до её конца, незакрытый блок кода - до конца текста
+func (r *Renderer) RenderPartial(text string) []string {
+	return r.render(text, true)
+}
+
+// SplitStable делит дописываемый текст на завершённые блоки и хвост по последней
+// пустой строке вне блока кода. Отображение stable не меняется при дописывании,
+// поэтому его можно кэшировать, а полный вывод получается как
+// Render(stable) + пустая строка + RenderPartial(tail).
+func SplitStable(text string) (stable, tail string) {
+	var fence string
+	offset, boundary := 0, 0
+	prevBlank := false
+	for _, line := range strings.SplitAfter(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence != "":
+			if isFenceClose(trimmed, fence) {
+				fence = ""
+			}
+		case trimmed == "":
+		default:
+			if prevBlank && offset > 0 {
+				boundary = offset
+			}
+			fence = fenceOpen(trimmed)
+		}
+		prevBlank = trimmed == "" && fence == "" && strings.HasSuffix(line, "\n")
+		offset += len(line)
+	}
+	return text[:boundary], text[boundary:]
+}
+
+// render разбирает текст по блокам
+func (r *Renderer) render(text string, partial bool) []string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	var out []string
+
+	for i := 0; i < len(lines); {
+		line := strings.ReplaceAll(lines[i], "\t", "    ")
+		trimmed := strings.TrimSpace(line)
+		last := partial && i == len(lines)-1
+
+		switch {
+		case trimmed == "":
+			// Несколько пустых строк подряд выводятся как одна
+			if len(out) > 0 && out[len(out)-1] != "" {
+				out = append(out, "")
+			}
+			i++
+
+		case fenceOpen(trimmed) != "":
+			var block []string
+			block, i = r.codeBlock(lines, i)
+			out = append(out, block...)
+
+		case headingRe.MatchString(line):
+			m := headingRe.FindStringSubmatch(line)
+			style := r.theme.Headings[len(m[1])-1]
+			out = append(out, wrapSpans(r.parseInline(m[2], style, last), r.width)...)
+			i++
+
+		case ruleRe.MatchString(line) && ruleChars(trimmed):
+			out = append(out, r.theme.Rule.Render(strings.Repeat("─", r.width)))
+			i++
+
+		case quoteRe.MatchString(line):
+			var block []string
+			block, i = r.quote(lines, i, partial)
+			out = append(out, block...)
+
+		case isTableStart(lines, i):
+			var block []string
+			block, i = r.table(lines, i)
+			out = append(out, block...)
+
+		case listRe.MatchString(line):
+			out = append(out, r.listItem(line, last)...)
+			i++
+
+		default:
+			out = append(out, wrapSpans(r.parseInline(trimmed, r.text, last), r.width)...)
+			i++
+		}
+	}
+
+	// Пустые строки в конце не выводятся
+	for len(out) > 0 && out[len(out)-1] == "" {
+		out = out[:len(out)-1]
+	}
+	return out
+}
+
+// ruleChars проверяет, что горизонтальная линия состоит из одного символа
+func ruleChars(s string) bool {
+	s = strings.ReplaceAll(s, " ", "")
+	return strings.Count(s, s[:1]) == len(s)
+}
+
+// === Блоки кода ===
+
+// fenceOpen возвращает маркер блока кода (``` или ~~~ нужной длины), если строка его открывает
+func fenceOpen(trimmed string) string {
+	for _, c := range []byte{'`', '~'} {
+		if n := runLength(trimmed, c); n >= 3 {
+			// В строке открытия ``` не может быть обратных кавычек
+			if c == '`' && strings.IndexByte(trimmed[n:], '`') >= 0 {
+				return ""
+			}
+			return trimmed[:n]
+		}
+	}
+	return ""
+}
+
+// isFenceClose проверяет, что строка закрывает блок кода с маркером fence
+func isFenceClose(trimmed, fence string) bool {
+	n := runLength(trimmed, fence[0])
+	return n >= len(fence) && strings.TrimSpace(trimmed[n:]) == ""
+}
+
+// codeBlock отображает блок кода с подсветкой и рамкой слева.
+// Незакрытый блок продолжается до конца текста. Возвращает строки и индекс следующей строки.
+func (r *Renderer) codeBlock(lines []string, start int) ([]string, int) {
+	open := strings.TrimSpace(lines[start])
+	indent := len(lines[start]) - len(strings.TrimLeft(lines[start], " "))
+	fence := fenceOpen(open)
+	lang := ""
+	if fields := strings.Fields(open[len(fence):]); len(fields) > 0 {
+		lang = fields[0]
+	}
+
+	end := start + 1
+	var code []string
+	for ; end < len(lines); end++ {
+		if isFenceClose(strings.TrimSpace(lines[end]), fence) {
+			break
+		}
+		// Отступ блока кода (например, внутри списка) не относится к коду
+		line := strings.ReplaceAll(lines[end], "\t", "    ")
+		code = append(code, line[min(indent, len(line)-len(strings.TrimLeft(line, " "))):])
+	}
+	closed := end < len(lines)
+
+	header := r.theme.CodeBorder.Render("┌─")
+	if lang != "" {
+		header += " " + r.theme.CodeLang.Render(lang)
+	}
+	out := []string{header}
+
+	bar := r.theme.CodeBorder.Render("│ ")
+	for _, line := range r.highlight(lang, strings.Join(code, "\n")) {
+		for _, part := range hardWrap(line, r.width-2) {
+			out = append(out, bar+renderSpans(part))
+		}
+	}
+
+	if !closed {
+		return out, end
+	}
+	return append(out, r.theme.CodeBorder.Render("└─")), end + 1
+}
+
+// === Цитаты ===
+
+// quote отображает подряд идущие строки цитаты с вертикальной чертой слева
+func (r *Renderer) quote(lines []string, start int, partial bool) ([]string, int) {
+	end := start
+	var inner []string
+	for end < len(lines) && quoteRe.MatchString(lines[end]) {
+		inner = append(inner, quoteRe.ReplaceAllString(lines[end], ""))
+		end++
+	}
+
+	sub := &Renderer{width: max(r.width-2, minWidth), theme: r.theme, text: r.theme.Quote}
+	bar := r.theme.QuoteBar.Render("│ ")
+	var out []string
+	for _, line := range sub.render(strings.Join(inner, "\n"), partial && end == len(lines)) {
+		out = append(out, bar+line)
+	}
+	return out, end
+}
+
+// === Списки ===
+
+// listItem отображает элемент списка; продолжение текста выравнивается по началу текста элемента
+func (r *Renderer) listItem(line string, last bool) []string {
+	m := listRe.FindStringSubmatch(line)
+	level := len(m[1]) / 2
+	marker := m[2]
+	text := m[3]
+
+	if marker == "-" || marker == "*" || marker == "+" {
+		marker = bullets[level%len(bullets)]
+	}
+	if task := taskRe.FindStringSubmatch(text); task != nil {
+		text = text[len(task[0]):]
+		if task[1] == " " {
+			marker += " ☐"
+		} else {
+			marker += " ☑"
+		}
+	}
+
+	pad := strings.Repeat("  ", min(level, 4))
+	markerWidth := lipgloss.Width(marker) + 1
+	width := max(r.width-len(pad)-markerWidth, minWidth)
+
+	wrapped := wrapSpans(r.parseInline(text, r.text, last), width)
+	if len(wrapped) == 0 {
+		wrapped = []string{""}
+	}
+	out := make([]string, 0, len(wrapped))
+	for i, l := range wrapped {
+		if i == 0 {
+			out = append(out, pad+r.theme.ListMarker.Render(marker)+" "+l)
+		} else {
+			out = append(out, pad+strings.Repeat(" ", markerWidth)+l)
+		}
+	}
+	return out
+}
+
+// === Таблицы ===
+
+// alignment - выравнивание столбца таблицы
+type alignment int
+
+const (
+	alignLeft alignment = iota
+	alignCenter
+	alignRight
+)
+
+// isTableStart проверяет, что строка - заголовок таблицы, за которым идёт строка-разделитель
+func isTableStart(lines []string, i int) bool {
+	if !strings.Contains(lines[i], "|") || i+1 >= len(lines) {
+		return false
+	}
+	sep := lines[i+1]
+	if !strings.Contains(sep, "|") && !strings.Contains(sep, "-") {
+		return false
+	}
+	for _, cell := range splitRow(sep) {
+		if !tableSepRe.MatchString(cell) {
+			return false
+		}
+	}
+	return true
+}
+
+// splitRow разбивает строку таблицы на ячейки; \| внутри ячейки не разделяет столбцы
+func splitRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// table отображает таблицу; если она шире экрана, ячейки самых широких столбцов обрезаются
+func (r *Renderer) table(lines []string, start int) ([]string, int) {
+	header := splitRow(lines[start])
+	cols := len(header)
+
+	aligns := make([]alignment, cols)
+	for i, cell := range splitRow(lines[start+1]) {
+		if i >= cols {
+			break
+		}
+		cell = strings.TrimSpace(cell)
+		switch {
+		case strings.HasPrefix(cell, ":") && strings.HasSuffix(cell, ":"):
+			aligns[i] = alignCenter
+		case strings.HasSuffix(cell, ":"):
+			aligns[i] = alignRight
+		}
+	}
+
+	end := start + 2
+	rows := [][][]span{r.tableCells(header, cols, r.text.Bold(true))}
+	for ; end < len(lines) && strings.Contains(lines[end], "|") && strings.TrimSpace(lines[end]) != ""; end++ {
+		rows = append(rows, r.tableCells(splitRow(lines[end]), cols, r.text))
+	}
+
+	widths := make([]int, cols)
+	for _, row := range rows {
+		for c, cell := range row {
+			widths[c] = max(widths[c], spansWidth(cell))
+		}
+	}
+	fitColumns(widths, r.width-3*(cols-1))
+
+	sep := r.theme.Table.Render(" │ ")
+	var out []string
+	for i, row := range rows {
+		cells := make([]string, cols)
+		for c, cell := range row {
+			cells[c] = alignCell(truncateSpans(cell, widths[c]), widths[c], aligns[c])
+		}
+		out = append(out, strings.Join(cells, sep))
+
+		if i == 0 {
+			parts := make([]string, cols)
+			for c, w := range widths {
+				parts[c] = strings.Repeat("─", w)
+			}
+			out = append(out, r.theme.Table.Render(strings.Join(parts, "─┼─")))
+		}
+	}
+	return out, end
+}
+
+// tableCells разбирает разметку ячеек строки, дополняя строку до cols столбцов
+func (r *Renderer) tableCells(cells []string, cols int, style lipgloss.Style) [][]span {
+	row := make([][]span, cols)
+	for c := 0; c < cols && c < len(cells); c++ {
+		row[c] = r.parseInline(cells[c], style, false)
+	}
+	return row
+}
+
+// fitColumns уменьшает самые широкие столбцы, пока таблица не поместится в ширину total
+func fitColumns(widths []int, total int) {
+	const minColumn = 3
+	for {
+		sum, widest := 0, 0
+		for i, w := range widths {
+			sum += w
+			if w > widths[widest] {
+				widest = i
+			}
+		}
+		if sum <= total || widths[widest] <= minColumn {
+			return
+		}
+		widths[widest]--
+	}
+}
+
+// alignCell дополняет ячейку пробелами до ширины столбца
+func alignCell(cell []span, width int, align alignment) string {
+	gap := max(width-spansWidth(cell), 0)
+	text := renderSpans(cell)
+	switch align {
+	case alignRight:
+		return strings.Repeat(" ", gap) + text
+	case alignCenter:
+		return strings.Repeat(" ", gap/2) + text + strings.Repeat(" ", gap-gap/2)
+	default:
+		return text + strings.Repeat(" ", gap)
+	}
+}
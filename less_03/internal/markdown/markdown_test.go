@@ -0,0 +1,137 @@
+package markdown
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func render(text string, width int) []string {
+	return NewRenderer(width, DarkTheme()).Render(text)
+}
+
+func TestRenderer_Blocks(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "heading and inline markup",
+			input: "## Итог\n\n**Жирный**, *курсив*, `код` и ~~лишнее~~",
+			want:  []string{"Итог", "", "Жирный, курсив, код и лишнее"},
+		},
+		{
+			name:  "snake_case is not emphasis",
+			input: "use my_var_name and 2 * 3 * 4",
+			want:  []string{"use my_var_name and 2 * 3 * 4"},
+		},
+		{
+			name:  "link",
+			input: "см. [документацию](https://go.dev/doc) и <https://go.dev>",
+			want:  []string{"см. документацию (https://go.dev/doc) и https://go.dev"},
+		},
+		{
+			name:  "lists",
+			input: "- один\n  - вложенный\n* [ ] задача\n3. третий",
+			want:  []string{"• один", "  ◦ вложенный", "• ☐ задача", "3. третий"},
+		},
+		{
+			name:  "quote",
+			input: "> первая\n> вторая",
+			want:  []string{"│ первая", "│ вторая"},
+		},
+		{
+			name:  "rule and collapsed blank lines",
+			input: "a\n\n\n***\nb\n\n",
+			want:  []string{"a", "", strings.Repeat("─", 60), "b"},
+		},
+		{
+			name:  "code block",
+			input: "```python\ndef f():\n    return 1\n```",
+			want:  []string{"┌─ python", "│ def f():", "│     return 1", "└─"},
+		},
+		{
+			name:  "table",
+			input: "| Язык | Год |\n|------|----:|\n| Go | 2009 |\n| C | 72 |",
+			want:  []string{"Язык │  Год", "─────┼─────", "Go   │ 2009", "C    │   72"},
+		},
+		{
+			name:  "escaped markup",
+			input: `\*не курсив\*`,
+			want:  []string{"*не курсив*"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := render(tt.input, 60); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Render() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderer_Wrap(t *testing.T) {
+	got := render("- раз два три четыре", 12)
+	want := []string{"• раз два", "  три четыре"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+
+	// Длинные строки кода переносятся по символам, а не обрезаются
+	got = render("```\n"+strings.Repeat("x", 25)+"\n```", 12)
+	if len(got) != 5 || got[1] != "│ "+strings.Repeat("x", 10) {
+		t.Errorf("code wrap = %q", got)
+	}
+
+	// Широкая таблица обрезается по ширине экрана
+	for _, line := range render("| a | b |\n|---|---|\n| "+strings.Repeat("y", 40)+" | z |", 20) {
+		if w := len([]rune(line)); w > 20 {
+			t.Errorf("table line %q is wider than 20", line)
+		}
+	}
+}
+
+func TestRenderer_RenderPartial(t *testing.T) {
+	r := NewRenderer(40, DarkTheme())
+
+	// Незакрытый блок кода остаётся кодом до конца текста
+	if got := r.RenderPartial("```go\nx := 1"); !reflect.DeepEqual(got, []string{"┌─ go", "│ x := 1"}) {
+		t.Errorf("RenderPartial() = %q", got)
+	}
+	// Незакрытое выделение на последней строке не выводит маркеры
+	if got := r.RenderPartial("text **bol"); !reflect.DeepEqual(got, []string{"text bol"}) {
+		t.Errorf("RenderPartial() = %q", got)
+	}
+	if got := r.Render("text **bol"); !reflect.DeepEqual(got, []string{"text **bol"}) {
+		t.Errorf("Render() = %q", got)
+	}
+}
+
+func TestSplitStable(t *testing.T) {
+	tests := []struct {
+		input, stable string
+	}{
+		{"a\nb", ""},
+		{"a\n\nb", "a\n\n"},
+		{"a\n\n```\nx\n\ny", "a\n\n"},
+		{"a\n\n```\nx\n\ny\n```\n\nz", "a\n\n```\nx\n\ny\n```\n\n"},
+		{"a\n\n", ""},
+	}
+	for _, tt := range tests {
+		stable, tail := SplitStable(tt.input)
+		if stable != tt.stable || stable+tail != tt.input {
+			t.Errorf("SplitStable(%q) = %q, %q; want stable %q", tt.input, stable, tail, tt.stable)
+		}
+	}
+
+	// Кэшируемая часть и хвост дают тот же вывод, что и полный текст
+	text := "# T\n\n- a\n- b\n\n```go\nfunc f() {}\n```\n\nend"
+	r := NewRenderer(30, DarkTheme())
+	stable, tail := SplitStable(text)
+	got := append(append(r.Render(stable), ""), r.RenderPartial(tail)...)
+	if want := r.Render(text); !reflect.DeepEqual(got, want) {
+		t.Errorf("split render = %q, want %q", got, want)
+	}
+}
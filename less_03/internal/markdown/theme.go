@@ -0,0 +1,92 @@
+package markdown
+
+import "github.com/charmbracelet/lipgloss"
+
+// Theme - стили элементов Markdown и подсветки кода
+type Theme struct {
+	Text       lipgloss.Style
+	Headings   [6]lipgloss.Style
+	Code       lipgloss.Style
+	Link       lipgloss.Style
+	URL        lipgloss.Style
+	Quote      lipgloss.Style
+	QuoteBar   lipgloss.Style
+	ListMarker lipgloss.Style
+	Rule       lipgloss.Style
+	Table      lipgloss.Style
+
+	// Блоки кода
+	CodeBorder lipgloss.Style
+	CodeLang   lipgloss.Style
+	CodeText   lipgloss.Style
+	Keyword    lipgloss.Style
+	Type       lipgloss.Style
+	Func       lipgloss.Style
+	String     lipgloss.Style
+	Number     lipgloss.Style
+	Comment    lipgloss.Style
+}
+
+// ThemeFor возвращает тему по названию из ui.theme ("light" или "dark")
+func ThemeFor(name string) Theme {
+	if name == "light" {
+		return LightTheme()
+	}
+	return DarkTheme()
+}
+
+// DarkTheme - тема для тёмного фона терминала
+func DarkTheme() Theme {
+	color := func(c string) lipgloss.Style { return lipgloss.NewStyle().Foreground(lipgloss.Color(c)) }
+	heading := color("75").Bold(true)
+	return Theme{
+		Text:       color("252"),
+		Headings:   [6]lipgloss.Style{color("205").Bold(true).Underline(true), color("39").Bold(true), heading, heading, heading, heading},
+		Code:       color("215").Background(lipgloss.Color("236")),
+		Link:       color("81").Underline(true),
+		URL:        color("241"),
+		Quote:      color("246").Italic(true),
+		QuoteBar:   color("240"),
+		ListMarker: color("212"),
+		Rule:       color("240"),
+		Table:      color("240"),
+
+		CodeBorder: color("240"),
+		CodeLang:   color("245").Italic(true),
+		CodeText:   color("252"),
+		Keyword:    color("204").Bold(true),
+		Type:       color("81"),
+		Func:       color("149"),
+		String:     color("186"),
+		Number:     color("141"),
+		Comment:    color("244").Italic(true),
+	}
+}
+
+// LightTheme - тема для светлого фона терминала
+func LightTheme() Theme {
+	color := func(c string) lipgloss.Style { return lipgloss.NewStyle().Foreground(lipgloss.Color(c)) }
+	heading := color("25").Bold(true)
+	return Theme{
+		Text:       color("235"),
+		Headings:   [6]lipgloss.Style{color("125").Bold(true).Underline(true), color("25").Bold(true), heading, heading, heading, heading},
+		Code:       color("124").Background(lipgloss.Color("254")),
+		Link:       color("26").Underline(true),
+		URL:        color("245"),
+		Quote:      color("241").Italic(true),
+		QuoteBar:   color("248"),
+		ListMarker: color("162"),
+		Rule:       color("248"),
+		Table:      color("248"),
+
+		CodeBorder: color("248"),
+		CodeLang:   color("243").Italic(true),
+		CodeText:   color("235"),
+		Keyword:    color("161").Bold(true),
+		Type:       color("31"),
+		Func:       color("28"),
+		String:     color("130"),
+		Number:     color("91"),
+		Comment:    color("245").Italic(true),
+	}
+}
@@ -0,0 +1,267 @@
+// Package oneshot реализует неинтерактивный режим: один запрос к модели
+// без TUI с выводом ответа в stdout в виде текста, JSON или потока JSONL-событий.
+package oneshot
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+
+	"llm-client/internal/chat"
+	"llm-client/internal/client"
+	"llm-client/internal/config"
+	apperrors "llm-client/internal/errors"
+	"llm-client/internal/logger"
+)
+
+// Форматы вывода
+const (
+	// FormatText - ответ как есть, по мере получения
+	FormatText = "text"
+	// FormatJSON - один JSON-объект с ответом после завершения
+	FormatJSON = "json"
+	// FormatJSONL - события стрима по одному JSON-объекту в строке
+	FormatJSONL = "jsonl"
+)
+
+// IsValidFormat проверяет формат вывода
+func IsValidFormat(format string) bool {
+	return format == FormatText || format == FormatJSON || format == FormatJSONL
+}
+
+// Result - итог запроса
+type Result struct {
+	Model        string        `json:"model"`
+	Content      string        `json:"content"`
+	FinishReason string        `json:"finish_reason,omitempty"`
+	Usage        *client.Usage `json:"usage,omitempty"`
+}
+
+// Event - событие JSONL-вывода
+type Event struct {
+	// Type - start, delta, done или error
+	Type         string             `json:"type"`
+	Model        string             `json:"model,omitempty"`
+	Content      string             `json:"content,omitempty"`
+	FinishReason string             `json:"finish_reason,omitempty"`
+	Usage        *client.Usage      `json:"usage,omitempty"`
+	Error        *apperrors.Details `json:"error,omitempty"`
+}
+
+// errorOutput - JSON-вывод при ошибке
+type errorOutput struct {
+	Error apperrors.Details `json:"error"`
+}
+
+// Runner выполняет запрос и выводит ответ
+type Runner struct {
+	client *client.Client
+	config *config.Config
+	format string
+	out    io.Writer
+	logger *logger.Logger
+}
+
+// Option - функция опция для настройки Runner
+type Option func(*Runner)
+
+// WithFormat устанавливает формат вывода (по умолчанию text)
+func WithFormat(format string) Option {
+	return func(r *Runner) {
+		r.format = format
+	}
+}
+
+// WithOutput устанавливает поток вывода (по умолчанию stdout)
+func WithOutput(w io.Writer) Option {
+	return func(r *Runner) {
+		r.out = w
+	}
+}
+
+// WithLogger устанавливает логгер
+func WithLogger(log *logger.Logger) Option {
+	return func(r *Runner) {
+		r.logger = log
+	}
+}
+
+// New создаёт Runner
+func New(cfg *config.Config, c *client.Client, opts ...Option) *Runner {
+	r := &Runner{
+		client: c,
+		config: cfg,
+		format: FormatText,
+		out:    os.Stdout,
+		logger: logger.DefaultLogger,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Message собирает сообщение пользователя из текста флага и данных stdin:
+// инструкция идёт первой, данные - после пустой строки
+func Message(prompt, input string) (string, error) {
+	prompt = strings.TrimSpace(prompt)
+	input = strings.TrimRight(input, "\r\n")
+
+	switch {
+	case prompt != "" && strings.TrimSpace(input) != "":
+		return prompt + "\n\n" + input, nil
+	case prompt != "":
+		return prompt, nil
+	case strings.TrimSpace(input) != "":
+		return input, nil
+	default:
+		return "", apperrors.NewValidationError("EMPTY_PROMPT", "prompt is empty: pass -p or pipe text to stdin", nil)
+	}
+}
+
+// Run отправляет сообщение модели и выводит ответ.
+// В форматах json и jsonl ошибка тоже выводится в stdout, а затем возвращается.
+func (r *Runner) Run(ctx context.Context, message string) (*Result, error) {
+	history := chat.NewChatHistory(r.config.Model.SystemPrompt)
+	history.AddUser(message)
+
+	req := &client.ChatRequest{
+		Model:       r.config.Model.Name,
+		Messages:    history.GetMessages(),
+		Stream:      r.config.Model.Stream,
+		Temperature: r.config.Model.Temperature,
+		TopP:        r.config.Model.TopP,
+		MaxTokens:   r.config.Model.MaxTokens,
+	}
+	r.logger.Info("Sending one-shot request",
+		"model", req.Model,
+		"stream", req.Stream,
+		"format", r.format,
+		"length", len(message),
+	)
+
+	if r.format == FormatJSONL {
+		r.emit(Event{Type: "start", Model: req.Model})
+	}
+
+	var result *Result
+	var err error
+	if req.Stream {
+		result, err = r.stream(ctx, req)
+	} else {
+		result, err = r.complete(ctx, req)
+	}
+	if err != nil {
+		r.logger.Error("One-shot request failed", "error", err)
+		r.writeError(err)
+		return result, err
+	}
+
+	switch r.format {
+	case FormatJSON:
+		err = r.writeJSON(result)
+	case FormatJSONL:
+		err = r.emit(Event{Type: "done", FinishReason: result.FinishReason, Usage: result.Usage})
+	default:
+		if !strings.HasSuffix(result.Content, "\n") {
+			_, err = io.WriteString(r.out, "\n")
+		}
+	}
+	if err != nil {
+		return result, apperrors.NewInternalError("WRITE_ERROR", "failed to write output", err)
+	}
+	return result, nil
+}
+
+// stream получает ответ по частям; в форматах text и jsonl части выводятся сразу
+func (r *Runner) stream(ctx context.Context, req *client.ChatRequest) (*Result, error) {
+	result := &Result{Model: req.Model}
+	var content strings.Builder
+
+	for chunk := range r.client.ChatStream(ctx, req) {
+		if chunk.Error != nil {
+			result.Content = content.String()
+			return result, chunk.Error
+		}
+		if chunk.Content != "" {
+			content.WriteString(chunk.Content)
+			if err := r.writeDelta(chunk.Content); err != nil {
+				return result, apperrors.NewInternalError("WRITE_ERROR", "failed to write output", err)
+			}
+		}
+		if chunk.Done {
+			result.FinishReason = chunk.FinishReason
+			result.Usage = chunk.Usage
+		}
+	}
+
+	result.Content = content.String()
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// complete получает ответ целиком
+func (r *Runner) complete(ctx context.Context, req *client.ChatRequest) (*Result, error) {
+	completion, err := r.client.ChatCompletion(ctx, req)
+	if err != nil {
+		return &Result{Model: req.Model}, err
+	}
+	result := &Result{
+		Model:        req.Model,
+		Content:      completion.Message.Content,
+		FinishReason: completion.FinishReason,
+		Usage:        completion.Usage,
+	}
+	if err := r.writeDelta(result.Content); err != nil {
+		return result, apperrors.NewInternalError("WRITE_ERROR", "failed to write output", err)
+	}
+	return result, nil
+}
+
+// writeDelta выводит часть ответа
+func (r *Runner) writeDelta(content string) error {
+	switch r.format {
+	case FormatText:
+		_, err := io.WriteString(r.out, content)
+		return err
+	case FormatJSONL:
+		return r.emit(Event{Type: "delta", Content: content})
+	}
+	return nil
+}
+
+// writeError выводит ошибку в машиночитаемых форматах; в текстовом формате
+// ошибку печатает вызывающий код в stderr
+func (r *Runner) writeError(err error) {
+	info := apperrors.Describe(err)
+	switch r.format {
+	case FormatJSON:
+		r.writeJSON(errorOutput{Error: info})
+	case FormatJSONL:
+		r.emit(Event{Type: "error", Error: &info})
+	}
+}
+
+// emit выводит событие JSONL
+func (r *Runner) emit(ev Event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	_, err = r.out.Write(append(data, '\n'))
+	return err
+}
+
+// writeJSON выводит объект с отступами
+func (r *Runner) writeJSON(v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = r.out.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,164 @@
+package oneshot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"llm-client/internal/client"
+	"llm-client/internal/config"
+	apperrors "llm-client/internal/errors"
+	"llm-client/internal/logger"
+)
+
+// newTestRunner создаёт Runner с сервером, который отвечает потоком "Hello, world"
+func newTestRunner(t *testing.T, format string, stream bool) (*Runner, *bytes.Buffer, <-chan client.ChatRequest) {
+	t.Helper()
+	requests := make(chan client.ChatRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req client.ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		requests <- req
+
+		if !req.Stream {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"Hello, world"},"finish_reason":"stop"}],` +
+				`"usage":{"prompt_tokens":5,"completion_tokens":3}}`))
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(`data: {"choices":[{"index":0,"delta":{"content":"Hello"}}]}` + "\n\n"))
+		w.Write([]byte(`data: {"choices":[{"index":0,"delta":{"content":", world"},"finish_reason":"stop"}]}` + "\n\n"))
+		w.Write([]byte(`data: {"choices":[],"usage":{"prompt_tokens":5,"completion_tokens":3,"total_tokens":8}}` + "\n\n"))
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := config.DefaultConfig()
+	cfg.Server.Address = server.URL
+	cfg.Model.Stream = stream
+
+	var out bytes.Buffer
+	log := logger.NewLogger(logger.Config{Enabled: false})
+	r := New(cfg, client.NewClientFromConfig(cfg, client.WithLogger(log)),
+		WithFormat(format),
+		WithOutput(&out),
+		WithLogger(log),
+	)
+	return r, &out, requests
+}
+
+func TestMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		prompt  string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"prompt only", "  hi  ", "", "hi", false},
+		{"stdin only", "", "text\n", "text", false},
+		{"prompt and stdin", "summarize", "line 1\nline 2\n", "summarize\n\nline 1\nline 2", false},
+		{"empty", "", " \n", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Message(tt.prompt, tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Message() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Message() = %q, want %q", got, tt.want)
+			}
+			if err != nil && apperrors.ExitCode(err) != apperrors.ExitValidation {
+				t.Errorf("empty prompt should be a validation error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRunner_Text(t *testing.T) {
+	for _, stream := range []bool{true, false} {
+		r, out, requests := newTestRunner(t, FormatText, stream)
+
+		result, err := r.Run(context.Background(), "hi")
+		if err != nil {
+			t.Fatalf("Run(stream=%v) error = %v", stream, err)
+		}
+		if out.String() != "Hello, world\n" {
+			t.Errorf("output (stream=%v) = %q", stream, out.String())
+		}
+		if result.Usage == nil || result.Usage.CompletionTokens != 3 {
+			t.Errorf("usage (stream=%v) = %+v", stream, result.Usage)
+		}
+
+		req := <-requests
+		if len(req.Messages) != 2 || req.Messages[0].Content != "You are a helpful assistant." || req.Messages[1].Content != "hi" {
+			t.Errorf("request messages = %+v", req.Messages)
+		}
+	}
+}
+
+func TestRunner_JSON(t *testing.T) {
+	r, out, _ := newTestRunner(t, FormatJSON, true)
+	if _, err := r.Run(context.Background(), "hi"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	var result Result
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out.String())
+	}
+	if result.Content != "Hello, world" || result.FinishReason != "stop" || result.Usage.TotalTokens != 8 {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestRunner_JSONL(t *testing.T) {
+	r, out, _ := newTestRunner(t, FormatJSONL, true)
+	if _, err := r.Run(context.Background(), "hi"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	var types []string
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var ev Event
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("line %q is not JSON: %v", line, err)
+		}
+		types = append(types, ev.Type)
+	}
+	if got := strings.Join(types, ","); got != "start,delta,delta,done" {
+		t.Errorf("events = %s", got)
+	}
+}
+
+func TestRunner_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"message":"model not found"}}`, http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Server.Address = server.URL
+	var out bytes.Buffer
+	r := New(cfg, client.NewClientFromConfig(cfg), WithFormat(FormatJSON), WithOutput(&out))
+
+	_, err := r.Run(context.Background(), "hi")
+	if apperrors.ExitCode(err) != apperrors.ExitAPI {
+		t.Fatalf("Run() error = %v, want API error", err)
+	}
+
+	var output errorOutput
+	if jsonErr := json.Unmarshal(out.Bytes(), &output); jsonErr != nil {
+		t.Fatalf("error output is not JSON: %v\n%s", jsonErr, out.String())
+	}
+	if output.Error.Kind != string(apperrors.KindAPI) {
+		t.Errorf("error kind = %q, want %q", output.Error.Kind, apperrors.KindAPI)
+	}
+}
@@ -0,0 +1,146 @@
+package proxy
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"llm-client/internal/client"
+	"llm-client/internal/logger"
+)
+
+// clientNameKey - ключ контекста запроса с именем клиента прокси
+type clientNameKey struct{}
+
+// authenticate проверяет ключ клиента из заголовка Authorization: Bearer.
+// Если ключи не настроены, доступ открыт.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(s.keys) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		name, found := s.lookupKey(strings.TrimSpace(token))
+		if !ok || !found {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, "invalid_request_error", "invalid API key")
+			return
+		}
+
+		setClientName(r, name)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// lookupKey ищет клиента по ключу; сравнение выполняется за постоянное время
+func (s *Server) lookupKey(token string) (string, bool) {
+	name, found := "", false
+	for key, owner := range s.keys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(token)) == 1 {
+			name, found = owner, true
+		}
+	}
+	return name, found
+}
+
+// logRequests записывает в лог каждый запрос к прокси. Идентификатор запроса берётся
+// из X-Request-ID клиента или создаётся, передаётся провайдеру и возвращается в ответе.
+func (s *Server) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		name := ""
+		id := r.Header.Get(client.RequestIDHeader)
+		if !validRequestID(id) {
+			id = logger.NewRequestID()
+		}
+		ctx := logger.WithRequestID(context.WithValue(r.Context(), clientNameKey{}, &name), id)
+		r = r.WithContext(ctx)
+		w.Header().Set(client.RequestIDHeader, id)
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		s.logger.InfoContext(ctx, "Proxy request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"client", name,
+			"remote", r.RemoteAddr,
+			"status", rec.status,
+			"bytes", rec.bytes,
+			"duration", time.Since(start),
+		)
+	})
+}
+
+// validRequestID проверяет идентификатор запроса клиента: он попадает в лог и заголовки провайдеру
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.:", r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// setClientName сохраняет имя клиента для лога запроса
+func setClientName(r *http.Request, name string) {
+	if p, ok := r.Context().Value(clientNameKey{}).(*string); ok {
+		*p = name
+	}
+}
+
+// statusRecorder запоминает статус и размер ответа, сохраняя поддержку Flush для стримов
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(p []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(p)
+	r.bytes += n
+	return n, err
+}
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// writeError отправляет ошибку в формате OpenAI API
+func writeError(w http.ResponseWriter, status int, errType, message string) {
+	writeJSON(w, status, map[string]any{
+		"error": map[string]any{
+			"message": message,
+			"type":    errType,
+			"code":    nil,
+		},
+	})
+}
+
+// writeJSON отправляет JSON-ответ
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// formatSeconds форматирует задержку для заголовка Retry-After
+func formatSeconds(d time.Duration) string {
+	return strconv.Itoa(int((d + time.Second - 1) / time.Second))
+}
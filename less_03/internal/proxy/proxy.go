@@ -0,0 +1,303 @@
+// Package proxy реализует локальный OpenAI-совместимый HTTP-сервер (подкоманда serve).
+// Запросы пересылаются провайдеру через client.Client, поэтому используют общие
+// конфигурацию, логирование, повторные попытки и учёт расхода токенов.
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"llm-client/internal/client"
+	"llm-client/internal/config"
+	apperrors "llm-client/internal/errors"
+	"llm-client/internal/logger"
+)
+
+const (
+	// maxBodySize - максимальный размер тела запроса
+	maxBodySize = 32 << 20
+	// shutdownTimeout - сколько ждать завершения активных запросов при остановке
+	shutdownTimeout = 30 * time.Second
+)
+
+// Server - OpenAI-совместимый прокси
+type Server struct {
+	client *client.Client
+	config *config.Config
+	// keys - имена клиентов по их ключам
+	keys   map[string]string
+	logger *logger.Logger
+}
+
+// Option - функция опция для настройки Server
+type Option func(*Server)
+
+// WithLogger устанавливает логгер
+func WithLogger(log *logger.Logger) Option {
+	return func(s *Server) {
+		s.logger = log
+	}
+}
+
+// New создаёт прокси; ключи клиентов берутся из serve.api_keys
+func New(cfg *config.Config, c *client.Client, opts ...Option) *Server {
+	s := &Server{
+		client: c,
+		config: cfg,
+		keys:   make(map[string]string, len(cfg.Serve.APIKeys)),
+		logger: logger.DefaultLogger,
+	}
+	for name, key := range cfg.Serve.APIKeys {
+		s.keys[key] = name
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Handler возвращает обработчик HTTP-запросов прокси
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/chat/completions", s.handleChatCompletions)
+	mux.HandleFunc("GET /v1/models", s.handleModels)
+	return s.logRequests(s.authenticate(mux))
+}
+
+// ListenAndServe принимает запросы на адресе addr до отмены ctx
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return apperrors.NewNetworkError("LISTEN_FAILED", "failed to listen", err).WithContext("address", addr)
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve принимает запросы из ln до отмены ctx. При остановке новые соединения
+// не принимаются, а активные запросы (в том числе стримы) дорабатывают до shutdownTimeout.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	srv := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve(ln)
+	}()
+	s.logger.Info("Proxy server started", "address", ln.Addr().String(), "api_keys", len(s.keys))
+
+	select {
+	case err := <-errCh:
+		return apperrors.NewNetworkError("SERVE_FAILED", "proxy server stopped", err)
+	case <-ctx.Done():
+	}
+
+	s.logger.Info("Proxy server shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+		return apperrors.NewInternalError("SHUTDOWN_FAILED", "active requests were interrupted", err)
+	}
+	s.logger.Info("Proxy server stopped")
+	return nil
+}
+
+// handleChatCompletions пересылает запрос /v1/chat/completions провайдеру.
+// Тело передаётся как есть: подставляется только модель по умолчанию и запрос usage в стриме.
+func (s *Server) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, "invalid_request_error", "request body is too large")
+		return
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "request body must be a JSON object")
+		return
+	}
+
+	var model string
+	var stream bool
+	if err := unmarshalField(fields, "model", &model); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "model must be a string")
+		return
+	}
+	if err := unmarshalField(fields, "stream", &stream); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "stream must be a boolean")
+		return
+	}
+
+	changed := false
+	if model == "" {
+		model = s.config.Model.Name
+		fields["model"], _ = json.Marshal(model)
+		changed = true
+	}
+
+	// Для учёта стоимости просим usage, но не показываем клиенту чанк, который он не запрашивал
+	hideUsage := false
+	if _, ok := fields["stream_options"]; stream && !ok {
+		fields["stream_options"] = json.RawMessage(`{"include_usage":true}`)
+		hideUsage = true
+		changed = true
+	}
+
+	if changed {
+		body, _ = json.Marshal(fields)
+	}
+
+	if stream {
+		s.forwardStream(w, r, model, body, hideUsage)
+		return
+	}
+
+	respBody, err := s.client.ForwardCompletion(r.Context(), model, body)
+	if err != nil {
+		s.writeUpstreamError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(respBody)
+}
+
+// forwardStream пересылает потоковый ответ провайдера событие за событием
+func (s *Server) forwardStream(w http.ResponseWriter, r *http.Request, model string, body []byte, hideUsage bool) {
+	flusher, _ := w.(http.Flusher)
+	started := false
+
+	err := s.client.ForwardStream(r.Context(), model, body, func(ev client.StreamEvent) error {
+		if hideUsage && isUsageOnly(ev.Data) {
+			return nil
+		}
+		if !started {
+			// Заголовки отправляются с первым событием: до него ошибку ещё можно вернуть статусом
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("Connection", "keep-alive")
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+		if _, err := io.WriteString(w, formatEvent(ev)); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		return
+	}
+	if started {
+		// Статус уже отправлен, клиент увидит оборванный поток
+		s.logger.ErrorContext(r.Context(), "Proxy stream interrupted", "error", err)
+		return
+	}
+	s.writeUpstreamError(w, err)
+}
+
+// handleModels возвращает модели из конфигурации: модель по умолчанию и модели,
+// для которых заданы контекстное окно или цена
+func (s *Server) handleModels(w http.ResponseWriter, r *http.Request) {
+	type model struct {
+		ID      string `json:"id"`
+		Object  string `json:"object"`
+		Created int64  `json:"created"`
+		OwnedBy string `json:"owned_by"`
+	}
+
+	names := map[string]bool{}
+	for name := range s.config.Model.ContextWindows {
+		names[name] = true
+	}
+	for name := range s.config.Pricing.Models {
+		names[name] = true
+	}
+	delete(names, s.config.Model.Name)
+
+	ids := []string{s.config.Model.Name}
+	for name := range names {
+		ids = append(ids, name)
+	}
+	sort.Strings(ids[1:])
+
+	data := make([]model, 0, len(ids))
+	for _, id := range ids {
+		data = append(data, model{ID: id, Object: "model", OwnedBy: "llm-client"})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"object": "list", "data": data})
+}
+
+// writeUpstreamError передаёт клиенту ошибку провайдера: ответ API - с исходным статусом и телом,
+// недоступность провайдера - как 502
+func (s *Server) writeUpstreamError(w http.ResponseWriter, err error) {
+	if errors.Is(err, context.Canceled) {
+		// Клиент отключился, отвечать некому
+		return
+	}
+
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) && appErr.Kind == apperrors.KindAPI {
+		if status := apperrors.GetStatusCode(err); status != 0 {
+			if body, ok := appErr.Context["body"].(string); ok && json.Valid([]byte(body)) {
+				if retryAfter := apperrors.GetRetryAfter(err); retryAfter > 0 {
+					w.Header().Set("Retry-After", formatSeconds(retryAfter))
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(status)
+				io.WriteString(w, body)
+				return
+			}
+			writeError(w, status, "api_error", appErr.Message)
+			return
+		}
+	}
+	writeError(w, http.StatusBadGateway, "upstream_error", err.Error())
+}
+
+// isUsageOnly проверяет, что событие содержит только расход токенов (пустой choices)
+func isUsageOnly(data string) bool {
+	var chunk struct {
+		Choices []json.RawMessage `json:"choices"`
+		Usage   json.RawMessage   `json:"usage"`
+	}
+	if json.Unmarshal([]byte(data), &chunk) != nil {
+		return false
+	}
+	return len(chunk.Choices) == 0 && len(chunk.Usage) > 0 && string(chunk.Usage) != "null"
+}
+
+// formatEvent сериализует событие SSE; многострочные данные разбиваются на несколько полей data:
+func formatEvent(ev client.StreamEvent) string {
+	var b strings.Builder
+	if ev.Event != "" {
+		b.WriteString("event: " + ev.Event + "\n")
+	}
+	if ev.ID != "" {
+		b.WriteString("id: " + ev.ID + "\n")
+	}
+	for _, line := range strings.Split(ev.Data, "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// unmarshalField разбирает необязательное поле тела запроса
+func unmarshalField(fields map[string]json.RawMessage, name string, v any) error {
+	raw, ok := fields[name]
+	if !ok || string(raw) == "null" {
+		return nil
+	}
+	return json.Unmarshal(raw, v)
+}
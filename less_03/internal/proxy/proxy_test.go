@@ -0,0 +1,230 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"llm-client/internal/client"
+	"llm-client/internal/config"
+	"llm-client/internal/logger"
+)
+
+// newTestProxy поднимает прокси к upstream и возвращает его адрес
+func newTestProxy(t *testing.T, upstream http.HandlerFunc, keys map[string]string) string {
+	t.Helper()
+	provider := httptest.NewServer(upstream)
+	t.Cleanup(provider.Close)
+
+	cfg := config.DefaultConfig()
+	cfg.Server.Address = provider.URL
+	cfg.Model.Name = "default-model"
+	cfg.Pricing.Models = map[string]config.ModelPrice{"gpt-4o": {Prompt: 1}}
+	cfg.Serve.APIKeys = keys
+
+	c := client.NewClientFromConfig(cfg, client.WithLogger(logger.NewLogger(logger.Config{Enabled: false})))
+	server := httptest.NewServer(New(cfg, c).Handler())
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func post(t *testing.T, url, key, body string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, url+"/v1/chat/completions", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	return string(data)
+}
+
+func TestServer_ChatCompletions(t *testing.T) {
+	var forwarded map[string]any
+	url := newTestProxy(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&forwarded)
+		w.Write([]byte(`{"id":"x","choices":[{"message":{"content":"ok"}}]}`))
+	}, nil)
+
+	resp := post(t, url, "", `{"messages":[{"role":"user","content":"hi"}],"seed":7}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	if got := readBody(t, resp); got != `{"id":"x","choices":[{"message":{"content":"ok"}}]}` {
+		t.Errorf("body = %s", got)
+	}
+	if forwarded["model"] != "default-model" || forwarded["seed"] != float64(7) {
+		t.Errorf("forwarded request = %v", forwarded)
+	}
+}
+
+func TestServer_ChatCompletions_Stream(t *testing.T) {
+	const stream = "id: 1\ndata: {\"choices\":[{\"delta\":{\"content\":\"Hi\"}}]}\n\n" +
+		"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":1,\"completion_tokens\":1}}\n\n" +
+		"data: [DONE]\n\n"
+
+	var forwarded map[string]any
+	url := newTestProxy(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&forwarded)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(stream))
+	}, nil)
+
+	resp := post(t, url, "", `{"model":"m","stream":true,"messages":[]}`)
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	// Usage запрошен прокси для учёта стоимости и не показывается клиенту
+	want := "id: 1\ndata: {\"choices\":[{\"delta\":{\"content\":\"Hi\"}}]}\n\ndata: [DONE]\n\n"
+	if got := readBody(t, resp); got != want {
+		t.Errorf("stream = %q, want %q", got, want)
+	}
+	if _, ok := forwarded["stream_options"]; !ok {
+		t.Errorf("proxy should request usage, forwarded = %v", forwarded)
+	}
+
+	t.Run("client asked for usage", func(t *testing.T) {
+		resp := post(t, url, "", `{"model":"m","stream":true,"stream_options":{"include_usage":true}}`)
+		if got := readBody(t, resp); got != stream {
+			t.Errorf("stream = %q, want %q", got, stream)
+		}
+	})
+}
+
+func TestServer_UpstreamError(t *testing.T) {
+	url := newTestProxy(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"message":"unknown model"}}`))
+	}, nil)
+
+	for _, body := range []string{`{"model":"m"}`, `{"model":"m","stream":true}`} {
+		resp := post(t, url, "", body)
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, resp.StatusCode)
+		}
+		if got := readBody(t, resp); got != `{"error":{"message":"unknown model"}}` {
+			t.Errorf("%s: body = %s", body, got)
+		}
+	}
+
+	resp := post(t, url, "", `not json`)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid JSON: status = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestServer_Auth(t *testing.T) {
+	url := newTestProxy(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer client-key" {
+			t.Errorf("proxy key must not be forwarded to provider")
+		}
+		w.Write([]byte(`{"choices":[]}`))
+	}, map[string]string{"ci": "client-key"})
+
+	if resp := post(t, url, "", `{}`); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("without key: status = %d, want 401", resp.StatusCode)
+	}
+	if resp := post(t, url, "wrong", `{}`); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("wrong key: status = %d, want 401", resp.StatusCode)
+	}
+	if resp := post(t, url, "client-key", `{}`); resp.StatusCode != http.StatusOK {
+		t.Errorf("valid key: status = %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestServer_RequestID(t *testing.T) {
+	var upstreamID string
+	url := newTestProxy(t, func(w http.ResponseWriter, r *http.Request) {
+		upstreamID = r.Header.Get(client.RequestIDHeader)
+		w.Write([]byte(`{"choices":[]}`))
+	}, nil)
+
+	send := func(id string) *http.Response {
+		req, _ := http.NewRequest(http.MethodPost, url+"/v1/chat/completions", strings.NewReader(`{}`))
+		if id != "" {
+			req.Header.Set(client.RequestIDHeader, id)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	// Идентификатор клиента передаётся провайдеру и возвращается в ответе
+	resp := send("ci-run-17:step.2")
+	if got := resp.Header.Get(client.RequestIDHeader); got != "ci-run-17:step.2" || upstreamID != got {
+		t.Errorf("response id = %q, upstream id = %q, want ci-run-17:step.2", got, upstreamID)
+	}
+
+	// Без заголовка и с недопустимым значением создаётся новый
+	for _, id := range []string{"", "bad id", strings.Repeat("a", 129)} {
+		resp := send(id)
+		got := resp.Header.Get(client.RequestIDHeader)
+		if !strings.HasPrefix(got, "req_") || upstreamID != got {
+			t.Errorf("id %q: response id = %q, upstream id = %q", id, got, upstreamID)
+		}
+	}
+}
+
+func TestServer_Models(t *testing.T) {
+	url := newTestProxy(t, func(w http.ResponseWriter, r *http.Request) {}, nil)
+
+	resp, err := http.Get(url + "/v1/models")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var list struct {
+		Object string `json:"object"`
+		Data   []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	json.NewDecoder(resp.Body).Decode(&list)
+	if list.Object != "list" || len(list.Data) != 2 || list.Data[0].ID != "default-model" || list.Data[1].ID != "gpt-4o" {
+		t.Errorf("models = %+v", list)
+	}
+}
+
+func TestServer_Serve_Shutdown(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := New(config.DefaultConfig(), client.NewClient("http://127.0.0.1:1", "/v1/chat/completions"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(ctx, ln) }()
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Serve() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not stop")
+	}
+}
@@ -0,0 +1,332 @@
+// Package session предоставляет хранение истории диалогов на диске
+// для продолжения работы после перезапуска приложения.
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"llm-client/internal/chat"
+	apperrors "llm-client/internal/errors"
+	"llm-client/internal/usage"
+)
+
+const (
+	// fileExt - расширение файлов сессий
+	fileExt = ".json"
+	// maxTitleLength - максимальная длина автоматически созданного заголовка (в символах)
+	maxTitleLength = 48
+)
+
+// validID - допустимые символы идентификатора (защита от выхода за пределы директории)
+var validID = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Meta содержит метаданные сессии без сообщений
+type Meta struct {
+	// ID - уникальный идентификатор сессии (имя файла)
+	ID string `json:"id"`
+	// Title - заголовок сессии
+	Title string `json:"title"`
+	// Model - модель, использованная в сессии
+	Model string `json:"model"`
+	// CreatedAt - время создания
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt - время последнего сохранения
+	UpdatedAt time.Time `json:"updated_at"`
+	// MessageCount - количество сообщений без учёта системного промпта
+	MessageCount int `json:"message_count"`
+	// Usage - расход токенов и стоимость диалога (отсутствует в старых файлах)
+	Usage *usage.Report `json:"usage,omitempty"`
+}
+
+// Session представляет сохранённый диалог
+type Session struct {
+	Meta
+	// Messages - сообщения активной ветки диалога, включая системный промпт
+	Messages []chat.Message `json:"messages"`
+	// Tree - полное дерево сообщений со всеми ветками (отсутствует в старых файлах)
+	Tree *chat.Snapshot `json:"tree,omitempty"`
+}
+
+// New создаёт новую пустую сессию
+func New(model string) *Session {
+	now := time.Now()
+	return &Session{
+		Meta: Meta{
+			ID:        newID(now),
+			Model:     model,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+	}
+}
+
+// SetMessages обновляет сообщения сессии и производные метаданные.
+// Если заголовок не задан, он создаётся из первого сообщения пользователя.
+func (s *Session) SetMessages(messages []chat.Message) {
+	s.Messages = append([]chat.Message(nil), messages...)
+
+	s.MessageCount = 0
+	for _, msg := range messages {
+		if msg.Role != chat.RoleSystem {
+			s.MessageCount++
+		}
+	}
+
+	if s.Title == "" {
+		for _, msg := range messages {
+			if msg.Role == chat.RoleUser {
+				s.Title = makeTitle(msg.Content)
+				break
+			}
+		}
+	}
+}
+
+// SetHistory сохраняет в сессию активную ветку и полное дерево истории
+func (s *Session) SetHistory(h *chat.ChatHistory) {
+	s.SetMessages(h.GetMessages())
+	s.Tree = h.Snapshot()
+}
+
+// History восстанавливает историю диалога из сессии.
+// Для файлов без дерева используется линейный список сообщений.
+func (s *Session) History() *chat.ChatHistory {
+	if s.Tree != nil {
+		return chat.NewChatHistoryFromSnapshot(s.Tree)
+	}
+	return chat.NewChatHistoryFromMessages(s.Messages)
+}
+
+// Store хранит сессии в виде JSON файлов в директории
+type Store struct {
+	dir string
+}
+
+// NewStore создаёт хранилище сессий в указанной директории
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// DefaultDir возвращает директорию сессий по умолчанию (~/.llm-client/sessions)
+func DefaultDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", apperrors.NewInternalError("HOME_DIR_ERROR", "failed to determine home directory", err)
+	}
+	return filepath.Join(homeDir, ".llm-client", "sessions"), nil
+}
+
+// Dir возвращает директорию хранилища
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Save сохраняет сессию на диск, обновляя время изменения.
+// Запись атомарна: данные пишутся во временный файл и затем переименовываются.
+func (s *Store) Save(sess *Session) error {
+	if err := validateID(sess.ID); err != nil {
+		return err
+	}
+
+	sess.UpdatedAt = time.Now()
+
+	data, err := json.MarshalIndent(sess, "", "  ")
+	if err != nil {
+		return apperrors.NewInternalError("MARSHAL_ERROR", "failed to marshal session", err)
+	}
+
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return apperrors.NewInternalError("MKDIR_ERROR", "failed to create sessions directory", err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, sess.ID+".*.tmp")
+	if err != nil {
+		return apperrors.NewInternalError("WRITE_ERROR", "failed to create session file", err)
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return apperrors.NewInternalError("WRITE_ERROR", "failed to write session file", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return apperrors.NewInternalError("WRITE_ERROR", "failed to write session file", err)
+	}
+	if err := os.Rename(tmpPath, s.path(sess.ID)); err != nil {
+		os.Remove(tmpPath)
+		return apperrors.NewInternalError("WRITE_ERROR", "failed to save session file", err)
+	}
+
+	return nil
+}
+
+// Load загружает сессию по идентификатору или его уникальному префиксу
+func (s *Store) Load(id string) (*Session, error) {
+	id, err := s.resolve(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.read(s.path(id))
+}
+
+// List возвращает метаданные всех сессий, начиная с последней изменённой
+func (s *Store) List() ([]Meta, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, apperrors.NewInternalError("READ_ERROR", "failed to read sessions directory", err)
+	}
+
+	var list []Meta
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != fileExt {
+			continue
+		}
+		sess, err := s.read(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			// Повреждённые файлы пропускаем, чтобы не блокировать остальные сессии
+			continue
+		}
+		list = append(list, sess.Meta)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].UpdatedAt.After(list[j].UpdatedAt)
+	})
+	return list, nil
+}
+
+// Rename изменяет заголовок сессии
+func (s *Store) Rename(id, title string) (*Session, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return nil, apperrors.NewValidationError("INVALID_TITLE", "session title cannot be empty", nil)
+	}
+
+	sess, err := s.Load(id)
+	if err != nil {
+		return nil, err
+	}
+	sess.Title = title
+	if err := s.Save(sess); err != nil {
+		return nil, err
+	}
+	return sess, nil
+}
+
+// Delete удаляет сессию по идентификатору или его уникальному префиксу.
+// Возвращает полный идентификатор удалённой сессии.
+func (s *Store) Delete(id string) (string, error) {
+	id, err := s.resolve(id)
+	if err != nil {
+		return "", err
+	}
+	if err := os.Remove(s.path(id)); err != nil {
+		return "", apperrors.NewInternalError("DELETE_ERROR", "failed to delete session", err)
+	}
+	return id, nil
+}
+
+// path возвращает путь к файлу сессии
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, id+fileExt)
+}
+
+// read читает и разбирает файл сессии
+func (s *Store) read(path string) (*Session, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, notFound(strings.TrimSuffix(filepath.Base(path), fileExt))
+		}
+		return nil, apperrors.NewInternalError("READ_ERROR", "failed to read session file", err)
+	}
+
+	var sess Session
+	if err := json.Unmarshal(data, &sess); err != nil {
+		return nil, apperrors.NewInternalError("UNMARSHAL_ERROR", "failed to parse session file", err).
+			WithContext("path", path)
+	}
+	return &sess, nil
+}
+
+// resolve находит полный идентификатор сессии по точному совпадению или префиксу
+func (s *Store) resolve(id string) (string, error) {
+	if err := validateID(id); err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(s.path(id)); err == nil {
+		return id, nil
+	}
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", notFound(id)
+		}
+		return "", apperrors.NewInternalError("READ_ERROR", "failed to read sessions directory", err)
+	}
+
+	var matches []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if filepath.Ext(name) == fileExt && strings.HasPrefix(name, id) {
+			matches = append(matches, strings.TrimSuffix(name, fileExt))
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", notFound(id)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", apperrors.NewValidationError("AMBIGUOUS_SESSION_ID",
+			fmt.Sprintf("session id %q matches %d sessions", id, len(matches)), nil)
+	}
+}
+
+// validateID проверяет что идентификатор безопасен для использования в имени файла
+func validateID(id string) error {
+	if !validID.MatchString(id) {
+		return apperrors.NewValidationError("INVALID_SESSION_ID", fmt.Sprintf("invalid session id %q", id), nil)
+	}
+	return nil
+}
+
+// notFound создаёт ошибку отсутствующей сессии
+func notFound(id string) error {
+	return apperrors.NewValidationError("SESSION_NOT_FOUND",
+		fmt.Sprintf("session %q not found", id), apperrors.ErrSessionNotFound)
+}
+
+// newID генерирует идентификатор вида 20240601-120000-a1b2c3
+func newID(now time.Time) string {
+	suffix := make([]byte, 3)
+	// Начиная с Go 1.24 crypto/rand.Read не возвращает ошибок
+	rand.Read(suffix)
+	return now.Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// makeTitle создаёт заголовок из текста сообщения
+func makeTitle(content string) string {
+	title := strings.Join(strings.Fields(content), " ")
+	runes := []rune(title)
+	if len(runes) > maxTitleLength {
+		title = strings.TrimSpace(string(runes[:maxTitleLength-1])) + "…"
+	}
+	return title
+}
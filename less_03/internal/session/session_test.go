@@ -0,0 +1,238 @@
+package session
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"llm-client/internal/chat"
+	apperrors "llm-client/internal/errors"
+)
+
+func testMessages() []chat.Message {
+	return []chat.Message{
+		{Role: chat.RoleSystem, Content: "system"},
+		{Role: chat.RoleUser, Content: "  Как   написать\nHTTP сервер на Go?  "},
+		{Role: chat.RoleAssistant, Content: "Используйте net/http"},
+	}
+}
+
+func TestSession_SetMessages(t *testing.T) {
+	sess := New("llama3")
+	sess.SetMessages(testMessages())
+
+	if sess.MessageCount != 2 {
+		t.Errorf("MessageCount = %d, want 2", sess.MessageCount)
+	}
+	if sess.Title != "Как написать HTTP сервер на Go?" {
+		t.Errorf("Title = %q", sess.Title)
+	}
+
+	t.Run("title is not overwritten", func(t *testing.T) {
+		sess.Title = "Custom"
+		sess.SetMessages(testMessages())
+		if sess.Title != "Custom" {
+			t.Errorf("Title = %q, want %q", sess.Title, "Custom")
+		}
+	})
+
+	t.Run("long title is truncated", func(t *testing.T) {
+		s := New("llama3")
+		s.SetMessages([]chat.Message{{Role: chat.RoleUser, Content: strings.Repeat("слово ", 30)}})
+		if n := len([]rune(s.Title)); n > maxTitleLength {
+			t.Errorf("len(Title) = %d, want <= %d", n, maxTitleLength)
+		}
+		if !strings.HasSuffix(s.Title, "…") {
+			t.Errorf("truncated title should end with ellipsis, got %q", s.Title)
+		}
+	})
+}
+
+func TestStore_SaveLoad(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "sessions"))
+
+	sess := New("llama3")
+	sess.SetMessages(testMessages())
+	created := sess.CreatedAt
+
+	if err := store.Save(sess); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := store.Load(sess.ID)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.ID != sess.ID || loaded.Model != "llama3" || loaded.MessageCount != 2 {
+		t.Errorf("loaded meta = %+v", loaded.Meta)
+	}
+	if !loaded.CreatedAt.Equal(created) {
+		t.Errorf("CreatedAt = %v, want %v", loaded.CreatedAt, created)
+	}
+	if len(loaded.Messages) != 3 || loaded.Messages[2].Content != "Используйте net/http" {
+		t.Errorf("Messages = %+v", loaded.Messages)
+	}
+
+	t.Run("load by prefix", func(t *testing.T) {
+		got, err := store.Load(sess.ID[:len(sess.ID)-2])
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if got.ID != sess.ID {
+			t.Errorf("ID = %q, want %q", got.ID, sess.ID)
+		}
+	})
+
+	t.Run("no temp files left", func(t *testing.T) {
+		entries, _ := os.ReadDir(store.Dir())
+		if len(entries) != 1 {
+			t.Errorf("directory should contain only session file, got %d entries", len(entries))
+		}
+	})
+}
+
+func TestSession_History(t *testing.T) {
+	h := chat.NewChatHistory("system")
+	h.AddUser("q")
+	h.AddAssistant("a1")
+	h.Regenerate()
+	h.AddAssistant("a2")
+
+	store := NewStore(t.TempDir())
+	sess := New("llama3")
+	sess.SetHistory(h)
+	if err := store.Save(sess); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := store.Load(sess.ID)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	restored := loaded.History()
+	if restored.LastAssistantMessage().Content != "a2" {
+		t.Errorf("active branch should be restored")
+	}
+	if _, total := restored.Siblings(1); total != 2 {
+		t.Errorf("alternative branches should be restored, total = %d", total)
+	}
+
+	t.Run("legacy file without tree", func(t *testing.T) {
+		legacy := &Session{Messages: testMessages()}
+		if legacy.History().Len() != 3 {
+			t.Errorf("legacy messages should be restored")
+		}
+	})
+}
+
+func TestStore_Load_Errors(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	tests := []struct {
+		name string
+		id   string
+		code string
+	}{
+		{"missing", "20240101-000000-abcdef", "SESSION_NOT_FOUND"},
+		{"path traversal", "../config", "INVALID_SESSION_ID"},
+		{"empty", "", "INVALID_SESSION_ID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := store.Load(tt.id)
+			var appErr *apperrors.AppError
+			if !errors.As(err, &appErr) || appErr.Code != tt.code {
+				t.Errorf("Load(%q) error = %v, want code %s", tt.id, err, tt.code)
+			}
+		})
+	}
+
+	t.Run("not found is detectable", func(t *testing.T) {
+		_, err := store.Load("missing")
+		if !errors.Is(err, apperrors.ErrSessionNotFound) {
+			t.Errorf("errors.Is(err, ErrSessionNotFound) = false for %v", err)
+		}
+	})
+
+	t.Run("ambiguous prefix", func(t *testing.T) {
+		for _, id := range []string{"abc-1", "abc-2"} {
+			sess := New("m")
+			sess.ID = id
+			store.Save(sess)
+		}
+		_, err := store.Load("abc")
+		var appErr *apperrors.AppError
+		if !errors.As(err, &appErr) || appErr.Code != "AMBIGUOUS_SESSION_ID" {
+			t.Errorf("Load() error = %v, want AMBIGUOUS_SESSION_ID", err)
+		}
+	})
+}
+
+func TestStore_List(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	if list, err := store.List(); err != nil || len(list) != 0 {
+		t.Fatalf("List() = %v, %v, want empty", list, err)
+	}
+
+	first := New("model-a")
+	first.Title = "first"
+	store.Save(first)
+	time.Sleep(10 * time.Millisecond)
+
+	second := New("model-b")
+	second.Title = "second"
+	store.Save(second)
+
+	// Повреждённый файл не должен ломать список
+	os.WriteFile(filepath.Join(store.Dir(), "broken.json"), []byte("{"), 0600)
+
+	list, err := store.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("len(List()) = %d, want 2", len(list))
+	}
+	if list[0].Title != "second" || list[1].Title != "first" {
+		t.Errorf("List() should be sorted by update time, got %q, %q", list[0].Title, list[1].Title)
+	}
+}
+
+func TestStore_RenameDelete(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	sess := New("llama3")
+	sess.SetMessages(testMessages())
+	store.Save(sess)
+
+	renamed, err := store.Rename(sess.ID, "  Go сервер  ")
+	if err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	if renamed.Title != "Go сервер" {
+		t.Errorf("Title = %q, want %q", renamed.Title, "Go сервер")
+	}
+	if loaded, _ := store.Load(sess.ID); loaded.Title != "Go сервер" {
+		t.Errorf("renamed title should be persisted, got %q", loaded.Title)
+	}
+
+	if _, err := store.Rename(sess.ID, " "); err == nil {
+		t.Errorf("empty title should return error")
+	}
+
+	id, err := store.Delete(sess.ID[:8])
+	if err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if id != sess.ID {
+		t.Errorf("Delete() id = %q, want %q", id, sess.ID)
+	}
+	if _, err := store.Load(sess.ID); !errors.Is(err, apperrors.ErrSessionNotFound) {
+		t.Errorf("deleted session should not be found, got %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+package tokenizer
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"llm-client/internal/chat"
+	"llm-client/internal/logger"
+)
+
+// Служебные токены формата чата: каждое сообщение обрамляется разметкой
+// <|start|>{role}<|message|>{content}<|end|>, а ответ модели начинается с <|start|>assistant
+const (
+	tokensPerMessage   = 3
+	tokensPerName      = 1
+	tokensReplyPriming = 3
+)
+
+// vocabularyExt - расширение файлов словарей
+const vocabularyExt = ".tiktoken"
+
+// Counter подсчитывает токены для конкретной модели.
+// Без словаря используется оценка 1 токен ≈ 4 байта.
+type Counter struct {
+	enc *Encoding
+}
+
+// NewCounter создаёт счётчик для кодировки (nil - приблизительная оценка)
+func NewCounter(enc *Encoding) *Counter {
+	return &Counter{enc: enc}
+}
+
+// Exact сообщает, используется ли настоящий словарь
+func (c *Counter) Exact() bool {
+	return c != nil && c.enc != nil
+}
+
+// Encoding возвращает имя используемой кодировки (пусто для оценки)
+func (c *Counter) Encoding() string {
+	if !c.Exact() {
+		return ""
+	}
+	return c.enc.Name()
+}
+
+// Count возвращает количество токенов текста
+func (c *Counter) Count(text string) int {
+	if !c.Exact() {
+		return len(text) / 4
+	}
+	return c.enc.Count(text)
+}
+
+// CountMessages возвращает количество токенов запроса с учётом служебной разметки сообщений.
+// Подходит в качестве chat.TokenCounter.
+func (c *Counter) CountMessages(messages []chat.Message) int {
+	if !c.Exact() {
+		return chat.EstimateTokens(messages)
+	}
+
+	total := tokensReplyPriming
+	for _, msg := range messages {
+		total += tokensPerMessage + c.Count(string(msg.Role)) + c.Count(msg.Content)
+		for _, part := range msg.Parts {
+			if part.Type == chat.PartText {
+				total += c.Count(part.Text)
+			} else {
+				total += chat.ImageTokens
+			}
+		}
+		if msg.Name != "" {
+			total += tokensPerName + c.Count(msg.Name)
+		}
+		if msg.ToolCallID != "" {
+			total += c.Count(msg.ToolCallID)
+		}
+		for _, call := range msg.ToolCalls {
+			total += c.Count(call.ID) + c.Count(call.Function.Name) + c.Count(call.Function.Arguments)
+		}
+	}
+	return total
+}
+
+// Loader загружает словари из каталога по требованию и кэширует их
+type Loader struct {
+	dir       string
+	overrides map[string]string
+	logger    *logger.Logger
+
+	mu        sync.Mutex
+	encodings map[string]*Encoding
+	failed    map[string]error
+}
+
+// LoaderOption - функция опция для настройки загрузчика
+type LoaderOption func(*Loader)
+
+// WithLogger устанавливает логгер для сообщений о недоступных словарях
+func WithLogger(log *logger.Logger) LoaderOption {
+	return func(l *Loader) {
+		l.logger = log
+	}
+}
+
+// WithModelEncodings задаёт кодировки для моделей, переопределяя встроенное сопоставление
+func WithModelEncodings(encodings map[string]string) LoaderOption {
+	return func(l *Loader) {
+		l.overrides = encodings
+	}
+}
+
+// NewLoader создаёт загрузчик словарей из каталога dir (файлы <encoding>.tiktoken)
+func NewLoader(dir string, opts ...LoaderOption) *Loader {
+	l := &Loader{
+		dir:       dir,
+		logger:    logger.DefaultLogger,
+		encodings: make(map[string]*Encoding),
+		failed:    make(map[string]error),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// DefaultDir возвращает каталог словарей по умолчанию (~/.llm-client/tokenizers)
+func DefaultDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".llm-client", "tokenizers")
+	}
+	return filepath.Join(home, ".llm-client", "tokenizers")
+}
+
+// Dir возвращает каталог словарей
+func (l *Loader) Dir() string {
+	return l.dir
+}
+
+// Encoding загружает кодировку по имени. Ошибка загрузки запоминается,
+// чтобы не читать отсутствующий файл при каждом подсчёте.
+func (l *Loader) Encoding(name string) (*Encoding, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if enc, ok := l.encodings[name]; ok {
+		return enc, nil
+	}
+	if err, ok := l.failed[name]; ok {
+		return nil, err
+	}
+
+	path := filepath.Join(l.dir, name+vocabularyExt)
+	enc, err := LoadEncodingFile(name, path)
+	if err != nil {
+		l.failed[name] = err
+		l.logger.Warn("Tokenizer vocabulary unavailable, using approximate token count",
+			"encoding", name, "path", path, "error", err)
+		return nil, err
+	}
+	l.encodings[name] = enc
+	l.logger.Debug("Tokenizer vocabulary loaded", "encoding", name, "tokens", len(enc.ranks))
+	return enc, nil
+}
+
+// ForModel возвращает счётчик токенов для модели.
+// Счётчик всегда пригоден к использованию: при ошибке загрузки он работает по оценке.
+func (l *Loader) ForModel(model string) *Counter {
+	name, ok := l.overrides[model]
+	if !ok {
+		name = EncodingForModel(model)
+	}
+	enc, _ := l.Encoding(name)
+	return NewCounter(enc)
+}
@@ -0,0 +1,44 @@
+package tokenizer
+
+import "strings"
+
+// DefaultEncoding используется для моделей без известного словаря.
+// Для моделей других семейств (llama, deepseek, qwen) подсчёт приблизителен,
+// но значительно точнее оценки по длине текста, особенно для кириллицы.
+const DefaultEncoding = Cl100kBase
+
+// modelEncodings сопоставляет префиксы имён моделей с кодировками.
+// Более длинные префиксы идут раньше.
+var modelEncodings = []struct {
+	prefix   string
+	encoding string
+}{
+	{"chatgpt-4o", O200kBase},
+	{"gpt-4o", O200kBase},
+	{"gpt-4.1", O200kBase},
+	{"gpt-4.5", O200kBase},
+	{"gpt-5", O200kBase},
+	{"o1", O200kBase},
+	{"o3", O200kBase},
+	{"o4", O200kBase},
+	{"gpt-4", Cl100kBase},
+	{"gpt-3.5", Cl100kBase},
+	{"gpt-35", Cl100kBase},
+	{"text-embedding-3", Cl100kBase},
+	{"text-embedding-ada-002", Cl100kBase},
+}
+
+// EncodingForModel возвращает имя кодировки для модели.
+// Префикс провайдера ("openai/gpt-4o") не учитывается.
+func EncodingForModel(model string) string {
+	name := strings.ToLower(model)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	for _, m := range modelEncodings {
+		if strings.HasPrefix(name, m.prefix) {
+			return m.encoding
+		}
+	}
+	return DefaultEncoding
+}
@@ -0,0 +1,78 @@
+package tokenizer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func split(text string, match matchFunc) []string {
+	var pieces []string
+	splitText(text, match, func(piece string) {
+		pieces = append(pieces, piece)
+	})
+	return pieces
+}
+
+func TestSplit_CL100K(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Hello world", []string{"Hello", " world"}},
+		{"I'm here, they'LL see", []string{"I", "'m", " here", ",", " they", "'LL", " see"}},
+		{"12345 apples", []string{"123", "45", " apples"}},
+		{"hello   world", []string{"hello", "  ", " world"}},
+		{"foo\n\nbar", []string{"foo", "\n\n", "bar"}},
+		{"a, b!!\n", []string{"a", ",", " b", "!!\n"}},
+		{"x  \n y", []string{"x", "  \n", " y"}},
+		{"end  ", []string{"end", "  "}},
+		{"Привет, мир!", []string{"Привет", ",", " мир", "!"}},
+		{"CamelCase path/to", []string{"CamelCase", " path", "/to"}},
+	}
+
+	for _, tt := range tests {
+		if got := split(tt.text, matchCL100K); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("split(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSplit_O200K(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Hello world", []string{"Hello", " world"}},
+		{"CamelCase", []string{"Camel", "Case"}},
+		{"don't DON'T", []string{"don't", " DON'T"}},
+		{"ПРИВЕТ мир", []string{"ПРИВЕТ", " мир"}},
+		{"a//b\n", []string{"a", "//", "b", "\n"}},
+		{"1234", []string{"123", "4"}},
+		{"日本語です", []string{"日本語です"}},
+	}
+
+	for _, tt := range tests {
+		if got := split(tt.text, matchO200K); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("split(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSplit_CoversInput(t *testing.T) {
+	// Разбиение не должно терять или дублировать байты, в том числе для некорректного UTF-8
+	inputs := []string{"", "\xff\xfe abc", "  \t\r\n x", "'", "a'", "🙂🙂 ok"}
+	for _, text := range inputs {
+		for _, match := range []matchFunc{matchCL100K, matchO200K} {
+			joined := ""
+			for _, piece := range split(text, match) {
+				if piece == "" {
+					t.Fatalf("split(%q) produced empty piece", text)
+				}
+				joined += piece
+			}
+			if joined != text {
+				t.Errorf("split(%q) joined = %q", text, joined)
+			}
+		}
+	}
+}
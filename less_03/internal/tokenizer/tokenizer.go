@@ -0,0 +1,161 @@
+// Package tokenizer подсчитывает токены с помощью byte-level BPE в формате tiktoken
+// (словари cl100k_base, o200k_base), загружаемых из локальных файлов.
+// Если словарь недоступен, используется приблизительная оценка 1 токен ≈ 4 байта.
+package tokenizer
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	apperrors "llm-client/internal/errors"
+)
+
+// Поддерживаемые кодировки
+const (
+	Cl100kBase = "cl100k_base"
+	O200kBase  = "o200k_base"
+)
+
+// matchers - правила предварительного разбиения текста для кодировок
+var matchers = map[string]matchFunc{
+	Cl100kBase: matchCL100K,
+	O200kBase:  matchO200K,
+}
+
+// Encoding - словарь BPE с правилами разбиения текста
+type Encoding struct {
+	name  string
+	ranks map[string]int
+	match matchFunc
+}
+
+// LoadEncoding читает словарь в формате tiktoken: по строке "<base64 токена> <ранг>"
+func LoadEncoding(name string, r io.Reader) (*Encoding, error) {
+	match, ok := matchers[name]
+	if !ok {
+		return nil, apperrors.NewConfigError("UNKNOWN_ENCODING", fmt.Sprintf("unknown encoding %q", name), nil)
+	}
+
+	ranks := make(map[string]int)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		token, rank, err := parseRankLine(text)
+		if err != nil {
+			return nil, apperrors.NewConfigError("TOKENIZER_PARSE", fmt.Sprintf("%s: line %d", name, line), err)
+		}
+		ranks[token] = rank
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, apperrors.NewConfigError("TOKENIZER_READ", fmt.Sprintf("failed to read %s", name), err)
+	}
+
+	// Byte-level BPE должен уметь закодировать любой байт
+	for b := 0; b < 256; b++ {
+		if _, ok := ranks[string([]byte{byte(b)})]; !ok {
+			return nil, apperrors.NewConfigError("TOKENIZER_INCOMPLETE",
+				fmt.Sprintf("%s: vocabulary has no token for byte 0x%02x", name, b), nil)
+		}
+	}
+
+	return &Encoding{name: name, ranks: ranks, match: match}, nil
+}
+
+// LoadEncodingFile загружает словарь из файла
+func LoadEncodingFile(name, path string) (*Encoding, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, apperrors.NewConfigError("TOKENIZER_NOT_FOUND", fmt.Sprintf("failed to open %s vocabulary", name), err).
+			WithContext("path", path)
+	}
+	defer f.Close()
+	return LoadEncoding(name, f)
+}
+
+// parseRankLine разбирает строку словаря
+func parseRankLine(line string) (string, int, error) {
+	encoded, rankText, ok := strings.Cut(line, " ")
+	if !ok {
+		return "", 0, fmt.Errorf("expected \"<base64> <rank>\"")
+	}
+	token, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", 0, err
+	}
+	rank, err := strconv.Atoi(rankText)
+	if err != nil {
+		return "", 0, err
+	}
+	return string(token), rank, nil
+}
+
+// Name возвращает имя кодировки
+func (e *Encoding) Name() string {
+	return e.name
+}
+
+// Encode возвращает идентификаторы токенов текста
+func (e *Encoding) Encode(text string) []int {
+	var tokens []int
+	splitText(text, e.match, func(piece string) {
+		tokens = e.encodePiece(piece, tokens)
+	})
+	return tokens
+}
+
+// Count возвращает количество токенов текста
+func (e *Encoding) Count(text string) int {
+	count := 0
+	splitText(text, e.match, func(piece string) {
+		if _, ok := e.ranks[piece]; ok {
+			count++
+			return
+		}
+		count += len(e.mergeParts(piece)) - 1
+	})
+	return count
+}
+
+// encodePiece кодирует один фрагмент после предварительного разбиения
+func (e *Encoding) encodePiece(piece string, tokens []int) []int {
+	if rank, ok := e.ranks[piece]; ok {
+		return append(tokens, rank)
+	}
+	parts := e.mergeParts(piece)
+	for i := 0; i+1 < len(parts); i++ {
+		tokens = append(tokens, e.ranks[piece[parts[i]:parts[i+1]]])
+	}
+	return tokens
+}
+
+// mergeParts выполняет слияния BPE: начиная с отдельных байтов, на каждом шаге объединяет
+// соседнюю пару с наименьшим рангом. Возвращает границы получившихся токенов.
+func (e *Encoding) mergeParts(piece string) []int {
+	parts := make([]int, len(piece)+1)
+	for i := range parts {
+		parts[i] = i
+	}
+
+	for len(parts) > 2 {
+		minRank, minIndex := math.MaxInt, -1
+		for i := 0; i+2 < len(parts); i++ {
+			if rank, ok := e.ranks[piece[parts[i]:parts[i+2]]]; ok && rank < minRank {
+				minRank, minIndex = rank, i
+			}
+		}
+		if minIndex < 0 {
+			break
+		}
+		parts = append(parts[:minIndex+1], parts[minIndex+2:]...)
+	}
+	return parts
+}
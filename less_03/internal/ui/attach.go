@@ -0,0 +1,190 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"llm-client/internal/chat"
+)
+
+const (
+	// maxImageSize - предельный размер изображения (ограничение OpenAI - 20 MB)
+	maxImageSize = 20 << 20
+	// maxTextFileSize - предельный размер текстового файла, встраиваемого в сообщение
+	maxTextFileSize = 256 << 10
+	// maxAttachments - сколько вложений можно добавить к одному сообщению
+	maxAttachments = 10
+)
+
+// imageTypes - форматы изображений, которые принимают модели с поддержкой зрения
+var imageTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// attachment - вложение, которое будет отправлено со следующим сообщением
+type attachment struct {
+	name string
+	// kind - MIME тип изображения или "text"
+	kind string
+	size int64
+	part chat.ContentPart
+}
+
+// String возвращает отметку вложения для поля ввода
+func (a attachment) String() string {
+	return fmt.Sprintf("%s (%s, %s)", a.name, a.kind, formatBytes(a.size))
+}
+
+// handleAttachCommand обрабатывает /attach <path>: без аргументов выводит вложения,
+// /attach clear удаляет их
+func (m *Model) handleAttachCommand(arg string) (tea.Model, tea.Cmd) {
+	switch arg {
+	case "":
+		if len(m.attachments) == 0 {
+			m.errorMsg = "Вложений нет (/attach <путь>)"
+		} else {
+			m.errorMsg = "Вложения: " + m.attachmentSummary()
+		}
+		m.status = StatusIdle
+		m.input.Reset()
+		return m, nil
+	case "clear":
+		m.attachments = nil
+		m.errorMsg = "Вложения удалены"
+		m.status = StatusIdle
+		m.input.Reset()
+		return m, nil
+	}
+
+	if len(m.attachments) >= maxAttachments {
+		return m.commandError(fmt.Sprintf("Не больше %d вложений в сообщении", maxAttachments))
+	}
+	a, err := loadAttachment(arg)
+	if err != nil {
+		m.logger.Error("Failed to attach file", "path", arg, "error", err)
+		return m.commandError(fmt.Sprintf("Ошибка: %v", err))
+	}
+
+	m.attachments = append(m.attachments, a)
+	m.logger.Info("File attached", "name", a.name, "type", a.kind, "size", a.size)
+	m.errorMsg = "Добавлено вложение: " + a.String() + ", будет отправлено со следующим сообщением"
+	m.status = StatusIdle
+	m.input.Reset()
+	return m, nil
+}
+
+// loadAttachment читает файл: изображения передаются в base64 data URL,
+// текстовые файлы встраиваются блоком кода
+func loadAttachment(path string) (attachment, error) {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, rest)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return attachment{}, err
+	}
+	if info.IsDir() {
+		return attachment{}, fmt.Errorf("%s - каталог", path)
+	}
+	if info.Size() > maxImageSize {
+		return attachment{}, fmt.Errorf("файл %s больше %s", info.Name(), formatBytes(maxImageSize))
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return attachment{}, err
+	}
+
+	a := attachment{name: info.Name(), size: int64(len(data))}
+	if kind := detectImageType(info.Name(), data); kind != "" {
+		a.kind = kind
+		a.part = chat.ImagePart(kind, data)
+		return a, nil
+	}
+
+	if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+		return attachment{}, fmt.Errorf("неподдерживаемый тип файла %s (%s): поддерживаются изображения PNG, JPEG, GIF, WebP и текст",
+			info.Name(), http.DetectContentType(data))
+	}
+	if len(data) > maxTextFileSize {
+		return attachment{}, fmt.Errorf("текстовый файл %s больше %s", info.Name(), formatBytes(maxTextFileSize))
+	}
+	a.kind = "text"
+	a.part = chat.TextPart(fencedFile(info.Name(), string(data)))
+	return a, nil
+}
+
+// detectImageType возвращает MIME тип изображения по содержимому, а если оно
+// не распознано - по расширению; пустая строка - не изображение
+func detectImageType(name string, data []byte) string {
+	kind := http.DetectContentType(data)
+	if imageTypes[kind] {
+		return kind
+	}
+	if strings.HasPrefix(kind, "text/") {
+		return ""
+	}
+	kind, _, _ = mime.ParseMediaType(mime.TypeByExtension(filepath.Ext(name)))
+	if imageTypes[kind] {
+		return kind
+	}
+	return ""
+}
+
+// fencedFile оформляет содержимое файла блоком кода с языком по расширению.
+// Ограждение длиннее любой последовательности ``` внутри файла.
+func fencedFile(name, content string) string {
+	fence := "```"
+	for strings.Contains(content, fence) {
+		fence += "`"
+	}
+	lang := strings.TrimPrefix(filepath.Ext(name), ".")
+	return fmt.Sprintf("Файл %s:\n%s%s\n%s\n%s", name, fence, lang, strings.TrimRight(content, "\n"), fence)
+}
+
+// takeAttachments возвращает части вложений для отправки и очищает список
+func (m *Model) takeAttachments() []chat.ContentPart {
+	if len(m.attachments) == 0 {
+		return nil
+	}
+	parts := make([]chat.ContentPart, len(m.attachments))
+	for i, a := range m.attachments {
+		parts[i] = a.part
+	}
+	m.attachments = nil
+	return parts
+}
+
+// attachmentSummary перечисляет ожидающие отправки вложения
+func (m *Model) attachmentSummary() string {
+	names := make([]string, len(m.attachments))
+	for i, a := range m.attachments {
+		names[i] = a.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+// formatBytes форматирует размер файла: 512 B, 12.3 KB, 1.5 MB
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"llm-client/internal/chat"
+)
+
+// pngData - начало файла PNG, по которому определяется тип
+var pngData = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadAttachment(t *testing.T) {
+	image, err := loadAttachment(writeFile(t, "screen shot.png", pngData))
+	if err != nil || image.kind != "image/png" {
+		t.Fatalf("png: %+v, %v", image, err)
+	}
+	if mimeType, _, ok := image.part.InlineImage(); !ok || mimeType != "image/png" {
+		t.Errorf("png part = %+v", image.part)
+	}
+
+	// Тип изображения без сигнатуры определяется по расширению
+	if webp, err := loadAttachment(writeFile(t, "a.webp", []byte{0x01, 0x02})); err != nil || webp.kind != "image/webp" {
+		t.Errorf("webp: %+v, %v", webp, err)
+	}
+
+	text, err := loadAttachment(writeFile(t, "main.go", []byte("package main\n\n// ```\n")))
+	if err != nil || text.kind != "text" {
+		t.Fatalf("text: %+v, %v", text, err)
+	}
+	if want := "Файл main.go:\n````go\npackage main\n\n// ```\n````"; text.part.Text != want {
+		t.Errorf("fenced text = %q, want %q", text.part.Text, want)
+	}
+
+	for name, data := range map[string][]byte{
+		"binary.bin": {0x00, 0x01, 0x02, 0xff},
+		"large.txt":  []byte(strings.Repeat("a", maxTextFileSize+1)),
+	} {
+		if _, err := loadAttachment(writeFile(t, name, data)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if _, err := loadAttachment(t.TempDir()); err == nil {
+		t.Errorf("directory: expected error")
+	}
+}
+
+func TestModel_AttachAndSend(t *testing.T) {
+	m, server := newCompareModel(t)
+	path := writeFile(t, "screen shot.png", pngData)
+
+	m.handleCommand("/attach " + path)
+	if m.status == StatusError || len(m.attachments) != 1 {
+		t.Fatalf("/attach failed: %s", m.errorMsg)
+	}
+	if lines := m.inputLines(); !strings.HasPrefix(lines[0], "📎 screen shot.png (image/png") {
+		t.Errorf("input indicator = %q", lines[0])
+	}
+
+	m.input.SetValue("Что на скриншоте?")
+	m.sendMessage()
+	req := server.Await(1)[0]
+	if len(m.attachments) != 0 {
+		t.Errorf("attachments should be sent with the message")
+	}
+
+	user := req.Messages[len(req.Messages)-1]
+	if user.Content != "Что на скриншоте?" || len(user.Parts) != 1 || user.Parts[0].Type != chat.PartImageURL {
+		t.Fatalf("request user message = %+v", user)
+	}
+	if !strings.Contains(string(req.Body), `"image_url":{"url":"data:image/png;base64,`) {
+		t.Errorf("request body should contain data URL:\n%s", req.Body)
+	}
+
+	content := m.renderHistoryContent()
+	if !strings.Contains(content, "[📎 изображение image/png") || strings.Contains(content, "base64") {
+		t.Errorf("history should show attachment indicator:\n%s", content)
+	}
+}
+
+func TestModel_AttachCommand(t *testing.T) {
+	m, _ := newCompareModel(t)
+
+	m.handleCommand("/attach /nonexistent/file.png")
+	if m.status != StatusError {
+		t.Errorf("missing file: status = %v", m.status)
+	}
+
+	m.handleCommand("/attach " + writeFile(t, "notes.md", []byte("# notes")))
+	m.handleCommand("/attach")
+	if !strings.Contains(m.errorMsg, "notes.md (text") {
+		t.Errorf("/attach list = %q", m.errorMsg)
+	}
+	m.handleCommand("/attach clear")
+	if len(m.attachments) != 0 {
+		t.Errorf("/attach clear should remove attachments")
+	}
+}
@@ -0,0 +1,134 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"llm-client/internal/chat"
+)
+
+// isBusy проверяет, выполняется ли сейчас запрос к модели
+func (m *Model) isBusy() bool {
+	return m.status == StatusSending || m.status == StatusStreaming || m.status == StatusToolCall ||
+		m.status == StatusSummarizing
+}
+
+// userMessageIndex возвращает индекс n-го (с 1) сообщения пользователя в активной ветке
+func (m *Model) userMessageIndex(n int) (int, bool) {
+	count := 0
+	for i, msg := range m.history.GetDisplayMessages() {
+		if msg.Role == chat.RoleUser {
+			count++
+			if count == n {
+				return i, true
+			}
+		}
+	}
+	return -1, false
+}
+
+// editMessage обрабатывает /edit <n> <text>: создаёт ветку с изменённым вопросом
+// и запрашивает новый ответ
+func (m *Model) editMessage(args []string) (tea.Model, tea.Cmd) {
+	if m.isBusy() {
+		return m.commandError("Дождитесь завершения ответа")
+	}
+	if len(args) < 2 {
+		return m.commandError("Использование: /edit <n> <текст>, где n - номер вашего сообщения")
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		return m.commandError(fmt.Sprintf("Некорректный номер сообщения: %s", args[0]))
+	}
+	index, ok := m.userMessageIndex(n)
+	if !ok {
+		return m.commandError(fmt.Sprintf("Сообщение #%d не найдено", n))
+	}
+
+	if err := m.history.EditUser(index, strings.Join(args[1:], " ")); err != nil {
+		return m.commandError(fmt.Sprintf("Ошибка: %v", err))
+	}
+	m.logger.Info("User message edited", "index", index)
+
+	return m.requestAnswer()
+}
+
+// regenerate обрабатывает /regen: сохраняет текущий ответ как альтернативу и запрашивает новый
+func (m *Model) regenerate() (tea.Model, tea.Cmd) {
+	if m.isBusy() {
+		return m.commandError("Дождитесь завершения ответа")
+	}
+
+	if messages := m.history.GetDisplayMessages(); len(messages) > 0 && messages[len(messages)-1].Role == chat.RoleUser {
+		// Ответ на последний вопрос был прерван - просто запрашиваем его заново
+		return m.requestAnswer()
+	}
+
+	index, err := m.history.Regenerate()
+	if err != nil {
+		return m.commandError("Нет ответа для повторной генерации")
+	}
+	m.logger.Info("Regenerating answer", "index", index)
+
+	return m.requestAnswer()
+}
+
+// requestAnswer отправляет запрос для текущей активной ветки
+func (m *Model) requestAnswer() (tea.Model, tea.Cmd) {
+	m.input.Reset()
+	m.status = StatusSending
+	m.errorMsg = ""
+	m.notice = ""
+	m.beginTurn()
+	m.streamingBuf.Reset()
+	m.viewport.GotoBottom()
+
+	return m, tea.Sequence(m.updateViewportContent(), m.prepareRequest())
+}
+
+// cycleBranch переключает последнее разветвлённое сообщение на соседний вариант
+func (m *Model) cycleBranch(delta int) (tea.Model, tea.Cmd) {
+	if m.isBusy() {
+		return m, nil
+	}
+
+	index := m.history.LastBranchPoint()
+	if index < 0 {
+		m.errorMsg = "Нет альтернативных вариантов"
+		m.status = StatusIdle
+		return m, nil
+	}
+
+	if err := m.history.SwitchBranch(index, delta); err != nil {
+		m.logger.Error("Failed to switch branch", "index", index, "error", err)
+		return m, nil
+	}
+
+	current, total := m.history.Siblings(index)
+	m.errorMsg = fmt.Sprintf("Вариант %d/%d", current+1, total)
+	m.status = StatusIdle
+	m.saveSession()
+	m.viewport.GotoBottom()
+	return m, m.updateViewportContent()
+}
+
+// commandError показывает ошибку команды и очищает ввод
+func (m *Model) commandError(text string) (tea.Model, tea.Cmd) {
+	m.errorMsg = text
+	m.status = StatusError
+	m.input.Reset()
+	return m, nil
+}
+
+// branchIndicator возвращает индикатор вида ‹2/3› для сообщения с альтернативами
+func (m *Model) branchIndicator(index int) string {
+	current, total := m.history.Siblings(index)
+	if total < 2 {
+		return ""
+	}
+	return fmt.Sprintf("‹%d/%d› ", current+1, total)
+}
@@ -0,0 +1,121 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"llm-client/internal/config"
+	"llm-client/internal/fakellm"
+	"llm-client/internal/logger"
+)
+
+// newBranchModel создаёт модель с диалогом из двух вопросов и сервером-заглушкой
+func newBranchModel(t *testing.T) *Model {
+	t.Helper()
+	server := fakellm.New(t, fakellm.WithDefaultReply(fakellm.Text("")))
+
+	cfg := config.DefaultConfig()
+	cfg.Server.Address = server.URL
+	m := NewModel(cfg, WithLogger(logger.NewLogger(logger.Config{Enabled: false})))
+
+	m.history.AddUser("q1")
+	m.history.AddAssistant("a1")
+	m.history.AddUser("q2")
+	m.history.AddAssistant("a2")
+	return m
+}
+
+// finishAnswer имитирует получение нового ответа от модели
+func finishAnswer(m *Model, answer string) {
+	m.cancel()
+	m.status = StatusStreaming
+	m.handleStreamMsg(StreamMsg{Content: answer})
+	m.handleStreamMsg(StreamMsg{Done: true})
+}
+
+func TestModel_handleCommand_Edit(t *testing.T) {
+	m := newBranchModel(t)
+
+	_, cmd := m.handleCommand("/edit 2 q2 edited")
+	if cmd == nil || m.status != StatusStreaming {
+		t.Fatalf("/edit should start streaming, status = %v, errorMsg = %q", m.status, m.errorMsg)
+	}
+	if last := m.history.LastUserMessage(); last.Content != "q2 edited" {
+		t.Errorf("last user message = %q, want %q", last.Content, "q2 edited")
+	}
+	finishAnswer(m, "a2 for edited")
+
+	content := m.renderHistoryContent()
+	if !strings.Contains(content, "‹2/2›") {
+		t.Errorf("edited message should show branch indicator")
+	}
+	if !strings.Contains(content, "[2] Вы:") {
+		t.Errorf("user messages should be numbered for /edit")
+	}
+
+	// Ctrl+P возвращает исходный вопрос вместе с ответом
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyCtrlP})
+	if last := m.history.LastAssistantMessage(); last.Content != "a2" {
+		t.Errorf("after Ctrl+P last answer = %q, want %q", last.Content, "a2")
+	}
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		for _, cmd := range []string{"/edit", "/edit x text", "/edit 9 text"} {
+			m.handleCommand(cmd)
+			if m.status != StatusError {
+				t.Errorf("%q: status = %v, want %v", cmd, m.status, StatusError)
+			}
+		}
+	})
+}
+
+func TestModel_handleCommand_Regen(t *testing.T) {
+	m := newBranchModel(t)
+
+	if _, cmd := m.handleCommand("/regen"); cmd == nil {
+		t.Fatalf("/regen should start streaming, errorMsg = %q", m.errorMsg)
+	}
+	if last := m.history.LastAssistantMessage(); last.Content != "a1" {
+		t.Errorf("regenerated answer should be detached from active path, last = %q", last.Content)
+	}
+	finishAnswer(m, "a2 v2")
+
+	if current, total := m.history.Siblings(3); current != 1 || total != 2 {
+		t.Errorf("Siblings(3) = %d/%d, want 1/2", current, total)
+	}
+
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyCtrlN})
+	if last := m.history.LastAssistantMessage(); last.Content != "a2" {
+		t.Errorf("after Ctrl+N last answer = %q, want %q", last.Content, "a2")
+	}
+	if m.errorMsg != "Вариант 1/2" {
+		t.Errorf("errorMsg = %q, want %q", m.errorMsg, "Вариант 1/2")
+	}
+
+	t.Run("busy", func(t *testing.T) {
+		m.status = StatusStreaming
+		m.handleCommand("/regen")
+		if m.status != StatusError {
+			t.Errorf("/regen during streaming should fail")
+		}
+	})
+
+	t.Run("empty history", func(t *testing.T) {
+		m := NewModel(config.DefaultConfig())
+		m.handleCommand("/regen")
+		if m.status != StatusError {
+			t.Errorf("status = %v, want %v", m.status, StatusError)
+		}
+	})
+}
+
+func TestModel_cycleBranch_NoAlternatives(t *testing.T) {
+	m := newBranchModel(t)
+
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyCtrlN})
+	if m.errorMsg != "Нет альтернативных вариантов" {
+		t.Errorf("errorMsg = %q", m.errorMsg)
+	}
+}
@@ -0,0 +1,510 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"llm-client/internal/chat"
+	"llm-client/internal/client"
+	"llm-client/internal/config"
+	"llm-client/internal/logger"
+)
+
+const (
+	// minCompareColumns, maxCompareColumns - сколько конфигураций сравнивается одновременно
+	minCompareColumns = 2
+	maxCompareColumns = 3
+	// compareGap - ширина промежутка между колонками
+	compareGap = 2
+)
+
+var compareHeaderStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("205")).
+	Bold(true)
+
+// CompareStreamMsg - чанк ответа одной колонки режима сравнения
+type CompareStreamMsg struct {
+	Column int
+	// Turn - номер хода; сообщения отменённого хода игнорируются
+	Turn int
+	StreamMsg
+	// Usage - расход токенов всех раундов хода, приходит в сообщении Done
+	Usage *client.Usage
+	// ToolRound - запрос вызова инструментов и их результаты для истории колонки
+	ToolRound []chat.Message
+}
+
+// compareColumn - колонка режима сравнения: своя конфигурация и история диалога
+type compareColumn struct {
+	runtime *config.RuntimeConfig
+	history *chat.ChatHistory
+	buf     strings.Builder
+	stream  <-chan CompareStreamMsg
+
+	// summarizer - стратегия суммаризации моделью колонки, создаётся по требованию
+	summarizer *chat.Summarizer
+
+	// Статистика последнего ответа
+	streaming  bool
+	start      time.Time
+	firstToken time.Duration
+	latency    time.Duration
+	tokens     int
+	// toolTokens - оценка токенов текста, отправленного вместе с вызовами инструментов
+	toolTokens int
+	err        error
+	// stopped - ответ прерван через Ctrl+C
+	stopped bool
+}
+
+// compareMode - состояние режима сравнения (/compare).
+// Истории колонок живут только в памяти: они не сохраняются в сессии
+// и теряются при /compare off, основной диалог при этом не меняется.
+type compareMode struct {
+	columns []*compareColumn
+	turn    int
+}
+
+// handleCompareCommand обрабатывает /compare <a> <b> [c]: каждая колонка - модель
+// с необязательной температурой (model@0.2, @0.2 - текущая модель). /compare off выключает режим.
+func (m *Model) handleCompareCommand(args []string) (tea.Model, tea.Cmd) {
+	if m.isBusy() {
+		return m.commandError("Дождитесь завершения ответа")
+	}
+
+	if len(args) == 1 && args[0] == "off" {
+		if m.compare == nil {
+			return m.commandError("Режим сравнения не включён")
+		}
+		m.compare = nil
+		m.errorMsg = "Режим сравнения выключен"
+		m.status = StatusIdle
+		m.input.Reset()
+		return m, m.updateViewportContent()
+	}
+	if len(args) < minCompareColumns || len(args) > maxCompareColumns {
+		return m.commandError(fmt.Sprintf("Использование: /compare <model[@temp]> ... (%d-%d колонки), /compare off",
+			minCompareColumns, maxCompareColumns))
+	}
+
+	columns := make([]*compareColumn, 0, len(args))
+	for _, spec := range args {
+		runtime, err := m.compareRuntime(spec)
+		if err != nil {
+			return m.commandError(fmt.Sprintf("Ошибка: %s: %v", spec, err))
+		}
+		// Колонки продолжают текущий диалог
+		columns = append(columns, &compareColumn{
+			runtime: runtime,
+			history: chat.NewChatHistoryFromMessages(m.history.GetMessages()),
+		})
+	}
+
+	m.compare = &compareMode{columns: columns}
+	m.logger.Info("Compare mode enabled", "columns", args)
+	m.errorMsg = fmt.Sprintf("Режим сравнения: %s (/compare off - выход)", strings.Join(args, ", "))
+	m.status = StatusIdle
+	m.input.Reset()
+	m.viewport.GotoBottom()
+	return m, m.updateViewportContent()
+}
+
+// compareRuntime создаёт конфигурацию колонки из "model", "model@temp" или "@temp"
+func (m *Model) compareRuntime(spec string) (*config.RuntimeConfig, error) {
+	runtime := *m.runtime
+	model, temperature, hasTemperature := strings.Cut(spec, "@")
+	if model != "" {
+		if err := runtime.SetParam("model", model); err != nil {
+			return nil, err
+		}
+	}
+	if hasTemperature {
+		if err := runtime.SetParam("temperature", temperature); err != nil {
+			return nil, err
+		}
+	}
+	return &runtime, nil
+}
+
+// sendCompare отправляет сообщение пользователя во все колонки одновременно.
+// Все потоки хода используют один контекст, поэтому Ctrl+C останавливает их вместе.
+func (m *Model) sendCompare() (tea.Model, tea.Cmd) {
+	text := m.input.Value()
+	m.input.Reset()
+	m.errorMsg = ""
+	m.notice = ""
+	m.beginTurn()
+
+	c := m.compare
+	c.turn++
+	ctx, cancel := context.WithCancel(m.requestContext())
+	m.cancel = cancel
+	m.status = StatusStreaming
+
+	parts := m.takeAttachments()
+	cmds := []tea.Cmd{tickCommand()}
+	for i, col := range c.columns {
+		col.history.AddUserParts(text, parts)
+		col.buf.Reset()
+		col.streaming = true
+		col.start = time.Now()
+		col.firstToken, col.latency, col.tokens, col.toolTokens, col.err, col.stopped = 0, 0, 0, 0, nil, false
+
+		turn := m.compareTurn(col)
+		m.logger.InfoContext(ctx, "Starting compare stream",
+			"column", i, "model", turn.req.Model, "temp", turn.req.Temperature, "tools", len(turn.req.Tools))
+		col.stream = turn.stream(ctx, i, c.turn)
+		cmds = append(cmds, readCompareMsg(col.stream))
+	}
+
+	m.viewport.GotoBottom()
+	return m, tea.Batch(append(cmds, m.updateViewportContent())...)
+}
+
+// compareTurn - ход одной колонки: запрос и всё, что нужно для него вне цикла обновления UI
+type compareTurn struct {
+	client *client.Client
+	tools  *ToolRegistry
+	req    *client.ChatRequest
+	// Сокращение истории под контекстное окно модели колонки, как в основном чате
+	trimmer chat.Trimmer
+	budget  int
+	log     *logger.Logger
+}
+
+// compareTurn собирает ход колонки по тем же правилам, что и prepareRequest:
+// с инструментами и сокращением истории под контекстное окно модели колонки
+func (m *Model) compareTurn(col *compareColumn) *compareTurn {
+	req := m.requestFor(col.runtime, col.history)
+	// Колонки всегда стримятся, чтобы ответы появлялись параллельно
+	req.Stream = true
+
+	return &compareTurn{
+		client: m.client,
+		tools:  m.tools,
+		req:    req,
+		trimmer: m.trimmerFor(col.runtime, func() *chat.Summarizer {
+			if col.summarizer == nil {
+				col.summarizer = m.newSummarizer(col.runtime.Model)
+			}
+			return col.summarizer
+		}),
+		budget: m.appConfig.Model.ContextBudget(col.runtime.Model),
+		log:    m.logger,
+	}
+}
+
+// stream запускает ход колонки и пересылает его чанки в UI.
+// Вызовы инструментов выполняются здесь же, после каждого раунда история заново сокращается.
+func (t *compareTurn) stream(ctx context.Context, column, turn int) <-chan CompareStreamMsg {
+	out := make(chan CompareStreamMsg, 64)
+	go func() {
+		defer close(out)
+		send := func(msg CompareStreamMsg) {
+			msg.Column, msg.Turn = column, turn
+			out <- msg
+		}
+
+		messages := t.req.Messages
+		var usage *client.Usage
+		for round := 0; ; round++ {
+			t.req.Messages = messages
+			if t.budget > 0 {
+				trimmed, err := t.trimmer.Trim(ctx, messages, t.budget)
+				if err != nil {
+					if ctx.Err() != nil {
+						send(CompareStreamMsg{StreamMsg: StreamMsg{Err: ctx.Err()}})
+						return
+					}
+					// Как и в основном чате, запрос отправляется с историей без старых реплик
+					t.log.ErrorContext(ctx, "Failed to summarize compare history", "column", column, "error", err)
+				}
+				t.req.Messages = trimmed
+			}
+
+			done, ok := t.streamRound(ctx, send)
+			if !ok {
+				return
+			}
+			usage = addUsage(usage, done.Usage)
+
+			if len(done.ToolCalls) == 0 || t.tools == nil {
+				send(CompareStreamMsg{StreamMsg: StreamMsg{Done: true}, Usage: usage})
+				return
+			}
+
+			toolRound := t.runTools(ctx, done, round)
+			send(CompareStreamMsg{ToolRound: toolRound})
+			if round >= maxToolRounds {
+				send(CompareStreamMsg{StreamMsg: StreamMsg{
+					Err: fmt.Errorf("превышен лимит вызовов инструментов (%d)", maxToolRounds),
+				}})
+				return
+			}
+			messages = append(messages, toolRound...)
+		}
+	}()
+	return out
+}
+
+// streamRound выполняет один запрос колонки и пересылает текст ответа.
+// Возвращает чанк завершения; false - поток завершился ошибкой, о которой UI уже сообщено.
+func (t *compareTurn) streamRound(ctx context.Context, send func(CompareStreamMsg)) (client.StreamChunk, bool) {
+	var content strings.Builder
+	for chunk := range t.client.ChatStream(ctx, t.req) {
+		switch {
+		case chunk.Error != nil:
+			send(CompareStreamMsg{StreamMsg: StreamMsg{Err: chunk.Error}})
+			return chunk, false
+		case chunk.Done:
+			chunk.Content = content.String()
+			return chunk, true
+		case chunk.Content != "":
+			content.WriteString(chunk.Content)
+			send(CompareStreamMsg{StreamMsg: StreamMsg{Content: chunk.Content}})
+		}
+	}
+	// Канал закрыт без чанка Done - ход отменён
+	send(CompareStreamMsg{StreamMsg: StreamMsg{Err: context.Canceled}})
+	return client.StreamChunk{}, false
+}
+
+// runTools выполняет вызовы инструментов раунда и возвращает сообщения для истории.
+// После maxToolRounds раундов инструменты не вызываются, модель получает ошибку.
+func (t *compareTurn) runTools(ctx context.Context, done client.StreamChunk, round int) []chat.Message {
+	messages := []chat.Message{{
+		Role:      chat.RoleAssistant,
+		Content:   done.Content,
+		ToolCalls: done.ToolCalls,
+	}}
+	for _, call := range done.ToolCalls {
+		var content string
+		if round >= maxToolRounds {
+			content = "error: tool call limit reached"
+		} else {
+			t.log.InfoContext(ctx, "Executing tool", "tool", call.Function.Name, "call_id", call.ID)
+			result, err := t.tools.Execute(ctx, call)
+			content = result
+			if err != nil {
+				t.log.ErrorContext(ctx, "Tool execution failed", "tool", call.Function.Name, "error", err)
+				content = "error: " + err.Error()
+			}
+		}
+		messages = append(messages, chat.Message{
+			Role:       chat.RoleTool,
+			Content:    content,
+			ToolCallID: call.ID,
+			Name:       call.Function.Name,
+		})
+	}
+	return messages
+}
+
+// addUsage суммирует расход токенов раундов хода; nil - провайдер расход не прислал
+func addUsage(total, usage *client.Usage) *client.Usage {
+	if usage == nil {
+		return total
+	}
+	if total == nil {
+		total = &client.Usage{}
+	}
+	total.PromptTokens += usage.PromptTokens
+	total.CompletionTokens += usage.CompletionTokens
+	total.TotalTokens += usage.TotalTokens
+	return total
+}
+
+// readCompareMsg читает сообщение из канала колонки
+func readCompareMsg(ch <-chan CompareStreamMsg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
+// handleCompareStreamMsg обрабатывает чанк ответа колонки
+func (m *Model) handleCompareStreamMsg(msg CompareStreamMsg) (tea.Model, tea.Cmd) {
+	c := m.compare
+	if c == nil || msg.Turn != c.turn || msg.Column >= len(c.columns) {
+		// Ход отменён или режим выключен
+		return m, nil
+	}
+	col := c.columns[msg.Column]
+	if !col.streaming {
+		return m, nil
+	}
+
+	switch {
+	case msg.Err != nil:
+		m.logger.ErrorContext(m.requestContext(), "Compare stream error", "column", msg.Column, "error", msg.Err)
+		col.err = msg.Err
+		col.finish(time.Now())
+
+	case msg.Done:
+		if msg.Usage != nil {
+			col.tokens = msg.Usage.CompletionTokens
+		} else {
+			// Провайдер не прислал usage - считаем ответ токенизатором модели колонки
+			col.tokens = col.toolTokens + m.countText(col.runtime.Model, col.buf.String())
+		}
+		col.finish(time.Now())
+
+	case len(msg.ToolRound) > 0:
+		col.addToolRound(msg.ToolRound)
+		col.toolTokens += m.countText(col.runtime.Model, col.buf.String())
+		col.tokens = col.toolTokens
+		col.buf.Reset()
+		return m, tea.Batch(readCompareMsg(col.stream), m.updateViewportContent())
+
+	default:
+		if col.firstToken == 0 {
+			col.firstToken = time.Since(col.start)
+		}
+		col.buf.WriteString(msg.Content)
+		// Оценка по чанкам; точное значение подставляется по завершении ответа
+		col.tokens += m.countText(col.runtime.Model, msg.Content)
+		return m, readCompareMsg(col.stream)
+	}
+
+	if !c.streaming() {
+		m.logger.InfoContext(m.requestContext(), "Compare turn completed", "columns", len(c.columns))
+		m.finishTurn()
+		m.status = StatusIdle
+		m.viewport.GotoBottom()
+	}
+	return m, m.updateViewportContent()
+}
+
+// finish сохраняет ответ колонки в её истории
+func (col *compareColumn) finish(now time.Time) {
+	col.streaming = false
+	col.latency = now.Sub(col.start)
+	if col.buf.Len() > 0 {
+		col.history.AddAssistant(col.buf.String())
+	}
+	col.buf.Reset()
+}
+
+// addToolRound добавляет в историю колонки вызовы инструментов и их результаты
+func (col *compareColumn) addToolRound(messages []chat.Message) {
+	for _, msg := range messages {
+		if msg.Role == chat.RoleTool {
+			col.history.AddToolResult(msg.ToolCallID, msg.Name, msg.Content)
+		} else {
+			col.history.AddAssistantToolCalls(msg.Content, msg.ToolCalls)
+		}
+	}
+}
+
+// countText возвращает количество токенов текста по словарю модели (без словаря - оценка)
+func (m *Model) countText(model, text string) int {
+	return m.tokenizers.ForModel(model).Count(text)
+}
+
+// streaming сообщает, получает ли ответ хотя бы одна колонка
+func (c *compareMode) streaming() bool {
+	for _, col := range c.columns {
+		if col.streaming {
+			return true
+		}
+	}
+	return false
+}
+
+// cancelCompare останавливает все колонки хода; полученная часть ответов остаётся в истории
+func (m *Model) cancelCompare() {
+	if m.compare == nil {
+		return
+	}
+	now := time.Now()
+	for _, col := range m.compare.columns {
+		if col.streaming {
+			col.stopped = true
+			col.finish(now)
+		}
+	}
+}
+
+// clear очищает истории колонок
+func (c *compareMode) clear(systemPrompt string) {
+	for _, col := range c.columns {
+		col.history.Clear(systemPrompt)
+		col.buf.Reset()
+		col.streaming, col.err, col.stopped = false, nil, false
+		col.latency, col.tokens, col.toolTokens = 0, 0, 0
+	}
+}
+
+// renderCompareContent рендерит колонки сравнения рядом друг с другом
+func (m *Model) renderCompareContent() string {
+	c := m.compare
+	n := len(c.columns)
+	width := max((m.getContentWidth()-compareGap*(n-1))/n, 10)
+
+	blocks := make([]string, 0, 2*n-1)
+	for i, col := range c.columns {
+		if i > 0 {
+			blocks = append(blocks, strings.Repeat(" ", compareGap))
+		}
+		lines := m.renderCompareColumn(col, width)
+		blocks = append(blocks, lipgloss.NewStyle().Width(width).Render(strings.Join(lines, "\n")))
+	}
+
+	content := lipgloss.JoinHorizontal(lipgloss.Top, blocks...) + "\n"
+	if m.notice != "" {
+		content += "\n" + statusStyle.Render(m.notice) + "\n"
+	}
+	return content
+}
+
+// renderCompareColumn возвращает строки колонки: заголовок, диалог, текущий ответ и статистику
+func (m *Model) renderCompareColumn(col *compareColumn, width int) []string {
+	header := fmt.Sprintf("%s · t=%.2f", col.runtime.Model, col.runtime.Temperature)
+	lines := []string{compareHeaderStyle.Render(truncateLine(header, width))}
+
+	for _, msg := range col.history.GetDisplayMessages() {
+		switch msg.Role {
+		case chat.RoleUser:
+			lines = append(lines, m.formatMessage(msg.DisplayContent(), "▸ Вы: ", messageUserStyle, width)...)
+		case chat.RoleAssistant:
+			lines = append(lines, m.formatMessage(msg.Content, "▸ AI: ", messageAssistantStyle, width)...)
+		}
+	}
+	if col.buf.Len() > 0 {
+		lines = append(lines, m.formatMessage(col.buf.String(), "▸ AI: ", messageAssistantStyle, width)...)
+	}
+	if stats := col.stats(); stats != "" {
+		lines = append(lines, statusStyle.Render(truncateLine(stats, width)))
+	}
+	return lines
+}
+
+// stats возвращает задержку и количество токенов последнего ответа колонки
+func (col *compareColumn) stats() string {
+	switch {
+	case col.streaming:
+		return fmt.Sprintf("… %s, %d ток.", formatDuration(time.Since(col.start)), col.tokens)
+	case col.latency == 0:
+		return ""
+	}
+	stats := formatDuration(col.latency)
+	if col.firstToken > 0 {
+		stats += ", первый токен " + formatDuration(col.firstToken)
+	}
+	stats += fmt.Sprintf(", %d ток.", col.tokens)
+	if col.stopped {
+		stats += ", остановлено"
+	}
+	if col.err != nil {
+		stats += ", ошибка: " + col.err.Error()
+	}
+	return stats
+}
@@ -0,0 +1,241 @@
+package ui
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"llm-client/internal/chat"
+	"llm-client/internal/client"
+	"llm-client/internal/config"
+	"llm-client/internal/fakellm"
+	"llm-client/internal/logger"
+)
+
+func newCompareModel(t *testing.T, opts ...fakellm.Option) (*Model, *fakellm.Server) {
+	t.Helper()
+	server := fakellm.New(t, opts...)
+	cfg := config.DefaultConfig()
+	cfg.Server.Address = server.URL
+	m := NewModel(cfg,
+		WithLogger(logger.NewLogger(logger.Config{Enabled: false})),
+		WithClientOptions(client.WithAPIKey("test-key")),
+	)
+	m.viewport.Width = 120
+	t.Cleanup(func() { m.cancel() })
+	return m, server
+}
+
+// sendCompareTurn отправляет сообщение и дочитывает потоки всех колонок
+func sendCompareTurn(m *Model, text string) {
+	m.input.SetValue(text)
+	m.sendMessage()
+	for _, col := range m.compare.columns {
+		for msg := range col.stream {
+			m.handleCompareStreamMsg(msg)
+		}
+	}
+}
+
+func TestModel_CompareSeparateHistories(t *testing.T) {
+	m, server := newCompareModel(t, fakellm.WithReplies(fakellm.Text("A"), fakellm.Text("B")))
+	completeTurn(m, "q0", "a0")
+
+	m.handleCommand("/compare model-a model-b@0.2")
+	if m.compare == nil {
+		t.Fatalf("/compare failed: %s", m.errorMsg)
+	}
+
+	sendCompareTurn(m, "q1")
+	system := m.runtime.SystemPrompt
+	if m.status != StatusIdle || m.compare.streaming() {
+		t.Fatalf("status = %v after all columns finished", m.status)
+	}
+	for _, req := range server.Await(2) {
+		if req.Contents() != system+",q0,a0,q1" || !req.Stream {
+			t.Errorf("first turn request = %q, want current dialog and q1", req.Contents())
+		}
+		if want := map[string]float64{"model-a": 0.7, "model-b": 0.2}[req.Model]; req.Param("temperature") != want {
+			t.Errorf("%s: temperature = %v, want %v", req.Model, req.Param("temperature"), want)
+		}
+	}
+
+	// Каждая колонка продолжает свой диалог
+	answers := map[string]string{}
+	for _, col := range m.compare.columns {
+		messages := col.history.GetMessages()
+		answers[col.runtime.Model] = messages[len(messages)-1].Content
+		if col.latency <= 0 || col.tokens == 0 || col.err != nil {
+			t.Errorf("%s: latency = %v, tokens = %d, err = %v", col.runtime.Model, col.latency, col.tokens, col.err)
+		}
+	}
+	if answers["model-a"] == answers["model-b"] {
+		t.Fatalf("columns got the same answer: %v", answers)
+	}
+
+	sendCompareTurn(m, "q2")
+	for _, req := range server.Await(4)[2:] {
+		if want := system + ",q0,a0,q1," + answers[req.Model] + ",q2"; req.Contents() != want {
+			t.Errorf("%s: second turn = %q, want %q", req.Model, req.Contents(), want)
+		}
+	}
+	// Основная история не меняется
+	if got := len(m.history.GetMessages()); got != 3 {
+		t.Errorf("main history has %d messages, want 3", got)
+	}
+
+	content := m.renderHistoryContent()
+	for _, want := range []string{"model-a · t=0.70", "model-b · t=0.20", "ток."} {
+		if !strings.Contains(content, want) {
+			t.Errorf("compare view should contain %q:\n%s", want, content)
+		}
+	}
+
+	m.handleCommand("/compare off")
+	if m.compare != nil || strings.Contains(m.renderHistoryContent(), "model-b") {
+		t.Errorf("/compare off should restore the single view")
+	}
+}
+
+func TestModel_CompareCancel(t *testing.T) {
+	m, _ := newCompareModel(t,
+		fakellm.WithDefaultReply(fakellm.Text("one two three four five six")),
+		fakellm.WithTokenDelay(50*time.Millisecond))
+	m.handleCommand("/compare a b c")
+
+	m.input.SetValue("q")
+	m.sendMessage()
+	// Ждём первые токены во всех колонках
+	for _, col := range m.compare.columns {
+		m.handleCompareStreamMsg(<-col.stream)
+	}
+
+	m.handleKeyPress(key(tea.KeyCtrlC))
+	if m.status != StatusIdle {
+		t.Errorf("status = %v after Ctrl+C", m.status)
+	}
+	for _, col := range m.compare.columns {
+		if col.streaming || !col.stopped || !strings.Contains(col.stats(), "остановлено") {
+			t.Errorf("%s: streaming = %v, stats = %q", col.runtime.Model, col.streaming, col.stats())
+		}
+		// Поток колонки завершается после отмены
+		done := make(chan struct{})
+		go func() {
+			for range col.stream {
+			}
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("%s: stream not stopped", col.runtime.Model)
+		}
+	}
+}
+
+func TestModel_CompareCommandErrors(t *testing.T) {
+	m, _ := newCompareModel(t)
+
+	for _, cmd := range []string{"/compare a", "/compare a b c d", "/compare a b@5", "/compare off"} {
+		m.handleCommand(cmd)
+		if m.status != StatusError || m.compare != nil {
+			t.Errorf("%s: status = %v, msg = %q", cmd, m.status, m.errorMsg)
+		}
+	}
+
+	m.handleCommand("/compare @0.1 @1.2")
+	if m.compare == nil || m.compare.columns[0].runtime.Model != m.runtime.Model {
+		t.Fatalf("@temp should use current model: %s", m.errorMsg)
+	}
+}
+
+func TestModel_CompareUsesContextBudgetAndTools(t *testing.T) {
+	m, server := newCompareModel(t, fakellm.WithDefaultReply(fakellm.Text("ok")))
+	m.tools = NewDefaultToolRegistry()
+	// Одно сообщение = один токен: бюджет 5 сообщений
+	m.appConfig.Model.ContextWindow = 6
+	m.appConfig.Model.MaxTokens = 1
+	m.runtime.ContextStrategy = config.ContextStrategyDropOldest
+	m.countTokens = func(messages []chat.Message) int { return len(messages) }
+	completeTurn(m, "q0", "a0")
+	completeTurn(m, "q1", "a1")
+
+	m.handleCommand("/compare model-a model-b")
+	sendCompareTurn(m, "q2")
+
+	for _, req := range server.Await(2) {
+		if want := m.runtime.SystemPrompt + ",q1,a1,q2"; req.Contents() != want {
+			t.Errorf("%s: request = %q, want %q", req.Model, req.Contents(), want)
+		}
+		if !req.HasParam("tools") {
+			t.Errorf("%s: request should contain tool definitions", req.Model)
+		}
+	}
+	// История колонок не сокращается
+	for _, col := range m.compare.columns {
+		if got := col.history.Len(); got != 7 {
+			t.Errorf("%s: history Len() = %d, want 7", col.runtime.Model, got)
+		}
+	}
+}
+
+func TestModel_CompareToolCalls(t *testing.T) {
+	call := chat.ToolCall{ID: "call_1", Type: "function", Function: chat.FunctionCall{Name: "get_current_time", Arguments: `{}`}}
+	m, server := newCompareModel(t, fakellm.WithReplies(fakellm.ToolCalls(call), fakellm.Text("done")))
+	m.tools = NewDefaultToolRegistry()
+	m.handleCommand("/compare model-a model-b")
+
+	// Один ход одной колонки, чтобы порядок ответов сервера был детерминированным
+	c := m.compare
+	c.turn++
+	col := c.columns[0]
+	col.history.AddUser("time?")
+	col.streaming, col.start = true, time.Now()
+	m.status = StatusStreaming
+	col.stream = m.compareTurn(col).stream(context.Background(), 0, c.turn)
+	for msg := range col.stream {
+		m.handleCompareStreamMsg(msg)
+	}
+
+	messages := col.history.GetMessages()
+	roles := make([]chat.Role, 0, len(messages))
+	for _, msg := range messages[1:] {
+		roles = append(roles, msg.Role)
+	}
+	want := []chat.Role{chat.RoleUser, chat.RoleAssistant, chat.RoleTool, chat.RoleAssistant}
+	if !reflect.DeepEqual(roles, want) || messages[len(messages)-1].Content != "done" {
+		t.Fatalf("column history = %+v", messages)
+	}
+	if col.err != nil || m.status != StatusIdle {
+		t.Errorf("err = %v, status = %v", col.err, m.status)
+	}
+
+	second := server.Await(2)[1]
+	if last := second.Messages[len(second.Messages)-1]; last.Role != chat.RoleTool || last.ToolCallID != "call_1" {
+		t.Errorf("second request should end with the tool result, got %+v", last)
+	}
+}
+
+func TestModel_CompareTokensWithoutUsage(t *testing.T) {
+	m, _ := newCompareModel(t)
+	m.handleCommand("/compare model-a model-b")
+	c := m.compare
+	col := c.columns[0]
+	col.streaming, col.start = true, time.Now()
+	m.status = StatusStreaming
+
+	text := "one two three four five six seven eight"
+	for _, word := range strings.SplitAfter(text, " ") {
+		m.handleCompareStreamMsg(CompareStreamMsg{Column: 0, Turn: c.turn, StreamMsg: StreamMsg{Content: word}})
+	}
+	m.handleCompareStreamMsg(CompareStreamMsg{Column: 0, Turn: c.turn, StreamMsg: StreamMsg{Done: true}})
+
+	// Без usage количество считается токенизатором, а не по числу чанков
+	if want := m.countText(col.runtime.Model, text); col.tokens != want {
+		t.Errorf("tokens = %d, want %d", col.tokens, want)
+	}
+}
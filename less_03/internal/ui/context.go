@@ -0,0 +1,191 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"llm-client/internal/chat"
+	"llm-client/internal/client"
+	"llm-client/internal/config"
+	"llm-client/internal/logger"
+	"llm-client/internal/tokenizer"
+)
+
+// ContextTrimmedMsg сообщает о завершении асинхронного сокращения истории
+type ContextTrimmedMsg struct {
+	Request  *client.ChatRequest
+	Messages []chat.Message
+	Err      error
+}
+
+// WithTokenCounter устанавливает функцию подсчёта токенов вместо словаря модели
+func WithTokenCounter(count chat.TokenCounter) ModelOption {
+	return func(m *Model) {
+		m.countTokens = count
+	}
+}
+
+// WithTokenizers устанавливает загрузчик словарей токенизатора
+func WithTokenizers(loader *tokenizer.Loader) ModelOption {
+	return func(m *Model) {
+		m.tokenizers = loader
+	}
+}
+
+// newTokenizerLoader создаёт загрузчик словарей по настройкам модели
+func newTokenizerLoader(cfg *config.Config, log *logger.Logger) *tokenizer.Loader {
+	dir := cfg.Model.TokenizerDir
+	if dir == "" {
+		dir = tokenizer.DefaultDir()
+	}
+	return tokenizer.NewLoader(dir,
+		tokenizer.WithLogger(log),
+		tokenizer.WithModelEncodings(cfg.Model.TokenizerEncodings),
+	)
+}
+
+// tokenCounter возвращает функцию подсчёта токенов для текущей модели
+func (m *Model) tokenCounter() chat.TokenCounter {
+	return m.tokenCounterFor(m.runtime.Model)
+}
+
+// tokenCounterFor возвращает функцию подсчёта токенов для модели model
+func (m *Model) tokenCounterFor(model string) chat.TokenCounter {
+	if m.countTokens != nil {
+		return m.countTokens
+	}
+	return m.tokenizers.ForModel(model).CountMessages
+}
+
+// prepareRequest собирает запрос и сокращает историю под контекстное окно модели.
+// Суммаризация требует отдельного запроса к модели, поэтому выполняется асинхронно;
+// остальные стратегии применяются сразу.
+func (m *Model) prepareRequest() tea.Cmd {
+	req := m.buildRequest()
+	budget := m.appConfig.Model.ContextBudget(m.runtime.Model)
+
+	m.contextTokens = m.tokenCounter()(req.Messages)
+	if budget <= 0 || m.contextTokens <= budget {
+		return m.startStreaming(req)
+	}
+
+	m.logger.InfoContext(m.requestContext(), "Chat history exceeds context budget",
+		"tokens", m.contextTokens,
+		"budget", budget,
+		"strategy", m.runtime.ContextStrategy,
+	)
+
+	if m.runtime.ContextStrategy == config.ContextStrategySummarize {
+		summarizer := m.contextSummarizer()
+		ctx, cancel := context.WithCancel(m.requestContext())
+		m.cancel = cancel
+		m.status = StatusSummarizing
+
+		return func() tea.Msg {
+			messages, err := summarizer.Trim(ctx, req.Messages, budget)
+			return ContextTrimmedMsg{Request: req, Messages: messages, Err: err}
+		}
+	}
+
+	messages, _ := m.contextTrimmer().Trim(context.Background(), req.Messages, budget)
+	m.applyTrimmed(req, messages)
+	return m.startStreaming(req)
+}
+
+// handleContextTrimmed отправляет запрос после суммаризации истории
+func (m *Model) handleContextTrimmed(msg ContextTrimmedMsg) (tea.Model, tea.Cmd) {
+	if m.status != StatusSummarizing {
+		// Запрос отменён пользователем
+		return m, nil
+	}
+
+	if msg.Err != nil {
+		if errors.Is(msg.Err, context.Canceled) {
+			return m, nil
+		}
+		// Запрос всё равно отправляется: стратегия вернула историю без старых реплик
+		m.logger.ErrorContext(m.requestContext(), "Failed to summarize chat history", "error", msg.Err)
+	}
+
+	m.applyTrimmed(msg.Request, msg.Messages)
+	return m, m.startStreaming(msg.Request)
+}
+
+// applyTrimmed подставляет сокращённую историю в запрос
+func (m *Model) applyTrimmed(req *client.ChatRequest, messages []chat.Message) {
+	m.logger.Info("Chat history trimmed",
+		"messages_before", len(req.Messages),
+		"messages_after", len(messages),
+		"tokens_after", m.tokenCounter()(messages),
+	)
+	req.Messages = messages
+}
+
+// contextTrimmer возвращает стратегию сокращения истории для текущих настроек
+func (m *Model) contextTrimmer() chat.Trimmer {
+	return m.trimmerFor(m.runtime, m.contextSummarizer)
+}
+
+// trimmerFor возвращает стратегию сокращения истории для настроек runtime.
+// summarizer вызывается только для стратегии summarize.
+func (m *Model) trimmerFor(runtime *config.RuntimeConfig, summarizer func() *chat.Summarizer) chat.Trimmer {
+	switch runtime.ContextStrategy {
+	case config.ContextStrategyNone:
+		return chat.NoTrim{}
+	case config.ContextStrategyLastN:
+		return chat.LastN{N: m.appConfig.Model.KeepLastTurns, Count: m.tokenCounterFor(runtime.Model)}
+	case config.ContextStrategySummarize:
+		return summarizer()
+	default:
+		return chat.DropOldest{Count: m.tokenCounterFor(runtime.Model)}
+	}
+}
+
+// contextSummarizer возвращает стратегию суммаризации; она пересоздаётся при смене модели,
+// так как накопленное содержание получено другой моделью
+func (m *Model) contextSummarizer() *chat.Summarizer {
+	if m.summarizer == nil || m.summaryModel != m.runtime.Model {
+		m.summaryModel = m.runtime.Model
+		m.summarizer = m.newSummarizer(m.runtime.Model)
+	}
+	return m.summarizer
+}
+
+// newSummarizer создаёт стратегию суммаризации, которая сжимает историю моделью model
+func (m *Model) newSummarizer(model string) *chat.Summarizer {
+	return chat.NewSummarizer(
+		m.client.Summarizer(model),
+		m.appConfig.Model.KeepLastTurns,
+		m.tokenCounterFor(model),
+	)
+}
+
+// refreshContextUsage пересчитывает размер текущей истории в токенах
+func (m *Model) refreshContextUsage() {
+	m.contextTokens = m.history.TokenCount(m.tokenCounter())
+}
+
+// renderContextUsage возвращает заполненность контекстного окна для строки статуса
+func (m *Model) renderContextUsage() string {
+	window := m.appConfig.Model.ContextWindowFor(m.runtime.Model)
+	if window <= 0 || m.history.Len() == 0 {
+		return ""
+	}
+
+	approx := ""
+	if m.countTokens == nil && !m.tokenizers.ForModel(m.runtime.Model).Exact() {
+		// Словарь модели недоступен - значение получено оценкой по длине текста
+		approx = "≈"
+	}
+	usage := fmt.Sprintf("Контекст: %s%d/%d (%d%%)", approx, m.contextTokens, window, m.contextTokens*100/window)
+	if budget := m.appConfig.Model.ContextBudget(m.runtime.Model); m.contextTokens > budget {
+		if m.runtime.ContextStrategy == config.ContextStrategyNone {
+			return "⚠ " + usage
+		}
+		usage += ", ранние сообщения сокращаются"
+	}
+	return usage
+}
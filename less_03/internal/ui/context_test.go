@@ -0,0 +1,149 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"llm-client/internal/chat"
+	"llm-client/internal/config"
+	"llm-client/internal/fakellm"
+	"llm-client/internal/logger"
+)
+
+// newContextModel создаёт модель с маленьким контекстным окном и сервером,
+// который отвечает summary на запросы
+func newContextModel(t *testing.T, strategy string) (*Model, *fakellm.Server) {
+	t.Helper()
+	server := fakellm.New(t, fakellm.WithDefaultReply(fakellm.Text("summary")))
+
+	cfg := config.DefaultConfig()
+	cfg.Server.Address = server.URL
+	cfg.Model.ContextWindow = 6
+	cfg.Model.MaxTokens = 1
+	cfg.Model.ContextStrategy = strategy
+	cfg.Model.KeepLastTurns = 1
+
+	// Одно сообщение = один токен: бюджет 5 сообщений
+	m := NewModel(cfg,
+		WithLogger(logger.NewLogger(logger.Config{Enabled: false})),
+		WithTokenCounter(func(messages []chat.Message) int { return len(messages) }),
+	)
+	for _, q := range []string{"q1", "q2"} {
+		m.history.AddUser(q)
+		m.history.AddAssistant("a" + q[1:])
+	}
+	t.Cleanup(func() { m.cancel() })
+	return m, server
+}
+
+func TestModel_sendMessage_DropOldest(t *testing.T) {
+	m, server := newContextModel(t, config.ContextStrategyDropOldest)
+
+	m.input.SetValue("q3")
+	m.sendMessage()
+	if m.status != StatusStreaming {
+		t.Fatalf("status = %v, want %v", m.status, StatusStreaming)
+	}
+
+	if got := server.Await(1)[0].Contents(); got != "You are a helpful assistant.,q2,a2,q3" {
+		t.Errorf("request messages = %v", got)
+	}
+	// История в UI не сокращается
+	if m.history.Len() != 6 {
+		t.Errorf("history Len() = %d, want 6", m.history.Len())
+	}
+}
+
+func TestModel_prepareRequest_Summarize(t *testing.T) {
+	m, server := newContextModel(t, config.ContextStrategySummarize)
+
+	m.history.AddUser("q3")
+	cmd := m.prepareRequest()
+	if m.status != StatusSummarizing {
+		t.Fatalf("status = %v, want %v", m.status, StatusSummarizing)
+	}
+
+	msg, ok := cmd().(ContextTrimmedMsg)
+	if !ok {
+		t.Fatalf("prepareRequest() should produce ContextTrimmedMsg")
+	}
+	if summaryReq := server.Await(1)[0]; summaryReq.Stream {
+		t.Errorf("summary request should not be streamed")
+	}
+
+	m.handleContextTrimmed(msg)
+	req := server.Await(2)[1]
+	if len(req.Messages) != 3 || req.Messages[1].Content != chat.SummaryPrefix+"summary" {
+		t.Errorf("request messages = %+v", req.Messages)
+	}
+}
+
+func TestModel_InputLockedWhileSummarizing(t *testing.T) {
+	m, server := newContextModel(t, config.ContextStrategySummarize)
+
+	m.input.SetValue("q3")
+	m.sendMessage()
+	if m.status != StatusSummarizing {
+		t.Fatalf("status = %v, want %v", m.status, StatusSummarizing)
+	}
+	historyLen := m.history.Len()
+
+	m.handleKeyPress(runes("x"))
+	_, enterCmd := m.handleKeyPress(key(tea.KeyEnter))
+
+	if enterCmd != nil {
+		t.Errorf("Enter while summarizing should not start a request")
+	}
+	if !m.input.Empty() {
+		t.Errorf("input = %q, want empty", m.input.Value())
+	}
+	if m.history.Len() != historyLen {
+		t.Errorf("history Len() = %d, want %d", m.history.Len(), historyLen)
+	}
+	if m.status != StatusSummarizing {
+		t.Errorf("status = %v, want %v", m.status, StatusSummarizing)
+	}
+	// Запрос сжатия ещё не выполнен, второй ход не должен был начаться
+	server.AssertRequestCount(0)
+
+	m.handleCommand("/new")
+	if m.history.Len() != historyLen || m.status != StatusSummarizing {
+		t.Errorf("/new while summarizing should be rejected")
+	}
+}
+
+func TestModel_renderContextUsage(t *testing.T) {
+	m, _ := newContextModel(t, config.ContextStrategyNone)
+	m.updateViewportContent()
+
+	if got := m.renderContextUsage(); got != "Контекст: 5/6 (83%)" {
+		t.Errorf("renderContextUsage() = %q", got)
+	}
+
+	m.history.AddUser("q3")
+	m.updateViewportContent()
+	if got := m.renderContextUsage(); !strings.HasPrefix(got, "⚠") {
+		t.Errorf("exceeded context should be marked, got %q", got)
+	}
+	if !strings.Contains(m.renderStatus(), "Контекст: 6/6") {
+		t.Errorf("status line should show context usage")
+	}
+}
+
+func TestModel_renderContextUsage_Approximate(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Model.TokenizerDir = t.TempDir()
+	cfg.Model.ContextWindow = 8192
+	m := NewModel(cfg, WithLogger(logger.NewLogger(logger.Config{Enabled: false})))
+	m.history.AddUser("hello")
+	m.updateViewportContent()
+
+	// Словаря нет - используется оценка по длине текста
+	want := chat.EstimateTokens(m.history.GetMessages())
+	if got := m.renderContextUsage(); !strings.HasPrefix(got, fmt.Sprintf("Контекст: ≈%d/", want)) {
+		t.Errorf("renderContextUsage() = %q, want approximate %d", got, want)
+	}
+}
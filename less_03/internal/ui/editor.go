@@ -0,0 +1,304 @@
+package ui
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rivo/uniseg"
+)
+
+// maxInputLines - максимальная высота поля ввода; более длинный текст прокручивается за курсором
+const maxInputLines = 8
+
+// editor - многострочное поле ввода с курсором.
+// Курсор перемещается и текст удаляется по графемам: буква с диакритикой
+// или эмодзи из нескольких кодовых точек обрабатываются как один символ.
+type editor struct {
+	value string
+	// pos - позиция курсора в байтах, всегда на границе графемы
+	pos int
+}
+
+// cursorStyle выделяет символ под курсором
+var cursorStyle = lipgloss.NewStyle().Reverse(true)
+
+// Value возвращает введённый текст
+func (e *editor) Value() string {
+	return e.value
+}
+
+// SetValue заменяет текст и ставит курсор в конец
+func (e *editor) SetValue(s string) {
+	e.value = normalizeInput(s)
+	e.pos = len(e.value)
+}
+
+// Reset очищает поле ввода
+func (e *editor) Reset() {
+	e.value, e.pos = "", 0
+}
+
+// Empty сообщает, что в поле нет текста, кроме пробелов
+func (e *editor) Empty() bool {
+	return strings.TrimSpace(e.value) == ""
+}
+
+// Cursor возвращает позицию курсора в байтах
+func (e *editor) Cursor() int {
+	return e.pos
+}
+
+// Insert вставляет текст в позицию курсора
+func (e *editor) Insert(s string) {
+	s = normalizeInput(s)
+	e.value = e.value[:e.pos] + s + e.value[e.pos:]
+	e.pos += len(s)
+}
+
+// Update обрабатывает клавиши редактирования. Возвращает false, если клавиша
+// не относится к полю ввода (например, ↑ на первой строке - это прокрутка истории).
+func (e *editor) Update(msg tea.KeyMsg) bool {
+	if msg.Paste {
+		// Вставка из буфера обмена может содержать переводы строк
+		e.Insert(string(msg.Runes))
+		return true
+	}
+
+	switch msg.String() {
+	case "alt+enter", "ctrl+j":
+		e.Insert("\n")
+	case "left", "ctrl+b":
+		e.pos = prevBoundary(e.value, e.pos)
+	case "right", "ctrl+f":
+		e.pos = nextBoundary(e.value, e.pos)
+	case "ctrl+left", "alt+left", "alt+b":
+		e.pos = e.wordStart()
+	case "ctrl+right", "alt+right", "alt+f":
+		e.pos = e.wordEnd()
+	case "home", "ctrl+a":
+		e.pos = e.lineStart(e.pos)
+	case "end", "ctrl+e":
+		e.pos = e.lineEnd(e.pos)
+	case "up":
+		return e.moveLine(-1)
+	case "down":
+		return e.moveLine(1)
+	case "backspace", "ctrl+h":
+		e.deleteTo(prevBoundary(e.value, e.pos))
+	case "delete":
+		e.deleteTo(nextBoundary(e.value, e.pos))
+	case "ctrl+w", "alt+backspace":
+		e.deleteTo(e.wordStart())
+	case "alt+d":
+		e.deleteTo(e.wordEnd())
+	case "ctrl+u":
+		e.deleteTo(e.lineStart(e.pos))
+	case "ctrl+k":
+		e.deleteTo(e.lineEnd(e.pos))
+	default:
+		switch {
+		case msg.Type == tea.KeySpace:
+			e.Insert(" ")
+		case msg.Type == tea.KeyRunes && !msg.Alt:
+			e.Insert(string(msg.Runes))
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// deleteTo удаляет текст между курсором и позицией to
+func (e *editor) deleteTo(to int) {
+	from := e.pos
+	if to < from {
+		from, to = to, from
+	}
+	e.value = e.value[:from] + e.value[to:]
+	e.pos = from
+}
+
+// lineStart возвращает начало строки, содержащей позицию pos
+func (e *editor) lineStart(pos int) int {
+	return strings.LastIndexByte(e.value[:pos], '\n') + 1
+}
+
+// lineEnd возвращает конец строки (позицию перевода строки или конец текста)
+func (e *editor) lineEnd(pos int) int {
+	if i := strings.IndexByte(e.value[pos:], '\n'); i >= 0 {
+		return pos + i
+	}
+	return len(e.value)
+}
+
+// wordStart возвращает начало слова слева от курсора (пробелы перед курсором пропускаются)
+func (e *editor) wordStart() int {
+	pos := e.pos
+	for pos > 0 && isSpaceAt(e.value, prevBoundary(e.value, pos)) {
+		pos = prevBoundary(e.value, pos)
+	}
+	for pos > 0 && !isSpaceAt(e.value, prevBoundary(e.value, pos)) {
+		pos = prevBoundary(e.value, pos)
+	}
+	return pos
+}
+
+// wordEnd возвращает конец слова справа от курсора
+func (e *editor) wordEnd() int {
+	pos := e.pos
+	for pos < len(e.value) && isSpaceAt(e.value, pos) {
+		pos = nextBoundary(e.value, pos)
+	}
+	for pos < len(e.value) && !isSpaceAt(e.value, pos) {
+		pos = nextBoundary(e.value, pos)
+	}
+	return pos
+}
+
+// moveLine перемещает курсор на строку выше (-1) или ниже (1), сохраняя колонку.
+// Возвращает false, если курсор уже на первой или последней строке.
+func (e *editor) moveLine(dir int) bool {
+	start := e.lineStart(e.pos)
+	column := uniseg.StringWidth(e.value[start:e.pos])
+
+	var target int
+	if dir < 0 {
+		if start == 0 {
+			return false
+		}
+		target = e.lineStart(start - 1)
+	} else {
+		end := e.lineEnd(e.pos)
+		if end == len(e.value) {
+			return false
+		}
+		target = end + 1
+	}
+
+	// Идём по графемам целевой строки до нужной колонки
+	end := e.lineEnd(target)
+	pos, width := target, 0
+	for pos < end {
+		next := nextBoundary(e.value, pos)
+		w := uniseg.StringWidth(e.value[pos:next])
+		if width+w > column {
+			break
+		}
+		width += w
+		pos = next
+	}
+	e.pos = pos
+	return true
+}
+
+// Height возвращает количество строк поля ввода при ширине width
+func (e *editor) Height(width int) int {
+	rows, _, _ := e.layout(width)
+	return min(len(rows), maxInputLines)
+}
+
+// View возвращает строки поля ввода шириной width. Строки длиннее ширины переносятся;
+// если строк больше maxInputLines, показывается окно вокруг курсора.
+func (e *editor) View(width int, showCursor bool) []string {
+	rows, cursorRow, cursorCol := e.layout(width)
+
+	first := 0
+	if len(rows) > maxInputLines {
+		first = min(max(cursorRow-maxInputLines+1, 0), len(rows)-maxInputLines)
+	}
+
+	var lines []string
+	for i := first; i < len(rows) && i < first+maxInputLines; i++ {
+		row := rows[i]
+		if !showCursor || i != cursorRow {
+			lines = append(lines, row)
+			continue
+		}
+		// Символ под курсором выделяется инверсией, в конце строки рисуется блок
+		before := row[:cursorCol]
+		if cursorCol >= len(row) {
+			lines = append(lines, before+cursor())
+			continue
+		}
+		cluster, rest, _, _ := uniseg.FirstGraphemeClusterInString(row[cursorCol:], -1)
+		lines = append(lines, before+cursorStyle.Render(cluster)+rest)
+	}
+	return lines
+}
+
+// layout разбивает текст на экранные строки шириной width (одна колонка оставлена
+// под курсор в конце строки; width <= 0 - без переноса). Возвращает строки и положение
+// курсора: номер строки и смещение в байтах внутри неё.
+func (e *editor) layout(width int) ([]string, int, int) {
+	width--
+	var rows []string
+	cursorRow, cursorCol := 0, 0
+
+	offset := 0
+	for _, line := range strings.Split(e.value, "\n") {
+		rowStart, rowWidth := 0, 0
+		for i := 0; i < len(line); {
+			cluster, _, w, _ := uniseg.FirstGraphemeClusterInString(line[i:], -1)
+			if width > 0 && rowWidth+w > width && rowWidth > 0 {
+				rows = append(rows, line[rowStart:i])
+				rowStart, rowWidth = i, 0
+			}
+			if offset+i == e.pos {
+				cursorRow, cursorCol = len(rows), i-rowStart
+			}
+			rowWidth += w
+			i += len(cluster)
+		}
+		if offset+len(line) == e.pos {
+			cursorRow, cursorCol = len(rows), len(line)-rowStart
+		}
+		rows = append(rows, line[rowStart:])
+		offset += len(line) + 1
+	}
+	return rows, cursorRow, cursorCol
+}
+
+// prevBoundary возвращает начало графемы, стоящей перед позицией pos
+func prevBoundary(s string, pos int) int {
+	if pos <= 0 {
+		return 0
+	}
+	// Графемы не пересекают перевод строки, поэтому достаточно разобрать текущую строку
+	start := strings.LastIndexByte(s[:pos-1], '\n') + 1
+	if s[pos-1] == '\n' {
+		return pos - 1
+	}
+	prev := start
+	for i, state := start, -1; i < pos; {
+		cluster, _, _, newState := uniseg.FirstGraphemeClusterInString(s[i:pos], state)
+		prev = i
+		i += len(cluster)
+		state = newState
+	}
+	return prev
+}
+
+// nextBoundary возвращает позицию после графемы, начинающейся в pos
+func nextBoundary(s string, pos int) int {
+	if pos >= len(s) {
+		return len(s)
+	}
+	cluster, _, _, _ := uniseg.FirstGraphemeClusterInString(s[pos:], -1)
+	return pos + len(cluster)
+}
+
+// isSpaceAt проверяет, что символ в позиции pos - пробельный
+func isSpaceAt(s string, pos int) bool {
+	r, _ := utf8.DecodeRuneInString(s[pos:])
+	return unicode.IsSpace(r)
+}
+
+// normalizeInput приводит переводы строк к \n и заменяет табуляцию пробелами
+func normalizeInput(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	return strings.ReplaceAll(s, "\t", "    ")
+}
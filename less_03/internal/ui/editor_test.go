@@ -0,0 +1,180 @@
+package ui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// typeKeys отправляет в редактор последовательность клавиш
+func typeKeys(e *editor, keys ...tea.KeyMsg) {
+	for _, key := range keys {
+		e.Update(key)
+	}
+}
+
+func runes(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func key(t tea.KeyType) tea.KeyMsg {
+	return tea.KeyMsg{Type: t}
+}
+
+func altKey(t tea.KeyType, r ...rune) tea.KeyMsg {
+	return tea.KeyMsg{Type: t, Runes: r, Alt: true}
+}
+
+func TestEditor_Backspace_UTF8(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"cyrillic", "привет", "приве"},
+		{"combining accent", "café", "caf"},
+		{"emoji sequence", "ok 👍🏽", "ok "},
+		{"family emoji", "a👨‍👩‍👧", "a"},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var e editor
+			e.SetValue(tt.input)
+			e.Update(key(tea.KeyBackspace))
+			if e.Value() != tt.want {
+				t.Errorf("Value() = %q, want %q", e.Value(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEditor_CursorMovement(t *testing.T) {
+	var e editor
+	typeKeys(&e, runes("мир"), key(tea.KeyHome), runes("привет "))
+	if e.Value() != "привет мир" {
+		t.Fatalf("insert at line start: Value() = %q", e.Value())
+	}
+
+	// Delete удаляет символ под курсором
+	typeKeys(&e, key(tea.KeyDelete))
+	if e.Value() != "привет ир" {
+		t.Errorf("delete: Value() = %q", e.Value())
+	}
+
+	typeKeys(&e, key(tea.KeyLeft), key(tea.KeyLeft), runes("!"))
+	if e.Value() != "приве!т ир" {
+		t.Errorf("left: Value() = %q", e.Value())
+	}
+
+	typeKeys(&e, key(tea.KeyEnd), key(tea.KeyRight), runes("."))
+	if e.Value() != "приве!т ир." {
+		t.Errorf("end: Value() = %q", e.Value())
+	}
+}
+
+func TestEditor_Words(t *testing.T) {
+	var e editor
+	e.SetValue("один два  три")
+
+	typeKeys(&e, key(tea.KeyCtrlLeft))
+	if got := e.Value()[e.Cursor():]; got != "три" {
+		t.Errorf("ctrl+left: text after cursor = %q", got)
+	}
+	typeKeys(&e, altKey(tea.KeyRunes, 'b'))
+	if got := e.Value()[e.Cursor():]; got != "два  три" {
+		t.Errorf("alt+b: text after cursor = %q", got)
+	}
+	typeKeys(&e, key(tea.KeyCtrlRight))
+	if got := e.Value()[:e.Cursor()]; got != "один два" {
+		t.Errorf("ctrl+right: text before cursor = %q", got)
+	}
+
+	// Ctrl+W удаляет слово перед курсором
+	typeKeys(&e, key(tea.KeyCtrlW))
+	if e.Value() != "один   три" {
+		t.Errorf("ctrl+w: Value() = %q", e.Value())
+	}
+	typeKeys(&e, altKey(tea.KeyRunes, 'd'))
+	if e.Value() != "один " {
+		t.Errorf("alt+d: Value() = %q", e.Value())
+	}
+}
+
+func TestEditor_Multiline(t *testing.T) {
+	var e editor
+	typeKeys(&e, runes("первая"), altKey(tea.KeyEnter), runes("2"), key(tea.KeyCtrlJ), runes("третья"))
+	if e.Value() != "первая\n2\nтретья" {
+		t.Fatalf("Value() = %q", e.Value())
+	}
+
+	// Колонка сохраняется по ширине, на короткой строке курсор встаёт в конец
+	typeKeys(&e, key(tea.KeyLeft), key(tea.KeyLeft))
+	if !e.Update(key(tea.KeyUp)) {
+		t.Fatalf("up should move to previous line")
+	}
+	typeKeys(&e, runes("!"))
+	if e.Value() != "первая\n2!\nтретья" {
+		t.Errorf("after up: Value() = %q", e.Value())
+	}
+
+	typeKeys(&e, key(tea.KeyUp))
+	if e.Update(key(tea.KeyUp)) {
+		t.Errorf("up on first line should not be handled")
+	}
+	typeKeys(&e, key(tea.KeyCtrlK))
+	if e.Value() != "пе\n2!\nтретья" {
+		t.Errorf("ctrl+k: Value() = %q", e.Value())
+	}
+
+	typeKeys(&e, key(tea.KeyDown), key(tea.KeyDown))
+	if e.Update(key(tea.KeyDown)) {
+		t.Errorf("down on last line should not be handled")
+	}
+	typeKeys(&e, key(tea.KeyCtrlU))
+	if e.Value() != "пе\n2!\nетья" {
+		t.Errorf("ctrl+u: Value() = %q", e.Value())
+	}
+}
+
+func TestEditor_Paste(t *testing.T) {
+	var e editor
+	e.SetValue("[]")
+	typeKeys(&e, key(tea.KeyLeft), tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a\r\n\tb"), Paste: true})
+	if e.Value() != "[a\n    b]" {
+		t.Errorf("Value() = %q", e.Value())
+	}
+	if e.Value()[e.Cursor():] != "]" {
+		t.Errorf("cursor should stay after pasted text")
+	}
+}
+
+func TestEditor_View(t *testing.T) {
+	var e editor
+	e.SetValue("абвгдежзик")
+
+	// Одна колонка оставлена под курсор: по 4 символа в строке
+	if got := e.View(5, true); !reflect.DeepEqual(got, []string{"абвг", "дежз", "ик█"}) {
+		t.Errorf("View() = %q", got)
+	}
+	if e.Height(5) != 3 {
+		t.Errorf("Height() = %d, want 3", e.Height(5))
+	}
+	if got := e.View(0, false); !reflect.DeepEqual(got, []string{"абвгдежзик"}) {
+		t.Errorf("View() without width = %q", got)
+	}
+
+	t.Run("window follows cursor", func(t *testing.T) {
+		e.SetValue(strings.Repeat("строка\n", maxInputLines+2) + "конец")
+		lines := e.View(40, false)
+		if len(lines) != maxInputLines || e.Height(40) != maxInputLines {
+			t.Fatalf("View() = %d lines, want %d", len(lines), maxInputLines)
+		}
+		if lines[len(lines)-1] != "конец" {
+			t.Errorf("last visible line = %q, want line with cursor", lines[len(lines)-1])
+		}
+	})
+}
@@ -0,0 +1,167 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"llm-client/internal/inputhistory"
+)
+
+// WithInputHistory устанавливает историю ввода (по умолчанию - только в памяти)
+func WithInputHistory(history *inputhistory.History) ModelOption {
+	return func(m *Model) {
+		m.inputHistory = history
+	}
+}
+
+// historyNav - состояние листания истории стрелками
+type historyNav struct {
+	active bool
+	// draft - текст, набранный до начала листания; по нему отбираются записи
+	draft   string
+	entries []string
+	// index - текущая запись; len(entries) соответствует черновику
+	index int
+}
+
+// historySearch - состояние поиска по истории (Ctrl+R)
+type historySearch struct {
+	query   string
+	matches []string
+	// index - выбранное совпадение, 0 - самое новое
+	index int
+}
+
+// rememberInput сохраняет отправленный запрос или команду в истории ввода
+func (m *Model) rememberInput(text string) {
+	m.historyNav = historyNav{}
+	if err := m.inputHistory.Add(text); err != nil {
+		m.logger.Warn("Failed to save input history", "error", err)
+	}
+}
+
+// recallHistory подставляет в поле ввода предыдущую (dir = -1) или следующую (dir = 1) запись.
+// Листаются записи того же раздела (запросы или команды), начинающиеся с набранного текста.
+// Возвращает false, если листать дальше некуда.
+func (m *Model) recallHistory(dir int) bool {
+	nav := &m.historyNav
+	if !nav.active {
+		if dir > 0 {
+			return false
+		}
+		draft := m.input.Value()
+		var entries []string
+		for _, entry := range m.inputHistory.Entries(inputhistory.KindOf(draft)) {
+			if strings.HasPrefix(entry, draft) && entry != draft {
+				entries = append(entries, entry)
+			}
+		}
+		*nav = historyNav{active: true, draft: draft, entries: entries, index: len(entries)}
+	}
+
+	index := nav.index + dir
+	if index < 0 || index > len(nav.entries) {
+		return false
+	}
+	nav.index = index
+	if index == len(nav.entries) {
+		// Вернулись к черновику
+		m.input.SetValue(nav.draft)
+		*nav = historyNav{}
+		return true
+	}
+	m.input.SetValue(nav.entries[index])
+	return true
+}
+
+// startSearch открывает поиск по истории; запрос, начинающийся с "/", ищет среди команд
+func (m *Model) startSearch() {
+	m.historyNav = historyNav{}
+	m.search = &historySearch{}
+	m.updateSearch()
+}
+
+// updateSearch пересчитывает совпадения после изменения запроса
+func (m *Model) updateSearch() {
+	kind := inputhistory.KindOf(m.search.query)
+	m.search.matches = inputhistory.Search(m.inputHistory.Entries(kind), m.search.query)
+	m.search.index = 0
+}
+
+// handleSearchKey обрабатывает клавиши в режиме поиска по истории
+func (m *Model) handleSearchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	s := m.search
+
+	switch msg.String() {
+	case "ctrl+c", "esc", "ctrl+g":
+		// Отмена: поле ввода не меняется
+		m.search = nil
+
+	case "enter", "tab":
+		// Выбранная запись переносится в поле ввода для редактирования и отправки
+		if len(s.matches) > 0 {
+			m.input.SetValue(s.matches[s.index])
+		}
+		m.search = nil
+
+	case "ctrl+r", "up":
+		// Следующее (более старое) совпадение
+		if s.index+1 < len(s.matches) {
+			s.index++
+		}
+
+	case "ctrl+s", "down":
+		if s.index > 0 {
+			s.index--
+		}
+
+	case "backspace", "ctrl+h":
+		s.query = s.query[:prevBoundary(s.query, len(s.query))]
+		m.updateSearch()
+
+	case "ctrl+u":
+		s.query = ""
+		m.updateSearch()
+
+	default:
+		if msg.Type == tea.KeySpace {
+			s.query += " "
+		} else if msg.Type == tea.KeyRunes && !msg.Alt {
+			s.query += strings.ReplaceAll(normalizeInput(string(msg.Runes)), "\n", " ")
+		} else {
+			return m, nil
+		}
+		m.updateSearch()
+	}
+	return m, nil
+}
+
+// searchLines возвращает строки поля ввода в режиме поиска: запрос и найденная запись
+func (m *Model) searchLines() []string {
+	s := m.search
+
+	counter := "нет совпадений"
+	if len(s.matches) > 0 {
+		counter = fmt.Sprintf("%d/%d", s.index+1, len(s.matches))
+	}
+	lines := []string{fmt.Sprintf("поиск [%s]: %s%s", counter, s.query, cursor())}
+	if len(s.matches) == 0 {
+		return lines
+	}
+
+	var match editor
+	match.SetValue(s.matches[s.index])
+	for i, line := range match.View(m.inputWidth(), false) {
+		if i == maxInputLines-1 {
+			break
+		}
+		if i == 0 {
+			lines = append(lines, "> "+line)
+		} else {
+			lines = append(lines, "  "+line)
+		}
+	}
+	return lines
+}
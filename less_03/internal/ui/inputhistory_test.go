@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"llm-client/internal/config"
+	"llm-client/internal/inputhistory"
+	"llm-client/internal/logger"
+)
+
+func newHistoryModel(t *testing.T, entries ...string) *Model {
+	t.Helper()
+	history := inputhistory.New("", 100)
+	for _, entry := range entries {
+		history.Add(entry)
+	}
+	return NewModel(config.DefaultConfig(),
+		WithLogger(logger.NewLogger(logger.Config{Enabled: false})),
+		WithInputHistory(history),
+	)
+}
+
+func TestModel_recallHistory(t *testing.T) {
+	m := newHistoryModel(t, "первый вопрос", "/usage", "второй вопрос")
+
+	m.input.SetValue("")
+	m.handleKeyPress(key(tea.KeyUp))
+	if m.input.Value() != "второй вопрос" {
+		t.Fatalf("up: input = %q", m.input.Value())
+	}
+	m.handleKeyPress(key(tea.KeyUp))
+	m.handleKeyPress(key(tea.KeyUp))
+	if m.input.Value() != "первый вопрос" {
+		t.Errorf("up past oldest entry: input = %q", m.input.Value())
+	}
+	m.handleKeyPress(key(tea.KeyDown))
+	m.handleKeyPress(key(tea.KeyDown))
+	if m.input.Value() != "" {
+		t.Errorf("down should return to draft, input = %q", m.input.Value())
+	}
+
+	t.Run("commands are listed by prefix", func(t *testing.T) {
+		m.input.SetValue("/")
+		m.handleKeyPress(key(tea.KeyUp))
+		if m.input.Value() != "/usage" {
+			t.Errorf("input = %q, want /usage", m.input.Value())
+		}
+	})
+
+	t.Run("editing resets navigation", func(t *testing.T) {
+		m := newHistoryModel(t, "первый вопрос", "второй вопрос")
+		m.handleKeyPress(key(tea.KeyUp))
+		m.handleKeyPress(runes("!"))
+		m.handleKeyPress(key(tea.KeyUp))
+		if m.input.Value() != "второй вопрос!" {
+			t.Errorf("edited entry should become new draft, input = %q", m.input.Value())
+		}
+	})
+}
+
+func TestModel_EnterRemembersInput(t *testing.T) {
+	m := newHistoryModel(t)
+
+	m.input.SetValue("/config")
+	m.handleKeyPress(key(tea.KeyEnter))
+	if got := m.inputHistory.Entries(inputhistory.Commands); len(got) != 1 || got[0] != "/config" {
+		t.Errorf("commands = %q", got)
+	}
+	if got := m.inputHistory.Entries(inputhistory.Prompts); len(got) != 0 {
+		t.Errorf("command should not be stored as prompt, prompts = %q", got)
+	}
+}
+
+func TestModel_HistorySearch(t *testing.T) {
+	m := newHistoryModel(t, "explain goroutines", "write a test", "translate to go")
+	m.input.SetValue("draft")
+
+	m.handleKeyPress(key(tea.KeyCtrlR))
+	if m.search == nil {
+		t.Fatalf("ctrl+r should open search")
+	}
+	for _, r := range "go" {
+		m.handleKeyPress(runes(string(r)))
+	}
+	if len(m.search.matches) != 2 {
+		t.Fatalf("matches = %q", m.search.matches)
+	}
+	if view := m.renderInput(); !strings.Contains(view, "поиск [1/2]: go") || !strings.Contains(view, "translate to go") {
+		t.Errorf("search view = %q", view)
+	}
+
+	// Повторный Ctrl+R переходит к более старому совпадению, Enter переносит его в поле ввода
+	m.handleKeyPress(key(tea.KeyCtrlR))
+	m.handleKeyPress(key(tea.KeyEnter))
+	if m.search != nil || m.input.Value() != "explain goroutines" {
+		t.Errorf("input = %q, search open = %v", m.input.Value(), m.search != nil)
+	}
+	if m.status != StatusIdle {
+		t.Errorf("accepting a match should not send it, status = %v", m.status)
+	}
+
+	t.Run("escape keeps input", func(t *testing.T) {
+		m.input.SetValue("draft")
+		m.handleKeyPress(key(tea.KeyCtrlR))
+		m.handleKeyPress(runes("wat"))
+		m.handleKeyPress(key(tea.KeyEsc))
+		if m.search != nil || m.input.Value() != "draft" {
+			t.Errorf("input = %q, search open = %v", m.input.Value(), m.search != nil)
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"llm-client/internal/config"
+	"llm-client/internal/markdown"
+)
+
+// maxMarkdownCache - количество сообщений, отображение которых хранится в кэше
+const maxMarkdownCache = 256
+
+// markdownView отображает ответы ассистента как Markdown и кэширует результат:
+// история перерисовывается на каждом чанке стрима, а готовые сообщения не меняются
+type markdownView struct {
+	renderer *markdown.Renderer
+	cache    map[string][]string
+}
+
+// markdownEnabled сообщает, включено ли отображение Markdown (/set render)
+func (m *Model) markdownEnabled() bool {
+	return m.runtime.Render != config.RenderPlain
+}
+
+// markdownView возвращает рендерер для текущей ширины; при изменении ширины кэш сбрасывается
+func (m *Model) markdownView(width int) *markdownView {
+	if m.markdown == nil || m.markdown.renderer.Width() != width {
+		m.markdown = &markdownView{
+			renderer: markdown.NewRenderer(width, markdown.ThemeFor(m.appConfig.UI.Theme)),
+			cache:    make(map[string][]string),
+		}
+	}
+	return m.markdown
+}
+
+// render возвращает строки завершённого текста из кэша
+func (v *markdownView) render(content string) []string {
+	if lines, ok := v.cache[content]; ok {
+		return lines
+	}
+	if len(v.cache) >= maxMarkdownCache {
+		v.cache = make(map[string][]string)
+	}
+	lines := v.renderer.Render(content)
+	v.cache[content] = lines
+	return lines
+}
+
+// renderPartial отображает дописываемый ответ: завершённые блоки берутся из кэша,
+// заново разбирается только последний блок
+func (v *markdownView) renderPartial(content string) []string {
+	stable, tail := markdown.SplitStable(content)
+	lines := v.render(stable)
+	tailLines := v.renderer.RenderPartial(tail)
+	if len(tailLines) == 0 {
+		return lines
+	}
+	result := make([]string, 0, len(lines)+1+len(tailLines))
+	result = append(result, lines...)
+	if len(lines) > 0 {
+		result = append(result, "")
+	}
+	return append(result, tailLines...)
+}
+
+// formatAssistantMessage форматирует ответ ассистента: префикс на отдельной строке,
+// затем Markdown с отступом. В режиме plain используется formatMessage.
+func (m *Model) formatAssistantMessage(content, prefix string, partial bool) []string {
+	contentWidth := m.getContentWidth()
+	if !m.markdownEnabled() {
+		return m.formatMessage(content, prefix, messageAssistantStyle, contentWidth)
+	}
+
+	view := m.markdownView(contentWidth - 2)
+	var body []string
+	if partial {
+		body = view.renderPartial(content)
+	} else {
+		body = view.render(content)
+	}
+
+	lines := make([]string, 0, len(body)+1)
+	lines = append(lines, messageAssistantStyle.Render(prefix))
+	for _, line := range body {
+		if line == "" {
+			lines = append(lines, "")
+			continue
+		}
+		lines = append(lines, "  "+line)
+	}
+	return lines
+}
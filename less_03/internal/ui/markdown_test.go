@@ -0,0 +1,35 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"llm-client/internal/config"
+	"llm-client/internal/logger"
+)
+
+func TestModel_formatAssistantMessage(t *testing.T) {
+	m := NewModel(config.DefaultConfig(), WithLogger(logger.NewLogger(logger.Config{Enabled: false})))
+	m.viewport.Width = 60
+
+	lines := m.formatAssistantMessage("# Ответ\n\n**Go** и `fmt`", "▸ AI: ", false)
+	joined := strings.Join(lines, "\n")
+	if !strings.Contains(lines[0], "AI:") || !strings.Contains(joined, "  Ответ") {
+		t.Errorf("markdown lines = %q", lines)
+	}
+	if strings.Contains(joined, "**") || strings.Contains(joined, "#") {
+		t.Errorf("markdown markers should not be shown: %q", lines)
+	}
+
+	// Ответ во время стрима: незакрытый блок кода уже отображается как код
+	lines = m.formatAssistantMessage("Пример:\n\n```go\nx := 1", "▸ AI: ", true)
+	if got := strings.Join(lines, "\n"); !strings.Contains(got, "┌─ go") || !strings.Contains(got, "│ x := 1") {
+		t.Errorf("partial markdown = %q", lines)
+	}
+
+	m.handleCommand("/set render plain")
+	lines = m.formatAssistantMessage("**Go**", "▸ AI: ", false)
+	if !strings.Contains(lines[0], "**Go**") {
+		t.Errorf("plain render should show source text, got %q", lines)
+	}
+}
@@ -0,0 +1,228 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"llm-client/internal/client"
+)
+
+// modelsTimeout - сколько ждать списка моделей от сервера
+const modelsTimeout = 30 * time.Second
+
+// ModelsMsg сообщает о загрузке списка моделей для /models
+type ModelsMsg struct {
+	Models []client.ModelInfo
+	// Query - начальный фильтр из аргумента команды
+	Query string
+	Err   error
+}
+
+// modelPicker - состояние выбора модели (/models)
+type modelPicker struct {
+	query   string
+	models  []client.ModelInfo
+	matches []client.ModelInfo
+	// index - выбранная модель среди совпадений
+	index int
+}
+
+// handleModelsCommand обрабатывает /models [фильтр]: загружает список моделей сервера
+// и открывает выбор модели
+func (m *Model) handleModelsCommand(args []string) (tea.Model, tea.Cmd) {
+	if m.isBusy() {
+		return m.commandError("Дождитесь завершения ответа")
+	}
+	query := strings.Join(args, " ")
+	m.input.Reset()
+
+	if models, ok := m.client.CachedModels(); ok {
+		return m.handleModelsMsg(ModelsMsg{Models: models, Query: query})
+	}
+
+	m.errorMsg = "Загрузка списка моделей..."
+	m.status = StatusIdle
+	c := m.client
+	return m, func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), modelsTimeout)
+		defer cancel()
+		models, err := c.ListModels(ctx)
+		return ModelsMsg{Models: models, Query: query, Err: err}
+	}
+}
+
+// handleModelsMsg открывает выбор модели по загруженному списку
+func (m *Model) handleModelsMsg(msg ModelsMsg) (tea.Model, tea.Cmd) {
+	if msg.Err != nil {
+		m.logger.Error("Failed to list models", "error", msg.Err)
+		m.errorMsg = fmt.Sprintf("Не удалось получить список моделей: %v", msg.Err)
+		m.status = StatusError
+		return m, nil
+	}
+	if len(msg.Models) == 0 {
+		m.errorMsg = "Сервер не вернул ни одной модели"
+		m.status = StatusIdle
+		return m, nil
+	}
+
+	m.historyNav = historyNav{}
+	m.picker = &modelPicker{query: msg.Query, models: msg.Models}
+	m.updatePicker()
+	// Курсор ставится на текущую модель, если она в списке
+	if i := slices.IndexFunc(m.picker.matches, func(info client.ModelInfo) bool {
+		return info.ID == m.runtime.Model
+	}); i >= 0 {
+		m.picker.index = i
+	}
+	m.errorMsg = fmt.Sprintf("Моделей на сервере: %d", len(msg.Models))
+	m.status = StatusIdle
+	return m, nil
+}
+
+// updatePicker пересчитывает совпадения после изменения фильтра.
+// Фильтр - слова через пробел, каждое должно встречаться в имени или описании модели.
+func (m *Model) updatePicker() {
+	p := m.picker
+	words := strings.Fields(strings.ToLower(p.query))
+	p.matches = p.matches[:0]
+	for _, info := range p.models {
+		text := strings.ToLower(info.ID + " " + info.Description)
+		if !slices.ContainsFunc(words, func(word string) bool { return !strings.Contains(text, word) }) {
+			p.matches = append(p.matches, info)
+		}
+	}
+	p.index = 0
+}
+
+// handlePickerKey обрабатывает клавиши в режиме выбора модели
+func (m *Model) handlePickerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := m.picker
+
+	switch msg.String() {
+	case "ctrl+c", "esc", "ctrl+g":
+		m.picker = nil
+
+	case "enter", "tab":
+		m.picker = nil
+		if len(p.matches) == 0 {
+			return m, nil
+		}
+		id := p.matches[p.index].ID
+		if err := m.runtime.SetParam("model", id); err != nil {
+			m.errorMsg = fmt.Sprintf("Ошибка: %v", err)
+			m.status = StatusError
+			return m, nil
+		}
+		m.logger.Info("Model selected", "model", id)
+		m.errorMsg = fmt.Sprintf("Установлено: model = %s", id)
+		m.status = StatusIdle
+
+	case "up", "ctrl+p":
+		if p.index > 0 {
+			p.index--
+		}
+
+	case "down", "ctrl+n":
+		if p.index+1 < len(p.matches) {
+			p.index++
+		}
+
+	case "backspace", "ctrl+h":
+		p.query = p.query[:prevBoundary(p.query, len(p.query))]
+		m.updatePicker()
+
+	case "ctrl+u":
+		p.query = ""
+		m.updatePicker()
+
+	default:
+		if msg.Type == tea.KeySpace {
+			p.query += " "
+		} else if msg.Type == tea.KeyRunes && !msg.Alt {
+			p.query += strings.ReplaceAll(normalizeInput(string(msg.Runes)), "\n", " ")
+		} else {
+			return m, nil
+		}
+		m.updatePicker()
+	}
+	return m, nil
+}
+
+// pickerLines возвращает строки поля ввода в режиме выбора модели:
+// фильтр и окно списка вокруг выбранной модели
+func (m *Model) pickerLines() []string {
+	p := m.picker
+
+	counter := "нет совпадений"
+	if len(p.matches) > 0 {
+		counter = fmt.Sprintf("%d/%d", p.index+1, len(p.matches))
+	}
+	lines := []string{fmt.Sprintf("модель [%s]: %s%s", counter, p.query, cursor())}
+
+	rows := maxInputLines - 1
+	first := min(max(p.index-rows/2, 0), max(len(p.matches)-rows, 0))
+	width := m.inputWidth()
+	for i := first; i < len(p.matches) && i < first+rows; i++ {
+		line := modelLine(p.matches[i])
+		if width > 2 {
+			line = truncateLine(line, width-2)
+		}
+		if i == p.index {
+			lines = append(lines, "> "+line)
+		} else {
+			lines = append(lines, "  "+line)
+		}
+	}
+	return lines
+}
+
+// modelLine описывает модель одной строкой: имя, описание, контекст и цена за 1 млн токенов
+func modelLine(info client.ModelInfo) string {
+	parts := []string{info.ID}
+	if info.Description != "" {
+		parts = append(parts, info.Description)
+	}
+	if info.ContextLength > 0 {
+		parts = append(parts, formatContextLength(info.ContextLength)+" ctx")
+	}
+	if info.Pricing != nil {
+		parts = append(parts, fmt.Sprintf("$%.2f/$%.2f за 1M", info.Pricing.Prompt, info.Pricing.Completion))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// formatContextLength сокращает размер контекста: 128000 -> 128K, 1048576 -> 1M
+func formatContextLength(tokens int) string {
+	switch {
+	case tokens >= 1_000_000:
+		return fmt.Sprintf("%gM", float64(tokens/100_000)/10)
+	case tokens >= 1000:
+		return fmt.Sprintf("%dK", tokens/1000)
+	default:
+		return fmt.Sprint(tokens)
+	}
+}
+
+// truncateLine обрезает строку до width символов, отмечая обрезку многоточием
+func truncateLine(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// checkModel возвращает предупреждение, если модели нет в загруженном списке сервера.
+// Пока список не загружался (/models), имя не проверяется.
+func (m *Model) checkModel(name string) string {
+	models, ok := m.client.CachedModels()
+	if !ok || slices.ContainsFunc(models, func(info client.ModelInfo) bool { return info.ID == name }) {
+		return ""
+	}
+	return fmt.Sprintf("модели %s нет в списке сервера (/models)", name)
+}
@@ -0,0 +1,105 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"llm-client/internal/client"
+	"llm-client/internal/config"
+	"llm-client/internal/fakellm"
+	"llm-client/internal/logger"
+)
+
+func newModelsModel(t *testing.T) *Model {
+	t.Helper()
+	server := fakellm.New(t, fakellm.WithModels("qwen2.5-7b", "llama3-8b", "qwen2.5-72b"))
+	cfg := config.DefaultConfig()
+	cfg.Server.Address = server.URL
+	return NewModel(cfg,
+		WithLogger(logger.NewLogger(logger.Config{Enabled: false})),
+		WithClientOptions(client.WithAPIKey("test-key")),
+	)
+}
+
+// runCmd выполняет команду Bubble Tea и передаёт результат модели
+func runCmd(m *Model, cmd tea.Cmd) {
+	if cmd != nil {
+		m.Update(cmd())
+	}
+}
+
+func TestModel_ModelsPicker(t *testing.T) {
+	m := newModelsModel(t)
+
+	// До загрузки списка имя модели не проверяется
+	m.handleCommand("/set model custom")
+	if strings.Contains(m.errorMsg, "предупреждение") {
+		t.Errorf("/set model without model list: %q", m.errorMsg)
+	}
+
+	_, cmd := m.handleCommand("/models qwen")
+	runCmd(m, cmd)
+	if m.picker == nil {
+		t.Fatalf("picker not opened: %s", m.errorMsg)
+	}
+	if len(m.picker.matches) != 2 || m.picker.matches[0].ID != "qwen2.5-72b" {
+		t.Errorf("matches = %+v, want sorted qwen models", m.picker.matches)
+	}
+
+	m.handleKeyPress(runes(" 7b"))
+	if len(m.picker.matches) != 1 || !strings.Contains(strings.Join(m.inputLines(), "\n"), "> qwen2.5-7b") {
+		t.Errorf("filtered picker:\n%s", strings.Join(m.inputLines(), "\n"))
+	}
+	m.handleKeyPress(key(tea.KeyEnter))
+	if m.picker != nil || m.runtime.Model != "qwen2.5-7b" {
+		t.Errorf("model = %q, picker open = %v", m.runtime.Model, m.picker != nil)
+	}
+
+	// Esc закрывает выбор без изменений
+	_, cmd = m.handleCommand("/models")
+	runCmd(m, cmd)
+	m.handleKeyPress(key(tea.KeyDown))
+	m.handleKeyPress(key(tea.KeyEsc))
+	if m.picker != nil || m.runtime.Model != "qwen2.5-7b" {
+		t.Errorf("esc: model = %q, picker open = %v", m.runtime.Model, m.picker != nil)
+	}
+
+	// После загрузки списка неизвестное имя принимается с предупреждением
+	m.handleCommand("/set model gpt-5")
+	if m.runtime.Model != "gpt-5" || m.status == StatusError || !strings.Contains(m.errorMsg, "предупреждение") {
+		t.Errorf("/set model unknown: model = %q, status = %v, msg = %q", m.runtime.Model, m.status, m.errorMsg)
+	}
+	m.handleCommand("/set model llama3-8b")
+	if strings.Contains(m.errorMsg, "предупреждение") {
+		t.Errorf("/set model known: %q", m.errorMsg)
+	}
+}
+
+func TestModel_ModelsError(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Server.Address = "http://127.0.0.1:1"
+	m := NewModel(cfg, WithLogger(logger.NewLogger(logger.Config{Enabled: false})))
+
+	_, cmd := m.handleCommand("/models")
+	runCmd(m, cmd)
+	if m.picker != nil || m.status != StatusError {
+		t.Errorf("status = %v, msg = %q, want error", m.status, m.errorMsg)
+	}
+}
+
+func TestModelLine(t *testing.T) {
+	info := client.ModelInfo{
+		ID:            "openai/gpt-4o",
+		Description:   "GPT-4o",
+		ContextLength: 128000,
+		Pricing:       &config.ModelPrice{Prompt: 2.5, Completion: 10},
+	}
+	if got, want := modelLine(info), "openai/gpt-4o · GPT-4o · 128K ctx · $2.50/$10.00 за 1M"; got != want {
+		t.Errorf("modelLine() = %q, want %q", got, want)
+	}
+	if got := formatContextLength(1048576); got != "1M" {
+		t.Errorf("formatContextLength(1048576) = %q", got)
+	}
+}
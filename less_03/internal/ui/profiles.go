@@ -0,0 +1,115 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"llm-client/internal/client"
+	"llm-client/internal/config"
+)
+
+// WithKeyResolver устанавливает источник API ключей профилей
+// (по умолчанию хранилище открывается паролем из LLM_CLIENT_KEYSTORE_PASSPHRASE)
+func WithKeyResolver(keys *config.KeyResolver) ModelOption {
+	return func(m *Model) {
+		m.keys = keys
+	}
+}
+
+// handleProfileCommand обрабатывает /profile: без аргументов выводит список профилей,
+// с именем - переключает подключение, сохраняя историю диалога
+func (m *Model) handleProfileCommand(args []string) (tea.Model, tea.Cmd) {
+	if len(m.appConfig.Profiles) == 0 {
+		return m.commandError("Профили не заданы (profiles в конфигурации)")
+	}
+	if len(args) == 0 {
+		m.showProfiles()
+		m.input.Reset()
+		return m, m.updateViewportContent()
+	}
+	if m.isBusy() {
+		return m.commandError("Дождитесь завершения ответа")
+	}
+
+	name := args[0]
+	if err := m.switchProfile(name); err != nil {
+		m.logger.Error("Failed to switch profile", "profile", name, "error", err)
+		return m.commandError(fmt.Sprintf("Ошибка: %v", err))
+	}
+
+	m.errorMsg = fmt.Sprintf("Профиль %s: %s", name, m.appConfig.Server.Address)
+	m.status = StatusIdle
+	m.input.Reset()
+	return m, nil
+}
+
+// switchProfile применяет профиль к конфигурации и пересоздаёт клиент.
+// Параметры, изменённые через /set и не заданные профилем, сохраняются.
+func (m *Model) switchProfile(name string) error {
+	next := *m.appConfig
+	m.runtime.ApplyToConfig(&next)
+	if err := next.ApplyProfile(name); err != nil {
+		return err
+	}
+	if err := next.Validate(); err != nil {
+		return err
+	}
+	if err := next.ResolveAPIKey(m.keys); err != nil {
+		return err
+	}
+
+	m.appConfig = &next
+	m.runtime = config.NewRuntimeConfig(m.appConfig)
+	m.client = m.newClient()
+	// Суммаризатор привязан к клиенту и создаётся заново при следующем сокращении истории
+	m.summarizer = nil
+
+	if m.history.GetSystemPrompt() != m.runtime.SystemPrompt {
+		m.history.SetSystemPrompt(m.runtime.SystemPrompt)
+	}
+
+	m.logger.Info("Profile switched",
+		"profile", name,
+		"address", m.appConfig.Server.Address,
+		"provider", m.appConfig.Server.Provider,
+		"model", m.runtime.Model,
+	)
+	return nil
+}
+
+// showProfiles выводит профили подключения, отмечая активный
+func (m *Model) showProfiles() {
+	var b strings.Builder
+	for _, name := range m.appConfig.ProfileNames() {
+		p := m.appConfig.Profiles[name]
+		marker := " "
+		if name == m.appConfig.ActiveProfile {
+			marker = "*"
+		}
+		provider := p.Provider
+		if provider == "" {
+			provider = config.ProviderOpenAI
+		}
+		fmt.Fprintf(&b, "%s %s: %s (%s)", marker, name, p.Address, provider)
+		if p.Model != "" {
+			fmt.Fprintf(&b, ", %s", p.Model)
+		}
+		b.WriteString("\n")
+	}
+
+	m.notice = strings.TrimRight(b.String(), "\n")
+	m.errorMsg = "Профили подключения (/profile <имя>)"
+	m.status = StatusIdle
+	m.viewport.GotoBottom()
+}
+
+// newClient создаёт клиент по текущей конфигурации
+func (m *Model) newClient() *client.Client {
+	opts := []client.ClientOption{
+		client.WithLogger(m.logger),
+		client.WithUsageHandler(m.recordUsage),
+	}
+	return client.NewClientFromConfig(m.appConfig, append(opts, m.clientOpts...)...)
+}
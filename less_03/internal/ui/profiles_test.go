@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"llm-client/internal/config"
+	"llm-client/internal/logger"
+)
+
+func newProfileModel(t *testing.T) *Model {
+	t.Helper()
+	cfg := config.DefaultConfig()
+	cfg.Profiles = map[string]config.Profile{
+		"local": {Address: "http://localhost:11434", Model: "llama3"},
+		"vllm": {
+			Address:      "http://vllm.internal:8000",
+			Model:        "qwen2.5",
+			SystemPrompt: "Answer in Russian.",
+			Temperature:  0.2,
+		},
+	}
+	return NewModel(cfg, WithLogger(logger.NewLogger(logger.Config{Enabled: false})))
+}
+
+func TestModel_ProfileSwitchKeepsHistory(t *testing.T) {
+	m := newProfileModel(t)
+	completeTurn(m, "Привет", "Здравствуйте")
+	m.handleCommand("/set top_p 0.5")
+
+	m.handleCommand("/profile vllm")
+
+	if m.status == StatusError {
+		t.Fatalf("/profile failed: %s", m.errorMsg)
+	}
+	if got := m.client.GetBaseURL(); got != "http://vllm.internal:8000" {
+		t.Errorf("client base URL = %q, want profile address", got)
+	}
+	if m.runtime.Model != "qwen2.5" || m.runtime.Temperature != 0.2 {
+		t.Errorf("runtime = %s, want profile model and temperature", m.runtime)
+	}
+	// Параметр, который профиль не задаёт, сохраняет значение из /set
+	if m.runtime.TopP != 0.5 {
+		t.Errorf("TopP = %f, want 0.5 from /set", m.runtime.TopP)
+	}
+	if m.appConfig.ActiveProfile != "vllm" {
+		t.Errorf("ActiveProfile = %q", m.appConfig.ActiveProfile)
+	}
+
+	messages := m.history.GetMessages()
+	if len(messages) != 3 || messages[1].Content != "Привет" || messages[2].Content != "Здравствуйте" {
+		t.Fatalf("history should survive profile switch, got %+v", messages)
+	}
+	if m.history.GetSystemPrompt() != "Answer in Russian." {
+		t.Errorf("system prompt = %q, want profile prompt", m.history.GetSystemPrompt())
+	}
+}
+
+func TestModel_ProfileCommandErrors(t *testing.T) {
+	m := newProfileModel(t)
+
+	m.handleCommand("/profile missing")
+	if m.status != StatusError || !strings.Contains(m.errorMsg, "missing") {
+		t.Errorf("unknown profile: status = %v, msg = %q", m.status, m.errorMsg)
+	}
+	if m.client.GetBaseURL() != "http://localhost:11434" {
+		t.Errorf("client should not change on failed switch")
+	}
+
+	m.status = StatusStreaming
+	m.handleCommand("/profile vllm")
+	if m.appConfig.ActiveProfile != "" {
+		t.Errorf("profile should not switch while streaming")
+	}
+	m.status = StatusIdle
+
+	m.handleCommand("/profile")
+	if !strings.Contains(m.notice, "local") || !strings.Contains(m.notice, "vllm") {
+		t.Errorf("notice = %q, want profile list", m.notice)
+	}
+
+	empty := NewModel(config.DefaultConfig(), WithLogger(logger.NewLogger(logger.Config{Enabled: false})))
+	empty.handleCommand("/profile")
+	if empty.status != StatusError {
+		t.Errorf("/profile without configured profiles should report an error")
+	}
+}
@@ -0,0 +1,218 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"llm-client/internal/session"
+	"llm-client/internal/usage"
+)
+
+// maxListedSessions ограничивает количество сессий в выводе /sessions
+const maxListedSessions = 20
+
+// WithSessionStore включает сохранение сессий в указанное хранилище
+func WithSessionStore(store *session.Store) ModelOption {
+	return func(m *Model) {
+		m.sessions = store
+	}
+}
+
+// WithSession восстанавливает диалог из сохранённой сессии
+func WithSession(sess *session.Session) ModelOption {
+	return func(m *Model) {
+		m.applySession(sess)
+	}
+}
+
+// applySession делает сессию текущей и восстанавливает её историю и модель
+func (m *Model) applySession(sess *session.Session) {
+	m.session = sess
+	m.history = sess.History()
+	if sess.Model != "" {
+		m.runtime.Model = sess.Model
+	}
+	if prompt := m.history.GetSystemPrompt(); prompt != "" {
+		m.runtime.SystemPrompt = prompt
+	}
+	// Расход продолжает считаться от сохранённого значения
+	var report usage.Report
+	if sess.Usage != nil {
+		report = *sess.Usage
+	}
+	m.usage.SetSession(report)
+}
+
+// saveSession сохраняет текущий диалог (вызывается после каждого ответа ассистента)
+func (m *Model) saveSession() {
+	if m.sessions == nil || m.history.LastUserMessage() == nil {
+		return
+	}
+
+	if m.session == nil {
+		m.session = session.New(m.runtime.Model)
+	}
+	m.session.Model = m.runtime.Model
+	m.session.SetHistory(m.history)
+	if report := m.usage.Session(); report.Requests > 0 {
+		m.session.Usage = &report
+	}
+
+	if err := m.sessions.Save(m.session); err != nil {
+		m.logger.Error("Failed to save session", "session", m.session.ID, "error", err)
+		m.status = StatusError
+		m.errorMsg = fmt.Sprintf("Ошибка сохранения сессии: %v", err)
+		return
+	}
+	m.logger.Debug("Session saved", "session", m.session.ID, "messages", m.session.MessageCount)
+}
+
+// handleSessionCommand обрабатывает команды управления сессиями
+func (m *Model) handleSessionCommand(command string, args []string) {
+	if m.isBusy() && (command == "load" || command == "new" || command == "delete") {
+		// Замена диалога во время запроса смешала бы ответ с другой сессией.
+		// Статус не меняется: его проверяют обработчики ответа текущего запроса.
+		m.errorMsg = "Дождитесь завершения ответа"
+		return
+	}
+	if m.sessions == nil {
+		m.errorMsg = "Хранилище сессий недоступно"
+		m.status = StatusError
+		return
+	}
+
+	var err error
+	switch command {
+	case "sessions":
+		err = m.listSessions()
+	case "load":
+		err = m.loadSession(args)
+	case "new":
+		m.newSession()
+	case "rename":
+		err = m.renameSession(args)
+	case "delete":
+		err = m.deleteSession(args)
+	}
+
+	if err != nil {
+		m.logger.Error("Session command failed", "command", command, "error", err)
+		m.errorMsg = fmt.Sprintf("Ошибка: %v", err)
+		m.status = StatusError
+		return
+	}
+	m.status = StatusIdle
+}
+
+// listSessions выводит список сохранённых сессий
+func (m *Model) listSessions() error {
+	list, err := m.sessions.List()
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		m.errorMsg = "Нет сохранённых сессий"
+		return nil
+	}
+
+	var b strings.Builder
+	b.WriteString("Сохранённые сессии (/load <id>):\n")
+	for i, meta := range list {
+		if i == maxListedSessions {
+			fmt.Fprintf(&b, "  ... и ещё %d\n", len(list)-maxListedSessions)
+			break
+		}
+		current := " "
+		if m.session != nil && m.session.ID == meta.ID {
+			current = "*"
+		}
+		fmt.Fprintf(&b, "%s %s  %s  %s (%d сообщ.)  %s\n",
+			current, meta.ID, meta.UpdatedAt.Format("2006-01-02 15:04"), meta.Title, meta.MessageCount, meta.Model)
+	}
+
+	m.notice = strings.TrimRight(b.String(), "\n")
+	m.errorMsg = fmt.Sprintf("Сессий: %d", len(list))
+	m.viewport.GotoBottom()
+	return nil
+}
+
+// loadSession загружает сессию и заменяет текущий диалог
+func (m *Model) loadSession(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("использование: /load <id>")
+	}
+
+	sess, err := m.sessions.Load(args[0])
+	if err != nil {
+		return err
+	}
+
+	m.applySession(sess)
+	m.streamingBuf.Reset()
+	m.viewport.GotoBottom()
+	m.errorMsg = fmt.Sprintf("Загружена сессия %s: %s", sess.ID, sess.Title)
+	m.logger.Info("Session loaded", "session", sess.ID, "messages", sess.MessageCount)
+	return nil
+}
+
+// newSession начинает новый диалог; текущая сессия уже сохранена после последнего ответа
+func (m *Model) newSession() {
+	m.session = nil
+	m.usage.SetSession(usage.Report{})
+	m.history.Clear(m.runtime.SystemPrompt)
+	m.streamingBuf.Reset()
+	m.viewport.GotoTop()
+	m.errorMsg = "Новая сессия"
+	m.logger.Info("New session started")
+}
+
+// renameSession изменяет заголовок текущей сессии
+func (m *Model) renameSession(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("использование: /rename <заголовок>")
+	}
+	if m.session == nil {
+		return fmt.Errorf("текущий диалог ещё не сохранён")
+	}
+
+	sess, err := m.sessions.Rename(m.session.ID, strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	m.session.Title = sess.Title
+	m.session.UpdatedAt = sess.UpdatedAt
+	m.errorMsg = fmt.Sprintf("Сессия переименована: %s", sess.Title)
+	return nil
+}
+
+// deleteSession удаляет сессию по id, по умолчанию - текущую
+func (m *Model) deleteSession(args []string) error {
+	var id string
+	switch {
+	case len(args) > 0:
+		id = args[0]
+	case m.session != nil:
+		id = m.session.ID
+	default:
+		return fmt.Errorf("использование: /delete <id>")
+	}
+
+	deleted, err := m.sessions.Delete(id)
+	if err != nil {
+		return err
+	}
+
+	m.errorMsg = fmt.Sprintf("Сессия %s удалена", deleted)
+	if m.session != nil && m.session.ID == deleted {
+		// Удалённая сессия больше не должна перезаписываться автосохранением
+		m.newSession()
+		m.errorMsg = fmt.Sprintf("Сессия %s удалена, начат новый диалог", deleted)
+	}
+	m.logger.Info("Session deleted", "session", deleted)
+	return nil
+}
+
+// GetSession возвращает текущую сессию (nil если диалог ещё не сохранялся)
+func (m *Model) GetSession() *session.Session {
+	return m.session
+}
@@ -0,0 +1,199 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"llm-client/internal/chat"
+	"llm-client/internal/config"
+	"llm-client/internal/fakellm"
+	"llm-client/internal/logger"
+	"llm-client/internal/session"
+)
+
+func newSessionModel(t *testing.T) (*Model, *session.Store) {
+	t.Helper()
+	store := session.NewStore(t.TempDir())
+	m := NewModel(config.DefaultConfig(),
+		WithLogger(logger.NewLogger(logger.Config{Enabled: false})),
+		WithSessionStore(store),
+	)
+	return m, store
+}
+
+// completeTurn имитирует завершённый ответ ассистента
+func completeTurn(m *Model, user, answer string) {
+	m.history.AddUser(user)
+	m.status = StatusStreaming
+	m.handleStreamMsg(StreamMsg{Content: answer})
+	m.handleStreamMsg(StreamMsg{Done: true})
+}
+
+func TestModel_AutosaveAfterAssistantTurn(t *testing.T) {
+	m, store := newSessionModel(t)
+
+	completeTurn(m, "Привет", "Здравствуйте")
+
+	sess := m.GetSession()
+	if sess == nil {
+		t.Fatalf("session should be created after first turn")
+	}
+
+	loaded, err := store.Load(sess.ID)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.MessageCount != 2 || loaded.Title != "Привет" || loaded.Model != "llama3" {
+		t.Errorf("saved meta = %+v", loaded.Meta)
+	}
+
+	completeTurn(m, "Как дела?", "Отлично")
+	loaded, _ = store.Load(sess.ID)
+	if loaded.MessageCount != 4 {
+		t.Errorf("MessageCount = %d, want 4 after second turn", loaded.MessageCount)
+	}
+	if m.GetSession().ID != sess.ID {
+		t.Errorf("second turn should update the same session")
+	}
+}
+
+func TestModel_SessionCommands(t *testing.T) {
+	m, store := newSessionModel(t)
+	completeTurn(m, "Первый диалог", "Ответ")
+	firstID := m.GetSession().ID
+
+	t.Run("new", func(t *testing.T) {
+		m.handleCommand("/new")
+		if m.GetSession() != nil {
+			t.Errorf("/new should reset current session")
+		}
+		if len(m.history.GetDisplayMessages()) != 0 {
+			t.Errorf("/new should clear history")
+		}
+	})
+
+	completeTurn(m, "Второй диалог", "Ответ")
+	secondID := m.GetSession().ID
+
+	t.Run("sessions", func(t *testing.T) {
+		m.handleCommand("/sessions")
+		if m.status != StatusIdle {
+			t.Fatalf("status = %v, errorMsg = %q", m.status, m.errorMsg)
+		}
+		if !strings.Contains(m.notice, firstID) || !strings.Contains(m.notice, secondID) {
+			t.Errorf("notice should list both sessions, got %q", m.notice)
+		}
+		if !strings.Contains(m.renderHistoryContent(), "Первый диалог") {
+			t.Errorf("session list should be rendered in viewport")
+		}
+	})
+
+	t.Run("load", func(t *testing.T) {
+		m.handleCommand("/load " + firstID)
+		if m.status != StatusIdle {
+			t.Fatalf("status = %v, errorMsg = %q", m.status, m.errorMsg)
+		}
+		if m.GetSession().ID != firstID {
+			t.Errorf("current session = %q, want %q", m.GetSession().ID, firstID)
+		}
+		if last := m.history.LastUserMessage(); last == nil || last.Content != "Первый диалог" {
+			t.Errorf("history should be restored, last user message = %+v", last)
+		}
+		if m.notice != "" {
+			t.Errorf("notice should be cleared by next command")
+		}
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		m.handleCommand("/rename Мой диалог")
+		loaded, _ := store.Load(firstID)
+		if loaded.Title != "Мой диалог" {
+			t.Errorf("Title = %q, want %q", loaded.Title, "Мой диалог")
+		}
+	})
+
+	t.Run("delete current", func(t *testing.T) {
+		m.handleCommand("/delete")
+		if m.GetSession() != nil {
+			t.Errorf("deleting current session should start a new one")
+		}
+		if _, err := store.Load(firstID); err == nil {
+			t.Errorf("session should be deleted")
+		}
+	})
+
+	t.Run("load missing", func(t *testing.T) {
+		m.handleCommand("/load nope")
+		if m.status != StatusError {
+			t.Errorf("status = %v, want %v", m.status, StatusError)
+		}
+	})
+}
+
+func TestModel_SessionCommands_NoStore(t *testing.T) {
+	m := NewModel(config.DefaultConfig())
+
+	m.handleCommand("/sessions")
+	if m.status != StatusError {
+		t.Errorf("status = %v, want %v", m.status, StatusError)
+	}
+}
+
+func TestWithSession(t *testing.T) {
+	sess := session.New("qwen")
+	sess.SetMessages([]chat.Message{
+		{Role: chat.RoleSystem, Content: "Be brief"},
+		{Role: chat.RoleUser, Content: "Hi"},
+		{Role: chat.RoleAssistant, Content: "Hello"},
+	})
+
+	m := NewModel(config.DefaultConfig(), WithSession(sess))
+
+	if m.runtime.Model != "qwen" {
+		t.Errorf("Model = %q, want %q", m.runtime.Model, "qwen")
+	}
+	if m.history.Len() != 3 || m.history.GetSystemPrompt() != "Be brief" {
+		t.Errorf("history should be restored from session")
+	}
+}
+
+func TestModel_CancelledTurnNotSaved(t *testing.T) {
+	server := fakellm.New(t,
+		fakellm.WithDefaultReply(fakellm.Text("one two three four five six")),
+		fakellm.WithTokenDelay(50*time.Millisecond))
+	store := session.NewStore(t.TempDir())
+	cfg := config.DefaultConfig()
+	cfg.Server.Address = server.URL
+	m := NewModel(cfg,
+		WithLogger(logger.NewLogger(logger.Config{Enabled: false})),
+		WithSessionStore(store),
+	)
+	completeTurn(m, "q0", "a0")
+
+	m.history.AddUser("q1")
+	m.startStreaming(m.buildRequest())
+	ch := m.streamMsgChan
+	m.handleStreamMsg(readStreamMsg(ch)().(StreamMsg))
+
+	m.handleKeyPress(key(tea.KeyCtrlC))
+	// Чанки, которые уже читались из канала до отмены, включая Done от клиента
+	for msg := readStreamMsg(ch)(); msg != nil; msg = readStreamMsg(ch)() {
+		m.handleStreamMsg(msg.(StreamMsg))
+	}
+
+	for _, msg := range m.history.GetMessages() {
+		if msg.Role == chat.RoleAssistant && msg.Content == "" {
+			t.Errorf("cancelled turn left an empty assistant message: %+v", m.history.GetMessages())
+		}
+	}
+	loaded, err := store.Load(m.GetSession().ID)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.MessageCount != 2 {
+		t.Errorf("MessageCount = %d, want 2: cancelled turn should not be saved", loaded.MessageCount)
+	}
+}
@@ -0,0 +1,239 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"llm-client/internal/chat"
+	"llm-client/internal/client"
+	apperrors "llm-client/internal/errors"
+)
+
+// maxToolRounds ограничивает количество последовательных раундов вызова инструментов
+// в одном ответе, чтобы модель не могла зациклиться
+const maxToolRounds = 5
+
+// ToolFunc - Go функция, которую может вызвать модель.
+// Получает аргументы вызова в виде JSON и возвращает результат для модели.
+type ToolFunc func(ctx context.Context, args json.RawMessage) (string, error)
+
+// registeredTool связывает описание инструмента с его реализацией
+type registeredTool struct {
+	definition client.Tool
+	fn         ToolFunc
+}
+
+// ToolRegistry хранит инструменты, доступные модели.
+// Потокобезопасная реализация с использованием мьютекса
+type ToolRegistry struct {
+	mu    sync.RWMutex
+	tools map[string]registeredTool
+	// order сохраняет порядок регистрации для стабильного списка в запросе
+	order []string
+}
+
+// NewToolRegistry создаёт пустой реестр инструментов
+func NewToolRegistry() *ToolRegistry {
+	return &ToolRegistry{
+		tools: make(map[string]registeredTool),
+	}
+}
+
+// NewDefaultToolRegistry создаёт реестр со встроенными инструментами
+func NewDefaultToolRegistry() *ToolRegistry {
+	r := NewToolRegistry()
+	_ = r.Register(client.NewFunctionTool(
+		"get_current_time",
+		"Returns the current date and time. Optionally accepts an IANA timezone name.",
+		map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"timezone": map[string]any{
+					"type":        "string",
+					"description": "IANA timezone, e.g. Europe/Moscow. Local time if empty.",
+				},
+			},
+		},
+	), currentTimeTool)
+	return r
+}
+
+// Register добавляет инструмент в реестр
+func (r *ToolRegistry) Register(tool client.Tool, fn ToolFunc) error {
+	name := tool.Function.Name
+	if name == "" {
+		return apperrors.NewValidationError("INVALID_TOOL", "tool name cannot be empty", nil)
+	}
+	if fn == nil {
+		return apperrors.NewValidationError("INVALID_TOOL", fmt.Sprintf("tool %q has no implementation", name), nil)
+	}
+	if tool.Type == "" {
+		tool.Type = client.ToolTypeFunction
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tools[name]; exists {
+		return apperrors.NewValidationError("DUPLICATE_TOOL", fmt.Sprintf("tool %q already registered", name), nil)
+	}
+	r.tools[name] = registeredTool{definition: tool, fn: fn}
+	r.order = append(r.order, name)
+	return nil
+}
+
+// Definitions возвращает описания инструментов для отправки в запросе
+func (r *ToolRegistry) Definitions() []client.Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	defs := make([]client.Tool, 0, len(r.order))
+	for _, name := range r.order {
+		defs = append(defs, r.tools[name].definition)
+	}
+	return defs
+}
+
+// Len возвращает количество зарегистрированных инструментов
+func (r *ToolRegistry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.tools)
+}
+
+// Execute выполняет вызов инструмента, запрошенный моделью
+func (r *ToolRegistry) Execute(ctx context.Context, call chat.ToolCall) (string, error) {
+	r.mu.RLock()
+	tool, ok := r.tools[call.Function.Name]
+	r.mu.RUnlock()
+
+	if !ok {
+		return "", apperrors.NewValidationError("UNKNOWN_TOOL", fmt.Sprintf("unknown tool %q", call.Function.Name), nil)
+	}
+
+	// Модели иногда присылают пустую строку вместо пустого объекта
+	args := json.RawMessage(call.Function.Arguments)
+	if len(args) == 0 {
+		args = json.RawMessage("{}")
+	}
+	if !json.Valid(args) {
+		return "", apperrors.NewValidationError("INVALID_ARGUMENTS",
+			fmt.Sprintf("tool %q: arguments are not valid JSON", call.Function.Name), nil)
+	}
+
+	return tool.fn(ctx, args)
+}
+
+// currentTimeTool реализует встроенный инструмент get_current_time
+func currentTimeTool(_ context.Context, args json.RawMessage) (string, error) {
+	var params struct {
+		Timezone string `json:"timezone"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	if params.Timezone != "" {
+		loc, err := time.LoadLocation(params.Timezone)
+		if err != nil {
+			return "", fmt.Errorf("unknown timezone %q", params.Timezone)
+		}
+		now = now.In(loc)
+	}
+	return now.Format(time.RFC3339), nil
+}
+
+// === Выполнение инструментов в UI ===
+
+// ToolResult представляет результат одного вызова инструмента
+type ToolResult struct {
+	Call    chat.ToolCall
+	Content string
+	Err     error
+}
+
+// ToolResultsMsg сообщает о завершении всех вызовов инструментов раунда
+type ToolResultsMsg struct {
+	Results []ToolResult
+}
+
+// WithToolRegistry устанавливает реестр инструментов, доступных модели
+func WithToolRegistry(registry *ToolRegistry) ModelOption {
+	return func(m *Model) {
+		m.tools = registry
+	}
+}
+
+// runTools выполняет вызовы инструментов вне цикла обновления UI
+func (m *Model) runTools(calls []chat.ToolCall) tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	registry := m.tools
+	log := m.logger
+
+	return func() tea.Msg {
+		defer cancel()
+
+		results := make([]ToolResult, 0, len(calls))
+		for _, call := range calls {
+			log.Info("Executing tool", "tool", call.Function.Name, "call_id", call.ID)
+			content, err := registry.Execute(ctx, call)
+			if err != nil {
+				log.Error("Tool execution failed", "tool", call.Function.Name, "error", err)
+			}
+			results = append(results, ToolResult{Call: call, Content: content, Err: err})
+		}
+		return ToolResultsMsg{Results: results}
+	}
+}
+
+// handleToolCalls сохраняет запрошенные вызовы в историю и запускает их выполнение
+func (m *Model) handleToolCalls(content string, calls []chat.ToolCall) (tea.Model, tea.Cmd) {
+	m.history.AddAssistantToolCalls(content, calls)
+	m.streamingBuf.Reset()
+	m.viewport.GotoBottom()
+
+	if m.toolRounds >= maxToolRounds {
+		m.logger.ErrorContext(m.requestContext(), "Tool call limit reached", "rounds", m.toolRounds)
+		// Отвечаем на вызовы, чтобы история оставалась корректной для следующих запросов
+		for _, call := range calls {
+			m.history.AddToolResult(call.ID, call.Function.Name, "error: tool call limit reached")
+		}
+		m.finishTurn()
+		m.status = StatusError
+		m.errorMsg = fmt.Sprintf("превышен лимит вызовов инструментов (%d)", maxToolRounds)
+		return m, m.updateViewportContent()
+	}
+
+	m.toolRounds++
+	m.status = StatusToolCall
+	m.logger.InfoContext(m.requestContext(), "Model requested tool calls", "count", len(calls), "round", m.toolRounds)
+
+	return m, tea.Batch(m.updateViewportContent(), m.runTools(calls))
+}
+
+// handleToolResults добавляет результаты в историю и отправляет повторный запрос
+func (m *Model) handleToolResults(msg ToolResultsMsg) (tea.Model, tea.Cmd) {
+	for _, result := range msg.Results {
+		content := result.Content
+		if result.Err != nil {
+			// Ошибка передаётся модели, чтобы она могла исправить аргументы
+			content = "error: " + result.Err.Error()
+		}
+		m.history.AddToolResult(result.Call.ID, result.Call.Function.Name, content)
+	}
+
+	// Пользователь мог прервать выполнение - в этом случае не продолжаем диалог
+	if m.status != StatusToolCall {
+		return m, m.updateViewportContent()
+	}
+
+	m.viewport.GotoBottom()
+	return m, tea.Sequence(m.updateViewportContent(), m.prepareRequest())
+}
@@ -0,0 +1,202 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"llm-client/internal/chat"
+	"llm-client/internal/client"
+	"llm-client/internal/config"
+	"llm-client/internal/fakellm"
+	"llm-client/internal/logger"
+)
+
+func echoTool(_ context.Context, args json.RawMessage) (string, error) {
+	return string(args), nil
+}
+
+func TestToolRegistry_Register(t *testing.T) {
+	r := NewToolRegistry()
+
+	if err := r.Register(client.NewFunctionTool("echo", "", nil), echoTool); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := r.Register(client.NewFunctionTool("echo", "", nil), echoTool); err == nil {
+		t.Errorf("duplicate tool should return error")
+	}
+	if err := r.Register(client.NewFunctionTool("", "", nil), echoTool); err == nil {
+		t.Errorf("empty name should return error")
+	}
+	if err := r.Register(client.NewFunctionTool("noop", "", nil), nil); err == nil {
+		t.Errorf("nil function should return error")
+	}
+	if err := r.Register(client.Tool{Function: client.FunctionDefinition{Name: "typed"}}, echoTool); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	defs := r.Definitions()
+	if r.Len() != 2 || len(defs) != 2 {
+		t.Fatalf("Len() = %d, len(Definitions()) = %d, want 2", r.Len(), len(defs))
+	}
+	if defs[0].Function.Name != "echo" || defs[1].Function.Name != "typed" {
+		t.Errorf("Definitions() should keep registration order, got %+v", defs)
+	}
+	if defs[1].Type != client.ToolTypeFunction {
+		t.Errorf("Type = %q, want %q", defs[1].Type, client.ToolTypeFunction)
+	}
+}
+
+func TestToolRegistry_Execute(t *testing.T) {
+	r := NewToolRegistry()
+	r.Register(client.NewFunctionTool("echo", "", nil), echoTool)
+
+	tests := []struct {
+		name     string
+		call     chat.ToolCall
+		expected string
+		wantErr  bool
+	}{
+		{"valid arguments", chat.ToolCall{Function: chat.FunctionCall{Name: "echo", Arguments: `{"a":1}`}}, `{"a":1}`, false},
+		{"empty arguments", chat.ToolCall{Function: chat.FunctionCall{Name: "echo"}}, `{}`, false},
+		{"invalid arguments", chat.ToolCall{Function: chat.FunctionCall{Name: "echo", Arguments: `{"a":`}}, "", true},
+		{"unknown tool", chat.ToolCall{Function: chat.FunctionCall{Name: "missing"}}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.Execute(context.Background(), tt.call)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("Execute() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDefaultToolRegistry_CurrentTime(t *testing.T) {
+	r := NewDefaultToolRegistry()
+
+	call := chat.ToolCall{Function: chat.FunctionCall{Name: "get_current_time", Arguments: `{"timezone":"UTC"}`}}
+	got, err := r.Execute(context.Background(), call)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	ts, err := time.Parse(time.RFC3339, got)
+	if err != nil {
+		t.Fatalf("result %q is not RFC3339: %v", got, err)
+	}
+	if _, offset := ts.Zone(); offset != 0 {
+		t.Errorf("UTC time should have zero offset, got %d", offset)
+	}
+
+	call.Function.Arguments = `{"timezone":"Nowhere/Unknown"}`
+	if _, err := r.Execute(context.Background(), call); err == nil {
+		t.Errorf("unknown timezone should return error")
+	}
+}
+
+func TestNewModel_EnableTools(t *testing.T) {
+	cfg := config.DefaultConfig()
+	log := logger.NewLogger(logger.Config{Enabled: false})
+
+	if m := NewModel(cfg, WithLogger(log)); m.tools != nil {
+		t.Errorf("tools should be nil when enable_tools is off")
+	}
+
+	cfg.Model.EnableTools = true
+	m := NewModel(cfg, WithLogger(log))
+	if m.tools == nil || m.tools.Len() == 0 {
+		t.Fatalf("default tools should be registered when enable_tools is on")
+	}
+	if req := m.buildRequest(); len(req.Tools) != m.tools.Len() {
+		t.Errorf("len(req.Tools) = %d, want %d", len(req.Tools), m.tools.Len())
+	}
+}
+
+func TestModel_ToolCallRoundTrip(t *testing.T) {
+	server := fakellm.New(t, fakellm.WithReplies(fakellm.Text("Готово")))
+
+	cfg := config.DefaultConfig()
+	cfg.Server.Address = server.URL
+
+	registry := NewToolRegistry()
+	registry.Register(client.NewFunctionTool("echo", "", nil), echoTool)
+	registry.Register(client.NewFunctionTool("fail", "", nil), func(context.Context, json.RawMessage) (string, error) {
+		return "", errors.New("boom")
+	})
+
+	m := NewModel(cfg, WithLogger(logger.NewLogger(logger.Config{Enabled: false})), WithToolRegistry(registry))
+	m.history.AddUser("test input")
+	m.status = StatusStreaming
+
+	calls := []chat.ToolCall{
+		{ID: "call_1", Type: "function", Function: chat.FunctionCall{Name: "echo", Arguments: `{"x":1}`}},
+		{ID: "call_2", Type: "function", Function: chat.FunctionCall{Name: "fail", Arguments: `{}`}},
+	}
+
+	_, cmd := m.handleStreamMsg(StreamMsg{Done: true, ToolCalls: calls})
+	if m.status != StatusToolCall {
+		t.Fatalf("status = %v, want %v", m.status, StatusToolCall)
+	}
+	if cmd == nil {
+		t.Fatalf("tool calls should produce a command")
+	}
+
+	// Выполняем инструменты напрямую, без цикла Bubble Tea
+	msg, ok := m.runTools(calls)().(ToolResultsMsg)
+	if !ok || len(msg.Results) != 2 {
+		t.Fatalf("runTools() = %+v, want 2 results", msg)
+	}
+
+	m.handleToolResults(msg)
+
+	messages := m.history.GetMessages()
+	// system, user, assistant(tool_calls), tool, tool
+	if len(messages) != 5 {
+		t.Fatalf("len(messages) = %d, want 5", len(messages))
+	}
+	if len(messages[2].ToolCalls) != 2 {
+		t.Errorf("assistant message should keep tool calls, got %+v", messages[2])
+	}
+	if messages[3].Role != chat.RoleTool || messages[3].ToolCallID != "call_1" || messages[3].Content != `{"x":1}` {
+		t.Errorf("first tool result = %+v", messages[3])
+	}
+	if !strings.Contains(messages[4].Content, "boom") {
+		t.Errorf("failed tool result should contain error, got %q", messages[4].Content)
+	}
+
+	// Повторный запрос должен содержать результаты инструментов
+	req := server.Await(1)[0]
+	if len(req.Messages) != 5 || req.Messages[4].ToolCallID != "call_2" {
+		t.Errorf("follow-up request messages = %+v", req.Messages)
+	}
+	if tools, _ := req.Param("tools").([]any); len(tools) != 2 {
+		t.Errorf("follow-up request should include tools, got %d", len(tools))
+	}
+	m.cancel()
+}
+
+func TestModel_ToolCallLimit(t *testing.T) {
+	cfg := config.DefaultConfig()
+	m := NewModel(cfg, WithLogger(logger.NewLogger(logger.Config{Enabled: false})), WithToolRegistry(NewDefaultToolRegistry()))
+	m.history.AddUser("test input")
+	m.toolRounds = maxToolRounds
+
+	call := chat.ToolCall{ID: "call_1", Type: "function", Function: chat.FunctionCall{Name: "get_current_time"}}
+	m.handleStreamMsg(StreamMsg{Done: true, ToolCalls: []chat.ToolCall{call}})
+
+	if m.status != StatusError {
+		t.Errorf("status = %v, want %v", m.status, StatusError)
+	}
+	// Каждый вызов должен получить ответ, иначе следующий запрос будет отклонён API
+	last := m.history.GetMessages()[m.history.Len()-1]
+	if last.Role != chat.RoleTool || last.ToolCallID != "call_1" {
+		t.Errorf("last message = %+v, want tool result for call_1", last)
+	}
+}
@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"llm-client/internal/client"
+)
+
+// beginTurn начинает ход диалога: новый идентификатор запроса и трассировка.
+// Продолжения после вызовов инструментов и суммаризация истории относятся к тому же ходу.
+func (m *Model) beginTurn() {
+	m.toolRounds = 0
+	m.turnCtx, m.trace = client.StartTrace(context.Background())
+	m.logger.InfoContext(m.turnCtx, "Turn started", "model", m.runtime.Model)
+}
+
+// finishTurn завершает трассировку текущего хода
+func (m *Model) finishTurn() {
+	if m.trace == nil || m.trace.Finished() {
+		return
+	}
+	m.trace.Finish()
+	m.logger.InfoContext(m.turnCtx, "Turn completed",
+		"duration_ms", m.trace.Duration().Milliseconds(),
+		"requests", len(m.trace.Spans()),
+	)
+}
+
+// requestContext возвращает контекст текущего хода для запросов к модели
+func (m *Model) requestContext() context.Context {
+	if m.turnCtx == nil {
+		return context.Background()
+	}
+	return m.turnCtx
+}
+
+// showTrace выводит тайминги последнего хода (/trace)
+func (m *Model) showTrace() {
+	if m.trace == nil {
+		m.errorMsg = "Запросов ещё не было"
+		m.status = StatusIdle
+		return
+	}
+
+	var b strings.Builder
+	state := "завершён"
+	if !m.trace.Finished() {
+		state = "выполняется"
+	}
+	fmt.Fprintf(&b, "Ход %s: %s, %s\n", m.trace.ID, formatDuration(m.trace.Duration()), state)
+
+	for i, span := range m.trace.Spans() {
+		fmt.Fprintf(&b, "%d. %s %s", i+1, span.Name, span.Model)
+		if span.Attempt > 1 {
+			fmt.Fprintf(&b, " (попытка %d)", span.Attempt)
+		}
+		fmt.Fprintf(&b, ": %s", formatDuration(span.Duration))
+		if span.FirstToken > 0 {
+			fmt.Fprintf(&b, ", первый токен %s", formatDuration(span.FirstToken))
+		}
+		if span.CompletionTokens > 0 {
+			fmt.Fprintf(&b, ", %d ток.", span.CompletionTokens)
+			if tps := span.TokensPerSecond(); tps > 0 {
+				fmt.Fprintf(&b, ", %.1f ток/с", tps)
+			}
+		}
+		if span.Err != nil {
+			fmt.Fprintf(&b, ", ошибка: %v", span.Err)
+		}
+		b.WriteString("\n")
+	}
+
+	m.notice = strings.TrimRight(b.String(), "\n")
+	m.errorMsg = "Тайминги последнего хода"
+	m.status = StatusIdle
+	m.viewport.GotoBottom()
+}
+
+// formatDuration округляет длительность для вывода: 850ms, 1.24s
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(10 * time.Millisecond).String()
+}
@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"llm-client/internal/client"
+	"llm-client/internal/config"
+	"llm-client/internal/logger"
+)
+
+func TestModel_handleCommand_Trace(t *testing.T) {
+	m := NewModel(config.DefaultConfig(), WithLogger(logger.NewLogger(logger.Config{Enabled: false})))
+
+	m.handleCommand("/trace")
+	if m.errorMsg != "Запросов ещё не было" || m.notice != "" {
+		t.Errorf("errorMsg = %q, notice = %q before first turn", m.errorMsg, m.notice)
+	}
+
+	m.beginTurn()
+	if logger.RequestID(m.requestContext()) != m.trace.ID {
+		t.Fatalf("turn context should carry trace ID %q", m.trace.ID)
+	}
+	m.trace.Add(client.Span{Name: client.SpanStream, Model: "llama3", Attempt: 1,
+		Duration: 300 * time.Millisecond, Err: errors.New("connection reset")})
+	m.trace.Add(client.Span{Name: client.SpanStream, Model: "llama3", Attempt: 2,
+		FirstToken: 250 * time.Millisecond, Duration: 1250 * time.Millisecond, CompletionTokens: 40})
+	completeTurn(m, "Привет", "Здравствуйте")
+
+	if !m.trace.Finished() {
+		t.Errorf("trace should be finished after the answer")
+	}
+
+	m.handleCommand("/trace")
+	for _, want := range []string{
+		"Ход " + m.trace.ID,
+		"завершён",
+		"1. stream llama3: 300ms, ошибка: connection reset",
+		"2. stream llama3 (попытка 2): 1.25s, первый токен 250ms, 40 ток., 40.0 ток/с",
+	} {
+		if !strings.Contains(m.notice, want) {
+			t.Errorf("notice should contain %q:\n%s", want, m.notice)
+		}
+	}
+
+	// Новый ход получает новый идентификатор
+	id := m.trace.ID
+	m.beginTurn()
+	if m.trace.ID == id || len(m.trace.Spans()) != 0 {
+		t.Errorf("new turn should start a new trace")
+	}
+}
@@ -16,7 +16,11 @@ import (
 	"llm-client/internal/chat"
 	"llm-client/internal/client"
 	"llm-client/internal/config"
+	"llm-client/internal/inputhistory"
 	"llm-client/internal/logger"
+	"llm-client/internal/session"
+	"llm-client/internal/tokenizer"
+	"llm-client/internal/usage"
 )
 
 // === Константы приложения ===
@@ -64,6 +68,10 @@ var (
 				Foreground(lipgloss.Color("252")).
 				MarginTop(1)
 
+	messageToolStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("243")).
+				Italic(true)
+
 	// Стили для поля ввода
 	inputStyle = lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
@@ -100,6 +108,10 @@ const (
 	StatusStreaming
 	// StatusError - ошибка
 	StatusError
+	// StatusToolCall - выполнение инструментов, запрошенных моделью
+	StatusToolCall
+	// StatusSummarizing - сжатие ранней части истории перед запросом
+	StatusSummarizing
 )
 
 // String возвращает строковое представление статуса
@@ -113,6 +125,10 @@ func (s AppStatus) String() string {
 		return "Печатает..."
 	case StatusError:
 		return "Ошибка"
+	case StatusToolCall:
+		return "Вызов инструментов..."
+	case StatusSummarizing:
+		return "Сжатие истории..."
 	default:
 		return "Неизвестно"
 	}
@@ -125,6 +141,11 @@ type StreamMsg struct {
 	Content string
 	Done    bool
 	Err     error
+	// ToolCalls - вызовы инструментов, запрошенные моделью (только в сообщении Done)
+	ToolCalls []chat.ToolCall
+
+	// source - канал, из которого прочитано сообщение; по нему отсеиваются чанки отменённого запроса
+	source <-chan StreamMsg
 }
 
 // ErrorMsg представляет ошибку приложения
@@ -141,15 +162,58 @@ type Model struct {
 	runtime   *config.RuntimeConfig
 	client    *client.Client
 	logger    *logger.Logger
+	// clientOpts - дополнительные опции клиента, применяются и при смене профиля
+	clientOpts []client.ClientOption
+	// keys получает API ключи профилей при переключении
+	keys *config.KeyResolver
 
 	// История диалога
 	history *chat.ChatHistory
 
-	// Ввод пользователя
-	input string
+	// Инструменты, доступные модели (nil - function calling выключен)
+	tools *ToolRegistry
+	// toolRounds - количество раундов вызова инструментов в текущем ответе
+	toolRounds int
+
+	// turnCtx несёт идентификатор запроса и трассировку текущего хода (/trace)
+	turnCtx context.Context
+	trace   *client.Trace
+
+	// Управление контекстным окном: словари токенизатора (countTokens переопределяет их),
+	// стратегия суммаризации и размер истории на момент последнего обновления
+	tokenizers    *tokenizer.Loader
+	countTokens   chat.TokenCounter
+	summarizer    *chat.Summarizer
+	summaryModel  string
+	contextTokens int
+
+	// Отображение Markdown в ответах (nil до первого использования)
+	markdown *markdownView
+
+	// Учёт расхода токенов и стоимости
+	usage *usage.Tracker
+
+	// Хранилище сессий (nil - сессии не сохраняются) и текущая сессия
+	sessions *session.Store
+	session  *session.Session
 
-	// Viewport для прокрутки истории
-	viewport viewport.Model
+	// Ввод пользователя и вложения для следующего сообщения (/attach)
+	input       editor
+	attachments []attachment
+
+	// История ввода: листание стрелками и поиск (nil - поиск закрыт)
+	inputHistory *inputhistory.History
+	historyNav   historyNav
+	search       *historySearch
+	// picker - выбор модели (/models); nil - закрыт
+	picker *modelPicker
+
+	// Режим сравнения моделей (/compare); nil - выключен
+	compare *compareMode
+
+	// Viewport для прокрутки истории; windowHeight - высота окна терминала
+	viewport     viewport.Model
+	windowHeight int
 
 	// Спиннер для индикатора загрузки
 	spinner spinner.Model
@@ -158,6 +222,8 @@ type Model struct {
 	status       AppStatus
 	errorMsg     string
 	streamingBuf strings.Builder
+	// notice - многострочный вывод команд, показывается под историей
+	notice string
 
 	// Контекст для отмены запроса
 	ctx    context.Context
@@ -180,6 +246,14 @@ func WithLogger(log *logger.Logger) ModelOption {
 	}
 }
 
+// WithClientOptions добавляет опции HTTP клиента модели (например, транспорт кассет)
+func WithClientOptions(opts ...client.ClientOption) ModelOption {
+	return func(m *Model) {
+		m.clientOpts = append(m.clientOpts, opts...)
+		m.client = m.newClient()
+	}
+}
+
 // NewModel создаёт новую модель приложения
 func NewModel(appConfig *config.Config, opts ...ModelOption) *Model {
 	ctx, cancel := context.WithCancel(context.Background())
@@ -196,17 +270,26 @@ func NewModel(appConfig *config.Config, opts ...ModelOption) *Model {
 	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
 
 	model := &Model{
-		appConfig:  appConfig,
-		runtime:    runtimeConfig,
-		client:     client.NewClient(appConfig.Server.Address, appConfig.Server.APIEndpoint, client.WithLogger(log)),
-		history:    chat.NewChatHistory(runtimeConfig.SystemPrompt),
-		input:      "",
-		viewport:   vp,
-		spinner:    s,
-		status:     StatusIdle,
-		ctx:        ctx,
-		cancel:     cancel,
-		logger:     log,
+		appConfig: appConfig,
+		runtime:   runtimeConfig,
+		history:   chat.NewChatHistory(runtimeConfig.SystemPrompt),
+		viewport:  vp,
+		spinner:   s,
+		status:    StatusIdle,
+		ctx:       ctx,
+		cancel:    cancel,
+		logger:    log,
+
+		keys:       config.NewKeyResolver(),
+		tokenizers: newTokenizerLoader(appConfig, log),
+		usage:      usage.NewTracker("", appConfig.Pricing),
+
+		inputHistory: inputhistory.New("", appConfig.UI.HistoryLimit),
+	}
+	model.client = model.newClient()
+
+	if appConfig.Model.EnableTools {
+		model.tools = NewDefaultToolRegistry()
 	}
 
 	// Применяем опции
@@ -236,7 +319,10 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		return m.handleKeyPress(msg)
+		model, cmd := m.handleKeyPress(msg)
+		// Поле ввода растёт вместе с текстом - пересчитываем высоту истории
+		m.layout()
+		return model, cmd
 
 	case tea.WindowSizeMsg:
 		return m.handleWindowSize(msg)
@@ -244,6 +330,18 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case StreamMsg:
 		return m.handleStreamMsg(msg)
 
+	case ToolResultsMsg:
+		return m.handleToolResults(msg)
+
+	case ContextTrimmedMsg:
+		return m.handleContextTrimmed(msg)
+
+	case ModelsMsg:
+		return m.handleModelsMsg(msg)
+
+	case CompareStreamMsg:
+		return m.handleCompareStreamMsg(msg)
+
 	case StreamTickMsg:
 		// Обновление UI во время стриминга
 		if m.status == StatusStreaming {
@@ -279,42 +377,76 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m *Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	m.logger.Debug("Key pressed", "key", msg.String())
 
+	if m.search != nil {
+		return m.handleSearchKey(msg)
+	}
+	if m.picker != nil {
+		return m.handlePickerKey(msg)
+	}
+
 	switch msg.String() {
 	case "ctrl+c", "ctrl+d":
 		// Прерывание генерации или выход
-		if m.status == StatusStreaming {
-			m.logger.Info("Cancelling stream generation")
+		if m.status == StatusStreaming || m.status == StatusToolCall || m.status == StatusSummarizing {
+			m.logger.InfoContext(m.requestContext(), "Cancelling stream generation")
 			m.cancel()
+			m.cancelCompare()
+			// После отмены клиент присылает Done - он не должен попасть в историю
+			m.streamMsgChan = nil
+			m.finishTurn()
 			m.status = StatusIdle
 			m.streamingBuf.Reset()
-			return m, nil
+			return m, m.updateViewportContent()
 		}
 		m.logger.Info("User requested exit")
 		return m, tea.Quit
 
+	case "ctrl+p":
+		// Предыдущий вариант ответа
+		return m.cycleBranch(-1)
+
+	case "ctrl+n":
+		// Следующий вариант ответа
+		return m.cycleBranch(1)
+
 	case "enter":
-		if m.input == "" {
+		// Пока идёт запрос (ответ, инструменты, сжатие истории), новый ход не начинается
+		if m.isBusy() || m.input.Empty() {
 			return m, nil
 		}
 
+		m.rememberInput(m.input.Value())
+
 		// Проверяем команды
-		if strings.HasPrefix(m.input, "/") {
-			m.logger.Debug("Processing command", "command", m.input)
-			return m.handleCommand(m.input)
+		if input := m.input.Value(); strings.HasPrefix(input, "/") {
+			m.logger.Debug("Processing command", "command", input)
+			return m.handleCommand(strings.TrimSpace(input))
 		}
 
 		// Отправляем сообщение
-		m.logger.Debug("Sending message", "input", m.input)
+		m.logger.Debug("Sending message", "input", m.input.Value())
 		return m.sendMessage()
 
-	case "up", "k":
-		// Скролл вверх
-		m.viewport.ScrollUp(1)
+	case "up":
+		// Курсор на строку выше, с первой строки - предыдущая запись истории ввода.
+		// Во время ответа поле ввода неактивно, и стрелки прокручивают историю сообщений.
+		if m.isBusy() || !m.input.Update(msg) && !m.recallHistory(-1) {
+			m.viewport.ScrollUp(1)
+		}
 		return m, nil
 
-	case "down", "j":
-		// Скролл вниз
-		m.viewport.ScrollDown(1)
+	case "down":
+		// Курсор на строку ниже, с последней строки - следующая запись истории ввода
+		if m.isBusy() || !m.input.Update(msg) && !m.recallHistory(1) {
+			m.viewport.ScrollDown(1)
+		}
+		return m, nil
+
+	case "ctrl+r":
+		// Поиск по истории ввода
+		if !m.isBusy() {
+			m.startSearch()
+		}
 		return m, nil
 
 	case "pgup":
@@ -327,20 +459,25 @@ func (m *Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.viewport.HalfPageDown()
 		return m, nil
 
-	case "home":
+	case "ctrl+home":
 		// В начало
 		m.viewport.GotoTop()
 		return m, nil
 
-	case "end":
+	case "ctrl+end":
 		// В конец
 		m.viewport.GotoBottom()
 		return m, nil
 
 	default:
-		// Ввод текста
-		if m.status != StatusStreaming {
-			m.input, _ = updateInput(m.input, msg)
+		// Ввод и редактирование текста
+		if !m.isBusy() {
+			before := m.input.Value()
+			m.input.Update(msg)
+			if m.input.Value() != before {
+				// Изменённый текст становится новым черновиком для листания истории
+				m.historyNav = historyNav{}
+			}
 		}
 		return m, nil
 	}
@@ -355,32 +492,60 @@ func (m *Model) handleWindowSize(msg tea.WindowSizeMsg) (tea.Model, tea.Cmd) {
 	// - 2 строки: статус
 	// - 1 строка: пустая
 	// - 1 строка: спиннер
-	// - 1 строка: поле ввода
+	// - 1 строка: поле ввода (растёт вместе с текстом, см. layout)
 	// - 1 строка: подсказки
 	// Итого: -8 строк
 	m.viewport.Width = msg.Width
-	m.viewport.Height = msg.Height - 8
+	m.windowHeight = msg.Height
+	m.layout()
 
 	m.logger.Debug("Window size updated", "width", msg.Width, "height", msg.Height)
 	return m, m.updateViewportContent()
 }
 
+// layout пересчитывает высоту истории: каждая дополнительная строка поля ввода
+// отнимает строку у viewport
+func (m *Model) layout() {
+	if m.windowHeight == 0 {
+		// Размер окна ещё неизвестен
+		return
+	}
+	atBottom := m.viewport.AtBottom()
+	m.viewport.Height = m.windowHeight - 8 - (len(m.inputLines()) - 1)
+	if atBottom {
+		m.viewport.GotoBottom()
+	}
+}
+
 // handleStreamMsg обрабатывает полученный чанк от LLM
 func (m *Model) handleStreamMsg(msg StreamMsg) (tea.Model, tea.Cmd) {
+	if msg.source != nil && msg.source != m.streamMsgChan {
+		// Чанк отменённого или уже завершённого запроса
+		return m, nil
+	}
+
 	if msg.Err != nil {
-		m.logger.Error("Stream message error", "error", msg.Err)
+		m.logger.ErrorContext(m.requestContext(), "Stream message error", "error", msg.Err)
+		m.finishTurn()
 		m.status = StatusError
 		m.errorMsg = msg.Err.Error()
 		return m, nil
 	}
 
 	if msg.Done {
+		if len(msg.ToolCalls) > 0 && m.tools != nil {
+			// Модель запросила инструменты - ответ продолжится после их выполнения
+			return m.handleToolCalls(m.streamingBuf.String(), msg.ToolCalls)
+		}
+
 		// Генерация завершена
-		m.logger.Info("Stream generation completed", "response_length", m.streamingBuf.Len())
+		m.logger.InfoContext(m.requestContext(), "Stream generation completed", "response_length", m.streamingBuf.Len())
+		m.finishTurn()
 		m.status = StatusIdle
 		// Сохраняем полный ответ в историю
 		m.history.AddAssistant(m.streamingBuf.String())
 		m.streamingBuf.Reset()
+		m.saveSession()
 		// Прокручиваем вниз
 		m.viewport.GotoBottom()
 		return m, m.updateViewportContent()
@@ -414,6 +579,7 @@ func (m *Model) handleCommand(cmd string) (tea.Model, tea.Cmd) {
 
 	command := strings.TrimPrefix(parts[0], "/")
 	m.logger.Info("Executing command", "command", command, "args", parts[1:])
+	m.notice = ""
 
 	switch command {
 	case "set":
@@ -431,28 +597,90 @@ func (m *Model) handleCommand(cmd string) (tea.Model, tea.Cmd) {
 		} else {
 			m.errorMsg = fmt.Sprintf("Установлено: %s = %s", param, value)
 			m.status = StatusIdle
+			if param == "model" {
+				// Неизвестное имя не запрещается: список сервера может быть неполным
+				if warning := m.checkModel(value); warning != "" {
+					m.errorMsg += " (предупреждение: " + warning + ")"
+				}
+			}
 			// Применяем изменения к истории
 			if param == "system" || param == "system_prompt" {
 				m.history.SetSystemPrompt(value)
 			}
+			if param == "render" {
+				m.updateViewportContent()
+			}
 		}
 
 	case "clear", "cls":
 		m.logger.Info("Clearing chat history")
 		m.history.Clear(m.runtime.SystemPrompt)
+		if m.compare != nil {
+			m.compare.clear(m.runtime.SystemPrompt)
+		}
+		// Сохранённая сессия не перезаписывается, очищенный диалог станет новой сессией
+		m.session = nil
+		m.usage.SetSession(usage.Report{})
 		m.viewport.GotoTop()
 		m.errorMsg = "История очищена"
 		m.status = StatusIdle
 		return m, m.updateViewportContent()
 
 	case "help", "h":
-		m.errorMsg = "Команды: /set <param> <value>, /clear, /help, /config, /save, /stream"
+		m.errorMsg = "Команды: /set <param> <value>, /clear, /help, /config, /save, /stream, /tools, " +
+			"/edit <n> <text>, /regen, /sessions, /load <id>, /new, /rename <title>, /delete [id], /usage, /trace, /profile [name], /models [filter], /compare <a> <b> [c], /attach <path>"
+		m.status = StatusIdle
+
+	case "edit":
+		return m.editMessage(parts[1:])
+
+	case "regen", "regenerate":
+		return m.regenerate()
+
+	case "profile", "profiles":
+		return m.handleProfileCommand(parts[1:])
+
+	case "models":
+		return m.handleModelsCommand(parts[1:])
+
+	case "compare":
+		return m.handleCompareCommand(parts[1:])
+
+	case "attach":
+		// Путь может содержать пробелы
+		return m.handleAttachCommand(strings.TrimSpace(strings.TrimPrefix(cmd, parts[0])))
+
+	case "sessions", "load", "new", "rename", "delete":
+		m.handleSessionCommand(command, parts[1:])
+		m.input.Reset()
+		return m, m.updateViewportContent()
+
+	case "tools":
+		if m.tools == nil || m.tools.Len() == 0 {
+			m.errorMsg = "Инструменты не подключены (model.enable_tools)"
+		} else {
+			names := make([]string, 0, m.tools.Len())
+			for _, tool := range m.tools.Definitions() {
+				names = append(names, tool.Function.Name)
+			}
+			m.errorMsg = "Инструменты: " + strings.Join(names, ", ")
+		}
 		m.status = StatusIdle
 
 	case "config", "cfg":
 		m.errorMsg = m.runtime.String()
 		m.status = StatusIdle
 
+	case "usage":
+		m.showUsage()
+		m.input.Reset()
+		return m, m.updateViewportContent()
+
+	case "trace":
+		m.showTrace()
+		m.input.Reset()
+		return m, m.updateViewportContent()
+
 	case "save":
 		// Сохраняем текущие настройки в файл
 		path := "config.json"
@@ -486,49 +714,65 @@ func (m *Model) handleCommand(cmd string) (tea.Model, tea.Cmd) {
 		m.logger.Error("Unknown command", "command", command)
 	}
 
-	m.input = ""
+	m.input.Reset()
 	return m, nil
 }
 
 // sendMessage отправляет сообщение пользователя к LLM
 func (m *Model) sendMessage() (tea.Model, tea.Cmd) {
-	userInput := m.input
+	userInput := m.input.Value()
 	m.logger.Info("Sending user message", "input", userInput, "length", len(userInput))
+	if m.compare != nil {
+		return m.sendCompare()
+	}
 
-	// Добавляем сообщение в историю
-	m.history.AddUser(userInput)
-	m.input = ""
+	// Добавляем сообщение в историю вместе с вложениями
+	m.history.AddUserParts(userInput, m.takeAttachments())
+	m.input.Reset()
 	m.status = StatusSending
 	m.errorMsg = ""
+	m.notice = ""
+	m.beginTurn()
 
 	// Сразу обновляем viewport чтобы показать сообщение
 	m.viewport.GotoBottom()
 
-	// Создаём запрос
+	// Возвращаем команду для стриминга
+	return m, tea.Sequence(m.updateViewportContent(), m.prepareRequest())
+}
+
+// buildRequest создаёт запрос из текущей истории и настроек
+func (m *Model) buildRequest() *client.ChatRequest {
+	return m.requestFor(m.runtime, m.history)
+}
+
+// requestFor создаёт запрос из истории history с настройками runtime
+func (m *Model) requestFor(runtime *config.RuntimeConfig, history *chat.ChatHistory) *client.ChatRequest {
 	req := &client.ChatRequest{
-		Model:       m.runtime.Model,
-		Messages:    m.history.GetMessages(),
-		Stream:      m.runtime.Stream,
-		Temperature: m.runtime.Temperature,
-		TopP:        m.runtime.TopP,
+		Model:       runtime.Model,
+		Messages:    history.GetMessages(),
+		Stream:      runtime.Stream,
+		Temperature: runtime.Temperature,
+		TopP:        runtime.TopP,
+	}
+	if m.tools != nil && m.tools.Len() > 0 {
+		req.Tools = m.tools.Definitions()
 	}
 	m.logger.Debug("Built chat request",
 		"model", req.Model,
 		"messages", len(req.Messages),
 		"temp", req.Temperature,
 		"stream", req.Stream,
+		"tools", len(req.Tools),
 	)
-
-	// Возвращаем команду для стриминга
-	return m, tea.Sequence(m.updateViewportContent(), m.startStreaming(req))
+	return req
 }
 
 // startStreaming запускает потоковое получение ответа
 func (m *Model) startStreaming(req *client.ChatRequest) tea.Cmd {
-	m.logger.Info("Starting stream request")
-
-	// Создаём новый контекст для этого запроса
-	ctx, cancel := context.WithCancel(context.Background())
+	// Контекст запроса несёт идентификатор и трассировку хода
+	ctx, cancel := context.WithCancel(m.requestContext())
+	m.logger.InfoContext(ctx, "Starting stream request")
 	m.cancel = cancel
 
 	// Сохраняем канал стрима в модели
@@ -542,7 +786,7 @@ func (m *Model) startStreaming(req *client.ChatRequest) tea.Cmd {
 	go func() {
 		for chunk := range m.streamChan {
 			if chunk.Done {
-				streamMsgChan <- StreamMsg{Done: true}
+				streamMsgChan <- StreamMsg{Done: true, ToolCalls: chunk.ToolCalls}
 				close(streamMsgChan)
 				return
 			}
@@ -571,6 +815,7 @@ func readStreamMsg(ch <-chan StreamMsg) tea.Cmd {
 		if !ok {
 			return nil
 		}
+		msg.source = ch
 		return msg
 	}
 }
@@ -587,6 +832,10 @@ type StreamTickMsg struct{}
 
 // updateViewportContent обновляет содержимое viewport
 func (m *Model) updateViewportContent() tea.Cmd {
+	if m.status != StatusStreaming {
+		// Во время стриминга история растёт на каждом чанке - пересчитываем после ответа
+		m.refreshContextUsage()
+	}
 	content := m.renderHistoryContent()
 	m.viewport.SetContent(content)
 	return nil
@@ -594,6 +843,10 @@ func (m *Model) updateViewportContent() tea.Cmd {
 
 // renderHistoryContent рендерит историю сообщений как строку
 func (m *Model) renderHistoryContent() string {
+	if m.compare != nil {
+		return m.renderCompareContent()
+	}
+
 	var b strings.Builder
 
 	messages := m.history.GetDisplayMessages()
@@ -621,6 +874,13 @@ func (m *Model) renderHistoryContent() string {
 		}
 	}
 
+	// Вывод последней команды (например, список сессий)
+	if m.notice != "" {
+		b.WriteString("\n")
+		b.WriteString(statusStyle.Render(m.notice))
+		b.WriteString("\n")
+	}
+
 	return b.String()
 }
 
@@ -628,7 +888,7 @@ func (m *Model) renderHistoryContent() string {
 func (m *Model) View() string {
 	var b strings.Builder
 
-	m.logger.Debug("View rendering", "status", m.status, "input_len", len(m.input))
+	m.logger.Debug("View rendering", "status", m.status, "input_len", len(m.input.Value()))
 
 	// Заголовок
 	b.WriteString(titleStyle.Render(AppName))
@@ -652,7 +912,7 @@ func (m *Model) View() string {
 
 	// Подсказки
 	b.WriteString("\n")
-	b.WriteString(helpStyle.Render("↑↓/j/k: скролл | PgUp/PgDn: страница | Home/End: начало/конец | Enter: отправить | /help: команды | Ctrl+C: выход"))
+	b.WriteString(helpStyle.Render("↑↓: история ввода | PgUp/PgDn: скролл | Ctrl+R: поиск | Alt+Enter: новая строка | Ctrl+P/N: варианты | Enter: отправить | /help: команды | Ctrl+C: выход"))
 
 	result := b.String()
 	m.logger.Debug("View rendered", "bytes", len(result))
@@ -664,10 +924,23 @@ func (m *Model) renderStatus() string {
 	switch m.status {
 	case StatusError:
 		return statusErrorStyle.Render(fmt.Sprintf("✗ %s: %s", m.status, m.errorMsg))
-	case StatusSending, StatusStreaming:
+	case StatusSending, StatusStreaming, StatusToolCall, StatusSummarizing:
 		return statusStreamingStyle.Render(m.status.String())
 	default:
-		return statusStyle.Render(fmt.Sprintf("○ %s | %s", m.status, m.runtime.String()))
+		status := fmt.Sprintf("○ %s | %s", m.status, m.runtime.String())
+		if m.appConfig.ActiveProfile != "" {
+			status = fmt.Sprintf("○ %s | %s | %s", m.status, m.appConfig.ActiveProfile, m.runtime.String())
+		}
+		if m.compare != nil {
+			status += fmt.Sprintf(" | сравнение: %d колонки", len(m.compare.columns))
+		}
+		if usage := m.renderContextUsage(); usage != "" {
+			status += " | " + usage
+		}
+		if usage := m.renderUsage(); usage != "" {
+			status += " | " + usage
+		}
+		return statusStyle.Render(status)
 	}
 }
 
@@ -685,12 +958,27 @@ func (m *Model) renderHistory() string {
 func (m *Model) renderMessagesToLines(messages []chat.Message) []string {
 	var lines []string
 
-	for _, msg := range messages {
+	contentWidth := m.getContentWidth()
+	userCount := 0
+
+	for i, msg := range messages {
 		switch msg.Role {
 		case chat.RoleUser:
-			lines = append(lines, m.renderUserMessage(msg.Content)...)
+			// Номер сообщения используется в команде /edit
+			userCount++
+			prefix := fmt.Sprintf("▸ [%d] Вы: %s", userCount, m.branchIndicator(i))
+			// Вложения показываются отметками вместо base64
+			lines = append(lines, m.formatMessage(msg.DisplayContent(), prefix, messageUserStyle, contentWidth)...)
 		case chat.RoleAssistant:
-			lines = append(lines, m.renderAssistantMessage(msg.Content)...)
+			if msg.Content != "" || len(msg.ToolCalls) == 0 {
+				prefix := "▸ AI: " + m.branchIndicator(i)
+				lines = append(lines, m.formatAssistantMessage(msg.Content, prefix, false)...)
+			}
+			for _, call := range msg.ToolCalls {
+				lines = append(lines, m.renderToolMessage("⚙ "+call.Function.Name+"("+call.Function.Arguments+")")...)
+			}
+		case chat.RoleTool:
+			lines = append(lines, m.renderToolMessage("↳ "+msg.Name+": "+msg.Content)...)
 		}
 	}
 
@@ -703,10 +991,15 @@ func (m *Model) renderUserMessage(content string) []string {
 	return m.formatMessage(content, "▸ Вы: ", messageUserStyle, contentWidth)
 }
 
-// renderAssistantMessage форматирует сообщение от ассистента
+// renderAssistantMessage форматирует ответ ассистента, который ещё дописывается
 func (m *Model) renderAssistantMessage(content string) []string {
+	return m.formatAssistantMessage(content, "▸ AI: ", true)
+}
+
+// renderToolMessage форматирует вызов инструмента или его результат
+func (m *Model) renderToolMessage(content string) []string {
 	contentWidth := m.getContentWidth()
-	return m.formatMessage(content, "▸ AI: ", messageAssistantStyle, contentWidth)
+	return m.formatMessage(content, "  ", messageToolStyle, contentWidth)
 }
 
 // formatMessage форматирует текст сообщения с префиксом и переносом строк
@@ -812,16 +1105,49 @@ func wrapText(text string, maxWidth int) []string {
 // renderInput рендерит поле ввода
 func (m *Model) renderInput() string {
 	style := inputStyle
-	if m.status == StatusStreaming {
+	if m.isBusy() {
 		style = style.Foreground(lipgloss.Color("241"))
 	}
 
+	return style.Render(strings.Join(m.inputLines(), "\n"))
+}
+
+// inputLines возвращает строки поля ввода с приглашением
+func (m *Model) inputLines() []string {
+	if m.search != nil {
+		return m.searchLines()
+	}
+	if m.picker != nil {
+		return m.pickerLines()
+	}
+
 	prompt := "> "
-	if m.status == StatusStreaming {
+	if m.isBusy() {
 		prompt = "│ "
 	}
 
-	return style.Render(prompt + m.input + cursor())
+	lines := m.input.View(m.inputWidth(), !m.isBusy())
+	for i := range lines {
+		if i == 0 {
+			lines[i] = prompt + lines[i]
+		} else {
+			lines[i] = "  " + lines[i]
+		}
+	}
+	if len(m.attachments) > 0 {
+		// Отметка вложений, которые уйдут со следующим сообщением
+		lines = append([]string{"📎 " + m.attachmentSummary()}, lines...)
+	}
+	return lines
+}
+
+// inputWidth возвращает ширину текста в поле ввода: окно минус рамка, отступы и приглашение.
+// 0 - ширина окна ещё неизвестна, строки не переносятся.
+func (m *Model) inputWidth() int {
+	if m.viewport.Width == 0 {
+		return 0
+	}
+	return max(m.viewport.Width-6, 10)
 }
 
 // cursor возвращает символ курсора
@@ -829,24 +1155,6 @@ func cursor() string {
 	return "█"
 }
 
-// updateInput обновляет строку ввода при нажатии клавиш
-func updateInput(input string, msg tea.KeyMsg) (string, tea.Cmd) {
-	switch msg.Type {
-	case tea.KeyRunes:
-		return input + string(msg.Runes), nil
-	case tea.KeySpace:
-		return input + " ", nil
-	case tea.KeyBackspace:
-		if len(input) > 0 {
-			return input[:len(input)-1], nil
-		}
-	case tea.KeyDelete:
-		// Простая реализация - ничего не делаем
-		return input, nil
-	}
-	return input, nil
-}
-
 // GetHistory возвращает историю диалога
 func (m *Model) GetHistory() *chat.ChatHistory {
 	return m.history
@@ -4,7 +4,9 @@ import (
 	"strings"
 	"testing"
 
+	"llm-client/internal/chat"
 	"llm-client/internal/config"
+	"llm-client/internal/fakellm"
 	"llm-client/internal/logger"
 )
 
@@ -18,6 +20,7 @@ func TestAppStatus_String(t *testing.T) {
 		{"sending", StatusSending, "Отправка..."},
 		{"streaming", StatusStreaming, "Печатает..."},
 		{"error", StatusError, "Ошибка"},
+		{"tool call", StatusToolCall, "Вызов инструментов..."},
 	}
 
 	for _, tt := range tests {
@@ -503,7 +506,7 @@ func TestModel_cursor(t *testing.T) {
 func TestModel_renderInput(t *testing.T) {
 	cfg := config.DefaultConfig()
 	m := NewModel(cfg)
-	m.input = "test input"
+	m.input.SetValue("test input")
 
 	rendered := m.renderInput()
 
@@ -554,3 +557,42 @@ func TestModel_handleCommand_Config(t *testing.T) {
 		t.Errorf("Config output should contain model info")
 	}
 }
+
+// drainStream передаёт модели все сообщения текущего стрима
+func drainStream(m *Model) {
+	ch := m.streamMsgChan
+	for msg := readStreamMsg(ch)(); msg != nil; msg = readStreamMsg(ch)() {
+		m.handleStreamMsg(msg.(StreamMsg))
+	}
+}
+
+func TestModel_sendMessage(t *testing.T) {
+	server := fakellm.New(t, fakellm.WithReplies(fakellm.Tokens("Привет", ", ", "мир"), fakellm.ServerError()))
+	cfg := config.DefaultConfig()
+	cfg.Server.Address = server.URL
+	m := NewModel(cfg, WithLogger(logger.NewLogger(logger.Config{Enabled: false})))
+
+	m.input.SetValue("Привет")
+	m.sendMessage()
+	drainStream(m)
+
+	if m.status != StatusIdle || !m.input.Empty() {
+		t.Errorf("status = %v, input = %q, want idle with empty input", m.status, m.input.Value())
+	}
+	messages := m.history.GetMessages()
+	if last := messages[len(messages)-1]; last.Role != chat.RoleAssistant || last.Content != "Привет, мир" {
+		t.Errorf("history = %+v, want streamed answer last", messages)
+	}
+	sent := server.LastRequest()
+	if sent.Model != "llama3" || !sent.Stream || sent.Param("temperature") != 0.7 || sent.Contents() != "You are a helpful assistant.,Привет" {
+		t.Errorf("request = %+v", sent)
+	}
+
+	m.input.SetValue("Ещё")
+	m.sendMessage()
+	drainStream(m)
+	if m.status != StatusError || m.errorMsg == "" {
+		t.Errorf("status = %v, error = %q, want server error shown", m.status, m.errorMsg)
+	}
+	server.AssertRequestCount(2)
+}
@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"llm-client/internal/client"
+	"llm-client/internal/usage"
+)
+
+// WithUsageTracker устанавливает учёт расхода токенов (по умолчанию - только в памяти)
+func WithUsageTracker(tracker *usage.Tracker) ModelOption {
+	return func(m *Model) {
+		m.usage = tracker
+	}
+}
+
+// recordUsage учитывает расход запроса; вызывается клиентом из горутины запроса,
+// в том числе для запросов суммаризации
+func (m *Model) recordUsage(model string, u client.Usage) {
+	entry, err := m.usage.Record(model, u.PromptTokens, u.CompletionTokens, u.TotalTokens)
+	if err != nil {
+		m.logger.Warn("Failed to save usage", "error", err)
+	}
+	m.logger.Debug("Usage recorded", "model", model, "total_tokens", entry.TotalTokens, "cost", entry.Cost)
+}
+
+// renderUsage возвращает расход текущей сессии для строки статуса
+func (m *Model) renderUsage() string {
+	session := m.usage.Session()
+	if session.Requests == 0 {
+		return ""
+	}
+	text := fmt.Sprintf("Токены: %d", session.TotalTokens)
+	if session.Unpriced < session.Requests {
+		text += " | " + m.formatCost(session.Totals)
+	}
+	return text
+}
+
+// showUsage выводит расход за сессию и за сегодня с разбивкой по моделям (/usage)
+func (m *Model) showUsage() {
+	var b strings.Builder
+	writeUsageReport(&b, "Сессия", m.usage.Session(), m)
+
+	today, err := m.usage.Today()
+	if err != nil {
+		m.logger.Error("Failed to read usage", "error", err)
+		fmt.Fprintf(&b, "Сегодня: ошибка чтения статистики: %v\n", err)
+	} else {
+		writeUsageReport(&b, "Сегодня", today, m)
+	}
+
+	m.notice = strings.TrimRight(b.String(), "\n")
+	m.errorMsg = "Расход токенов"
+	m.status = StatusIdle
+	m.viewport.GotoBottom()
+}
+
+// writeUsageReport выводит итоги отчёта и строку на каждую модель
+func writeUsageReport(b *strings.Builder, title string, r usage.Report, m *Model) {
+	if r.Requests == 0 {
+		fmt.Fprintf(b, "%s: запросов не было\n", title)
+		return
+	}
+	fmt.Fprintf(b, "%s: %s\n", title, m.formatTotals(r.Totals))
+	for _, name := range r.ModelNames() {
+		fmt.Fprintf(b, "  %s: %s\n", name, m.formatTotals(r.Models[name]))
+	}
+}
+
+// formatTotals форматирует расход: запросы, токены и стоимость
+func (m *Model) formatTotals(t usage.Totals) string {
+	return fmt.Sprintf("%d запр., %d ток. (вход %d, выход %d), %s",
+		t.Requests, t.TotalTokens, t.PromptTokens, t.CompletionTokens, m.formatCost(t))
+}
+
+// formatCost форматирует стоимость; запросы к моделям без цены отмечаются отдельно
+func (m *Model) formatCost(t usage.Totals) string {
+	switch {
+	case t.Unpriced == t.Requests:
+		return "цена не задана"
+	case t.Unpriced > 0:
+		return fmt.Sprintf("%.4f %s (без цены: %d запр.)", t.Cost, m.usage.Currency(), t.Unpriced)
+	default:
+		return fmt.Sprintf("%.4f %s", t.Cost, m.usage.Currency())
+	}
+}
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"llm-client/internal/client"
+	"llm-client/internal/config"
+	"llm-client/internal/logger"
+	"llm-client/internal/session"
+	"llm-client/internal/usage"
+)
+
+func newUsageModel(t *testing.T) *Model {
+	t.Helper()
+	cfg := config.DefaultConfig()
+	cfg.Pricing.Models = map[string]config.ModelPrice{"gpt-4o": {Prompt: 2, Completion: 8}}
+	return NewModel(cfg,
+		WithLogger(logger.NewLogger(logger.Config{Enabled: false})),
+		WithUsageTracker(usage.NewTracker("", cfg.Pricing)),
+	)
+}
+
+func TestModel_UsageCommand(t *testing.T) {
+	m := newUsageModel(t)
+	m.recordUsage("gpt-4o", client.Usage{PromptTokens: 1000, CompletionTokens: 500, TotalTokens: 1500})
+	m.recordUsage("llama3", client.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15})
+
+	if status := m.renderStatus(); !strings.Contains(status, "Токены: 1515 | 0.0060 USD") {
+		t.Errorf("status line should show session usage, got %q", status)
+	}
+
+	m.handleCommand("/usage")
+	for _, want := range []string{
+		"Сессия: 2 запр., 1515 ток.",
+		"gpt-4o: 1 запр., 1500 ток. (вход 1000, выход 500), 0.0060 USD",
+		"llama3: 1 запр., 15 ток. (вход 10, выход 5), цена не задана",
+		"Сегодня: 2 запр.",
+	} {
+		if !strings.Contains(m.notice, want) {
+			t.Errorf("/usage output should contain %q, got:\n%s", want, m.notice)
+		}
+	}
+
+	m.handleCommand("/clear")
+	if m.renderUsage() != "" {
+		t.Errorf("/clear should reset session usage")
+	}
+}
+
+func TestModel_UsageSession(t *testing.T) {
+	m := newUsageModel(t)
+	m.sessions = session.NewStore(t.TempDir())
+	m.history.AddUser("hi")
+	m.history.AddAssistant("hello")
+	m.recordUsage("gpt-4o", client.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15})
+	m.saveSession()
+
+	loaded, err := m.sessions.Load(m.session.ID)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.Usage == nil || loaded.Usage.TotalTokens != 15 {
+		t.Fatalf("saved session usage = %+v", loaded.Usage)
+	}
+
+	restored := newUsageModel(t)
+	restored.applySession(loaded)
+	if got := restored.usage.Session().TotalTokens; got != 15 {
+		t.Errorf("restored session usage = %d, want 15", got)
+	}
+}
@@ -0,0 +1,256 @@
+// Package usage ведёт учёт расхода токенов и стоимости запросов
+// за текущую сессию и по дням (с сохранением на диск).
+package usage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"llm-client/internal/config"
+	apperrors "llm-client/internal/errors"
+)
+
+// dayLayout - формат ключа дня в файле учёта
+const dayLayout = "2006-01-02"
+
+// Totals - суммарный расход
+type Totals struct {
+	Requests         int     `json:"requests"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	Cost             float64 `json:"cost"`
+	// Unpriced - запросы к моделям, для которых не задана цена (их стоимость не учтена)
+	Unpriced int `json:"unpriced,omitempty"`
+}
+
+// Add добавляет расход к итогам
+func (t *Totals) Add(other Totals) {
+	t.Requests += other.Requests
+	t.PromptTokens += other.PromptTokens
+	t.CompletionTokens += other.CompletionTokens
+	t.TotalTokens += other.TotalTokens
+	t.Cost += other.Cost
+	t.Unpriced += other.Unpriced
+}
+
+// Report - итоги с разбивкой по моделям
+type Report struct {
+	Totals
+	Models map[string]Totals `json:"models,omitempty"`
+}
+
+// Add учитывает расход модели
+func (r *Report) Add(model string, t Totals) {
+	r.Totals.Add(t)
+	if r.Models == nil {
+		r.Models = make(map[string]Totals)
+	}
+	total := r.Models[model]
+	total.Add(t)
+	r.Models[model] = total
+}
+
+// ModelNames возвращает имена моделей отчёта в алфавитном порядке
+func (r Report) ModelNames() []string {
+	names := make([]string, 0, len(r.Models))
+	for name := range r.Models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// clone возвращает копию отчёта, не разделяющую карту моделей
+func (r Report) clone() Report {
+	if r.Models == nil {
+		return r
+	}
+	models := make(map[string]Totals, len(r.Models))
+	for name, t := range r.Models {
+		models[name] = t
+	}
+	r.Models = models
+	return r
+}
+
+// file - содержимое файла учёта
+type file struct {
+	Days map[string]Report `json:"days"`
+}
+
+// Tracker накапливает расход за сессию и по дням. Безопасен для параллельного использования.
+type Tracker struct {
+	mu      sync.Mutex
+	path    string
+	pricing config.PricingConfig
+	session Report
+	today   Report
+	day     string
+	now     func() time.Time
+}
+
+// NewTracker создаёт учёт расхода; path - файл дневной статистики ("" - только в памяти)
+func NewTracker(path string, pricing config.PricingConfig) *Tracker {
+	return &Tracker{path: path, pricing: pricing, now: time.Now}
+}
+
+// DefaultPath возвращает путь к файлу учёта по умолчанию (~/.llm-client/usage.json)
+func DefaultPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", apperrors.NewInternalError("HOME_DIR_ERROR", "failed to determine home directory", err)
+	}
+	return filepath.Join(homeDir, ".llm-client", "usage.json"), nil
+}
+
+// Currency возвращает валюту цен
+func (t *Tracker) Currency() string {
+	return t.pricing.Currency
+}
+
+// Priced сообщает, задана ли цена модели
+func (t *Tracker) Priced(model string) bool {
+	_, ok := t.pricing.Price(model)
+	return ok
+}
+
+// Record учитывает расход одного запроса и сохраняет дневную статистику.
+// Возвращает учтённый расход; ошибка означает только сбой записи файла.
+func (t *Tracker) Record(model string, promptTokens, completionTokens, totalTokens int) (Totals, error) {
+	entry := Totals{
+		Requests:         1,
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      totalTokens,
+	}
+	if entry.TotalTokens == 0 {
+		entry.TotalTokens = promptTokens + completionTokens
+	}
+	if cost, ok := t.pricing.Cost(model, promptTokens, completionTokens); ok {
+		entry.Cost = cost
+	} else {
+		entry.Unpriced = 1
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.session.Add(model, entry)
+
+	day := t.now().Format(dayLayout)
+	if t.path == "" {
+		if day != t.day {
+			t.day, t.today = day, Report{}
+		}
+		t.today.Add(model, entry)
+		return entry, nil
+	}
+
+	// Файл перечитывается перед записью, чтобы не потерять расход других запущенных клиентов
+	days, err := t.load()
+	if err != nil {
+		return entry, err
+	}
+	today := days[day]
+	today.Add(model, entry)
+	days[day] = today
+	t.day, t.today = day, today.clone()
+
+	return entry, t.save(days)
+}
+
+// Session возвращает расход текущей сессии
+func (t *Tracker) Session() Report {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.session.clone()
+}
+
+// SetSession заменяет расход сессии (при загрузке сохранённого диалога)
+func (t *Tracker) SetSession(r Report) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.session = r.clone()
+}
+
+// Today возвращает расход за текущий день
+func (t *Tracker) Today() (Report, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	day := t.now().Format(dayLayout)
+	if t.path == "" {
+		if day != t.day {
+			return Report{}, nil
+		}
+		return t.today.clone(), nil
+	}
+
+	days, err := t.load()
+	if err != nil {
+		return Report{}, err
+	}
+	t.day, t.today = day, days[day].clone()
+	return t.today.clone(), nil
+}
+
+// load читает дневную статистику; отсутствующий файл означает пустую статистику
+func (t *Tracker) load() (map[string]Report, error) {
+	data, err := os.ReadFile(t.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return make(map[string]Report), nil
+		}
+		return nil, apperrors.NewInternalError("READ_ERROR", "failed to read usage file", err).
+			WithContext("path", t.path)
+	}
+
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, apperrors.NewInternalError("PARSE_ERROR", "failed to parse usage file", err).
+			WithContext("path", t.path)
+	}
+	if f.Days == nil {
+		f.Days = make(map[string]Report)
+	}
+	return f.Days, nil
+}
+
+// save атомарно записывает дневную статистику
+func (t *Tracker) save(days map[string]Report) error {
+	data, err := json.MarshalIndent(file{Days: days}, "", "  ")
+	if err != nil {
+		return apperrors.NewInternalError("MARSHAL_ERROR", "failed to marshal usage", err)
+	}
+
+	dir := filepath.Dir(t.path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return apperrors.NewInternalError("MKDIR_ERROR", "failed to create usage directory", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(t.path)+".*.tmp")
+	if err != nil {
+		return apperrors.NewInternalError("WRITE_ERROR", "failed to create usage file", err)
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return apperrors.NewInternalError("WRITE_ERROR", "failed to write usage file", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return apperrors.NewInternalError("WRITE_ERROR", "failed to write usage file", err)
+	}
+	if err := os.Rename(tmpPath, t.path); err != nil {
+		os.Remove(tmpPath)
+		return apperrors.NewInternalError("WRITE_ERROR", "failed to save usage file", err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package usage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"llm-client/internal/config"
+)
+
+func testPricing() config.PricingConfig {
+	return config.PricingConfig{
+		Currency: "USD",
+		Models:   map[string]config.ModelPrice{"gpt-4o": {Prompt: 2, Completion: 8}},
+	}
+}
+
+func TestTracker_Record(t *testing.T) {
+	tracker := NewTracker("", testPricing())
+
+	entry, err := tracker.Record("gpt-4o", 1000, 500, 0)
+	if err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if entry.TotalTokens != 1500 || entry.Cost != 0.006 {
+		t.Errorf("Record() = %+v, want total 1500 and cost 0.006", entry)
+	}
+	tracker.Record("llama3", 10, 5, 15)
+
+	session := tracker.Session()
+	if session.Requests != 2 || session.TotalTokens != 1515 || session.Unpriced != 1 {
+		t.Errorf("Session() = %+v", session.Totals)
+	}
+	if names := session.ModelNames(); len(names) != 2 || names[0] != "gpt-4o" {
+		t.Errorf("ModelNames() = %v", names)
+	}
+
+	// Копия отчёта не меняется при следующих запросах
+	tracker.Record("gpt-4o", 1, 1, 2)
+	if session.Models["gpt-4o"].Requests != 1 {
+		t.Errorf("Session() should return a copy")
+	}
+}
+
+func TestTracker_Today(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.json")
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	first := NewTracker(path, testPricing())
+	first.now = func() time.Time { return now }
+	first.Record("gpt-4o", 100, 50, 150)
+
+	// Второй клиент продолжает статистику того же дня
+	second := NewTracker(path, testPricing())
+	second.now = func() time.Time { return now }
+	second.Record("gpt-4o", 100, 50, 150)
+
+	today, err := first.Today()
+	if err != nil {
+		t.Fatalf("Today() error = %v", err)
+	}
+	if today.Requests != 2 || today.TotalTokens != 300 {
+		t.Errorf("Today() = %+v, want 2 requests and 300 tokens", today.Totals)
+	}
+	if first.Session().Requests != 1 {
+		t.Errorf("session totals should not include other clients")
+	}
+
+	now = now.Add(24 * time.Hour)
+	if today, _ := first.Today(); today.Requests != 0 {
+		t.Errorf("Today() on next day = %+v, want empty", today.Totals)
+	}
+
+	t.Run("corrupted file is not overwritten", func(t *testing.T) {
+		os.WriteFile(path, []byte("{"), 0600)
+		if _, err := first.Record("gpt-4o", 1, 1, 2); err == nil {
+			t.Errorf("Record() should report corrupted file")
+		}
+		if data, _ := os.ReadFile(path); string(data) != "{" {
+			t.Errorf("corrupted file was overwritten")
+		}
+	})
+}
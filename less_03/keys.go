@@ -0,0 +1,152 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+
+	"llm-client/internal/config"
+	apperrors "llm-client/internal/errors"
+)
+
+// newKeyResolver создаёт источник API ключей; пароль хранилища берётся из
+// LLM_CLIENT_KEYSTORE_PASSPHRASE или запрашивается в терминале
+func newKeyResolver() *config.KeyResolver {
+	keys := config.NewKeyResolver()
+	keys.Passphrase = func() (string, error) {
+		if passphrase, err := config.PassphraseFromEnv(); err == nil {
+			return passphrase, nil
+		}
+		return readPassword("Пароль хранилища ключей: ")
+	}
+	return keys
+}
+
+// readPassword запрашивает значение в терминале без отображения ввода.
+// Терминал открывается напрямую, поэтому stdin может быть занят pipe.
+func readPassword(prompt string) (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		if !term.IsTerminal(os.Stdin.Fd()) {
+			return "", fmt.Errorf("no terminal to read passphrase, set %s", config.EnvKeystorePassphrase)
+		}
+		tty = os.Stdin
+	} else {
+		defer tty.Close()
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	data, err := term.ReadPassword(tty.Fd())
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// runKeys выполняет подкоманду keys: управление зашифрованным хранилищем API ключей
+func runKeys(args []string) int {
+	var path string
+
+	fs := flag.NewFlagSet(appName+" keys", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: llm-client keys [flags] set <name> | list | delete <name>\n")
+		fs.PrintDefaults()
+	}
+	defaultPath, _ := config.DefaultKeystorePath()
+	fs.StringVar(&path, "keystore", defaultPath, "Keystore file")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return apperrors.ExitOK
+		}
+		return apperrors.ExitUsage
+	}
+
+	command, name := fs.Arg(0), fs.Arg(1)
+	wantArgs := map[string]int{"set": 2, "delete": 2, "list": 1}
+	if n, ok := wantArgs[command]; !ok || fs.NArg() != n {
+		fs.Usage()
+		return apperrors.ExitUsage
+	}
+
+	ks, err := openKeystore(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка открытия хранилища ключей: %v\n", err)
+		return apperrors.ExitConfig
+	}
+
+	switch command {
+	case "list":
+		for _, name := range ks.Names() {
+			fmt.Printf("keystore:%s\n", name)
+		}
+		return apperrors.ExitOK
+
+	case "set":
+		key, err := readKey(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+			return apperrors.ExitUsage
+		}
+		ks.Set(name, key)
+
+	case "delete":
+		if !ks.Delete(name) {
+			fmt.Fprintf(os.Stderr, "Ключ %q не найден\n", name)
+			return apperrors.ExitUsage
+		}
+	}
+
+	if err := ks.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка сохранения хранилища ключей: %v\n", err)
+		return apperrors.ExitConfig
+	}
+	if command == "set" {
+		fmt.Fprintf(os.Stderr, "Ключ сохранён, используйте \"api_key\": \"keystore:%s\"\n", name)
+	}
+	return apperrors.ExitOK
+}
+
+// openKeystore открывает хранилище; новое хранилище требует повторного ввода пароля
+func openKeystore(path string) (*config.Keystore, error) {
+	passphrase, err := config.PassphraseFromEnv()
+	if err != nil {
+		if passphrase, err = readPassword("Пароль хранилища ключей: "); err != nil {
+			return nil, err
+		}
+		if _, statErr := os.Stat(path); os.IsNotExist(statErr) {
+			confirm, err := readPassword("Повторите пароль: ")
+			if err != nil {
+				return nil, err
+			}
+			if confirm != passphrase {
+				return nil, fmt.Errorf("passphrases do not match")
+			}
+		}
+	}
+	return config.OpenKeystore(path, passphrase)
+}
+
+// readKey читает сохраняемый ключ из pipe или запрашивает его в терминале
+func readKey(name string) (string, error) {
+	// keys set всегда ждёт ключ, поэтому pipe читается как при -p
+	input, err := readStdin(true)
+	if err != nil {
+		return "", err
+	}
+	if input == "" {
+		if input, err = readPassword(fmt.Sprintf("Ключ %s: ", name)); err != nil {
+			return "", err
+		}
+	}
+	key := strings.TrimSpace(input)
+	if key == "" {
+		return "", fmt.Errorf("key cannot be empty")
+	}
+	return key, nil
+}
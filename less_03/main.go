@@ -4,17 +4,32 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"llm-client/internal/batch"
+	"llm-client/internal/cassette"
+	"llm-client/internal/client"
 	"llm-client/internal/config"
+	apperrors "llm-client/internal/errors"
+	"llm-client/internal/inputhistory"
 	"llm-client/internal/logger"
+	"llm-client/internal/oneshot"
+	"llm-client/internal/proxy"
+	"llm-client/internal/session"
 	"llm-client/internal/ui"
+	"llm-client/internal/usage"
 )
 
 const (
@@ -27,11 +42,19 @@ const (
 // CLIConfig хранит настройки из командной строки
 type CLIConfig struct {
 	ConfigFile   string
+	Profile      string
 	Address      string
+	Provider     string
 	Model        string
 	SystemPrompt string
 	Temperature  float64
 	TopP         float64
+	Session      string
+	Record       string
+	Replay       string
+	Prompt       string
+	PromptShort  bool
+	Output       string
 	ShowConfig   bool
 	InitConfig   bool
 	ShowVersion  bool
@@ -43,8 +66,25 @@ func main() {
 
 // run выполняет основную логику приложения и возвращает код выхода
 func run(args []string) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "batch":
+			return runBatch(args[1:])
+		case "serve":
+			return runServe(args[1:])
+		case "keys":
+			return runKeys(args[1:])
+		}
+	}
+
 	// Парсим аргументы командной строки
-	cli := parseCLIConfig(args)
+	cli, err := parseCLIConfig(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return apperrors.ExitOK
+		}
+		return apperrors.ExitUsage
+	}
 
 	// Обработка специальных флагов
 	if cli.ShowVersion {
@@ -70,12 +110,37 @@ func run(args []string) int {
 		return 0
 	}
 
+	if cli.Record != "" && cli.Replay != "" {
+		fmt.Fprintf(os.Stderr, "Флаги -record и -replay нельзя использовать вместе\n")
+		return apperrors.ExitUsage
+	}
+
+	if !oneshot.IsValidFormat(cli.Output) {
+		fmt.Fprintf(os.Stderr, "Неизвестный формат вывода %q: ожидается text, json или jsonl\n", cli.Output)
+		return apperrors.ExitUsage
+	}
+
+	// Раньше -p означало -top-p: "llm-client -p 0.9" не должен уходить в модель платным запросом,
+	// в том числе из скриптов с pipe. Числовой запрос можно передать через -prompt.
+	if cli.PromptShort && looksLikeTopP(cli.Prompt) {
+		fmt.Fprintf(os.Stderr, "Флаг -p теперь задаёт запрос, а не top_p. Для top_p используйте -top-p %s\n", cli.Prompt)
+		return apperrors.ExitUsage
+	}
+
+	// Текст из stdin включает неинтерактивный режим
+	input, err := readStdin(cli.Prompt != "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка чтения stdin: %v\n", err)
+		return apperrors.ExitInternal
+	}
+
 	// Загружаем конфигурацию
-	appConfig, err := loadConfig(cli)
+	keys := newKeyResolver()
+	appConfig, err := loadConfig(cli, keys)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Ошибка загрузки конфигурации: %v\n", err)
 		fmt.Fprintf(os.Stderr, "Используйте --help для просмотра доступных опций\n")
-		return 1
+		return apperrors.ExitCode(err)
 	}
 
 	// Инициализируем логгер
@@ -84,12 +149,46 @@ func run(args []string) int {
 
 	log.Info("Application starting",
 		"version", version,
+		"profile", appConfig.ActiveProfile,
 		"address", appConfig.Server.Address,
 		"model", appConfig.Model.Name,
 	)
 
+	clientOpts, err := cassetteOptions(cli, appConfig, log)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка открытия кассет: %v\n", err)
+		return apperrors.ExitCode(err)
+	}
+
+	if cli.Prompt != "" || input != "" {
+		return runOneShot(appConfig, log, cli, input, clientOpts)
+	}
+
+	// /profile не может запросить пароль хранилища во время работы интерфейса
+	if appConfig.UsesKeystore() && cli.Replay == "" {
+		if err := keys.Unlock(); err != nil {
+			fmt.Fprintf(os.Stderr, "Ошибка открытия хранилища ключей: %v\n", err)
+			return apperrors.ExitConfig
+		}
+	}
+
+	// Подключаем хранилище сессий
+	opts := []ui.ModelOption{
+		ui.WithLogger(log),
+		ui.WithKeyResolver(keys),
+		ui.WithUsageTracker(newUsageTracker(appConfig, log)),
+		inputHistoryOption(appConfig, log),
+		ui.WithClientOptions(clientOpts...),
+	}
+	sessionOpts, err := sessionOptions(cli, log)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка загрузки сессии: %v\n", err)
+		return 1
+	}
+	opts = append(opts, sessionOpts...)
+
 	// Создаём модель приложения с dependency injection
-	model := ui.NewModel(appConfig, ui.WithLogger(log))
+	model := ui.NewModel(appConfig, opts...)
 
 	// Создаём и запускаем TUI приложение
 	p := tea.NewProgram(
@@ -101,11 +200,14 @@ func run(args []string) int {
 
 	log.Info("Starting TUI program")
 
-	// Запускаем обработку сигналов
-	cancelCtx, cancel := context.WithCancel(context.Background())
+	// Запускаем обработку сигналов: SIGTERM закрывает интерфейс
+	ctx, cancel := setupSignalHandler(log)
 	defer cancel()
 
-	setupSignalHandler(cancelCtx, log)
+	go func() {
+		<-ctx.Done()
+		p.Quit()
+	}()
 
 	// Запускаем приложение и обрабатываем ошибки
 	if _, err := p.Run(); err != nil {
@@ -118,14 +220,220 @@ func run(args []string) int {
 	return 0
 }
 
+// runOneShot выполняет один запрос без TUI и выводит ответ в stdout.
+// Возвращает код завершения по категории ошибки.
+func runOneShot(appConfig *config.Config, log *logger.Logger, cli *CLIConfig, input string, clientOpts []client.ClientOption) int {
+	message, err := oneshot.Message(cli.Prompt, input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		return apperrors.ExitCode(err)
+	}
+
+	opts := []client.ClientOption{
+		client.WithLogger(log),
+		client.WithUsageHandler(recordUsage(newUsageTracker(appConfig, log), log)),
+	}
+	c := client.NewClientFromConfig(appConfig, append(opts, clientOpts...)...)
+
+	// Ctrl+C отменяет запрос
+	ctx, cancel := setupSignalHandler(log)
+	defer cancel()
+
+	runner := oneshot.New(appConfig, c, oneshot.WithFormat(cli.Output), oneshot.WithLogger(log))
+	if _, err := runner.Run(ctx, message); err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		return apperrors.ExitCode(err)
+	}
+
+	log.Info("One-shot request completed")
+	return apperrors.ExitOK
+}
+
+// runBatch выполняет подкоманду batch: прогоняет файл запросов JSONL через модель
+func runBatch(args []string) int {
+	cli := &CLIConfig{}
+	var outputPath string
+	var concurrency int
+	var rate float64
+
+	fs := flag.NewFlagSet(appName+" batch", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: llm-client batch [flags] <input.jsonl>\n")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&cli.ConfigFile, "config", "", "Path to config file (or use LLM_CLIENT_CONFIG env)")
+	fs.StringVar(&cli.Profile, "profile", "", "Connection profile from config (or use LLM_CLIENT_PROFILE env)")
+	fs.StringVar(&cli.Address, "address", "", "LLM server address")
+	fs.StringVar(&cli.Address, "a", "", "Shorthand for -address")
+	fs.StringVar(&cli.Provider, "provider", "", "API format: openai, anthropic, ollama, gemini")
+	fs.StringVar(&cli.Model, "model", "", "Default model (rows may override)")
+	fs.StringVar(&cli.Model, "m", "", "Shorthand for -model")
+	fs.StringVar(&outputPath, "output", "", "Results file (default <input>.results.jsonl); existing results are skipped")
+	fs.StringVar(&outputPath, "o", "", "Shorthand for -output")
+	fs.IntVar(&concurrency, "concurrency", 4, "Number of parallel requests")
+	fs.IntVar(&concurrency, "c", 4, "Shorthand for -concurrency")
+	fs.Float64Var(&rate, "rate", 0, "Max requests per second (0 - unlimited)")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return apperrors.ExitOK
+		}
+		return apperrors.ExitUsage
+	}
+	if fs.NArg() != 1 || concurrency < 1 || rate < 0 {
+		fs.Usage()
+		return apperrors.ExitUsage
+	}
+	inputPath := fs.Arg(0)
+	if outputPath == "" {
+		outputPath = strings.TrimSuffix(inputPath, filepath.Ext(inputPath)) + ".results.jsonl"
+	}
+
+	appConfig, err := loadConfig(cli, newKeyResolver())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка загрузки конфигурации: %v\n", err)
+		return apperrors.ExitCode(err)
+	}
+
+	log := initLogger(appConfig)
+	defer log.Close()
+
+	c := client.NewClientFromConfig(appConfig,
+		client.WithLogger(log),
+		client.WithUsageHandler(recordUsage(newUsageTracker(appConfig, log), log)),
+	)
+
+	// Ctrl+C останавливает обработку; запуск с теми же файлами продолжит её
+	ctx, cancel := setupSignalHandler(log)
+	defer cancel()
+
+	processed := 0
+	runner := batch.New(appConfig, c,
+		batch.WithConcurrency(concurrency),
+		batch.WithRate(rate),
+		batch.WithLogger(log),
+		batch.WithProgress(func(result batch.Result) {
+			processed++
+			if result.Error != nil {
+				fmt.Fprintf(os.Stderr, "Строка %d: %s\n", result.Line, result.Error.Message)
+			} else if processed%100 == 0 {
+				fmt.Fprintf(os.Stderr, "Обработано: %d\n", processed)
+			}
+		}),
+	)
+
+	summary, err := runner.Run(ctx, inputPath, outputPath)
+	fmt.Fprintf(os.Stderr, "Всего: %d, пропущено: %d, успешно: %d, с ошибкой: %d. Результаты: %s\n",
+		summary.Total, summary.Skipped, summary.Succeeded, summary.Failed, outputPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		return apperrors.ExitCode(err)
+	}
+	return apperrors.ExitOK
+}
+
+// runServe выполняет подкоманду serve: OpenAI-совместимый прокси к настроенному провайдеру
+func runServe(args []string) int {
+	cli := &CLIConfig{}
+	var listen string
+
+	fs := flag.NewFlagSet(appName+" serve", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: llm-client serve [flags]\n")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&cli.ConfigFile, "config", "", "Path to config file (or use LLM_CLIENT_CONFIG env)")
+	fs.StringVar(&cli.Profile, "profile", "", "Connection profile from config (or use LLM_CLIENT_PROFILE env)")
+	fs.StringVar(&cli.Address, "address", "", "LLM server address")
+	fs.StringVar(&cli.Address, "a", "", "Shorthand for -address")
+	fs.StringVar(&cli.Model, "model", "", "Model for requests without \"model\"")
+	fs.StringVar(&cli.Model, "m", "", "Shorthand for -model")
+	fs.StringVar(&listen, "listen", "", "Listen address (default serve.listen from config)")
+	fs.StringVar(&listen, "l", "", "Shorthand for -listen")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return apperrors.ExitOK
+		}
+		return apperrors.ExitUsage
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return apperrors.ExitUsage
+	}
+
+	appConfig, err := loadConfig(cli, newKeyResolver())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка загрузки конфигурации: %v\n", err)
+		return apperrors.ExitCode(err)
+	}
+	if listen != "" {
+		appConfig.Serve.Listen = listen
+	}
+
+	log := initLogger(appConfig)
+	defer log.Close()
+
+	c := client.NewClientFromConfig(appConfig,
+		client.WithLogger(log),
+		client.WithUsageHandler(recordUsage(newUsageTracker(appConfig, log), log)),
+	)
+
+	// SIGINT/SIGTERM останавливают приём запросов; активные запросы дорабатывают
+	ctx, cancel := setupSignalHandler(log)
+	defer cancel()
+
+	if len(appConfig.Serve.APIKeys) == 0 {
+		fmt.Fprintf(os.Stderr, "Внимание: serve.api_keys не заданы, прокси доступен без ключа\n")
+	}
+	fmt.Fprintf(os.Stderr, "Прокси %s -> %s, адрес http://%s/v1\n",
+		appConfig.Model.Name, appConfig.Server.Address, appConfig.Serve.Listen)
+
+	if !c.SupportsPassthrough() {
+		fmt.Fprintf(os.Stderr, "Ошибка: прокси поддерживает только server.provider = %q, задан %q\n",
+			config.ProviderOpenAI, appConfig.Server.Provider)
+		return apperrors.ExitConfig
+	}
+
+	server := proxy.New(appConfig, c, proxy.WithLogger(log))
+	if err := server.ListenAndServe(ctx, appConfig.Serve.Listen); err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		return apperrors.ExitCode(err)
+	}
+	fmt.Fprintf(os.Stderr, "Прокси остановлен\n")
+	return apperrors.ExitOK
+}
+
+// readStdin читает данные, переданные через pipe или перенаправление.
+// Для терминала возвращает пустую строку, не дожидаясь ввода. Pipe читается только
+// вместе с -p: без него stdin может оказаться открытым каналом, унаследованным
+// от IDE или CI, и чтение заблокировало бы запуск интерфейса навсегда.
+// Перенаправленный файл читается всегда - он не блокирует.
+func readStdin(prompted bool) (string, error) {
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice != 0 {
+		return "", nil
+	}
+	if !prompted && !info.Mode().IsRegular() {
+		return "", nil
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
 // parseCLIConfig парсит аргументы командной строки
-func parseCLIConfig(args []string) *CLIConfig {
+func parseCLIConfig(args []string) (*CLIConfig, error) {
 	cli := &CLIConfig{}
 
 	fs := flag.NewFlagSet(appName, flag.ContinueOnError)
 	fs.StringVar(&cli.ConfigFile, "config", "", "Path to config file (or use LLM_CLIENT_CONFIG env)")
+	fs.StringVar(&cli.Profile, "profile", "", "Connection profile from config (or use LLM_CLIENT_PROFILE env)")
 	fs.StringVar(&cli.Address, "address", "", "LLM server address")
 	fs.StringVar(&cli.Address, "a", "", "Shorthand for -address")
+	fs.StringVar(&cli.Provider, "provider", "", "API format: openai, anthropic, ollama, gemini")
 	fs.StringVar(&cli.Model, "model", "", "Model name to use")
 	fs.StringVar(&cli.Model, "m", "", "Shorthand for -model")
 	fs.StringVar(&cli.SystemPrompt, "system", "", "System prompt")
@@ -133,23 +441,41 @@ func parseCLIConfig(args []string) *CLIConfig {
 	fs.Float64Var(&cli.Temperature, "temperature", 0, "Temperature (0.0-2.0)")
 	fs.Float64Var(&cli.Temperature, "t", 0, "Shorthand for -temperature")
 	fs.Float64Var(&cli.TopP, "top-p", 0, "Top P (0.0-1.0)")
-	fs.Float64Var(&cli.TopP, "p", 0, "Shorthand for -top-p")
+	fs.StringVar(&cli.Prompt, "prompt", "", "Send prompt without TUI and print the answer (stdin is appended)")
+	fs.StringVar(&cli.Prompt, "p", "", "Shorthand for -prompt")
+	fs.StringVar(&cli.Output, "output", oneshot.FormatText, "Non-interactive output format: text, json, jsonl")
+	fs.StringVar(&cli.Output, "o", oneshot.FormatText, "Shorthand for -output")
+	fs.StringVar(&cli.Session, "session", "", "Resume saved session by id (see /sessions)")
+	fs.StringVar(&cli.Record, "record", "", "Record provider responses into cassette directory")
+	fs.StringVar(&cli.Replay, "replay", "", "Replay responses from cassette directory without network")
 	fs.BoolVar(&cli.ShowConfig, "show-config", false, "Show default config and exit")
 	fs.BoolVar(&cli.InitConfig, "init-config", false, "Create default config file")
 	fs.BoolVar(&cli.ShowVersion, "version", false, "Show version and exit")
 	fs.BoolVar(&cli.ShowVersion, "v", false, "Shorthand for -version")
 
 	if err := fs.Parse(args); err != nil {
-		return cli
+		return nil, err
 	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "p" {
+			cli.PromptShort = true
+		}
+	})
+
+	return cli, nil
+}
 
-	return cli
+// looksLikeTopP сообщает, похоже ли значение -p на top_p из старых версий, где -p было сокращением -top-p
+func looksLikeTopP(value string) bool {
+	v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	return err == nil && v >= 0 && v <= 1
 }
 
-// loadConfig загружает и валидирует конфигурацию
-func loadConfig(cli *CLIConfig) (*config.Config, error) {
+
+// loadConfig загружает и валидирует конфигурацию и получает API ключ из server.api_key
+func loadConfig(cli *CLIConfig, keys *config.KeyResolver) (*config.Config, error) {
 	// Загружаем конфигурацию из файла
-	cfg, err := config.Load(cli.ConfigFile)
+	cfg, err := config.LoadProfile(cli.ConfigFile, cli.Profile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
@@ -158,6 +484,9 @@ func loadConfig(cli *CLIConfig) (*config.Config, error) {
 	if cli.Address != "" {
 		cfg.Server.Address = cli.Address
 	}
+	if cli.Provider != "" {
+		cfg.Server.Provider = cli.Provider
+	}
 	if cli.Model != "" {
 		cfg.Model.Name = cli.Model
 	}
@@ -173,12 +502,81 @@ func loadConfig(cli *CLIConfig) (*config.Config, error) {
 
 	// Повторно валидируем после применения CLI флагов
 	if err := cfg.Validate(); err != nil {
-		return nil, fmt.Errorf("config validation failed: %w", err)
+		return nil, apperrors.NewValidationError("INVALID_CONFIG", "config validation failed", err)
+	}
+
+	// Воспроизведению кассет ключ не нужен
+	if cli.Replay != "" {
+		return cfg, nil
+	}
+	if err := cfg.ResolveAPIKey(keys); err != nil {
+		return nil, err
 	}
 
 	return cfg, nil
 }
 
+// sessionOptions создаёт хранилище сессий и при необходимости загружает сессию из -session
+func sessionOptions(cli *CLIConfig, log *logger.Logger) ([]ui.ModelOption, error) {
+	dir, err := session.DefaultDir()
+	if err != nil {
+		if cli.Session != "" {
+			return nil, err
+		}
+		// Без домашней директории приложение работает, но не сохраняет сессии
+		log.Warn("Session storage disabled", "error", err)
+		return nil, nil
+	}
+
+	store := session.NewStore(dir)
+	opts := []ui.ModelOption{ui.WithSessionStore(store)}
+
+	if cli.Session != "" {
+		sess, err := store.Load(cli.Session)
+		if err != nil {
+			return nil, err
+		}
+		log.Info("Resuming session", "session", sess.ID, "messages", sess.MessageCount)
+		opts = append(opts, ui.WithSession(sess))
+	}
+
+	return opts, nil
+}
+
+// newUsageTracker создаёт учёт расхода токенов с дневной статистикой в ~/.llm-client/usage.json.
+// В TUI опция учёта должна применяться до восстановления сессии, чтобы сохранённый расход не был потерян.
+func newUsageTracker(appConfig *config.Config, log *logger.Logger) *usage.Tracker {
+	path, err := usage.DefaultPath()
+	if err != nil {
+		// Без домашней директории расход считается только в памяти
+		log.Warn("Usage statistics will not be saved", "error", err)
+	}
+	return usage.NewTracker(path, appConfig.Pricing)
+}
+
+// inputHistoryOption подключает историю ввода, сохраняемую в ~/.llm-client/input_history.json
+func inputHistoryOption(appConfig *config.Config, log *logger.Logger) ui.ModelOption {
+	path, err := inputhistory.DefaultPath()
+	if err != nil {
+		log.Warn("Input history will not be saved", "error", err)
+	}
+	history := inputhistory.New(path, appConfig.UI.HistoryLimit)
+	if err := history.Load(); err != nil {
+		// Файл не перезаписывается, история этого запуска хранится в памяти
+		log.Warn("Failed to load input history", "error", err)
+	}
+	return ui.WithInputHistory(history)
+}
+
+// recordUsage возвращает обработчик, сохраняющий расход токенов запросов без TUI
+func recordUsage(tracker *usage.Tracker, log *logger.Logger) client.UsageHandler {
+	return func(model string, u client.Usage) {
+		if _, err := tracker.Record(model, u.PromptTokens, u.CompletionTokens, u.TotalTokens); err != nil {
+			log.Warn("Failed to save usage", "error", err)
+		}
+	}
+}
+
 // initLogger инициализирует логгер с заданной конфигурацией
 func initLogger(cfg *config.Config) *logger.Logger {
 	logCfg := logger.Config{
@@ -186,25 +584,71 @@ func initLogger(cfg *config.Config) *logger.Logger {
 		FilePath:  cfg.Log.FilePath,
 		Level:     logger.ParseLevel(cfg.Log.Level),
 		AddSource: cfg.Log.Level == "debug",
+		Redact:    redactConfig(cfg),
+		Format:    logger.Format(cfg.Log.Format),
+		Rotate: logger.RotateConfig{
+			MaxSize:    int64(cfg.Log.MaxSizeMB) << 20,
+			MaxAge:     time.Duration(cfg.Log.MaxAgeHours) * time.Hour,
+			MaxBackups: cfg.Log.MaxBackups,
+			Compress:   cfg.Log.Compress,
+		},
 	}
-
 	log := logger.NewLogger(logCfg)
 	logger.SetDefault(log)
 
 	return log
 }
 
-// setupSignalHandler настраивает обработку сигналов ОС
-func setupSignalHandler(ctx context.Context, log *logger.Logger) {
+// redactConfig преобразует настройки скрытия данных из конфигурации
+func redactConfig(cfg *config.Config) logger.RedactConfig {
+	redact := logger.RedactConfig{Mode: logger.RedactMode(cfg.Log.Redact.Mode)}
+	for _, rule := range cfg.Log.Redact.Rules {
+		redact.Rules = append(redact.Rules, logger.RedactRule{Name: rule.Name, Pattern: rule.Pattern})
+	}
+	return redact
+}
+
+// cassetteOptions подключает запись (-record) или воспроизведение (-replay) кассет.
+// Без флагов опций нет.
+func cassetteOptions(cli *CLIConfig, appConfig *config.Config, log *logger.Logger) ([]client.ClientOption, error) {
+	mode, dir := cassette.ModeRecord, cli.Record
+	if cli.Replay != "" {
+		mode, dir = cassette.ModeReplay, cli.Replay
+	}
+	if dir == "" {
+		return nil, nil
+	}
+
+	redactor, err := logger.NewRedactor(redactConfig(appConfig))
+	if err != nil {
+		return nil, err
+	}
+	transport, err := cassette.New(dir, mode, cassette.WithLogger(log), cassette.WithRedactor(redactor))
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Cassettes enabled", "mode", mode, "dir", dir, "cassettes", transport.Len())
+	return []client.ClientOption{client.WithHTTPClient(transport.Client())}, nil
+}
+
+// setupSignalHandler настраивает обработку сигналов ОС.
+// Возвращает контекст, отменяемый при SIGINT/SIGTERM; cancel прекращает обработку сигналов.
+func setupSignalHandler(log *logger.Logger) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
 	go func() {
-		sig := <-sigChan
-		log.Info("Received signal, shutting down", "signal", sig)
-		// Контекст будет отменён через cancel в main
-		_ = ctx
+		defer signal.Stop(sigChan)
+		select {
+		case sig := <-sigChan:
+			log.Info("Received signal, shutting down", "signal", sig)
+			cancel()
+		case <-ctx.Done():
+		}
 	}()
+
+	return ctx, cancel
 }
 
 // printDefaultConfig выводит конфигурацию по умолчанию в stdout